@@ -0,0 +1,5 @@
+# name    width  options
+color     8
+shape     10
+k         3      align=right pad=0
+quantity  10     align=right overflow=error
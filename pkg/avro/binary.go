@@ -0,0 +1,331 @@
+// The Avro binary encoding: decoding of values given their schema, and
+// encoding of primitive values, from which callers build up the encodings of
+// their own values.
+
+package avro
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"time"
+
+	"github.com/johnkerl/miller/v6/pkg/lib"
+)
+
+// Date is a date logical-type value: days since 1970-01-01.
+type Date int32
+
+// TimeOfDay is a time-millis or time-micros logical-type value: time after
+// midnight, in ticks of the unit.
+type TimeOfDay struct {
+	Ticks int64
+	Unit  time.Duration
+}
+
+// Timestamp is a timestamp-* or local-timestamp-* logical-type value: time
+// since 1970-01-01T00:00:00, in ticks of the unit. It's UTC unless Local.
+type Timestamp struct {
+	Ticks int64
+	Unit  time.Duration
+	Local bool
+}
+
+// Decimal is a decimal logical-type value: Unscaled * 10^-Scale.
+type Decimal struct {
+	Unscaled *big.Int
+	Scale    int
+}
+
+var errShortData = errors.New("unexpected end of data")
+
+type decoder struct {
+	data []byte
+	pos  int
+}
+
+// Decode decodes one value of the schema from the start of the data, and
+// returns the rest of the data after it.
+func Decode(data []byte, schema *Schema) (any, []byte, error) {
+	decoder := &decoder{data: data}
+	value, err := decoder.decode(schema)
+	if err != nil {
+		return nil, nil, err
+	}
+	return value, data[decoder.pos:], nil
+}
+
+func (decoder *decoder) decode(schema *Schema) (any, error) {
+	switch schema.Type {
+	case "null":
+		return nil, nil
+	case "boolean":
+		b, err := decoder.readByte()
+		if err != nil {
+			return nil, err
+		}
+		return b != 0, nil
+	case "int", "long":
+		n, err := decoder.readLong()
+		if err != nil {
+			return nil, err
+		}
+		return withLongLogicalType(schema, n), nil
+	case "float":
+		b, err := decoder.readN(4)
+		if err != nil {
+			return nil, err
+		}
+		return math.Float32frombits(binary.LittleEndian.Uint32(b)), nil
+	case "double":
+		b, err := decoder.readN(8)
+		if err != nil {
+			return nil, err
+		}
+		return math.Float64frombits(binary.LittleEndian.Uint64(b)), nil
+	case "bytes":
+		b, err := decoder.readBytes()
+		if err != nil {
+			return nil, err
+		}
+		return withBytesLogicalType(schema, b), nil
+	case "fixed":
+		b, err := decoder.readN(schema.Size)
+		if err != nil {
+			return nil, err
+		}
+		return withBytesLogicalType(schema, append([]byte(nil), b...)), nil
+	case "string":
+		b, err := decoder.readBytes()
+		if err != nil {
+			return nil, err
+		}
+		return string(b), nil
+	case "enum":
+		n, err := decoder.readLong()
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 || n >= int64(len(schema.Symbols)) {
+			return nil, fmt.Errorf("enum %s: symbol index %d out of range", schema.Name, n)
+		}
+		return schema.Symbols[n], nil
+	case "array":
+		array := make([]any, 0)
+		err := decoder.readBlocks(func() error {
+			element, err := decoder.decode(schema.Items)
+			array = append(array, element)
+			return err
+		})
+		return array, err
+	case "map":
+		m := lib.NewOrderedMap[any]()
+		err := decoder.readBlocks(func() error {
+			key, err := decoder.readBytes()
+			if err != nil {
+				return err
+			}
+			value, err := decoder.decode(schema.Values)
+			m.Put(string(key), value)
+			return err
+		})
+		return m, err
+	case "record":
+		record := lib.NewOrderedMap[any]()
+		for _, field := range schema.Fields {
+			value, err := decoder.decode(field.Type)
+			if err != nil {
+				return nil, err
+			}
+			record.Put(field.Name, value)
+		}
+		return record, nil
+	case "union":
+		n, err := decoder.readLong()
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 || n >= int64(len(schema.Branches)) {
+			return nil, fmt.Errorf("union branch index %d out of range", n)
+		}
+		return decoder.decode(schema.Branches[n])
+	default:
+		return nil, fmt.Errorf("unsupported type \"%s\"", schema.Type)
+	}
+}
+
+func withLongLogicalType(schema *Schema, n int64) any {
+	switch schema.LogicalType {
+	case "date":
+		return Date(n)
+	case "time-millis":
+		return TimeOfDay{Ticks: n, Unit: time.Millisecond}
+	case "time-micros":
+		return TimeOfDay{Ticks: n, Unit: time.Microsecond}
+	case "timestamp-millis":
+		return Timestamp{Ticks: n, Unit: time.Millisecond}
+	case "timestamp-micros":
+		return Timestamp{Ticks: n, Unit: time.Microsecond}
+	case "timestamp-nanos":
+		return Timestamp{Ticks: n, Unit: time.Nanosecond}
+	case "local-timestamp-millis":
+		return Timestamp{Ticks: n, Unit: time.Millisecond, Local: true}
+	case "local-timestamp-micros":
+		return Timestamp{Ticks: n, Unit: time.Microsecond, Local: true}
+	case "local-timestamp-nanos":
+		return Timestamp{Ticks: n, Unit: time.Nanosecond, Local: true}
+	default:
+		return n
+	}
+}
+
+func withBytesLogicalType(schema *Schema, b []byte) any {
+	if schema.LogicalType != "decimal" {
+		return b
+	}
+	// Big-endian two's complement
+	unscaled := new(big.Int).SetBytes(b)
+	if len(b) > 0 && b[0]&0x80 != 0 {
+		unscaled.Sub(unscaled, new(big.Int).Lsh(big.NewInt(1), uint(8*len(b))))
+	}
+	return Decimal{Unscaled: unscaled, Scale: schema.Scale}
+}
+
+// readBlocks reads the blocks of an array or map, calling readItem for each
+// item.
+func (decoder *decoder) readBlocks(readItem func() error) error {
+	for {
+		count, err := decoder.readLong()
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			return nil
+		}
+		if count < 0 {
+			// Followed by the block's size in bytes, which we don't need
+			count = -count
+			if _, err := decoder.readLong(); err != nil {
+				return err
+			}
+		}
+		// Each item takes at least one byte, except for nulls, so this guards
+		// against allocating for absurd counts from corrupt data.
+		if count > int64(len(decoder.data)-decoder.pos)+1<<20 {
+			return fmt.Errorf("block count %d exceeds the data size", count)
+		}
+		for i := int64(0); i < count; i++ {
+			if err := readItem(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (decoder *decoder) readByte() (byte, error) {
+	if decoder.pos >= len(decoder.data) {
+		return 0, errShortData
+	}
+	b := decoder.data[decoder.pos]
+	decoder.pos++
+	return b, nil
+}
+
+func (decoder *decoder) readN(n int) ([]byte, error) {
+	if n < 0 || n > len(decoder.data)-decoder.pos {
+		return nil, errShortData
+	}
+	b := decoder.data[decoder.pos : decoder.pos+n]
+	decoder.pos += n
+	return b, nil
+}
+
+func (decoder *decoder) readBytes() ([]byte, error) {
+	n, err := decoder.readLong()
+	if err != nil {
+		return nil, err
+	}
+	if n < 0 || n > int64(len(decoder.data)-decoder.pos) {
+		return nil, errShortData
+	}
+	return decoder.readN(int(n))
+}
+
+func (decoder *decoder) readLong() (int64, error) {
+	u, n := binary.Uvarint(decoder.data[decoder.pos:])
+	if n <= 0 {
+		return 0, errShortData
+	}
+	decoder.pos += n
+	return unzigzag(u), nil
+}
+
+// readLongFrom reads a long from a stream, such as a container file's block
+// header.
+func readLongFrom(reader interface{ ReadByte() (byte, error) }) (int64, error) {
+	u, err := binary.ReadUvarint(reader)
+	if err != nil {
+		return 0, err
+	}
+	return unzigzag(u), nil
+}
+
+func unzigzag(u uint64) int64 {
+	return int64(u>>1) ^ -int64(u&1)
+}
+
+// AppendLong appends the encoding of an int or long.
+func AppendLong(buffer []byte, n int64) []byte {
+	return binary.AppendUvarint(buffer, uint64(n<<1)^uint64(n>>63))
+}
+
+// AppendBoolean appends the encoding of a boolean.
+func AppendBoolean(buffer []byte, b bool) []byte {
+	if b {
+		return append(buffer, 1)
+	}
+	return append(buffer, 0)
+}
+
+// AppendFloat appends the encoding of a float.
+func AppendFloat(buffer []byte, f float32) []byte {
+	return binary.LittleEndian.AppendUint32(buffer, math.Float32bits(f))
+}
+
+// AppendDouble appends the encoding of a double.
+func AppendDouble(buffer []byte, f float64) []byte {
+	return binary.LittleEndian.AppendUint64(buffer, math.Float64bits(f))
+}
+
+// AppendBytes appends the encoding of bytes.
+func AppendBytes(buffer []byte, b []byte) []byte {
+	return append(AppendLong(buffer, int64(len(b))), b...)
+}
+
+// AppendString appends the encoding of a string.
+func AppendString(buffer []byte, s string) []byte {
+	return append(AppendLong(buffer, int64(len(s))), s...)
+}
+
+// DecimalBytes returns the big-endian two's-complement bytes of the unscaled
+// value of a decimal, in the given size, or the minimal size if size is 0.
+func DecimalBytes(unscaled *big.Int, size int) ([]byte, error) {
+	minimal := (unscaled.BitLen() + 8) / 8 // with room for the sign bit
+	if size == 0 {
+		size = minimal
+	} else if minimal > size {
+		return nil, fmt.Errorf("decimal value %s does not fit in %d bytes", unscaled.String(), size)
+	}
+	b := make([]byte, size)
+	if unscaled.Sign() >= 0 {
+		unscaled.FillBytes(b)
+	} else {
+		// Two's complement: 2^(8*size) + unscaled
+		twos := new(big.Int).Lsh(big.NewInt(1), uint(8*size))
+		twos.Add(twos, unscaled)
+		twos.FillBytes(b)
+	}
+	return b, nil
+}
@@ -0,0 +1,306 @@
+// Avro object-container files: a header with the schema and codec, then
+// blocks of encoded values, each compressed with the codec and followed by
+// the file's sync marker.
+
+package avro
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+)
+
+var magic = []byte{'O', 'b', 'j', 1}
+
+const syncSize = 16
+
+// Codecs are the supported block codecs.
+var Codecs = []string{"null", "deflate", "snappy", "zstandard"}
+
+// CheckCodec returns an error if the codec isn't one of Codecs.
+func CheckCodec(codec string) error {
+	for _, c := range Codecs {
+		if codec == c {
+			return nil
+		}
+	}
+	return fmt.Errorf("avro codec \"%s\" not found; please use one of null, deflate, snappy, zstandard", codec)
+}
+
+// OCFReader reads the values from an object-container file.
+type OCFReader struct {
+	handle *bufio.Reader
+	schema *Schema
+	codec  string
+	sync   []byte
+
+	// The rest of the current block, and the number of values in it
+	block          []byte
+	blockRemaining int64
+}
+
+// NewOCFReader reads the file's header.
+func NewOCFReader(handle io.Reader) (*OCFReader, error) {
+	reader := &OCFReader{handle: bufio.NewReader(handle)}
+
+	header := make([]byte, len(magic))
+	if _, err := io.ReadFull(reader.handle, header); err != nil || !bytes.Equal(header, magic) {
+		return nil, fmt.Errorf("not an Avro object-container file")
+	}
+
+	metadata, err := reader.readMetadata()
+	if err != nil {
+		return nil, fmt.Errorf("avro header: %v", err)
+	}
+	schemaText, ok := metadata["avro.schema"]
+	if !ok {
+		return nil, fmt.Errorf("avro header: no avro.schema")
+	}
+	reader.schema, err = ParseSchema(schemaText)
+	if err != nil {
+		return nil, err
+	}
+	reader.codec = "null"
+	if codec, ok := metadata["avro.codec"]; ok && len(codec) > 0 {
+		reader.codec = string(codec)
+	}
+	if err := CheckCodec(reader.codec); err != nil {
+		return nil, err
+	}
+
+	reader.sync = make([]byte, syncSize)
+	if _, err := io.ReadFull(reader.handle, reader.sync); err != nil {
+		return nil, fmt.Errorf("avro header: %v", noEOF(err))
+	}
+	return reader, nil
+}
+
+// Schema returns the schema from the file's header.
+func (reader *OCFReader) Schema() *Schema {
+	return reader.schema
+}
+
+// readMetadata reads the header's map from names to bytes.
+func (reader *OCFReader) readMetadata() (map[string][]byte, error) {
+	metadata := make(map[string][]byte)
+	for {
+		count, err := readLongFrom(reader.handle)
+		if err != nil {
+			return nil, noEOF(err)
+		}
+		if count == 0 {
+			return metadata, nil
+		}
+		if count < 0 {
+			count = -count
+			if _, err := readLongFrom(reader.handle); err != nil {
+				return nil, noEOF(err)
+			}
+		}
+		for i := int64(0); i < count; i++ {
+			key, err := reader.readStreamBytes()
+			if err != nil {
+				return nil, err
+			}
+			value, err := reader.readStreamBytes()
+			if err != nil {
+				return nil, err
+			}
+			metadata[string(key)] = value
+		}
+	}
+}
+
+func (reader *OCFReader) readStreamBytes() ([]byte, error) {
+	n, err := readLongFrom(reader.handle)
+	if err != nil {
+		return nil, noEOF(err)
+	}
+	if n < 0 {
+		return nil, fmt.Errorf("negative length %d", n)
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(reader.handle, b); err != nil {
+		return nil, noEOF(err)
+	}
+	return b, nil
+}
+
+// Read returns the next value, or io.EOF after the last one.
+func (reader *OCFReader) Read() (any, error) {
+	for reader.blockRemaining == 0 {
+		if err := reader.readBlock(); err != nil {
+			return nil, err
+		}
+	}
+	value, rest, err := Decode(reader.block, reader.schema)
+	if err != nil {
+		return nil, fmt.Errorf("avro data: %v", err)
+	}
+	reader.block = rest
+	reader.blockRemaining--
+	return value, nil
+}
+
+func (reader *OCFReader) readBlock() error {
+	count, err := readLongFrom(reader.handle)
+	if err == io.EOF {
+		return io.EOF
+	}
+	if err != nil {
+		return fmt.Errorf("avro block: %v", err)
+	}
+	size, err := readLongFrom(reader.handle)
+	if err != nil {
+		return fmt.Errorf("avro block: %v", noEOF(err))
+	}
+	if count < 0 || size < 0 {
+		return fmt.Errorf("avro block: negative count or size")
+	}
+
+	data := make([]byte, size)
+	if _, err := io.ReadFull(reader.handle, data); err != nil {
+		return fmt.Errorf("avro block: %v", noEOF(err))
+	}
+	sync := make([]byte, syncSize)
+	if _, err := io.ReadFull(reader.handle, sync); err != nil {
+		return fmt.Errorf("avro block: %v", noEOF(err))
+	}
+	if !bytes.Equal(sync, reader.sync) {
+		return fmt.Errorf("avro block: sync marker does not match the header's")
+	}
+
+	reader.block, err = decompressBlock(reader.codec, data)
+	if err != nil {
+		return fmt.Errorf("avro block: %s: %v", reader.codec, err)
+	}
+	reader.blockRemaining = count
+	return nil
+}
+
+func noEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+func decompressBlock(codec string, data []byte) ([]byte, error) {
+	switch codec {
+	case "deflate":
+		return io.ReadAll(flate.NewReader(bytes.NewReader(data)))
+	case "snappy":
+		// The compressed data, then the CRC32 of the uncompressed data
+		if len(data) < 4 {
+			return nil, errors.New("block too short")
+		}
+		decompressed, err := snappy.Decode(nil, data[:len(data)-4])
+		if err != nil {
+			return nil, err
+		}
+		if crc32.ChecksumIEEE(decompressed) != binary.BigEndian.Uint32(data[len(data)-4:]) {
+			return nil, errors.New("checksum mismatch")
+		}
+		return decompressed, nil
+	case "zstandard":
+		decoder, err := zstd.NewReader(nil)
+		if err != nil {
+			return nil, err
+		}
+		defer decoder.Close()
+		return decoder.DecodeAll(data, nil)
+	default:
+		return data, nil
+	}
+}
+
+func compressBlock(codec string, data []byte) ([]byte, error) {
+	switch codec {
+	case "deflate":
+		var buffer bytes.Buffer
+		writer, err := flate.NewWriter(&buffer, flate.DefaultCompression)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := writer.Write(data); err != nil {
+			return nil, err
+		}
+		if err := writer.Close(); err != nil {
+			return nil, err
+		}
+		return buffer.Bytes(), nil
+	case "snappy":
+		compressed := snappy.Encode(nil, data)
+		return binary.BigEndian.AppendUint32(compressed, crc32.ChecksumIEEE(data)), nil
+	case "zstandard":
+		encoder, err := zstd.NewWriter(nil)
+		if err != nil {
+			return nil, err
+		}
+		defer encoder.Close()
+		return encoder.EncodeAll(data, nil), nil
+	default:
+		return data, nil
+	}
+}
+
+// OCFWriter writes blocks of encoded values to an object-container file.
+type OCFWriter struct {
+	handle io.Writer
+	codec  string
+	sync   []byte
+}
+
+// NewOCFWriter writes the file's header.
+func NewOCFWriter(handle io.Writer, schema string, codec string) (*OCFWriter, error) {
+	if err := CheckCodec(codec); err != nil {
+		return nil, err
+	}
+	writer := &OCFWriter{
+		handle: handle,
+		codec:  codec,
+		sync:   make([]byte, syncSize),
+	}
+	if _, err := rand.Read(writer.sync); err != nil {
+		return nil, err
+	}
+
+	header := append([]byte(nil), magic...)
+	header = AppendLong(header, 2)
+	header = AppendString(header, "avro.schema")
+	header = AppendString(header, schema)
+	header = AppendString(header, "avro.codec")
+	header = AppendString(header, codec)
+	header = AppendLong(header, 0)
+	header = append(header, writer.sync...)
+	if _, err := handle.Write(header); err != nil {
+		return nil, err
+	}
+	return writer, nil
+}
+
+// WriteBlock writes a block of count encoded values.
+func (writer *OCFWriter) WriteBlock(count int64, data []byte) error {
+	if count == 0 {
+		return nil
+	}
+	compressed, err := compressBlock(writer.codec, data)
+	if err != nil {
+		return err
+	}
+	block := AppendLong(nil, count)
+	block = AppendLong(block, int64(len(compressed)))
+	block = append(block, compressed...)
+	block = append(block, writer.sync...)
+	_, err = writer.handle.Write(block)
+	return err
+}
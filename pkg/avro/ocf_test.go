@@ -0,0 +1,134 @@
+package avro
+
+import (
+	"bytes"
+	"io"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/johnkerl/miller/v6/pkg/lib"
+)
+
+const testSchema = `{"type":"record","name":"r","fields":[` +
+	`{"name":"n","type":"long"},` +
+	`{"name":"s","type":["null","string"]},` +
+	`{"name":"at","type":{"type":"long","logicalType":"timestamp-micros"}},` +
+	`{"name":"d","type":{"type":"fixed","name":"dec","size":4,"logicalType":"decimal","precision":8,"scale":2}}]}`
+
+func encodeTestRecord(n int64, s string, at int64, unscaled int64) []byte {
+	buffer := AppendLong(nil, n)
+	if s == "" {
+		buffer = AppendLong(buffer, 0)
+	} else {
+		buffer = AppendString(AppendLong(buffer, 1), s)
+	}
+	buffer = AppendLong(buffer, at)
+	decimal, _ := DecimalBytes(big.NewInt(unscaled), 4)
+	return append(buffer, decimal...)
+}
+
+func TestOCFRoundTrip(t *testing.T) {
+	for _, codec := range Codecs {
+		var file bytes.Buffer
+		writer, err := NewOCFWriter(&file, testSchema, codec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		block := encodeTestRecord(1, "abc", 1700000000123456, 12345)
+		block = append(block, encodeTestRecord(-2, "", 0, -5)...)
+		if err := writer.WriteBlock(2, block); err != nil {
+			t.Fatal(err)
+		}
+		if err := writer.WriteBlock(1, encodeTestRecord(3, "def", 1, 0)); err != nil {
+			t.Fatal(err)
+		}
+
+		reader, err := NewOCFReader(&file)
+		if err != nil {
+			t.Fatalf("%s: %v", codec, err)
+		}
+		if reader.Schema().String() != testSchema {
+			t.Errorf("%s: unexpected schema %s", codec, reader.Schema().String())
+		}
+
+		var values []*lib.OrderedMap[any]
+		for {
+			value, err := reader.Read()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("%s: %v", codec, err)
+			}
+			values = append(values, value.(*lib.OrderedMap[any]))
+		}
+		if len(values) != 3 {
+			t.Fatalf("%s: expected 3 values; got %d", codec, len(values))
+		}
+
+		first := values[0]
+		if first.Get("n") != int64(1) || first.Get("s") != "abc" {
+			t.Errorf("%s: unexpected first value n=%v s=%v", codec, first.Get("n"), first.Get("s"))
+		}
+		if first.Get("at") != (Timestamp{Ticks: 1700000000123456, Unit: time.Microsecond}) {
+			t.Errorf("%s: unexpected timestamp %v", codec, first.Get("at"))
+		}
+		if d := first.Get("d").(Decimal); d.Unscaled.Int64() != 12345 || d.Scale != 2 {
+			t.Errorf("%s: unexpected decimal %v", codec, d)
+		}
+		second := values[1]
+		if second.Get("n") != int64(-2) || second.Get("s") != nil {
+			t.Errorf("%s: unexpected second value n=%v s=%v", codec, second.Get("n"), second.Get("s"))
+		}
+		if d := second.Get("d").(Decimal); d.Unscaled.Int64() != -5 {
+			t.Errorf("%s: unexpected negative decimal %v", codec, d)
+		}
+		if values[2].Get("s") != "def" {
+			t.Errorf("%s: unexpected third value %v", codec, values[2].Get("s"))
+		}
+	}
+}
+
+func TestOCFReaderErrors(t *testing.T) {
+	if _, err := NewOCFReader(bytes.NewReader([]byte("a,b,c\n1,2,3\n"))); err == nil {
+		t.Errorf("expected error for non-OCF data")
+	}
+
+	var file bytes.Buffer
+	writer, err := NewOCFWriter(&file, testSchema, "null")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.WriteBlock(1, encodeTestRecord(1, "abc", 0, 0)); err != nil {
+		t.Fatal(err)
+	}
+	// Corrupt the block's sync marker
+	data := file.Bytes()
+	data[len(data)-1] ^= 0xff
+	reader, err := NewOCFReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := reader.Read(); err == nil {
+		t.Errorf("expected sync-marker error")
+	}
+
+	if _, err := NewOCFWriter(&file, testSchema, "nosuch"); err == nil {
+		t.Errorf("expected codec error")
+	}
+}
+
+func TestLongEncoding(t *testing.T) {
+	for _, n := range []int64{0, -1, 1, 63, -64, 64, 1 << 40, -(1 << 62), 1<<63 - 1, -1 << 63} {
+		encoded := AppendLong(nil, n)
+		decoded, rest, err := Decode(encoded, &Schema{Type: "long"})
+		if err != nil || len(rest) != 0 || decoded != n {
+			t.Errorf("%d: got %v rest %v err %v", n, decoded, rest, err)
+		}
+	}
+	// Zig-zag: small magnitudes are one byte
+	if len(AppendLong(nil, -64)) != 1 || len(AppendLong(nil, 64)) != 2 {
+		t.Errorf("unexpected zig-zag lengths")
+	}
+}
@@ -0,0 +1,353 @@
+// Package avro reads and writes Apache Avro object-container files (OCF):
+// schemas, the binary encoding of values, and the container's blocks and
+// codecs. See https://avro.apache.org/docs/1.11.1/specification/.
+//
+// Decoded values are Go values:
+//
+//	null -> nil
+//	boolean -> bool
+//	int, long -> int64
+//	float -> float32
+//	double -> float64
+//	bytes, fixed -> []byte
+//	string, enum -> string
+//	array -> []any
+//	map, record -> *lib.OrderedMap[any], in encoded or field order
+//	union -> the value of its branch
+//
+// with these logical types as Date, TimeOfDay, Timestamp, and Decimal values.
+package avro
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Schema is a parsed Avro schema.
+type Schema struct {
+	// A primitive type name, or record, enum, array, map, fixed, or union
+	Type string
+	// The full name, with namespace if any, of a record, enum, or fixed
+	Name string
+
+	Fields   []*Field  // record
+	Symbols  []string  // enum
+	Items    *Schema   // array
+	Values   *Schema   // map
+	Branches []*Schema // union
+	Size     int       // fixed
+
+	LogicalType string
+	Precision   int // decimal
+	Scale       int // decimal
+}
+
+// Field is a record field.
+type Field struct {
+	Name string
+	Type *Schema
+	// Default is as in the schema JSON, if HasDefault.
+	Default    any
+	HasDefault bool
+}
+
+var primitiveTypes = map[string]bool{
+	"null":    true,
+	"boolean": true,
+	"int":     true,
+	"long":    true,
+	"float":   true,
+	"double":  true,
+	"bytes":   true,
+	"string":  true,
+}
+
+var nameRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// IsValidName says whether the name is usable as a record, field, enum, or
+// fixed name, or enum symbol.
+func IsValidName(name string) bool {
+	return nameRegex.MatchString(name)
+}
+
+// ParseSchema parses schema JSON, as in an .avsc file or an OCF header.
+func ParseSchema(text []byte) (*Schema, error) {
+	var parsed any
+	if err := json.Unmarshal(text, &parsed); err != nil {
+		return nil, fmt.Errorf("avro schema: %v", err)
+	}
+	parser := &schemaParser{names: make(map[string]*Schema)}
+	schema, err := parser.parse(parsed, "")
+	if err != nil {
+		return nil, fmt.Errorf("avro schema: %v", err)
+	}
+	return schema, nil
+}
+
+type schemaParser struct {
+	// Named types seen so far, by full name
+	names map[string]*Schema
+}
+
+func (parser *schemaParser) parse(parsed any, namespace string) (*Schema, error) {
+	switch p := parsed.(type) {
+	case string:
+		return parser.parseTypeName(p, namespace)
+	case []any:
+		return parser.parseUnion(p, namespace)
+	case map[string]any:
+		return parser.parseObject(p, namespace)
+	default:
+		return nil, fmt.Errorf("expected a type name, object, or array; got %v", parsed)
+	}
+}
+
+func (parser *schemaParser) parseTypeName(name string, namespace string) (*Schema, error) {
+	if primitiveTypes[name] {
+		return &Schema{Type: name}, nil
+	}
+	if schema, ok := parser.names[fullName(name, namespace)]; ok {
+		return schema, nil
+	}
+	if schema, ok := parser.names[name]; ok {
+		return schema, nil
+	}
+	return nil, fmt.Errorf("unknown type \"%s\"", name)
+}
+
+func (parser *schemaParser) parseUnion(branches []any, namespace string) (*Schema, error) {
+	schema := &Schema{Type: "union", Branches: make([]*Schema, len(branches))}
+	for i, branch := range branches {
+		branchSchema, err := parser.parse(branch, namespace)
+		if err != nil {
+			return nil, err
+		}
+		if branchSchema.Type == "union" {
+			return nil, fmt.Errorf("unions may not immediately contain other unions")
+		}
+		schema.Branches[i] = branchSchema
+	}
+	return schema, nil
+}
+
+func (parser *schemaParser) parseObject(object map[string]any, namespace string) (*Schema, error) {
+	typeName, ok := object["type"].(string)
+	if !ok {
+		// Such as {"type": {"type": "array", ...}}
+		if nested, ok := object["type"]; ok {
+			return parser.parse(nested, namespace)
+		}
+		return nil, fmt.Errorf("object without \"type\"")
+	}
+
+	var schema *Schema
+	var err error
+	switch typeName {
+	case "record", "error", "enum", "fixed":
+		schema, err = parser.parseNamed(typeName, object, namespace)
+	case "array":
+		schema = &Schema{Type: "array"}
+		schema.Items, err = parser.parse(object["items"], namespace)
+	case "map":
+		schema = &Schema{Type: "map"}
+		schema.Values, err = parser.parse(object["values"], namespace)
+	default:
+		schema, err = parser.parseTypeName(typeName, namespace)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if logicalType, ok := object["logicalType"].(string); ok {
+		schema.LogicalType = logicalType
+		schema.Precision = intProperty(object, "precision")
+		schema.Scale = intProperty(object, "scale")
+	}
+	return schema, nil
+}
+
+func (parser *schemaParser) parseNamed(
+	typeName string,
+	object map[string]any,
+	namespace string,
+) (*Schema, error) {
+	name, ok := object["name"].(string)
+	if !ok {
+		return nil, fmt.Errorf("%s without \"name\"", typeName)
+	}
+	if ns, ok := object["namespace"].(string); ok && !strings.Contains(name, ".") {
+		namespace = ns
+	}
+	name = fullName(name, namespace)
+	if _, ok := parser.names[name]; ok {
+		return nil, fmt.Errorf("type \"%s\" is defined more than once", name)
+	}
+	// Names in the type are relative to its own namespace.
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		namespace = name[:i]
+	} else {
+		namespace = ""
+	}
+
+	schema := &Schema{Name: name}
+	parser.names[name] = schema
+
+	switch typeName {
+	case "record", "error":
+		schema.Type = "record"
+		fields, ok := object["fields"].([]any)
+		if !ok {
+			return nil, fmt.Errorf("record \"%s\" without \"fields\"", name)
+		}
+		for _, fieldObject := range fields {
+			fieldMap, ok := fieldObject.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("record \"%s\": expected field object; got %v", name, fieldObject)
+			}
+			fieldName, ok := fieldMap["name"].(string)
+			if !ok {
+				return nil, fmt.Errorf("record \"%s\": field without \"name\"", name)
+			}
+			fieldType, err := parser.parse(fieldMap["type"], namespace)
+			if err != nil {
+				return nil, fmt.Errorf("record \"%s\" field \"%s\": %v", name, fieldName, err)
+			}
+			field := &Field{Name: fieldName, Type: fieldType}
+			field.Default, field.HasDefault = fieldMap["default"]
+			schema.Fields = append(schema.Fields, field)
+		}
+
+	case "enum":
+		schema.Type = "enum"
+		symbols, ok := object["symbols"].([]any)
+		if !ok {
+			return nil, fmt.Errorf("enum \"%s\" without \"symbols\"", name)
+		}
+		for _, symbol := range symbols {
+			s, ok := symbol.(string)
+			if !ok {
+				return nil, fmt.Errorf("enum \"%s\": expected string symbol; got %v", name, symbol)
+			}
+			schema.Symbols = append(schema.Symbols, s)
+		}
+
+	case "fixed":
+		schema.Type = "fixed"
+		size, ok := object["size"].(float64)
+		if !ok || size < 0 || size != float64(int(size)) {
+			return nil, fmt.Errorf("fixed \"%s\" without non-negative integer \"size\"", name)
+		}
+		schema.Size = int(size)
+	}
+	return schema, nil
+}
+
+func fullName(name string, namespace string) string {
+	if namespace == "" || strings.Contains(name, ".") {
+		return name
+	}
+	return namespace + "." + name
+}
+
+func intProperty(object map[string]any, key string) int {
+	if f, ok := object[key].(float64); ok {
+		return int(f)
+	}
+	return 0
+}
+
+// String returns the schema as JSON, for an OCF header. Named types are
+// written in full the first time and by name after that.
+func (schema *Schema) String() string {
+	var buffer strings.Builder
+	schema.writeJSON(&buffer, make(map[string]bool))
+	return buffer.String()
+}
+
+func (schema *Schema) writeJSON(buffer *strings.Builder, written map[string]bool) {
+	if schema.Name != "" {
+		if written[schema.Name] {
+			writeJSONString(buffer, schema.Name)
+			return
+		}
+		written[schema.Name] = true
+	}
+
+	switch schema.Type {
+	case "union":
+		buffer.WriteString("[")
+		for i, branch := range schema.Branches {
+			if i > 0 {
+				buffer.WriteString(",")
+			}
+			branch.writeJSON(buffer, written)
+		}
+		buffer.WriteString("]")
+		return
+	case "record", "enum", "array", "map", "fixed":
+	default:
+		if schema.LogicalType == "" {
+			writeJSONString(buffer, schema.Type)
+			return
+		}
+	}
+
+	buffer.WriteString(`{"type":`)
+	writeJSONString(buffer, schema.Type)
+	if schema.Name != "" {
+		buffer.WriteString(`,"name":`)
+		writeJSONString(buffer, schema.Name)
+	}
+	switch schema.Type {
+	case "record":
+		buffer.WriteString(`,"fields":[`)
+		for i, field := range schema.Fields {
+			if i > 0 {
+				buffer.WriteString(",")
+			}
+			buffer.WriteString(`{"name":`)
+			writeJSONString(buffer, field.Name)
+			buffer.WriteString(`,"type":`)
+			field.Type.writeJSON(buffer, written)
+			if field.HasDefault {
+				buffer.WriteString(`,"default":`)
+				defaultJSON, _ := json.Marshal(field.Default)
+				buffer.Write(defaultJSON)
+			}
+			buffer.WriteString("}")
+		}
+		buffer.WriteString("]")
+	case "enum":
+		buffer.WriteString(`,"symbols":[`)
+		for i, symbol := range schema.Symbols {
+			if i > 0 {
+				buffer.WriteString(",")
+			}
+			writeJSONString(buffer, symbol)
+		}
+		buffer.WriteString("]")
+	case "array":
+		buffer.WriteString(`,"items":`)
+		schema.Items.writeJSON(buffer, written)
+	case "map":
+		buffer.WriteString(`,"values":`)
+		schema.Values.writeJSON(buffer, written)
+	case "fixed":
+		fmt.Fprintf(buffer, `,"size":%d`, schema.Size)
+	}
+	if schema.LogicalType != "" {
+		buffer.WriteString(`,"logicalType":`)
+		writeJSONString(buffer, schema.LogicalType)
+		if schema.LogicalType == "decimal" {
+			fmt.Fprintf(buffer, `,"precision":%d,"scale":%d`, schema.Precision, schema.Scale)
+		}
+	}
+	buffer.WriteString("}")
+}
+
+func writeJSONString(buffer *strings.Builder, s string) {
+	encoded, _ := json.Marshal(s)
+	buffer.Write(encoded)
+}
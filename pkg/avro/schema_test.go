@@ -0,0 +1,77 @@
+package avro
+
+import (
+	"testing"
+)
+
+func TestParseSchemaNamedTypes(t *testing.T) {
+	schema, err := ParseSchema([]byte(`{
+		"type": "record", "name": "Node", "namespace": "com.example",
+		"fields": [
+			{"name": "value", "type": {"type": "enum", "name": "Color", "symbols": ["RED", "GREEN"]}},
+			{"name": "other", "type": "Color"},
+			{"name": "next", "type": ["null", "Node"], "default": null}
+		]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if schema.Type != "record" || schema.Name != "com.example.Node" {
+		t.Fatalf("unexpected record %s %s", schema.Type, schema.Name)
+	}
+	if schema.Fields[0].Type != schema.Fields[1].Type {
+		t.Errorf("expected the reference to Color to be the same schema")
+	}
+	if schema.Fields[2].Type.Branches[1] != schema {
+		t.Errorf("expected the recursive reference to be the record itself")
+	}
+	if !schema.Fields[2].HasDefault || schema.Fields[2].Default != nil {
+		t.Errorf("expected null default")
+	}
+
+	expected := `{"type":"record","name":"com.example.Node","fields":[` +
+		`{"name":"value","type":{"type":"enum","name":"com.example.Color","symbols":["RED","GREEN"]}},` +
+		`{"name":"other","type":"com.example.Color"},` +
+		`{"name":"next","type":["null","com.example.Node"],"default":null}]}`
+	if schema.String() != expected {
+		t.Errorf("got\n%s\nexpected\n%s", schema.String(), expected)
+	}
+
+	// The output must parse back to the same thing.
+	reparsed, err := ParseSchema([]byte(schema.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reparsed.String() != expected {
+		t.Errorf("round trip: got\n%s", reparsed.String())
+	}
+}
+
+func TestParseSchemaLogicalTypes(t *testing.T) {
+	schema, err := ParseSchema([]byte(`{"type": "bytes", "logicalType": "decimal", "precision": 9, "scale": 3}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if schema.Type != "bytes" || schema.LogicalType != "decimal" || schema.Precision != 9 || schema.Scale != 3 {
+		t.Errorf("unexpected schema %+v", schema)
+	}
+	if schema.String() != `{"type":"bytes","logicalType":"decimal","precision":9,"scale":3}` {
+		t.Errorf("unexpected JSON %s", schema.String())
+	}
+}
+
+func TestParseSchemaErrors(t *testing.T) {
+	for _, text := range []string{
+		`nosuch`,
+		`"nosuch"`,
+		`{"name": "x"}`,
+		`{"type": "record", "name": "R"}`,
+		`{"type": "fixed", "name": "F", "size": -1}`,
+		`[["null"]]`,
+		`{"type": "record", "name": "R", "fields": [{"name": "a", "type": {"type": "enum", "name": "R", "symbols": []}}]}`,
+	} {
+		if _, err := ParseSchema([]byte(text)); err == nil {
+			t.Errorf("expected error for %s", text)
+		}
+	}
+}
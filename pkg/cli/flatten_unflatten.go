@@ -59,7 +59,8 @@ package cli
 // isNestable returns true for formats which can represent nested/array
 // structures natively, and thus don't need auto-flatten/auto-unflatten.
 func isNestable(format string) bool {
-	return format == "json" || format == "jsonl" || format == "yaml"
+	return format == "json" || format == "jsonl" || format == "yaml" || format == "parquet" || format == "arrow" || format == "avro" || format == "xml" ||
+		format == "msgpack" || format == "cbor" || format == "toml" || format == "geojson" || format == "template"
 }
 
 func DecideFinalFlatten(writerOptions *TWriterOptions) bool {
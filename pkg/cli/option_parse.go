@@ -83,6 +83,27 @@ func FinalizeReaderOptions(readerOptions *TReaderOptions) error {
 	return nil
 }
 
+// FinalizeSniffedReaderOptions is for -i auto: once an input file has been
+// sniffed, it returns a copy of the reader options for the detected format,
+// with the detected field separator if any. Separators from the command line
+// take precedence.
+func FinalizeSniffedReaderOptions(
+	readerOptions *TReaderOptions,
+	format string,
+	ifs string,
+) (*TReaderOptions, error) {
+	sniffedOptions := *readerOptions
+	sniffedOptions.InputFileFormat = format
+	if !sniffedOptions.ifsWasSpecified && ifs != "" {
+		sniffedOptions.IFS = ifs
+		sniffedOptions.ifsWasSpecified = true
+	}
+	if err := FinalizeReaderOptions(&sniffedOptions); err != nil {
+		return nil, err
+	}
+	return &sniffedOptions, nil
+}
+
 // FinalizeWriterOptions unbackslashes OPS, OFS, and ORS.  This is because
 // the '\n' at the command line which is Go "\\n" (a backslash and an
 // n) needs to become the single newline character., and likewise for "\t", etc.
@@ -123,6 +144,17 @@ var FLAG_TABLE = FlagTable{
 		&PPRINTOnlyFlagSection,
 		&MarkdownOnlyFlagSection,
 		&DKVPOnlyFlagSection,
+		&ParquetOnlyFlagSection,
+		&ArrowOnlyFlagSection,
+		&AvroOnlyFlagSection,
+		&XLSXOnlyFlagSection,
+		&XMLOnlyFlagSection,
+		&SQLiteOnlyFlagSection,
+		&SQLOnlyFlagSection,
+		&HTMLOnlyFlagSection,
+		&FixedWidthOnlyFlagSection,
+		&RegexOnlyFlagSection,
+		&GeoJSONOnlyFlagSection,
 		&CompressedDataFlagSection,
 		&CommentsInDataFlagSection,
 		&OutputColorizationFlagSection,
@@ -482,7 +514,7 @@ var SeparatorFlagSection = FlagSection{
 // JSON-ONLY FLAGS
 
 func JSONOnlyPrintInfo() {
-	fmt.Println("These are flags which are applicable to JSON output format.")
+	fmt.Println("These are flags which are applicable to JSON and YAML formats.")
 }
 
 func init() { JSONOnlyFlagSection.Sort() }
@@ -553,6 +585,49 @@ var JSONOnlyFlagSection = FlagSection{
 			},
 		},
 
+		{
+			name: "--yaml-comments",
+			help: "For YAML input, keep the comments before each record, without their `#`s, as its `_comment` field. " +
+				"For YAML output, write each record's `_comment` field as comment lines before it.",
+			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
+				options.ReaderOptions.YAMLComments = true
+				options.WriterOptions.YAMLComments = true
+				*pargi += 1
+				return nil
+			},
+		},
+
+		{
+			name: "--json-records-path",
+			arg:  "{JSON Pointer}",
+			help: "For JSON input, stream records from the array at this path, such as `/data` for `{\"meta\": {...}, \"data\": [...]}`, " +
+				"rather than from the top level. The path is a JSON Pointer (RFC 6901); the leading slash may be omitted. " +
+				"Array elements may be selected by index, as in `/results/0/items`.",
+			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
+				if err := CheckArgCount(args, *pargi, argc, 2); err != nil {
+					return err
+				}
+				if _, err := lib.ParseJSONPointer(args[*pargi+1]); err != nil {
+					return FlagErrorf("mlr: --json-records-path: %v", err)
+				}
+				options.ReaderOptions.JSONRecordsPath = args[*pargi+1]
+				*pargi += 2
+				return nil
+			},
+		},
+
+		{
+			name: "--json-records-siblings",
+			help: "With `--json-records-path`, attach to each record the other fields of the object holding the array, " +
+				"such as `meta` for `/data`, which come before the array in the input. Fields after the array can't be attached, " +
+				"since records are streamed as they are read. Record fields of the same name take precedence.",
+			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
+				options.ReaderOptions.JSONRecordsSiblings = true
+				*pargi += 1
+				return nil
+			},
+		},
+
 		{
 			name: "--jvquoteall",
 			help: "Force all JSON values -- recursively into lists and object -- to string.",
@@ -594,7 +669,8 @@ var PPRINTOnlyFlagSection = FlagSection{
 				"other fields left-justified. Headers are right-justified over columns " +
 				"whose values are all numeric, so that header and data share the same " +
 				"alignment. Also applies to markdown output, where numeric columns get " +
-				"right-alignment markers (`---:`) in the header-separator line.",
+				"right-alignment markers (`---:`) in the header-separator line, and to " +
+				"HTML, LaTeX, reStructuredText, AsciiDoc, and org-mode output.",
 			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
 				options.WriterOptions.RightAlignNumericOutput = true
 				*pargi += 1
@@ -729,249 +805,1125 @@ var DKVPOnlyFlagSection = FlagSection{
 	},
 }
 
-// LEGACY FLAGS
+// PARQUET-ONLY FLAGS
 
-func LegacyFlagInfoPrint() {
-	fmt.Println(`These are flags which don't do anything in the current Miller version.
-They are accepted as no-op flags in order to keep old scripts from breaking.`)
+func ParquetOnlyPrintInfo() {
+	fmt.Println("These are flags which are applicable to Parquet format.")
 }
 
-func init() { LegacyFlagSection.Sort() }
+func init() { ParquetOnlyFlagSection.Sort() }
 
-var LegacyFlagSection = FlagSection{
-	name:        "Legacy flags",
-	infoPrinter: LegacyFlagInfoPrint,
+var ParquetOnlyFlagSection = FlagSection{
+	name:        "Parquet-only flags",
+	infoPrinter: ParquetOnlyPrintInfo,
 	flags: []Flag{
 
 		{
-			name:   "--mmap",
-			help:   "Miller no longer uses memory-mapping to access data files.",
-			parser: NoOpParse1,
-		},
-
-		{
-			name:   "--no-mmap",
-			help:   "Miller no longer uses memory-mapping to access data files.",
-			parser: NoOpParse1,
+			name: "--parquet-compression",
+			arg:  "{name}",
+			help: "Compression codec for Parquet output: one of none, snappy, gzip, zstd, brotli, lz4. Default: `" +
+				DEFAULT_PARQUET_COMPRESSION + "`.",
+			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
+				if err := CheckArgCount(args, *pargi, argc, 2); err != nil {
+					return err
+				}
+				options.WriterOptions.ParquetCompression = args[*pargi+1]
+				*pargi += 2
+				return nil
+			},
 		},
 
 		{
-			name:   "--jsonx",
-			help:   "The `--jvstack` flag is now default true in Miller 6.",
-			parser: NoOpParse1,
-		},
-		{
-			name:   "--ojsonx",
-			help:   "The `--jvstack` flag is now default true in Miller 6.",
-			parser: NoOpParse1,
+			name: "--parquet-row-group-size",
+			arg:  "{n}",
+			help: "Number of records per row group for Parquet output. The output schema is inferred from the first row group. " +
+				"Default: " + fmt.Sprintf("%d", DEFAULT_PARQUET_ROW_GROUP_SIZE) + ".",
+			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
+				if err := CheckArgCount(args, *pargi, argc, 2); err != nil {
+					return err
+				}
+				rowGroupSize, ok := lib.TryIntFromString(args[*pargi+1])
+				if !ok || rowGroupSize <= 0 {
+					return FlagErrorf(
+						"%s: --parquet-row-group-size argument must be a positive integer; got \"%s\".",
+						"mlr", args[*pargi+1])
+				}
+				options.WriterOptions.ParquetRowGroupSize = rowGroupSize
+				*pargi += 2
+				return nil
+			},
 		},
+	},
+}
 
-		{
-			name:   "--jknquoteint",
-			help:   "Type information from JSON input files is now preserved throughout the processing stream.",
-			parser: NoOpParse1,
-		},
+// ARROW-ONLY FLAGS
 
-		{
-			name:   "--jquoteall",
-			help:   "Type information from JSON input files is now preserved throughout the processing stream.",
-			parser: NoOpParse1,
-		},
+func ArrowOnlyPrintInfo() {
+	fmt.Println("These are flags which are applicable to Arrow IPC format.")
+}
 
-		{
-			name:   "--json-fatal-arrays-on-input",
-			help:   "Miller now supports arrays as of version 6.",
-			parser: NoOpParse1,
-		},
+func init() { ArrowOnlyFlagSection.Sort() }
 
-		{
-			name:   "--json-map-arrays-on-input",
-			help:   "Miller now supports arrays as of version 6.",
-			parser: NoOpParse1,
-		},
+var ArrowOnlyFlagSection = FlagSection{
+	name:        "Arrow-only flags",
+	infoPrinter: ArrowOnlyPrintInfo,
+	flags: []Flag{
 
 		{
-			name:   "--json-skip-arrays-on-input",
-			help:   "Miller now supports arrays as of version 6.",
-			parser: NoOpParse1,
+			name: "--arrow-compression",
+			arg:  "{name}",
+			help: "Compression codec for Arrow output: one of none, lz4, zstd. Default: `" +
+				DEFAULT_ARROW_COMPRESSION + "`.",
+			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
+				if err := CheckArgCount(args, *pargi, argc, 2); err != nil {
+					return err
+				}
+				options.WriterOptions.ArrowCompression = args[*pargi+1]
+				*pargi += 2
+				return nil
+			},
 		},
 
 		{
-			name:   "--vflatsep",
-			help:   "Ignored as of version 6. This functionality is subsumed into JSON formatting.",
-			parser: NoOpParse1,
+			name: "--arrow-batch-size",
+			arg:  "{n}",
+			help: "Number of records per record batch for Arrow output. The output schema is inferred from the first batch. " +
+				"Default: " + fmt.Sprintf("%d", DEFAULT_ARROW_BATCH_SIZE) + ".",
+			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
+				if err := CheckArgCount(args, *pargi, argc, 2); err != nil {
+					return err
+				}
+				batchSize, ok := lib.TryIntFromString(args[*pargi+1])
+				if !ok || batchSize <= 0 {
+					return FlagErrorf(
+						"%s: --arrow-batch-size argument must be a positive integer; got \"%s\".",
+						"mlr", args[*pargi+1])
+				}
+				options.WriterOptions.ArrowBatchSize = batchSize
+				*pargi += 2
+				return nil
+			},
 		},
 
 		{
-			name:   "--quote-none",
-			help:   "Ignored as of version 6. Types are inferred/retained through the processing flow now.",
-			parser: NoOpParse1,
-		},
-		{
-			name:   "--quote-minimal",
-			help:   "Ignored as of version 6. Types are inferred/retained through the processing flow now.",
-			parser: NoOpParse1,
-		},
-		{
-			name:   "--quote-numeric",
-			help:   "Ignored as of version 6. Types are inferred/retained through the processing flow now.",
-			parser: NoOpParse1,
-		},
-		{
-			name:   "--quote-original",
-			help:   "Ignored as of version 6. Types are inferred/retained through the processing flow now.",
-			parser: NoOpParse1,
+			name: "--arrow-dictionary-strings",
+			help: "Dictionary-encode string columns in Arrow output. This makes for smaller output when there are many repeated values.",
+			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
+				options.WriterOptions.ArrowDictionaryStrings = true
+				*pargi += 1
+				return nil
+			},
 		},
 	},
 }
 
-// FILE-FORMAT FLAGS
-
-func FileFormatPrintInfo() {
-	fmt.Println(`See the File formats doc page, and or ` + "`mlr help file-formats`" + `, for more
-about file formats Miller supports.
-
-Examples: ` + "`--csv`" + ` for CSV-formatted input and output; ` + "`--icsv --opprint`" + ` for
-CSV-formatted input and pretty-printed output.
+// AVRO-ONLY FLAGS
 
-Please use ` + "`--iformat1 --oformat2`" + ` rather than ` + "`--format1 --oformat2`" + `.
-The latter sets up input and output flags for ` + "`format1`" + `, not all of which
-are overridden in all cases by setting output format to ` + "`format2`" + `.`)
+func AvroOnlyPrintInfo() {
+	fmt.Println("These are flags which are applicable to Avro format.")
 }
 
-func init() { FileFormatFlagSection.Sort() }
+func init() { AvroOnlyFlagSection.Sort() }
 
-var FileFormatFlagSection = FlagSection{
-	name:        "File-format flags",
-	infoPrinter: FileFormatPrintInfo,
+var AvroOnlyFlagSection = FlagSection{
+	name:        "Avro-only flags",
+	infoPrinter: AvroOnlyPrintInfo,
 	flags: []Flag{
 
 		{
-			name: "--icsv",
-			help: "Use CSV format for input data.",
+			name: "--avro-codec",
+			arg:  "{name}",
+			help: "Block codec for Avro output: one of null, deflate, snappy, zstandard. Default: `" +
+				DEFAULT_AVRO_CODEC + "`.",
 			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
-				options.ReaderOptions.InputFileFormat = "csv"
-				*pargi += 1
+				if err := CheckArgCount(args, *pargi, argc, 2); err != nil {
+					return err
+				}
+				options.WriterOptions.AvroCodec = args[*pargi+1]
+				*pargi += 2
 				return nil
 			},
 		},
 
 		{
-			name: "--icsvlite",
-			help: "Use CSV-lite format for input data.",
+			name: "--avro-block-size",
+			arg:  "{n}",
+			help: "Number of records per block for Avro output. Without --avro-schema, the output schema is inferred from the first block. " +
+				"Default: " + fmt.Sprintf("%d", DEFAULT_AVRO_BLOCK_SIZE) + ".",
 			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
-				options.ReaderOptions.InputFileFormat = "csvlite"
-				*pargi += 1
+				if err := CheckArgCount(args, *pargi, argc, 2); err != nil {
+					return err
+				}
+				blockSize, ok := lib.TryIntFromString(args[*pargi+1])
+				if !ok || blockSize <= 0 {
+					return FlagErrorf(
+						"%s: --avro-block-size argument must be a positive integer; got \"%s\".",
+						"mlr", args[*pargi+1])
+				}
+				options.WriterOptions.AvroBlockSize = blockSize
+				*pargi += 2
 				return nil
 			},
 		},
 
 		{
-			name: "--itsv",
-			help: "Use TSV format for input data.",
+			name: "--avro-schema",
+			arg:  "{filename}",
+			help: "Schema file (.avsc) for Avro output, rather than inferring the schema from the records. " +
+				"The schema must be a record, and each output record must fit it.",
 			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
-				options.ReaderOptions.InputFileFormat = "tsv"
-				*pargi += 1
+				if err := CheckArgCount(args, *pargi, argc, 2); err != nil {
+					return err
+				}
+				options.WriterOptions.AvroSchemaFile = args[*pargi+1]
+				*pargi += 2
 				return nil
 			},
 		},
+	},
+}
+
+// XLSX-ONLY FLAGS
+
+func XLSXOnlyPrintInfo() {
+	fmt.Println("These are flags which are applicable to XLSX (Excel spreadsheet) format.")
+}
+
+func init() { XLSXOnlyFlagSection.Sort() }
+
+var XLSXOnlyFlagSection = FlagSection{
+	name:        "XLSX-only flags",
+	infoPrinter: XLSXOnlyPrintInfo,
+	flags: []Flag{
+
 		{
-			name: "--itsvlite",
-			help: "Use TSV-lite format for input data.",
+			name: "--xlsx-sheet",
+			arg:  "{name or number}",
+			help: "Sheet to read from XLSX input, by name, or by number starting with 1. Default: the first sheet.",
 			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
-				options.ReaderOptions.InputFileFormat = "csvlite"
-				options.ReaderOptions.IFS = "\t"
-				options.ReaderOptions.ifsWasSpecified = true
-				*pargi += 1
+				if err := CheckArgCount(args, *pargi, argc, 2); err != nil {
+					return err
+				}
+				options.ReaderOptions.XLSXSheet = args[*pargi+1]
+				*pargi += 2
 				return nil
 			},
 		},
 
 		{
-			name:     "--iasv",
-			altNames: []string{"--iasvlite"},
-			help:     "Use ASV format for input data.",
+			name: "--xlsx-header-offset",
+			arg:  "{n}",
+			help: "Number of rows of XLSX input to skip before the header row, counting from the first row of the sheet, or of the `--xlsx-range` if given. Default: 0.",
 			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
-				options.ReaderOptions.InputFileFormat = "csvlite"
-				options.ReaderOptions.IFS = ASV_FS
-				options.ReaderOptions.IRS = ASV_RS
-				options.ReaderOptions.ifsWasSpecified = true
-				options.ReaderOptions.irsWasSpecified = true
-				*pargi += 1
+				if err := CheckArgCount(args, *pargi, argc, 2); err != nil {
+					return err
+				}
+				headerOffset, ok := lib.TryIntFromString(args[*pargi+1])
+				if !ok || headerOffset < 0 {
+					return FlagErrorf(
+						"%s: --xlsx-header-offset argument must be a non-negative integer; got \"%s\".",
+						"mlr", args[*pargi+1])
+				}
+				options.ReaderOptions.XLSXHeaderOffset = headerOffset
+				*pargi += 2
 				return nil
 			},
 		},
 
 		{
-			name:     "--iusv",
-			altNames: []string{"--iusvlite"},
-			help:     "Use USV format for input data.",
+			name: "--xlsx-range",
+			arg:  "{range}",
+			help: "Cells to read from XLSX input: e.g. `B2:F100`, `B:F` for whole columns, or `B2` for everything below and to the right of a cell. Default: the whole sheet.",
 			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
-				options.ReaderOptions.InputFileFormat = "csvlite"
-				options.ReaderOptions.IFS = USV_FS
-				options.ReaderOptions.IRS = USV_RS
-				options.ReaderOptions.ifsWasSpecified = true
-				options.ReaderOptions.irsWasSpecified = true
-				*pargi += 1
+				if err := CheckArgCount(args, *pargi, argc, 2); err != nil {
+					return err
+				}
+				options.ReaderOptions.XLSXRange = args[*pargi+1]
+				*pargi += 2
 				return nil
 			},
 		},
 
 		{
-			name: "--idkvp",
-			help: "Use DKVP format for input data.",
+			name: "--xlsx-one-sheet",
+			help: "For XLSX output: when the record keys change, start a new header block after an empty row in the same sheet, as with CSV-lite output, rather than starting a new sheet.",
 			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
-				options.ReaderOptions.InputFileFormat = "dkvp"
+				options.WriterOptions.XLSXOneSheet = true
 				*pargi += 1
 				return nil
 			},
 		},
+	},
+}
+
+// XML-ONLY FLAGS
+
+func XMLOnlyPrintInfo() {
+	fmt.Println("These are flags which are applicable to XML format.")
+}
+
+func init() { XMLOnlyFlagSection.Sort() }
+
+var XMLOnlyFlagSection = FlagSection{
+	name:        "XML-only flags",
+	infoPrinter: XMLOnlyPrintInfo,
+	flags: []Flag{
 
 		{
-			name: "--ijson",
-			help: "Use JSON format for input data.",
+			name: "--xml-record-path",
+			arg:  "{path}",
+			help: "Which XML elements are records, such as `/feed/entry`. A path starting with `/` is from the document root; otherwise, such as `entry`, it matches at any depth. Path components may be `*` to match any element. " +
+				"For XML output, the path gives the names of the enclosing elements and of the record element. Default: `" + DEFAULT_XML_RECORD_PATH + "`, i.e. the children of the root element.",
 			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
-				options.ReaderOptions.InputFileFormat = "json"
-				*pargi += 1
+				if err := CheckArgCount(args, *pargi, argc, 2); err != nil {
+					return err
+				}
+				options.ReaderOptions.XMLRecordPath = args[*pargi+1]
+				options.WriterOptions.XMLRecordPath = args[*pargi+1]
+				*pargi += 2
+				return nil
+			},
+		},
+	},
+}
+
+// SQLITE-ONLY FLAGS
+
+func SQLiteOnlyPrintInfo() {
+	fmt.Println("These are flags which are applicable to SQLite format.")
+}
+
+func init() { SQLiteOnlyFlagSection.Sort() }
+
+var SQLiteOnlyFlagSection = FlagSection{
+	name:        "SQLite-only flags",
+	infoPrinter: SQLiteOnlyPrintInfo,
+	flags: []Flag{
+
+		{
+			name: "--sqlite-query",
+			arg:  "{SQL}",
+			help: "Query to run against SQLite input, such as `'select * from t where x > 1'`. Each result row is a record.",
+			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
+				if err := CheckArgCount(args, *pargi, argc, 2); err != nil {
+					return err
+				}
+				options.ReaderOptions.SQLiteQuery = args[*pargi+1]
+				*pargi += 2
+				return nil
+			},
+		},
+
+		{
+			name: "--sqlite-table",
+			arg:  "{name}",
+			help: "Table to read from SQLite input, if `--sqlite-query` isn't given; needed only if the database has more than one table. " +
+				"Also, the table to write SQLite output to. Default for output: `" + DEFAULT_SQLITE_TABLE + "`.",
+			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
+				if err := CheckArgCount(args, *pargi, argc, 2); err != nil {
+					return err
+				}
+				options.ReaderOptions.SQLiteTable = args[*pargi+1]
+				options.WriterOptions.SQLiteTable = args[*pargi+1]
+				*pargi += 2
+				return nil
+			},
+		},
+
+		{
+			name: "--sqlite-output",
+			arg:  "{filename}",
+			help: "Database file to write SQLite output into, which is created if it doesn't exist. If the table already exists, records are added to it. " +
+				"Without this flag, a new database is written to standard output, so `> out.db` works.",
+			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
+				if err := CheckArgCount(args, *pargi, argc, 2); err != nil {
+					return err
+				}
+				options.WriterOptions.SQLiteOutput = args[*pargi+1]
+				*pargi += 2
+				return nil
+			},
+		},
+
+		{
+			name: "--sqlite-batch-size",
+			arg:  "{n}",
+			help: "Number of records per transaction for SQLite output. " +
+				"Default: " + fmt.Sprintf("%d", DEFAULT_SQLITE_BATCH_SIZE) + ".",
+			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
+				if err := CheckArgCount(args, *pargi, argc, 2); err != nil {
+					return err
+				}
+				batchSize, ok := lib.TryIntFromString(args[*pargi+1])
+				if !ok || batchSize <= 0 {
+					return FlagErrorf(
+						"%s: --sqlite-batch-size argument must be a positive integer; got \"%s\".",
+						"mlr", args[*pargi+1])
+				}
+				options.WriterOptions.SQLiteBatchSize = batchSize
+				*pargi += 2
+				return nil
+			},
+		},
+	},
+}
+
+// SQL-ONLY FLAGS
+
+func SQLOnlyPrintInfo() {
+	fmt.Println("These are flags which are applicable to SQL-statement output, via --osql.")
+}
+
+func init() { SQLOnlyFlagSection.Sort() }
+
+var SQLOnlyFlagSection = FlagSection{
+	name:        "SQL-only flags",
+	infoPrinter: SQLOnlyPrintInfo,
+	flags: []Flag{
+
+		{
+			name: "--sql-table",
+			arg:  "{name}",
+			help: "Table to create and insert into for SQL output. Default: `" + DEFAULT_SQL_TABLE + "`.",
+			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
+				if err := CheckArgCount(args, *pargi, argc, 2); err != nil {
+					return err
+				}
+				options.WriterOptions.SQLTable = args[*pargi+1]
+				*pargi += 2
+				return nil
+			},
+		},
+
+		{
+			name: "--sql-dialect",
+			arg:  "{name}",
+			help: "Database to write SQL output for, which determines identifier and string quoting and column type names: " +
+				"`postgres`, `mysql`, or `sqlite`. Default: `" + DEFAULT_SQL_DIALECT + "`.",
+			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
+				if err := CheckArgCount(args, *pargi, argc, 2); err != nil {
+					return err
+				}
+				dialect := args[*pargi+1]
+				if dialect != "postgres" && dialect != "mysql" && dialect != "sqlite" {
+					return FlagErrorf(
+						"%s: --sql-dialect argument must be postgres, mysql, or sqlite; got \"%s\".",
+						"mlr", dialect)
+				}
+				options.WriterOptions.SQLDialect = dialect
+				*pargi += 2
+				return nil
+			},
+		},
+
+		{
+			name: "--sql-infer-records",
+			arg:  "{n}",
+			help: "Number of records to infer column types from for the CREATE TABLE statement of SQL output. " +
+				"Default: " + fmt.Sprintf("%d", DEFAULT_SQL_INFER_RECORDS) + ".",
+			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
+				if err := CheckArgCount(args, *pargi, argc, 2); err != nil {
+					return err
+				}
+				n, ok := lib.TryIntFromString(args[*pargi+1])
+				if !ok || n <= 0 {
+					return FlagErrorf(
+						"%s: --sql-infer-records argument must be a positive integer; got \"%s\".",
+						"mlr", args[*pargi+1])
+				}
+				options.WriterOptions.SQLInferRecords = n
+				*pargi += 2
+				return nil
+			},
+		},
+
+		{
+			name: "--sql-batch-size",
+			arg:  "{n}",
+			help: "Maximum number of rows per INSERT statement for SQL output. " +
+				"Default: " + fmt.Sprintf("%d", DEFAULT_SQL_BATCH_SIZE) + ".",
+			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
+				if err := CheckArgCount(args, *pargi, argc, 2); err != nil {
+					return err
+				}
+				batchSize, ok := lib.TryIntFromString(args[*pargi+1])
+				if !ok || batchSize <= 0 {
+					return FlagErrorf(
+						"%s: --sql-batch-size argument must be a positive integer; got \"%s\".",
+						"mlr", args[*pargi+1])
+				}
+				options.WriterOptions.SQLBatchSize = batchSize
+				*pargi += 2
+				return nil
+			},
+		},
+
+		{
+			name: "--sql-copy",
+			help: "For SQL output, write records as Postgres `COPY ... FROM stdin` blocks, for psql, rather than as INSERT statements. " +
+				"Only for the postgres dialect.",
+			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
+				options.WriterOptions.SQLCopy = true
+				*pargi += 1
+				return nil
+			},
+		},
+
+		{
+			name: "--sql-no-create-table",
+			help: "For SQL output, don't write CREATE TABLE or ALTER TABLE statements, for inserting into an existing table.",
+			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
+				options.WriterOptions.SQLNoCreateTable = true
+				*pargi += 1
+				return nil
+			},
+		},
+	},
+}
+
+// HTML-ONLY FLAGS
+
+func HTMLOnlyPrintInfo() {
+	fmt.Println("These are flags which are applicable to HTML format.")
+}
+
+func init() { HTMLOnlyFlagSection.Sort() }
+
+var HTMLOnlyFlagSection = FlagSection{
+	name:        "HTML-only flags",
+	infoPrinter: HTMLOnlyPrintInfo,
+	flags: []Flag{
+
+		{
+			name: "--html-table",
+			arg:  "{number or id}",
+			help: "Table to read from HTML input: a number starting with 1, counting tables in document order, or the id of a table. " +
+				"Default: the first table.",
+			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
+				if err := CheckArgCount(args, *pargi, argc, 2); err != nil {
+					return err
+				}
+				options.ReaderOptions.HTMLTable = args[*pargi+1]
+				*pargi += 2
+				return nil
+			},
+		},
+
+		{
+			name: "--html-standalone",
+			help: "Write HTML output as a complete document, with a minimal stylesheet, rather than just the tables.",
+			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
+				options.WriterOptions.HTMLStandalone = true
+				*pargi += 1
+				return nil
+			},
+		},
+
+		{
+			name: "--html-title",
+			arg:  "{title}",
+			help: "Title for `--html-standalone` output. Default: `" + DEFAULT_HTML_TITLE + "`.",
+			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
+				if err := CheckArgCount(args, *pargi, argc, 2); err != nil {
+					return err
+				}
+				options.WriterOptions.HTMLTitle = args[*pargi+1]
+				*pargi += 2
+				return nil
+			},
+		},
+	},
+}
+
+// FIXED-WIDTH-ONLY FLAGS
+
+func FixedWidthOnlyPrintInfo() {
+	fmt.Println(`These are flags which are applicable to fixed-width format, for input
+via --ifixed or --ipprint, and output via --ofixed. A column-spec file has one
+column per line: name, width, and optionally align=left or align=right,
+pad=C for the pad character, and overflow=truncate or overflow=error for
+output values which are longer than the width. Names with spaces may be
+double-quoted. Lines starting with # are comments. For example:
+
+  # name  width  options
+  id      6      align=right pad=0
+  name    20
+  amount  10     align=right overflow=error
+
+With a column spec, input lines have no header line; field names are taken
+from the spec.`)
+}
+
+func init() { FixedWidthOnlyFlagSection.Sort() }
+
+var FixedWidthOnlyFlagSection = FlagSection{
+	name:        "Fixed-width-only flags",
+	infoPrinter: FixedWidthOnlyPrintInfo,
+	flags: []Flag{
+
+		{
+			name: "--fixed-spec",
+			arg:  "{filename}",
+			help: "Column-spec file for fixed-width input and output.",
+			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
+				if err := CheckArgCount(args, *pargi, argc, 2); err != nil {
+					return err
+				}
+				options.ReaderOptions.FixedWidthSpecFile = args[*pargi+1]
+				options.WriterOptions.FixedWidthSpecFile = args[*pargi+1]
+				*pargi += 2
+				return nil
+			},
+		},
+
+		{
+			name: "--ifixed-spec",
+			arg:  "{filename}",
+			help: "Column-spec file for fixed-width input.",
+			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
+				if err := CheckArgCount(args, *pargi, argc, 2); err != nil {
+					return err
+				}
+				options.ReaderOptions.FixedWidthSpecFile = args[*pargi+1]
+				*pargi += 2
+				return nil
+			},
+		},
+
+		{
+			name: "--ofixed-spec",
+			arg:  "{filename}",
+			help: "Column-spec file for fixed-width output.",
+			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
+				if err := CheckArgCount(args, *pargi, argc, 2); err != nil {
+					return err
+				}
+				options.WriterOptions.FixedWidthSpecFile = args[*pargi+1]
+				*pargi += 2
+				return nil
+			},
+		},
+	},
+}
+
+// REGEX-INPUT-ONLY FLAGS
+
+func RegexOnlyPrintInfo() {
+	fmt.Println(`These are flags which are applicable to regex input, via --iregex. The
+presets are:`)
+	fmt.Println()
+	for _, preset := range lib.REGEX_PRESETS {
+		fmt.Printf("  %-15s %s\n", preset.Name, preset.Description)
+	}
+}
+
+func init() { RegexOnlyFlagSection.Sort() }
+
+var RegexOnlyFlagSection = FlagSection{
+	name:        "Regex-input-only flags",
+	infoPrinter: RegexOnlyPrintInfo,
+	flags: []Flag{
+
+		{
+			name: "--regex-nonmatch",
+			arg:  "{skip|raw|error}",
+			help: "What to do with input lines which don't match the `--iregex` regex: skip them; " +
+				"make a record with the line in a field named `_raw`; or stop with an error. Default: skip.",
+			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
+				if err := CheckArgCount(args, *pargi, argc, 2); err != nil {
+					return err
+				}
+				policy := args[*pargi+1]
+				if policy != "skip" && policy != "raw" && policy != "error" {
+					return FlagErrorf("mlr: --regex-nonmatch: expected skip, raw, or error; got \"%s\".", policy)
+				}
+				options.ReaderOptions.RegexNonMatch = policy
+				*pargi += 2
+				return nil
+			},
+		},
+
+		{
+			name: "--regex-reject-file",
+			arg:  "{filename}",
+			help: "Write input lines which don't match the `--iregex` regex to this file, rather than skipping them.",
+			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
+				if err := CheckArgCount(args, *pargi, argc, 2); err != nil {
+					return err
+				}
+				options.ReaderOptions.RegexNonMatch = "reject"
+				options.ReaderOptions.RegexRejectFile = args[*pargi+1]
+				*pargi += 2
+				return nil
+			},
+		},
+	},
+}
+
+// GEOJSON-ONLY FLAGS
+
+func GeoJSONOnlyPrintInfo() {
+	fmt.Println(`These are flags which are applicable to GeoJSON output, via --ogeojson.`)
+}
+
+func init() { GeoJSONOnlyFlagSection.Sort() }
+
+var GeoJSONOnlyFlagSection = FlagSection{
+	name:        "GeoJSON-only flags",
+	infoPrinter: GeoJSONOnlyPrintInfo,
+	flags: []Flag{
+
+		{
+			name: "--geojson-lat-field",
+			arg:  "{name}",
+			help: "For GeoJSON output, make each feature's geometry a Point from this latitude field and the `--geojson-lon-field` longitude field, " +
+				"rather than from the `geometry` field. Records where either is absent or empty get their geometry from the `geometry` field, if any.",
+			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
+				if err := CheckArgCount(args, *pargi, argc, 2); err != nil {
+					return err
+				}
+				options.WriterOptions.GeoJSONLatField = args[*pargi+1]
+				*pargi += 2
+				return nil
+			},
+		},
+
+		{
+			name: "--geojson-lon-field",
+			arg:  "{name}",
+			help: "For GeoJSON output, the longitude field to go with `--geojson-lat-field`.",
+			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
+				if err := CheckArgCount(args, *pargi, argc, 2); err != nil {
+					return err
+				}
+				options.WriterOptions.GeoJSONLonField = args[*pargi+1]
+				*pargi += 2
+				return nil
+			},
+		},
+	},
+}
+
+// LEGACY FLAGS
+
+func LegacyFlagInfoPrint() {
+	fmt.Println(`These are flags which don't do anything in the current Miller version.
+They are accepted as no-op flags in order to keep old scripts from breaking.`)
+}
+
+func init() { LegacyFlagSection.Sort() }
+
+var LegacyFlagSection = FlagSection{
+	name:        "Legacy flags",
+	infoPrinter: LegacyFlagInfoPrint,
+	flags: []Flag{
+
+		{
+			name:   "--mmap",
+			help:   "Miller no longer uses memory-mapping to access data files.",
+			parser: NoOpParse1,
+		},
+
+		{
+			name:   "--no-mmap",
+			help:   "Miller no longer uses memory-mapping to access data files.",
+			parser: NoOpParse1,
+		},
+
+		{
+			name:   "--jsonx",
+			help:   "The `--jvstack` flag is now default true in Miller 6.",
+			parser: NoOpParse1,
+		},
+		{
+			name:   "--ojsonx",
+			help:   "The `--jvstack` flag is now default true in Miller 6.",
+			parser: NoOpParse1,
+		},
+
+		{
+			name:   "--jknquoteint",
+			help:   "Type information from JSON input files is now preserved throughout the processing stream.",
+			parser: NoOpParse1,
+		},
+
+		{
+			name:   "--jquoteall",
+			help:   "Type information from JSON input files is now preserved throughout the processing stream.",
+			parser: NoOpParse1,
+		},
+
+		{
+			name:   "--json-fatal-arrays-on-input",
+			help:   "Miller now supports arrays as of version 6.",
+			parser: NoOpParse1,
+		},
+
+		{
+			name:   "--json-map-arrays-on-input",
+			help:   "Miller now supports arrays as of version 6.",
+			parser: NoOpParse1,
+		},
+
+		{
+			name:   "--json-skip-arrays-on-input",
+			help:   "Miller now supports arrays as of version 6.",
+			parser: NoOpParse1,
+		},
+
+		{
+			name:   "--vflatsep",
+			help:   "Ignored as of version 6. This functionality is subsumed into JSON formatting.",
+			parser: NoOpParse1,
+		},
+
+		{
+			name:   "--quote-none",
+			help:   "Ignored as of version 6. Types are inferred/retained through the processing flow now.",
+			parser: NoOpParse1,
+		},
+		{
+			name:   "--quote-minimal",
+			help:   "Ignored as of version 6. Types are inferred/retained through the processing flow now.",
+			parser: NoOpParse1,
+		},
+		{
+			name:   "--quote-numeric",
+			help:   "Ignored as of version 6. Types are inferred/retained through the processing flow now.",
+			parser: NoOpParse1,
+		},
+		{
+			name:   "--quote-original",
+			help:   "Ignored as of version 6. Types are inferred/retained through the processing flow now.",
+			parser: NoOpParse1,
+		},
+	},
+}
+
+// FILE-FORMAT FLAGS
+
+func FileFormatPrintInfo() {
+	fmt.Println(`See the File formats doc page, and or ` + "`mlr help file-formats`" + `, for more
+about file formats Miller supports.
+
+Examples: ` + "`--csv`" + ` for CSV-formatted input and output; ` + "`--icsv --opprint`" + ` for
+CSV-formatted input and pretty-printed output.
+
+Please use ` + "`--iformat1 --oformat2`" + ` rather than ` + "`--format1 --oformat2`" + `.
+The latter sets up input and output flags for ` + "`format1`" + `, not all of which
+are overridden in all cases by setting output format to ` + "`format2`" + `.`)
+}
+
+func init() { FileFormatFlagSection.Sort() }
+
+var FileFormatFlagSection = FlagSection{
+	name:        "File-format flags",
+	infoPrinter: FileFormatPrintInfo,
+	flags: []Flag{
+
+		{
+			name: "--icsv",
+			help: "Use CSV format for input data.",
+			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
+				options.ReaderOptions.InputFileFormat = "csv"
+				*pargi += 1
+				return nil
+			},
+		},
+
+		{
+			name: "--icsvlite",
+			help: "Use CSV-lite format for input data.",
+			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
+				options.ReaderOptions.InputFileFormat = "csvlite"
+				*pargi += 1
+				return nil
+			},
+		},
+
+		{
+			name: "--itsv",
+			help: "Use TSV format for input data.",
+			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
+				options.ReaderOptions.InputFileFormat = "tsv"
+				*pargi += 1
+				return nil
+			},
+		},
+		{
+			name: "--itsvlite",
+			help: "Use TSV-lite format for input data.",
+			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
+				options.ReaderOptions.InputFileFormat = "csvlite"
+				options.ReaderOptions.IFS = "\t"
+				options.ReaderOptions.ifsWasSpecified = true
+				*pargi += 1
+				return nil
+			},
+		},
+
+		{
+			name:     "--iasv",
+			altNames: []string{"--iasvlite"},
+			help:     "Use ASV format for input data.",
+			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
+				options.ReaderOptions.InputFileFormat = "csvlite"
+				options.ReaderOptions.IFS = ASV_FS
+				options.ReaderOptions.IRS = ASV_RS
+				options.ReaderOptions.ifsWasSpecified = true
+				options.ReaderOptions.irsWasSpecified = true
+				*pargi += 1
+				return nil
+			},
+		},
+
+		{
+			name:     "--iusv",
+			altNames: []string{"--iusvlite"},
+			help:     "Use USV format for input data.",
+			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
+				options.ReaderOptions.InputFileFormat = "csvlite"
+				options.ReaderOptions.IFS = USV_FS
+				options.ReaderOptions.IRS = USV_RS
+				options.ReaderOptions.ifsWasSpecified = true
+				options.ReaderOptions.irsWasSpecified = true
+				*pargi += 1
+				return nil
+			},
+		},
+
+		{
+			name: "--idkvp",
+			help: "Use DKVP format for input data.",
+			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
+				options.ReaderOptions.InputFileFormat = "dkvp"
+				*pargi += 1
+				return nil
+			},
+		},
+
+		{
+			name: "--ijson",
+			help: "Use JSON format for input data.",
+			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
+				options.ReaderOptions.InputFileFormat = "json"
+				*pargi += 1
+				return nil
+			},
+		},
+
+		{
+			name: "--ijsonl",
+			help: "Use JSON Lines format for input data.",
+			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
+				options.ReaderOptions.InputFileFormat = "json"
+				*pargi += 1
+				return nil
+			},
+		},
+
+		{
+			name: "--iyaml",
+			help: "Use YAML format for input data.",
+			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
+				options.ReaderOptions.InputFileFormat = "yaml"
+				*pargi += 1
+				return nil
+			},
+		},
+
+		{
+			name: "--idcf",
+			help: "Use Debian control file (DCF) format for input data.",
+			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
+				options.ReaderOptions.InputFileFormat = "dcf"
+				*pargi += 1
+				return nil
+			},
+		},
+
+		{
+			name: "--irecutils",
+			help: "Use GNU recutils (.rec) format for input data.",
+			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
+				options.ReaderOptions.InputFileFormat = "recutils"
+				*pargi += 1
+				return nil
+			},
+		},
+
+		{
+			name: "--iparquet",
+			help: "Use Parquet format for input data.",
+			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
+				options.ReaderOptions.InputFileFormat = "parquet"
+				*pargi += 1
+				return nil
+			},
+		},
+
+		{
+			name: "--iarrow",
+			help: "Use Arrow IPC format for input data.",
+			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
+				options.ReaderOptions.InputFileFormat = "arrow"
+				*pargi += 1
+				return nil
+			},
+		},
+
+		{
+			name: "--iavro",
+			help: "Use Avro object-container-file format for input data.",
+			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
+				options.ReaderOptions.InputFileFormat = "avro"
+				*pargi += 1
+				return nil
+			},
+		},
+
+		{
+			name: "--ixlsx",
+			help: "Use XLSX (Excel spreadsheet) format for input data.",
+			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
+				options.ReaderOptions.InputFileFormat = "xlsx"
+				*pargi += 1
+				return nil
+			},
+		},
+
+		{
+			name: "--ixml",
+			help: "Use XML format for input data.",
+			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
+				options.ReaderOptions.InputFileFormat = "xml"
+				*pargi += 1
 				return nil
 			},
 		},
 
 		{
-			name: "--ijsonl",
-			help: "Use JSON Lines format for input data.",
+			name: "--isqlite",
+			help: "Use SQLite format for input data.",
 			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
-				options.ReaderOptions.InputFileFormat = "json"
+				options.ReaderOptions.InputFileFormat = "sqlite"
 				*pargi += 1
 				return nil
 			},
 		},
 
 		{
-			name: "--iyaml",
-			help: "Use YAML format for input data.",
+			name: "--ihtml",
+			help: "Use HTML-table format for input data.",
 			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
-				options.ReaderOptions.InputFileFormat = "yaml"
+				options.ReaderOptions.InputFileFormat = "html"
 				*pargi += 1
 				return nil
 			},
 		},
 
 		{
-			name: "--idcf",
-			help: "Use Debian control file (DCF) format for input data.",
+			name: "--ifixed",
+			help: "Use fixed-width format for input data, with columns as given by `--fixed-spec` or `--ifixed-spec`, or else as for `--ipprint --fw`.",
 			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
-				options.ReaderOptions.InputFileFormat = "dcf"
+				options.ReaderOptions.InputFileFormat = "fixed"
 				*pargi += 1
 				return nil
 			},
 		},
 
 		{
-			name: "--irecutils",
-			help: "Use GNU recutils (.rec) format for input data.",
+			name: "--imsgpack",
+			help: "Use MessagePack format for input data.",
 			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
-				options.ReaderOptions.InputFileFormat = "recutils"
+				options.ReaderOptions.InputFileFormat = "msgpack"
+				*pargi += 1
+				return nil
+			},
+		},
+
+		{
+			name: "--icbor",
+			help: "Use CBOR format for input data.",
+			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
+				options.ReaderOptions.InputFileFormat = "cbor"
+				*pargi += 1
+				return nil
+			},
+		},
+
+		{
+			name: "--ilogfmt",
+			help: "Use logfmt format for input data.",
+			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
+				options.ReaderOptions.InputFileFormat = "logfmt"
+				*pargi += 1
+				return nil
+			},
+		},
+
+		{
+			name: "--iltsv",
+			help: "Use LTSV format for input data.",
+			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
+				options.ReaderOptions.InputFileFormat = "ltsv"
+				*pargi += 1
+				return nil
+			},
+		},
+
+		{
+			name: "--iregex",
+			arg:  "{regex or preset}",
+			help: "Use regex format for input data: each line matching the regex is a record, with fields named by its named capture groups. " +
+				"Unnamed groups are named by their position, starting at 1. Instead of a regex, you can give the name of a preset: " +
+				lib.GetRegexPresetNames() + ". See also `--regex-nonmatch`.",
+			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
+				if err := CheckArgCount(args, *pargi, argc, 2); err != nil {
+					return err
+				}
+				options.ReaderOptions.InputFileFormat = "regex"
+				options.ReaderOptions.RegexPattern = args[*pargi+1]
+				*pargi += 2
+				return nil
+			},
+		},
+
+		{
+			name: "--itoml",
+			help: "Use TOML format for input data: each table, or array-of-tables entry, is a record, with the table name in a `_section` field.",
+			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
+				options.ReaderOptions.InputFileFormat = "toml"
+				*pargi += 1
+				return nil
+			},
+		},
+
+		{
+			name: "--iini",
+			help: "Use INI format for input data: each section is a record, with the section name in a `_section` field.",
+			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
+				options.ReaderOptions.InputFileFormat = "ini"
+				*pargi += 1
+				return nil
+			},
+		},
+
+		{
+			name: "--igeojson",
+			help: "Use GeoJSON format for input data: each feature of a FeatureCollection is a record, with its properties as fields, and its geometry as a map in a `geometry` field.",
+			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
+				options.ReaderOptions.InputFileFormat = "geojson"
+				*pargi += 1
+				return nil
+			},
+		},
+
+		{
+			name: "--iorg",
+			help: "Use Emacs org-mode table format for input data.",
+			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
+				options.ReaderOptions.InputFileFormat = "org"
 				*pargi += 1
 				return nil
 			},
@@ -1010,9 +1962,12 @@ var FileFormatFlagSection = FlagSection{
 		},
 
 		{
-			name: "-i",
-			arg:  "{format name}",
-			help: "Use format name for input data. For example: `-i csv` is the same as `--icsv`.",
+			name:     "-i",
+			altNames: []string{"--ifmt"},
+			arg:      "{format name}",
+			help: "Use format name for input data. For example: `-i csv` is the same as `--icsv`. " +
+				"Use `-i auto` to detect CSV, TSV, JSON, JSON Lines, DKVP, NIDX, or XTAB from the start of each file; " +
+				"see `mlr sniff --help`.",
 			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
 				if err := CheckArgCount(args, *pargi, argc, 2); err != nil {
 					return err
@@ -1166,117 +2121,331 @@ var FileFormatFlagSection = FlagSection{
 		},
 
 		{
-			name:     "--ousv",
-			altNames: []string{"--ousvlite"},
-			help:     "Use USV format for output data.",
+			name:     "--ousv",
+			altNames: []string{"--ousvlite"},
+			help:     "Use USV format for output data.",
+			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
+				options.WriterOptions.OutputFileFormat = "csvlite"
+				options.WriterOptions.OFS = USV_FS
+				options.WriterOptions.ORS = USV_RS
+				options.WriterOptions.ofsWasSpecified = true
+				options.WriterOptions.orsWasSpecified = true
+				*pargi += 1
+				return nil
+			},
+		},
+
+		{
+			name:     "--imd",
+			altNames: []string{"--imarkdown"},
+			help:     "Use markdown-tabular format for input data.",
+			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
+				options.ReaderOptions.InputFileFormat = "markdown"
+				*pargi += 1
+				return nil
+			},
+		},
+
+		{
+			name:     "--omd",
+			altNames: []string{"--omarkdown"},
+			help:     "Use markdown-tabular format for output data.",
+			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
+				options.WriterOptions.OutputFileFormat = "markdown"
+				*pargi += 1
+				return nil
+			},
+		},
+
+		{
+			name:     "--md",
+			altNames: []string{"--markdown"},
+			help:     "Use markdown-tabular format for input and output data.",
+			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
+				options.ReaderOptions.InputFileFormat = "markdown"
+				options.WriterOptions.OutputFileFormat = "markdown"
+				*pargi += 1
+				return nil
+			},
+		},
+
+		{
+			name: "--odkvp",
+			help: "Use DKVP format for output data.",
+			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
+				options.WriterOptions.OutputFileFormat = "dkvp"
+				*pargi += 1
+				return nil
+			},
+		},
+
+		{
+			name: "--ojson",
+			help: "Use JSON format for output data.",
+			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
+				options.WriterOptions.OutputFileFormat = "json"
+				options.WriterOptions.WrapJSONOutputInOuterList = true
+				options.WriterOptions.JSONOutputMultiline = true
+				*pargi += 1
+				return nil
+			},
+		},
+
+		{
+			name: "--ojsonl",
+			help: "Use JSON Lines format for output data.",
+			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
+				options.WriterOptions.OutputFileFormat = "jsonl"
+				*pargi += 1
+				return nil
+			},
+		},
+
+		{
+			name: "--oyaml",
+			help: "Use YAML format for output data.",
+			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
+				options.WriterOptions.OutputFileFormat = "yaml"
+				options.WriterOptions.WrapYAMLOutputInOuterList = true
+				*pargi += 1
+				return nil
+			},
+		},
+
+		{
+			name: "--odcf",
+			help: "Use Debian control file (DCF) format for output data.",
+			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
+				options.WriterOptions.OutputFileFormat = "dcf"
+				*pargi += 1
+				return nil
+			},
+		},
+
+		{
+			name: "--orecutils",
+			help: "Use GNU recutils (.rec) format for output data.",
+			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
+				options.WriterOptions.OutputFileFormat = "recutils"
+				*pargi += 1
+				return nil
+			},
+		},
+
+		{
+			name: "--oparquet",
+			help: "Use Parquet format for output data.",
+			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
+				options.WriterOptions.OutputFileFormat = "parquet"
+				*pargi += 1
+				return nil
+			},
+		},
+
+		{
+			name: "--oarrow",
+			help: "Use Arrow IPC format for output data.",
+			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
+				options.WriterOptions.OutputFileFormat = "arrow"
+				*pargi += 1
+				return nil
+			},
+		},
+
+		{
+			name: "--oavro",
+			help: "Use Avro object-container-file format for output data.",
+			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
+				options.WriterOptions.OutputFileFormat = "avro"
+				*pargi += 1
+				return nil
+			},
+		},
+
+		{
+			name: "--oxlsx",
+			help: "Use XLSX (Excel spreadsheet) format for output data.",
+			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
+				options.WriterOptions.OutputFileFormat = "xlsx"
+				*pargi += 1
+				return nil
+			},
+		},
+
+		{
+			name: "--oxml",
+			help: "Use XML format for output data.",
+			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
+				options.WriterOptions.OutputFileFormat = "xml"
+				*pargi += 1
+				return nil
+			},
+		},
+
+		{
+			name: "--osqlite",
+			help: "Use SQLite format for output data.",
+			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
+				options.WriterOptions.OutputFileFormat = "sqlite"
+				*pargi += 1
+				return nil
+			},
+		},
+
+		{
+			name: "--ohtml",
+			help: "Use HTML-table format for output data.",
+			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
+				options.WriterOptions.OutputFileFormat = "html"
+				*pargi += 1
+				return nil
+			},
+		},
+
+		{
+			name: "--olatex",
+			help: "Use LaTeX tabular format, with booktabs rules, for output data.",
+			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
+				options.WriterOptions.OutputFileFormat = "latex"
+				*pargi += 1
+				return nil
+			},
+		},
+
+		{
+			name: "--orst",
+			help: "Use reStructuredText grid-table format for output data.",
+			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
+				options.WriterOptions.OutputFileFormat = "rst"
+				*pargi += 1
+				return nil
+			},
+		},
+
+		{
+			name: "--oasciidoc",
+			help: "Use AsciiDoc table format for output data.",
+			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
+				options.WriterOptions.OutputFileFormat = "asciidoc"
+				*pargi += 1
+				return nil
+			},
+		},
+
+		{
+			name: "--ofixed",
+			help: "Use fixed-width format for output data, with columns as given by `--fixed-spec` or `--ofixed-spec`.",
 			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
-				options.WriterOptions.OutputFileFormat = "csvlite"
-				options.WriterOptions.OFS = USV_FS
-				options.WriterOptions.ORS = USV_RS
-				options.WriterOptions.ofsWasSpecified = true
-				options.WriterOptions.orsWasSpecified = true
+				options.WriterOptions.OutputFileFormat = "fixed"
 				*pargi += 1
 				return nil
 			},
 		},
 
 		{
-			name:     "--imd",
-			altNames: []string{"--imarkdown"},
-			help:     "Use markdown-tabular format for input data.",
+			name: "--omsgpack",
+			help: "Use MessagePack format for output data.",
 			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
-				options.ReaderOptions.InputFileFormat = "markdown"
+				options.WriterOptions.OutputFileFormat = "msgpack"
 				*pargi += 1
 				return nil
 			},
 		},
 
 		{
-			name:     "--omd",
-			altNames: []string{"--omarkdown"},
-			help:     "Use markdown-tabular format for output data.",
+			name: "--ocbor",
+			help: "Use CBOR format for output data.",
 			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
-				options.WriterOptions.OutputFileFormat = "markdown"
+				options.WriterOptions.OutputFileFormat = "cbor"
 				*pargi += 1
 				return nil
 			},
 		},
 
 		{
-			name:     "--md",
-			altNames: []string{"--markdown"},
-			help:     "Use markdown-tabular format for input and output data.",
+			name: "--ologfmt",
+			help: "Use logfmt format for output data.",
 			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
-				options.ReaderOptions.InputFileFormat = "markdown"
-				options.WriterOptions.OutputFileFormat = "markdown"
+				options.WriterOptions.OutputFileFormat = "logfmt"
 				*pargi += 1
 				return nil
 			},
 		},
 
 		{
-			name: "--odkvp",
-			help: "Use DKVP format for output data.",
+			name: "--oltsv",
+			help: "Use LTSV format for output data.",
 			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
-				options.WriterOptions.OutputFileFormat = "dkvp"
+				options.WriterOptions.OutputFileFormat = "ltsv"
 				*pargi += 1
 				return nil
 			},
 		},
 
 		{
-			name: "--ojson",
-			help: "Use JSON format for output data.",
+			name: "--otoml",
+			help: "Use TOML format for output data: each record is a table, named by its `_section` field.",
 			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
-				options.WriterOptions.OutputFileFormat = "json"
-				options.WriterOptions.WrapJSONOutputInOuterList = true
-				options.WriterOptions.JSONOutputMultiline = true
+				options.WriterOptions.OutputFileFormat = "toml"
 				*pargi += 1
 				return nil
 			},
 		},
 
 		{
-			name: "--ojsonl",
-			help: "Use JSON Lines format for output data.",
+			name: "--oini",
+			help: "Use INI format for output data: each record is a section, named by its `_section` field.",
 			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
-				options.WriterOptions.OutputFileFormat = "jsonl"
+				options.WriterOptions.OutputFileFormat = "ini"
 				*pargi += 1
 				return nil
 			},
 		},
 
 		{
-			name: "--oyaml",
-			help: "Use YAML format for output data.",
+			name: "--ogeojson",
+			help: "Use GeoJSON format for output data: records are written as the features of a FeatureCollection. See also `--geojson-lat-field`.",
 			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
-				options.WriterOptions.OutputFileFormat = "yaml"
-				options.WriterOptions.WrapYAMLOutputInOuterList = true
+				options.WriterOptions.OutputFileFormat = "geojson"
+				options.WriterOptions.JSONOutputMultiline = true
 				*pargi += 1
 				return nil
 			},
 		},
 
 		{
-			name: "--odcf",
-			help: "Use Debian control file (DCF) format for output data.",
+			name: "--oorg",
+			help: "Use Emacs org-mode table format for output data.",
 			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
-				options.WriterOptions.OutputFileFormat = "dcf"
+				options.WriterOptions.OutputFileFormat = "org"
 				*pargi += 1
 				return nil
 			},
 		},
 
 		{
-			name: "--orecutils",
-			help: "Use GNU recutils (.rec) format for output data.",
+			name: "--osql",
+			help: "Use SQL statements for output data: CREATE TABLE, then INSERT statements or a Postgres COPY block. See also `--sql-dialect`.",
 			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
-				options.WriterOptions.OutputFileFormat = "recutils"
+				options.WriterOptions.OutputFileFormat = "sql"
 				*pargi += 1
 				return nil
 			},
 		},
 
+		{
+			name: "--otemplate",
+			arg:  "{filename}",
+			help: "Render each output record through the Go text/template in the given file, with optional `header`, `record`, and `footer` templates. See the file-formats doc page for the available functions.",
+			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
+				CheckArgCount(args, *pargi, argc, 2)
+				options.WriterOptions.OutputFileFormat = "template"
+				options.WriterOptions.TemplateFile = args[*pargi+1]
+				*pargi += 2
+				return nil
+			},
+		},
+
 		{
 			name: "--onidx",
 			help: "Use NIDX format for output data.",
@@ -1503,6 +2672,172 @@ var FileFormatFlagSection = FlagSection{
 			},
 		},
 
+		{
+			name: "--parquet",
+			help: "Use Parquet format for input and output data.",
+			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
+				options.ReaderOptions.InputFileFormat = "parquet"
+				options.WriterOptions.OutputFileFormat = "parquet"
+				*pargi += 1
+				return nil
+			},
+		},
+
+		{
+			name: "--arrow",
+			help: "Use Arrow IPC format for input and output data.",
+			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
+				options.ReaderOptions.InputFileFormat = "arrow"
+				options.WriterOptions.OutputFileFormat = "arrow"
+				*pargi += 1
+				return nil
+			},
+		},
+
+		{
+			name: "--avro",
+			help: "Use Avro object-container-file format for input and output data.",
+			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
+				options.ReaderOptions.InputFileFormat = "avro"
+				options.WriterOptions.OutputFileFormat = "avro"
+				*pargi += 1
+				return nil
+			},
+		},
+
+		{
+			name: "--xlsx",
+			help: "Use XLSX (Excel spreadsheet) format for input and output data.",
+			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
+				options.ReaderOptions.InputFileFormat = "xlsx"
+				options.WriterOptions.OutputFileFormat = "xlsx"
+				*pargi += 1
+				return nil
+			},
+		},
+
+		{
+			name: "--xml",
+			help: "Use XML format for input and output data.",
+			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
+				options.ReaderOptions.InputFileFormat = "xml"
+				options.WriterOptions.OutputFileFormat = "xml"
+				*pargi += 1
+				return nil
+			},
+		},
+
+		{
+			name: "--sqlite",
+			help: "Use SQLite format for input and output data.",
+			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
+				options.ReaderOptions.InputFileFormat = "sqlite"
+				options.WriterOptions.OutputFileFormat = "sqlite"
+				*pargi += 1
+				return nil
+			},
+		},
+
+		{
+			name: "--html",
+			help: "Use HTML-table format for input and output data.",
+			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
+				options.ReaderOptions.InputFileFormat = "html"
+				options.WriterOptions.OutputFileFormat = "html"
+				*pargi += 1
+				return nil
+			},
+		},
+
+		{
+			name: "--msgpack",
+			help: "Use MessagePack format for input and output data.",
+			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
+				options.ReaderOptions.InputFileFormat = "msgpack"
+				options.WriterOptions.OutputFileFormat = "msgpack"
+				*pargi += 1
+				return nil
+			},
+		},
+
+		{
+			name: "--cbor",
+			help: "Use CBOR format for input and output data.",
+			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
+				options.ReaderOptions.InputFileFormat = "cbor"
+				options.WriterOptions.OutputFileFormat = "cbor"
+				*pargi += 1
+				return nil
+			},
+		},
+
+		{
+			name: "--logfmt",
+			help: "Use logfmt format for input and output data.",
+			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
+				options.ReaderOptions.InputFileFormat = "logfmt"
+				options.WriterOptions.OutputFileFormat = "logfmt"
+				*pargi += 1
+				return nil
+			},
+		},
+
+		{
+			name: "--ltsv",
+			help: "Use LTSV format for input and output data.",
+			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
+				options.ReaderOptions.InputFileFormat = "ltsv"
+				options.WriterOptions.OutputFileFormat = "ltsv"
+				*pargi += 1
+				return nil
+			},
+		},
+
+		{
+			name: "--toml",
+			help: "Use TOML format for input and output data.",
+			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
+				options.ReaderOptions.InputFileFormat = "toml"
+				options.WriterOptions.OutputFileFormat = "toml"
+				*pargi += 1
+				return nil
+			},
+		},
+
+		{
+			name: "--ini",
+			help: "Use INI format for input and output data.",
+			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
+				options.ReaderOptions.InputFileFormat = "ini"
+				options.WriterOptions.OutputFileFormat = "ini"
+				*pargi += 1
+				return nil
+			},
+		},
+
+		{
+			name: "--geojson",
+			help: "Use GeoJSON format for input and output data.",
+			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
+				options.ReaderOptions.InputFileFormat = "geojson"
+				options.WriterOptions.OutputFileFormat = "geojson"
+				options.WriterOptions.JSONOutputMultiline = true
+				*pargi += 1
+				return nil
+			},
+		},
+
+		{
+			name: "--org",
+			help: "Use Emacs org-mode table format for input and output data.",
+			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
+				options.ReaderOptions.InputFileFormat = "org"
+				options.WriterOptions.OutputFileFormat = "org"
+				*pargi += 1
+				return nil
+			},
+		},
+
 		{
 			name:     "--nidx",
 			help:     "Use NIDX format for input and output data.",
@@ -3155,6 +4490,27 @@ var CSVTSVOnlyFlagSection = FlagSection{
 			},
 		},
 
+		{
+			name: "--csv-quote-char",
+			arg:  "{character}",
+			help: `Quote character for CSV input, such as ' for data like 'a,b',c. The names squote and dquote may be used for ' and ". Default: double quote.`,
+			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
+				if err := CheckArgCount(args, *pargi, argc, 2); err != nil {
+					return err
+				}
+				switch quote := args[*pargi+1]; quote {
+				case "squote":
+					options.ReaderOptions.CSVQuoteChar = "'"
+				case "dquote":
+					options.ReaderOptions.CSVQuoteChar = "\""
+				default:
+					options.ReaderOptions.CSVQuoteChar = quote
+				}
+				*pargi += 2
+				return nil
+			},
+		},
+
 		{
 			name: "--quote-all",
 			help: "Force double-quoting of CSV fields.",
@@ -3173,10 +4529,22 @@ func CompressedDataPrintInfo() {
 	fmt.Print(`Miller offers a few different ways to handle reading data files
 	which have been compressed.
 
-* Decompression done within the Miller process itself: ` + "`--bz2in`" + ` ` + "`--gzin`" + ` ` + "`--zin`" + "`--zstdin`" +
-		`
+* Decompression done within the Miller process itself: ` + "`--bz2in`" + ` ` + "`--gzin`" + ` ` + "`--zin`" + ` ` + "`--zstdin`" +
+		` ` + "`--xzin`" + ` ` + "`--lz4in`" + ` ` + "`--brin`" + `
 * Decompression done outside the Miller process: ` + "`--prepipe`" + ` ` + "`--prepipex`" + `
 
+Without a flag, in-process decompression is done if the data starts with the
+magic number of gzip, bzip2, zlib, zstd, xz, or lz4 -- so mislabeled files
+still decode -- or else if the filename ends in ` + "`.gz`" + `, ` + "`.bz2`" + `, ` + "`.z`" + `, ` + "`.zst`" + `,
+` + "`.xz`" + `, ` + "`.lz4`" + `, or ` + "`.br`" + `. Brotli has no magic number, so it needs the
+` + "`.br`" + ` suffix or the ` + "`--brin`" + ` flag. For ` + "`http://`" + ` and ` + "`https://`" + ` URLs, gzip and
+brotli are requested, and undone according to the response's Content-Encoding.
+
+Members of zip and tar archives (.zip, .tar, .tar.gz, .tgz, .tar.bz2, .tar.zst,
+.tar.xz, .tar.lz4) are read in-process, each as a separate file, using names like
+` + "`data.zip#2024/jan.csv`" + `, or ` + "`data.tar.gz#*.csv`" + ` for all matching members, or just
+` + "`data.zip`" + ` for all members.
+
 Using ` + "`--prepipe`" + ` and ` + "`--prepipex`" + ` you can specify an action to be
 taken on each input file.  The prepipe command must be able to read from
 standard input; it will be invoked with ` + "`{command} < {filename}`" + `.  The
@@ -3191,13 +4559,20 @@ Examples:
     mlr --prepipe cat
 
 Note that this feature is quite general and is not limited to decompression
-utilities. You can use it to apply per-file filters of your choice.  For output
-compression (or other) utilities, simply pipe the output:
+utilities. You can use it to apply per-file filters of your choice.
+
+For output, ` + "`--bz2out`" + ` ` + "`--gzout`" + ` ` + "`--zout`" + ` ` + "`--zstdout`" + ` compress within the Miller process.
+These apply to the main output, and also to files written by the ` + "`tee`" + ` and
+` + "`split`" + ` verbs and by DSL redirects such as ` + "`tee >`" + ` and ` + "`emit >`" + `. Those
+files are also compressed, without a flag, if their names end in ` + "`.bz2`" + `,
+` + "`.gz`" + `, ` + "`.z`" + `, or ` + "`.zst`" + `. The flags may also be given to those verbs, as in
+` + "`mlr --icsv --ojson split -n 1000 --gzout`" + ` or ` + "`mlr put --zstdout 'tee > $a.\".csv\", $*'`" + `.
+For other output compression (or other) utilities, simply pipe the output:
 ` + "`mlr ... | {your compression command} > outputfilenamegoeshere`" + `
 
 Lastly, note that if ` + "`--prepipe`" + ` or ` + "`--prepipex`" + ` is specified, it replaces any
 decisions that might have been made based on the file suffix. Likewise,
-` + "`--gzin`" + `/` + "`--bz2in`" + `/` + "`--zin`" + "`--zin`" + ` are ignored if ` + "`--prepipe`" + ` is also specified.
+` + "`--gzin`" + `/` + "`--bz2in`" + `/` + "`--zin`" + `/` + "`--zstdin`" + `/etc. are ignored if ` + "`--prepipe`" + ` is also specified.
 `)
 }
 
@@ -3312,6 +4687,56 @@ var CompressedDataFlagSection = FlagSection{
 			},
 		},
 
+		{
+			name: "--gzout",
+			help: "Compress output with gzip within the Miller process. Done by default for `tee`/`split`/DSL-redirect output files ending in `.gz`.",
+			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
+				options.WriterOptions.FileOutputEncoding = lib.FileOutputEncodingGzip
+				*pargi += 1
+				return nil
+			},
+		},
+
+		{
+			name: "--zout",
+			help: "Compress output with zlib within the Miller process. Done by default for `tee`/`split`/DSL-redirect output files ending in `.z`.",
+			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
+				options.WriterOptions.FileOutputEncoding = lib.FileOutputEncodingZlib
+				*pargi += 1
+				return nil
+			},
+		},
+
+		{
+			name: "--bz2out",
+			help: "Compress output with bzip2 within the Miller process. Done by default for `tee`/`split`/DSL-redirect output files ending in `.bz2`.",
+			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
+				options.WriterOptions.FileOutputEncoding = lib.FileOutputEncodingBzip2
+				*pargi += 1
+				return nil
+			},
+		},
+
+		{
+			name: "--zstdout",
+			help: "Compress output with zstd within the Miller process. Done by default for `tee`/`split`/DSL-redirect output files ending in `.zst`.",
+			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
+				options.WriterOptions.FileOutputEncoding = lib.FileOutputEncodingZstd
+				*pargi += 1
+				return nil
+			},
+		},
+
+		{
+			name: "--no-compress-out",
+			help: "Don't compress output, even for files ending in `.gz` etc. Useful for a verb such as `tee` when a flag such as `--gzout` was given for the main output.",
+			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
+				options.WriterOptions.FileOutputEncoding = lib.FileOutputEncodingNone
+				*pargi += 1
+				return nil
+			},
+		},
+
 		{
 			name: "--zstdin",
 			help: "Uncompress zstd within the Miller process. Done by default if file ends in `.zstd`.",
@@ -3321,6 +4746,36 @@ var CompressedDataFlagSection = FlagSection{
 				return nil
 			},
 		},
+
+		{
+			name: "--xzin",
+			help: "Uncompress xz within the Miller process. Done by default if file ends in `.xz`.",
+			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
+				options.ReaderOptions.FileInputEncoding = lib.FileInputEncodingXz
+				*pargi += 1
+				return nil
+			},
+		},
+
+		{
+			name: "--lz4in",
+			help: "Uncompress lz4 within the Miller process. Done by default if file ends in `.lz4`.",
+			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
+				options.ReaderOptions.FileInputEncoding = lib.FileInputEncodingLz4
+				*pargi += 1
+				return nil
+			},
+		},
+
+		{
+			name: "--brin",
+			help: "Uncompress brotli within the Miller process. Done by default if file ends in `.br`.",
+			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
+				options.ReaderOptions.FileInputEncoding = lib.FileInputEncodingBrotli
+				*pargi += 1
+				return nil
+			},
+		},
 	},
 }
 
@@ -3805,6 +5260,20 @@ var MiscFlagSection = FlagSection{
 			},
 		},
 
+		{
+			name: "--to",
+			arg:  "{filename}",
+			help: "Write the main output to this file, or to an S3 object such as `s3://mybucket/out.csv`, rather than to standard output. The output is compressed if there's a flag such as `--gzout`, or if the name ends in `.gz` etc.",
+			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
+				if err := CheckArgCount(args, *pargi, argc, 2); err != nil {
+					return err
+				}
+				options.OutputFileName = args[*pargi+1]
+				*pargi += 2
+				return nil
+			},
+		},
+
 		{
 			name: "--files",
 			arg:  "{filename}",
@@ -3952,6 +5421,20 @@ var MiscFlagSection = FlagSection{
 			},
 		},
 
+		{
+			name: "--s3-endpoint",
+			arg:  "{URL}",
+			help: "Use this endpoint for `s3://` input and output, such as `http://localhost:9000` for a local S3-compatible store, overriding `$AWS_ENDPOINT_URL_S3` environment variable (if any). Credentials are from `$AWS_ACCESS_KEY_ID`, `$AWS_SECRET_ACCESS_KEY`, and `$AWS_SESSION_TOKEN`, and the region from `$AWS_REGION`.",
+			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
+				if err := CheckArgCount(args, *pargi, argc, 2); err != nil {
+					return err
+				}
+				_ = os.Setenv("AWS_ENDPOINT_URL_S3", args[*pargi+1])
+				*pargi += 2
+				return nil
+			},
+		},
+
 		{
 			name: "--nr-progress-mod",
 			arg:  "{m}",
@@ -4030,6 +5513,30 @@ on JSON input records, where duplicate keys always result in the last one's valu
 			},
 		},
 
+		{
+			name: "--follow",
+			help: `Like ` + "`tail -F`" + `: after reading the last input file to its end, keep waiting for more
+data to be appended to it, and process new records as they arrive. If the file is rotated (renamed
+away and recreated), the rest of the old file is read and then the new one is followed; if it's
+truncated, it's read again from the start. For CSV, TSV, PPRINT, and markdown the header from the
+first file is remembered, and is skipped when a rotated-to file starts with the same header. Output
+is flushed after every record, and records are read one at a time, unless --no-fflush or
+--records-per-batch say otherwise. This is for line-oriented formats (CSV, TSV, PPRINT, markdown,
+DKVP, NIDX, JSON, XTAB, logfmt, LTSV, and regex), for local, uncompressed files.`,
+			parser: func(args []string, argc int, pargi *int, options *TOptions) error {
+				options.ReaderOptions.Follow = true
+				if options.ReaderOptions.RecordsPerBatch == DEFAULT_RECORDS_PER_BATCH {
+					options.ReaderOptions.RecordsPerBatch = 1
+				}
+				if !options.WriterOptions.flushOnEveryRecordWasSpecified {
+					options.WriterOptions.FlushOnEveryRecord = true
+					options.WriterOptions.flushOnEveryRecordWasSpecified = true
+				}
+				*pargi += 1
+				return nil
+			},
+		},
+
 		{
 			name: "--hash-records",
 			help: `This is an internal parameter which normally does not need to be modified.
@@ -26,6 +26,21 @@ const DEFAULT_GEN_STOP_AS_STRING = "100"
 
 const DEFAULT_RECORDS_PER_BATCH = 500
 
+const DEFAULT_PARQUET_COMPRESSION = "snappy"
+const DEFAULT_PARQUET_ROW_GROUP_SIZE = 10000
+const DEFAULT_ARROW_COMPRESSION = "none"
+const DEFAULT_ARROW_BATCH_SIZE = 10000
+const DEFAULT_AVRO_CODEC = "deflate"
+const DEFAULT_AVRO_BLOCK_SIZE = 10000
+const DEFAULT_XML_RECORD_PATH = "/*/*"
+const DEFAULT_SQLITE_TABLE = "records"
+const DEFAULT_SQLITE_BATCH_SIZE = 1000
+const DEFAULT_SQL_TABLE = "records"
+const DEFAULT_SQL_DIALECT = "postgres"
+const DEFAULT_SQL_INFER_RECORDS = 100
+const DEFAULT_SQL_BATCH_SIZE = 100
+const DEFAULT_HTML_TITLE = "Miller output"
+
 type TGeneratorOptions struct {
 	FieldName     string
 	StartAsString string
@@ -58,13 +73,48 @@ type TReaderOptions struct {
 	// CSV/TSV record-readers know that trivial records -- e.g. blank lines
 	// at the end of a CSV file -- are to be skipped rather than treated as
 	// fatal header/data length mismatches. See issue #1535.
-	SkipTrivialRecords   bool
-	CSVLazyQuotes        bool
-	CSVTrimLeadingSpace  bool
+	SkipTrivialRecords  bool
+	CSVLazyQuotes       bool
+	CSVTrimLeadingSpace bool
+	// CSV input: quote character, if not double quote
+	CSVQuoteChar         string
 	BarredPprintInput    bool
 	IncrementImplicitKey bool
 
 	FixedWidthSpec string
+	// Fixed-width input: column spec file, as for --ofixed output
+	FixedWidthSpecFile string
+
+	// XLSX input: sheet name or one-up index, number of rows to skip before
+	// the header row, and cell range such as A1:D20
+	XLSXSheet        string
+	XLSXHeaderOffset int64
+	XLSXRange        string
+
+	// XML input: which elements are records, such as /feed/entry
+	XMLRecordPath string
+
+	// SQLite input: the query to run, or else the table to read
+	SQLiteQuery string
+	SQLiteTable string
+
+	// HTML input: which table to read, by number or id
+	HTMLTable string
+
+	// Regex input: the regex, or preset name, from --iregex; what to do with
+	// lines which don't match it; and where to write them for "reject"
+	RegexPattern    string
+	RegexNonMatch   string
+	RegexRejectFile string
+
+	// JSON input: the array to stream records from, as a JSON Pointer, if not
+	// top-level; and whether to attach the fields before it to each record
+	JSONRecordsPath     string
+	JSONRecordsSiblings bool
+
+	// YAML input: whether to keep the comments before each record, as its
+	// _comment field
+	YAMLComments bool
 
 	CommentHandling TCommentHandling
 	CommentString   string
@@ -84,6 +134,10 @@ type TReaderOptions struct {
 
 	// TODO: comment
 	RecordsPerBatch int64
+
+	// For mlr --follow: keep reading the last input file as it grows, like
+	// tail -F
+	Follow bool
 }
 
 type TWriterOptions struct {
@@ -96,6 +150,10 @@ type TWriterOptions struct {
 	FlushOnEveryRecord             bool
 	flushOnEveryRecordWasSpecified bool
 
+	// Compression of output, from flags like --gzout; if unspecified, files
+	// are compressed according to their suffix, like ".gz"
+	FileOutputEncoding lib.TFileOutputEncoding
+
 	// If unspecified on the command line, these take input-format-dependent
 	// defaults.  E.g. default FS is comma for DKVP but space for NIDX.
 	ofsWasSpecified bool
@@ -121,9 +179,69 @@ type TWriterOptions struct {
 
 	// YAML output: wrap in outer list (single document array) vs one document per record
 	WrapYAMLOutputInOuterList bool
+	// YAML output: whether to write each record's _comment field as a comment
+	YAMLComments bool
 
 	CSVQuoteAll bool // --quote-all
 
+	// Parquet output: compression codec name, and number of records per row
+	// group. The first row group is also what the schema is inferred from.
+	ParquetCompression  string
+	ParquetRowGroupSize int64
+
+	// Arrow output: body-buffer compression codec name, number of records
+	// per record batch (the first batch is also what the schema is inferred
+	// from), and whether to dictionary-encode string columns.
+	ArrowCompression       string
+	ArrowBatchSize         int64
+	ArrowDictionaryStrings bool
+
+	// Avro output: block codec name, number of records per block (the first
+	// block is also what the schema is inferred from), and the schema file if
+	// not inferring
+	AvroCodec      string
+	AvroBlockSize  int64
+	AvroSchemaFile string
+
+	// XLSX output: on schema change, start a new header block within the
+	// same sheet, rather than a new sheet.
+	XLSXOneSheet bool
+
+	// XML output: the enclosing elements and record element, as for input
+	XMLRecordPath string
+
+	// SQLite output: the table to write, the database file to write it in
+	// (else the database is written to standard output), and the number of
+	// records per transaction
+	SQLiteTable     string
+	SQLiteOutput    string
+	SQLiteBatchSize int64
+
+	// SQL-statement output: the table to write, the database to write it
+	// for, the number of records to infer column types from, and the number
+	// of rows per INSERT statement; whether to use COPY rather than INSERT,
+	// and whether to leave out CREATE TABLE
+	SQLTable         string
+	SQLDialect       string
+	SQLInferRecords  int64
+	SQLBatchSize     int64
+	SQLCopy          bool
+	SQLNoCreateTable bool
+
+	// Template output: the text/template file given by --otemplate
+	TemplateFile string
+
+	// HTML output: whether to write a complete document, and its title
+	HTMLStandalone bool
+	HTMLTitle      string
+
+	// Fixed-width output: column spec file, as for fixed-width input
+	FixedWidthSpecFile string
+
+	// GeoJSON output: fields to make Point geometries from, if any
+	GeoJSONLatField string
+	GeoJSONLonField string
+
 	// When we read things like
 	//
 	//   x:a=1,x:b=2
@@ -197,6 +315,10 @@ type TOptions struct {
 	// is ["foo.dat", "bar.dat"].
 	FileNames []string
 
+	// For the main output, rather than standard output: e.g. given
+	// 'mlr --to out.csv cat foo.csv', this is "out.csv".
+	OutputFileName string
+
 	// DSL files to be loaded for every put/filter operation -- like 'put -f'
 	// or 'filter -f' but specified up front on the command line, suitable for
 	// .mlrrc. Use-case is someone has DSL functions they always want to be
@@ -246,6 +368,8 @@ func DefaultReaderOptions() TReaderOptions {
 		},
 		DedupeFieldNames: true,
 
+		XMLRecordPath: DEFAULT_XML_RECORD_PATH,
+
 		// TODO: comment
 		RecordsPerBatch: DEFAULT_RECORDS_PER_BATCH,
 	}
@@ -267,6 +391,27 @@ func DefaultWriterOptions() TWriterOptions {
 		JSONOutputMultiline:       true,
 		WrapYAMLOutputInOuterList: true,
 
+		ParquetCompression:  DEFAULT_PARQUET_COMPRESSION,
+		ParquetRowGroupSize: DEFAULT_PARQUET_ROW_GROUP_SIZE,
+
+		ArrowCompression: DEFAULT_ARROW_COMPRESSION,
+		ArrowBatchSize:   DEFAULT_ARROW_BATCH_SIZE,
+
+		AvroCodec:     DEFAULT_AVRO_CODEC,
+		AvroBlockSize: DEFAULT_AVRO_BLOCK_SIZE,
+
+		XMLRecordPath: DEFAULT_XML_RECORD_PATH,
+
+		SQLiteTable:     DEFAULT_SQLITE_TABLE,
+		SQLiteBatchSize: DEFAULT_SQLITE_BATCH_SIZE,
+
+		SQLTable:        DEFAULT_SQL_TABLE,
+		SQLDialect:      DEFAULT_SQL_DIALECT,
+		SQLInferRecords: DEFAULT_SQL_INFER_RECORDS,
+		SQLBatchSize:    DEFAULT_SQL_BATCH_SIZE,
+
+		HTMLTitle: DEFAULT_HTML_TITLE,
+
 		AutoUnflatten: true,
 		AutoFlatten:   true,
 
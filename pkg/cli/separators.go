@@ -93,6 +93,29 @@ var defaultFSes = map[string]string{
 	"yaml":     "N/A",
 	"dcf":      "N/A",
 	"recutils": "N/A",
+	"parquet":  "N/A",
+	"arrow":    "N/A",
+	"avro":     "N/A",
+	"auto":     "N/A",
+	"xlsx":     "N/A",
+	"xml":      "N/A",
+	"sqlite":   "N/A",
+	"sql":      "N/A",
+	"template": "N/A",
+	"html":     "N/A",
+	"latex":    "N/A",
+	"rst":      "N/A",
+	"asciidoc": "N/A",
+	"fixed":    "N/A",
+	"msgpack":  "N/A",
+	"cbor":     "N/A",
+	"logfmt":   " ",
+	"ltsv":     "\t",
+	"regex":    "N/A",
+	"toml":     "N/A",
+	"ini":      "N/A",
+	"geojson":  "N/A",
+	"org":      " ",
 	"nidx":     " ",
 	"markdown": " ",
 	"pprint":   " ",
@@ -131,6 +154,29 @@ var defaultPSes = map[string]string{
 	"yaml":     "N/A",
 	"dcf":      "N/A",
 	"recutils": "N/A",
+	"parquet":  "N/A",
+	"arrow":    "N/A",
+	"avro":     "N/A",
+	"auto":     "N/A",
+	"xlsx":     "N/A",
+	"xml":      "N/A",
+	"sqlite":   "N/A",
+	"sql":      "N/A",
+	"template": "N/A",
+	"html":     "N/A",
+	"latex":    "N/A",
+	"rst":      "N/A",
+	"asciidoc": "N/A",
+	"fixed":    "N/A",
+	"msgpack":  "N/A",
+	"cbor":     "N/A",
+	"logfmt":   "=",
+	"ltsv":     ":",
+	"regex":    "N/A",
+	"toml":     "N/A",
+	"ini":      "N/A",
+	"geojson":  "N/A",
+	"org":      "N/A",
 	"markdown": "N/A",
 	"nidx":     "N/A",
 	"pprint":   "N/A",
@@ -148,6 +194,29 @@ var defaultRSes = map[string]string{
 	"yaml":     "N/A",
 	"dcf":      "N/A",
 	"recutils": "N/A",
+	"parquet":  "N/A",
+	"arrow":    "N/A",
+	"avro":     "N/A",
+	"auto":     "N/A",
+	"xlsx":     "N/A",
+	"xml":      "N/A",
+	"sqlite":   "N/A",
+	"sql":      "N/A",
+	"template": "N/A",
+	"html":     "N/A",
+	"latex":    "N/A",
+	"rst":      "N/A",
+	"asciidoc": "N/A",
+	"fixed":    "\n",
+	"msgpack":  "N/A",
+	"cbor":     "N/A",
+	"logfmt":   "\n",
+	"ltsv":     "\n",
+	"regex":    "\n",
+	"toml":     "N/A",
+	"ini":      "\n",
+	"geojson":  "N/A",
+	"org":      "\n",
 	"markdown": "\n",
 	"nidx":     "\n",
 	"pprint":   "\n",
@@ -165,6 +234,29 @@ var defaultAllowRepeatIFSes = map[string]bool{
 	"yaml":     false,
 	"dcf":      false,
 	"recutils": false,
+	"parquet":  false,
+	"arrow":    false,
+	"avro":     false,
+	"auto":     false,
+	"xlsx":     false,
+	"xml":      false,
+	"sqlite":   false,
+	"sql":      false,
+	"template": false,
+	"html":     false,
+	"latex":    false,
+	"rst":      false,
+	"asciidoc": false,
+	"fixed":    false,
+	"msgpack":  false,
+	"cbor":     false,
+	"logfmt":   false,
+	"ltsv":     false,
+	"regex":    false,
+	"toml":     false,
+	"ini":      false,
+	"geojson":  false,
+	"org":      false,
 	"markdown": false,
 	"nidx":     false,
 	"pprint":   true,
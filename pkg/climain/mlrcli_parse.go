@@ -476,9 +476,60 @@ func parseCommandLinePassTwo(
 		}
 	}
 
+	if options.DoInPlace && options.OutputFileName != "" {
+		return nil, nil, &CLIError{
+			Kind: "generic",
+			Msg:  "mlr: -I option (in-place operation) and --to are mutually exclusive.",
+		}
+	}
+
+	if options.ReaderOptions.Follow {
+		if err := checkFollowOptions(options); err != nil {
+			return nil, nil, &CLIError{
+				Kind: "generic",
+				Msg:  "mlr: --follow: " + err.Error() + ".",
+			}
+		}
+	}
+
 	if options.HaveRandSeed {
 		lib.SeedRandom(int64(options.RandSeed))
 	}
 
 	return options, recordTransformers, nil
 }
+
+// Formats whose record-readers process input as it arrives, so that following
+// a growing file makes sense.
+var followableInputFormats = map[string]bool{
+	"csv":      true,
+	"csvlite":  true,
+	"tsv":      true,
+	"pprint":   true,
+	"markdown": true,
+	"md":       true,
+	"dkvp":     true,
+	"nidx":     true,
+	"json":     true,
+	"xtab":     true,
+	"logfmt":   true,
+	"ltsv":     true,
+	"regex":    true,
+}
+
+func checkFollowOptions(options *cli.TOptions) error {
+	if !followableInputFormats[options.ReaderOptions.InputFileFormat] {
+		return fmt.Errorf("input format \"%s\" can't be followed", options.ReaderOptions.InputFileFormat)
+	}
+	if options.DoInPlace {
+		return fmt.Errorf("not available with -I")
+	}
+	if len(options.FileNames) == 0 {
+		return fmt.Errorf("an input file name is required")
+	}
+	return lib.IsFollowable(
+		options.FileNames[len(options.FileNames)-1],
+		options.ReaderOptions.Prepipe,
+		options.ReaderOptions.FileInputEncoding,
+	)
+}
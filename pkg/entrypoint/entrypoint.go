@@ -109,7 +109,34 @@ func processToStdout(
 	options *cli.TOptions,
 	recordTransformers []transformers.RecordTransformer,
 ) error {
-	return stream.Stream(options.FileNames, options, recordTransformers, os.Stdout, true)
+	// With --to, write to a file or S3 object, compressing if there's a flag
+	// like --gzout or a suffix like .gz.
+	if options.OutputFileName != "" {
+		outputStream, err := lib.OpenFileForWrite(options.OutputFileName, options.WriterOptions.FileOutputEncoding, false)
+		if err != nil {
+			return err
+		}
+		err = stream.Stream(options.FileNames, options, recordTransformers, outputStream, false)
+		if cerr := outputStream.Close(); err == nil {
+			err = cerr
+		}
+		return err
+	}
+
+	// With a flag like --gzout, compress the output within the Miller process.
+	outputStream, isNew, err := lib.WrapOutputHandle(os.Stdout, options.WriterOptions.FileOutputEncoding)
+	if err != nil {
+		return err
+	}
+	if !isNew {
+		return stream.Stream(options.FileNames, options, recordTransformers, os.Stdout, true)
+	}
+
+	err = stream.Stream(options.FileNames, options, recordTransformers, outputStream, false)
+	if cerr := outputStream.Close(); err == nil {
+		err = cerr
+	}
+	return err
 }
 
 // processFilesInPlace is in-place processing without mlr -I.
@@ -150,6 +177,14 @@ func processFileInPlace(
 	originalOptions *cli.TOptions,
 ) error {
 
+	// We can't in-place update http://, https://, etc. Also, anything with
+	// --prepipe or --prepipex, we won't try to guess how to invert that
+	// command to produce re-compressed output.
+	err := lib.IsUpdateableInPlace(fileName, originalOptions.ReaderOptions.Prepipe)
+	if err != nil {
+		return err
+	}
+
 	if _, err := os.Stat(fileName); os.IsNotExist(err) {
 		return err
 	}
@@ -163,14 +198,6 @@ func processFileInPlace(
 		return err
 	}
 
-	// We can't in-place update http://, https://, etc. Also, anything with
-	// --prepipe or --prepipex, we won't try to guess how to invert that
-	// command to produce re-compressed output.
-	err = lib.IsUpdateableInPlace(fileName, options.ReaderOptions.Prepipe)
-	if err != nil {
-		return err
-	}
-
 	// Get the original file's mode so we can preserve it.
 	fileInfo, err := os.Stat(fileName)
 	if err != nil {
@@ -189,11 +216,16 @@ func processFileInPlace(
 
 	// If the input file is compressed and we'll be doing in-process
 	// decompression as we read the input file, try to do in-process
-	// compression as we write the output.
-	inputFileEncoding := lib.FindInputEncoding(fileName, options.ReaderOptions.FileInputEncoding)
+	// compression as we write the output -- unless a flag like --gzout
+	// says otherwise.
+	outputFileEncoding := options.WriterOptions.FileOutputEncoding
+	if outputFileEncoding == lib.FileOutputEncodingDefault {
+		inputFileEncoding := lib.FindInputEncoding(fileName, options.ReaderOptions.FileInputEncoding)
+		outputFileEncoding = lib.OutputEncodingForInputEncoding(inputFileEncoding)
+	}
 
 	// Get a handle with, perhaps, a recompression wrapper around it.
-	wrappedHandle, isNew, err := lib.WrapOutputHandle(handle, inputFileEncoding)
+	wrappedHandle, isNew, err := lib.WrapOutputHandle(handle, outputFileEncoding)
 	if err != nil {
 		_ = os.Remove(tempFileName)
 		return err
@@ -147,6 +147,10 @@ type Reader struct {
 	// This is done even if the field delimiter, Comma, is white space.
 	TrimLeadingSpace bool
 
+	// MILLER-SPECIFIC UPDATE: Quote is the quote character, such as '\''.
+	// If it is 0, the double quote is used.
+	Quote byte
+
 	// ReuseRecord controls whether calls to Read may return a slice sharing
 	// the backing array of the previous call's returned slice for performance.
 	// By default, each call to Read returns newly allocated memory owned by the caller.
@@ -303,6 +307,13 @@ func (r *Reader) readRecord(dst []string) ([]string, error) {
 	if r.Comma == r.Comment || !validDelim(r.Comma) || (r.Comment != 0 && !validDelim(r.Comment)) {
 		return nil, errInvalidDelim
 	}
+	quote := byte('"')
+	if r.Quote != 0 {
+		quote = r.Quote
+	}
+	if r.Comma == rune(quote) {
+		return nil, errInvalidDelim
+	}
 
 	// Read line (automatically skipping past empty lines and any comments).
 	var line []byte
@@ -358,7 +369,7 @@ parseField:
 			line = line[i:]
 			pos.col += i
 		}
-		if len(line) == 0 || line[0] != '"' {
+		if len(line) == 0 || line[0] != quote {
 			// Non-quoted string field
 			i := bytes.IndexRune(line, r.Comma)
 			field := line
@@ -369,7 +380,7 @@ parseField:
 			}
 			// Check to make sure a quote does not appear in field.
 			if !r.LazyQuotes {
-				if j := bytes.IndexByte(field, '"'); j >= 0 {
+				if j := bytes.IndexByte(field, quote); j >= 0 {
 					col := pos.col + j
 					err = &ParseError{StartLine: recLine, Line: r.numLine, Column: col, Err: ErrBareQuote}
 					break parseField
@@ -390,16 +401,16 @@ parseField:
 			line = line[quoteLen:]
 			pos.col += quoteLen
 			for {
-				i := bytes.IndexByte(line, '"')
+				i := bytes.IndexByte(line, quote)
 				if i >= 0 {
 					// Hit next quote.
 					r.recordBuffer = append(r.recordBuffer, line[:i]...)
 					line = line[i+quoteLen:]
 					pos.col += i + quoteLen
 					switch rn := nextRune(line); {
-					case rn == '"':
+					case rn == rune(quote):
 						// `""` sequence (append quote).
-						r.recordBuffer = append(r.recordBuffer, '"')
+						r.recordBuffer = append(r.recordBuffer, quote)
 						line = line[quoteLen:]
 						pos.col += quoteLen
 					case rn == r.Comma:
@@ -416,7 +427,7 @@ parseField:
 						break parseField
 					case r.LazyQuotes:
 						// `"` sequence (bare quote).
-						r.recordBuffer = append(r.recordBuffer, '"')
+						r.recordBuffer = append(r.recordBuffer, quote)
 					default:
 						// `"*` sequence (invalid non-escaped quote).
 						err = &ParseError{StartLine: recLine, Line: r.numLine, Column: pos.col - quoteLen, Err: ErrQuote}
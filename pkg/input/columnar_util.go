@@ -0,0 +1,93 @@
+// Helpers shared by the columnar record-readers (Parquet, Arrow), and by the
+// Avro record-reader.
+
+package input
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/johnkerl/miller/v6/pkg/mlrval"
+)
+
+// getRandomAccessReader returns random access to the input, for formats such
+// as Parquet and the Arrow file format which keep their metadata at the end of
+// the file. Plain files are used as-is; anything else (stdin, decompression,
+// prepipe) is read into memory.
+func getRandomAccessReader(handle io.Reader) (io.ReaderAt, int64, error) {
+	if file, ok := handle.(*os.File); ok {
+		fileInfo, err := file.Stat()
+		if err == nil && fileInfo.Mode().IsRegular() {
+			return file, fileInfo.Size(), nil
+		}
+	}
+	contents, err := io.ReadAll(handle)
+	if err != nil {
+		return nil, 0, err
+	}
+	return bytes.NewReader(contents), int64(len(contents)), nil
+}
+
+// formatEpochTimestamp formats ticks of the given unit since the epoch as
+// ISO8601 UTC, with as many decimal places as the unit has, if the
+// fractional part is nonzero.
+func formatEpochTimestamp(ticks int64, unit time.Duration) string {
+	t := time.Unix(0, 0).UTC().Add(time.Duration(ticks) * unit)
+	return t.Format("2006-01-02T15:04:05") + formatEpochFraction(t.Nanosecond(), unit) + "Z"
+}
+
+func formatEpochTime(ticks int64, unit time.Duration) string {
+	t := time.Unix(0, 0).UTC().Add(time.Duration(ticks) * unit)
+	return t.Format("15:04:05") + formatEpochFraction(t.Nanosecond(), unit)
+}
+
+func formatEpochFraction(nanos int, unit time.Duration) string {
+	if nanos == 0 {
+		return ""
+	}
+	switch unit {
+	case time.Millisecond:
+		return fmt.Sprintf(".%03d", nanos/1000000)
+	case time.Microsecond:
+		return fmt.Sprintf(".%06d", nanos/1000)
+	default:
+		return fmt.Sprintf(".%09d", nanos)
+	}
+}
+
+// float32ToMlrval formats at 32-bit precision so that 1.1 doesn't come out as
+// 1.100000023841858. The float value is from that formatting too, so that it
+// stays 1.1 if written back out at 64-bit precision.
+func float32ToMlrval(f float32) *mlrval.Mlrval {
+	formatted := strconv.FormatFloat(float64(f), 'f', -1, 32)
+	f64, err := strconv.ParseFloat(formatted, 64)
+	if err != nil {
+		f64 = float64(f)
+	}
+	return mlrval.FromPrevalidatedFloatString(formatted, f64)
+}
+
+// decimalToMlrval formats unscaled * 10^-scale exactly, then type-infers it,
+// so that output retains all the original digits.
+func decimalToMlrval(unscaled *big.Int, scale int) *mlrval.Mlrval {
+	digits := unscaled.String()
+	if scale <= 0 {
+		return mlrval.FromInferredType(digits)
+	}
+	sign := ""
+	if strings.HasPrefix(digits, "-") {
+		sign = "-"
+		digits = digits[1:]
+	}
+	if len(digits) <= scale {
+		digits = strings.Repeat("0", scale-len(digits)+1) + digits
+	}
+	point := len(digits) - scale
+	return mlrval.FromInferredType(sign + digits[:point] + "." + digits[point:])
+}
@@ -3,7 +3,13 @@
 
 package input
 
-import "github.com/johnkerl/miller/v6/pkg/types"
+import (
+	"io"
+
+	"github.com/johnkerl/miller/v6/pkg/cli"
+	"github.com/johnkerl/miller/v6/pkg/lib"
+	"github.com/johnkerl/miller/v6/pkg/types"
+)
 
 // Since Go is concurrent, the context struct (AWK-like variables such as
 // FILENAME, NF, NF, FNR, etc.) needs to be duplicated and passed through the
@@ -34,3 +40,44 @@ func hasNonEmptyField(fields []string) bool {
 	}
 	return false
 }
+
+// openFileForRead opens one of the input files. With mlr --follow, the last
+// file is followed as it grows, like tail -F, rather than read only up to its
+// current end; the downstream-done channel is how waiting for more data stops
+// for things like mlr head.
+func openFileForRead(
+	filenames []string,
+	i int,
+	readerOptions *cli.TReaderOptions,
+	downstreamDoneChannel <-chan bool,
+) (io.ReadCloser, error) {
+	if readerOptions.Follow && i == len(filenames)-1 {
+		return lib.OpenFileForFollow(filenames[i], followHeaderLineCount(readerOptions), downstreamDoneChannel)
+	}
+	return lib.OpenFileForRead(
+		filenames[i],
+		readerOptions.Prepipe,
+		readerOptions.PrepipeIsRaw,
+		readerOptions.FileInputEncoding,
+	)
+}
+
+// followHeaderLineCount is how many lines at the start of a followed file are
+// header, to be skipped if they recur after the file is rotated.
+func followHeaderLineCount(readerOptions *cli.TReaderOptions) int {
+	if readerOptions.UseImplicitHeader {
+		return 0
+	}
+	switch readerOptions.InputFileFormat {
+	case "csv", "csvlite", "tsv":
+		return 1
+	case "pprint":
+		if readerOptions.BarredPprintInput {
+			return 3 // +-----+, | a | b |, +-----+
+		}
+		return 1
+	case "markdown", "md":
+		return 2 // | a | b |, | --- | --- |
+	}
+	return 0
+}
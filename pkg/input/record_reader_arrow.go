@@ -0,0 +1,328 @@
+// Arrow IPC record-reader.
+//
+// Arrow data is columnar, in record batches. We read one record batch at a
+// time, and send its rows downstream in batches of at most recordsPerBatch
+// records. Both the IPC stream format and the IPC file format (also known as
+// Feather V2) are accepted: the latter is recognized by its leading magic
+// bytes.
+//
+// Type mapping:
+// * signed and unsigned integers, durations -> int
+// * half/single/double-precision floats -> float
+// * boolean -> boolean
+// * string, large string, string view -> string
+// * binary types -> string if valid UTF-8, else bytes
+// * dictionary-encoded columns -> their decoded values
+// * date -> YYYY-MM-DD string; timestamp -> ISO8601 UTC string; time -> HH:MM:SS string
+// * decimal -> number, from its exact decimal string
+// * list types -> array; struct and map -> map
+// * null -> empty
+
+package input
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/ipc"
+
+	"github.com/johnkerl/miller/v6/pkg/cli"
+	"github.com/johnkerl/miller/v6/pkg/lib"
+	"github.com/johnkerl/miller/v6/pkg/mlrval"
+	"github.com/johnkerl/miller/v6/pkg/types"
+)
+
+// arrowFileMagic starts (and ends) the IPC file format. The stream format has
+// no magic bytes.
+const arrowFileMagic = "ARROW1"
+
+type RecordReaderArrow struct {
+	readerOptions   *cli.TReaderOptions
+	recordsPerBatch int64
+}
+
+func NewRecordReaderArrow(
+	readerOptions *cli.TReaderOptions,
+	recordsPerBatch int64,
+) (*RecordReaderArrow, error) {
+	return &RecordReaderArrow{
+		readerOptions:   readerOptions,
+		recordsPerBatch: recordsPerBatch,
+	}, nil
+}
+
+func (reader *RecordReaderArrow) Read(
+	filenames []string,
+	context types.Context,
+	readerChannel chan<- []*types.RecordAndContext,
+	errorChannel chan error,
+	downstreamDoneChannel <-chan bool,
+) {
+	if filenames != nil {
+		if len(filenames) == 0 {
+			handle, err := lib.OpenStdin(
+				reader.readerOptions.Prepipe,
+				reader.readerOptions.PrepipeIsRaw,
+				reader.readerOptions.FileInputEncoding,
+			)
+			if err != nil {
+				errorChannel <- err
+			} else {
+				err = reader.processHandle(handle, "(stdin)", &context, readerChannel, downstreamDoneChannel)
+				if err != nil {
+					errorChannel <- err
+				}
+			}
+		} else {
+			for _, filename := range filenames {
+				handle, err := lib.OpenFileForRead(
+					filename,
+					reader.readerOptions.Prepipe,
+					reader.readerOptions.PrepipeIsRaw,
+					reader.readerOptions.FileInputEncoding,
+				)
+				if err != nil {
+					errorChannel <- err
+					break
+				}
+				err = reader.processHandle(handle, filename, &context, readerChannel, downstreamDoneChannel)
+				_ = handle.Close()
+				if err != nil {
+					errorChannel <- err
+					break
+				}
+			}
+		}
+	}
+	readerChannel <- types.NewEndOfStreamMarkerList(&context)
+}
+
+// arrowRecordBatchReader is what the IPC stream and file readers have in
+// common, for our purposes.
+type arrowRecordBatchReader interface {
+	Read() (arrow.RecordBatch, error)
+}
+
+func (reader *RecordReaderArrow) processHandle(
+	handle io.Reader,
+	filename string,
+	context *types.Context,
+	readerChannel chan<- []*types.RecordAndContext,
+	downstreamDoneChannel <-chan bool,
+) error {
+	context.UpdateForStartOfFile(filename)
+
+	batchReader, err := openArrowReader(handle)
+	if err != nil {
+		return fmt.Errorf("arrow: %s: %v", filename, err)
+	}
+	if batchReader == nil {
+		// Empty input: no records, as with CSV.
+		return nil
+	}
+
+	for {
+		recordBatch, err := batchReader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("arrow: %s: %v", filename, err)
+		}
+		if reader.processRecordBatch(recordBatch, context, readerChannel, downstreamDoneChannel) {
+			return nil
+		}
+	}
+}
+
+// openArrowReader distinguishes the IPC file format, which needs random
+// access, from the IPC stream format, which we can read as it arrives. It
+// returns nil for empty input.
+func openArrowReader(handle io.Reader) (arrowRecordBatchReader, error) {
+	bufferedHandle := bufio.NewReader(handle)
+	magic, _ := bufferedHandle.Peek(len(arrowFileMagic))
+	if len(magic) == 0 {
+		return nil, nil
+	}
+	if string(magic) != arrowFileMagic {
+		return ipc.NewReader(bufferedHandle)
+	}
+
+	// A plain file can be used as-is, since peeking at it via the buffered
+	// reader doesn't affect reads at given offsets. Anything else must be
+	// read from the buffered reader since that has the bytes peeked at.
+	var source io.Reader = bufferedHandle
+	if file, ok := handle.(*os.File); ok {
+		if fileInfo, err := file.Stat(); err == nil && fileInfo.Mode().IsRegular() {
+			source = file
+		}
+	}
+	readerAt, size, err := getRandomAccessReader(source)
+	if err != nil {
+		return nil, err
+	}
+	return ipc.NewFileReader(io.NewSectionReader(readerAt, 0, size))
+}
+
+// processRecordBatch sends the rows of the record batch downstream. The
+// return value is true if downstream processing has finished, e.g. for mlr
+// head.
+func (reader *RecordReaderArrow) processRecordBatch(
+	recordBatch arrow.RecordBatch,
+	context *types.Context,
+	readerChannel chan<- []*types.RecordAndContext,
+	downstreamDoneChannel <-chan bool,
+) bool {
+	schema := recordBatch.Schema()
+	columns := recordBatch.Columns()
+	numRows := int(recordBatch.NumRows())
+	recordsPerBatch := int(reader.recordsPerBatch)
+
+	for start := 0; start < numRows; start += recordsPerBatch {
+		select {
+		case <-downstreamDoneChannel:
+			return true
+		default:
+		}
+
+		end := min(start+recordsPerBatch, numRows)
+		recordsAndContexts := make([]*types.RecordAndContext, 0, end-start)
+		for i := start; i < end; i++ {
+			record := mlrval.NewMlrmapAsRecord()
+			for j, column := range columns {
+				record.PutReference(schema.Field(j).Name, arrowValueToMlrval(column, i))
+			}
+			context.UpdateForInputRecord()
+			recordsAndContexts = append(recordsAndContexts, types.NewRecordAndContext(record, context))
+		}
+		readerChannel <- recordsAndContexts
+	}
+	return false
+}
+
+// arrowValueToMlrval converts the value at the given index in an Arrow array,
+// recursing into nested and dictionary-encoded arrays.
+func arrowValueToMlrval(column arrow.Array, i int) *mlrval.Mlrval {
+	if column.IsNull(i) {
+		return mlrval.VOID
+	}
+
+	switch typedColumn := column.(type) {
+	case *array.Null:
+		return mlrval.VOID
+
+	case *array.Boolean:
+		return mlrval.FromBool(typedColumn.Value(i))
+
+	case *array.Int8:
+		return mlrval.FromInt(int64(typedColumn.Value(i)))
+	case *array.Int16:
+		return mlrval.FromInt(int64(typedColumn.Value(i)))
+	case *array.Int32:
+		return mlrval.FromInt(int64(typedColumn.Value(i)))
+	case *array.Int64:
+		return mlrval.FromInt(typedColumn.Value(i))
+	case *array.Uint8:
+		return mlrval.FromInt(int64(typedColumn.Value(i)))
+	case *array.Uint16:
+		return mlrval.FromInt(int64(typedColumn.Value(i)))
+	case *array.Uint32:
+		return mlrval.FromInt(int64(typedColumn.Value(i)))
+	case *array.Uint64:
+		u := typedColumn.Value(i)
+		if u <= 1<<63-1 {
+			return mlrval.FromInt(int64(u))
+		}
+		return mlrval.FromInferredType(strconv.FormatUint(u, 10))
+	case *array.Duration:
+		return mlrval.FromInt(int64(typedColumn.Value(i)))
+
+	case *array.Float16:
+		return float32ToMlrval(typedColumn.Value(i).Float32())
+	case *array.Float32:
+		return float32ToMlrval(typedColumn.Value(i))
+	case *array.Float64:
+		return mlrval.FromFloat(typedColumn.Value(i))
+
+	case *array.String:
+		return mlrval.FromString(typedColumn.Value(i))
+	case *array.LargeString:
+		return mlrval.FromString(typedColumn.Value(i))
+	case *array.StringView:
+		return mlrval.FromString(typedColumn.Value(i))
+
+	case *array.Binary:
+		return arrowBinaryToMlrval(typedColumn.Value(i))
+	case *array.LargeBinary:
+		return arrowBinaryToMlrval(typedColumn.Value(i))
+	case *array.BinaryView:
+		return arrowBinaryToMlrval(typedColumn.Value(i))
+	case *array.FixedSizeBinary:
+		return arrowBinaryToMlrval(typedColumn.Value(i))
+
+	case *array.Date32:
+		return mlrval.FromString(typedColumn.Value(i).FormattedString())
+	case *array.Date64:
+		return mlrval.FromString(typedColumn.Value(i).FormattedString())
+	case *array.Timestamp:
+		unit := typedColumn.DataType().(*arrow.TimestampType).Unit
+		return mlrval.FromString(formatEpochTimestamp(int64(typedColumn.Value(i)), unit.Multiplier()))
+	case *array.Time32:
+		unit := typedColumn.DataType().(*arrow.Time32Type).Unit
+		return mlrval.FromString(formatEpochTime(int64(typedColumn.Value(i)), unit.Multiplier()))
+	case *array.Time64:
+		unit := typedColumn.DataType().(*arrow.Time64Type).Unit
+		return mlrval.FromString(formatEpochTime(int64(typedColumn.Value(i)), unit.Multiplier()))
+
+	case *array.Dictionary:
+		return arrowValueToMlrval(typedColumn.Dictionary(), typedColumn.GetValueIndex(i))
+
+	case *array.Struct:
+		structType := typedColumn.DataType().(*arrow.StructType)
+		mapval := mlrval.NewMlrmap()
+		for j := 0; j < typedColumn.NumField(); j++ {
+			mapval.PutReference(structType.Field(j).Name, arrowValueToMlrval(typedColumn.Field(j), i))
+		}
+		return mlrval.FromMap(mapval)
+
+	case *array.Map:
+		// Checked before the list types since an Arrow map is a list of
+		// key-value structs.
+		start, end := typedColumn.ValueOffsets(i)
+		mapval := mlrval.NewMlrmap()
+		for k := int(start); k < int(end); k++ {
+			key := arrowValueToMlrval(typedColumn.Keys(), k)
+			mapval.PutReference(key.String(), arrowValueToMlrval(typedColumn.Items(), k))
+		}
+		return mlrval.FromMap(mapval)
+
+	case array.ListLike:
+		start, end := typedColumn.ValueOffsets(i)
+		values := typedColumn.ListValues()
+		elements := make([]*mlrval.Mlrval, 0, end-start)
+		for k := int(start); k < int(end); k++ {
+			elements = append(elements, arrowValueToMlrval(values, k))
+		}
+		return mlrval.FromArray(elements)
+
+	default:
+		// Decimals, intervals, and anything else Arrow knows how to format.
+		// Decimals are formatted exactly, and type-inferred, so that output
+		// retains all the original digits.
+		return mlrval.FromInferredType(typedColumn.ValueStr(i))
+	}
+}
+
+func arrowBinaryToMlrval(b []byte) *mlrval.Mlrval {
+	if utf8.Valid(b) {
+		return mlrval.FromString(string(b))
+	}
+	return mlrval.FromBytes(bytes.Clone(b))
+}
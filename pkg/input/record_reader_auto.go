@@ -0,0 +1,125 @@
+// Record-reader for -i auto (or --ifmt auto). Each input file is sniffed (see
+// sniff.go) and then read by the record-reader for the detected format, so
+// different files can have different formats.
+
+package input
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/johnkerl/miller/v6/pkg/cli"
+	"github.com/johnkerl/miller/v6/pkg/lib"
+	"github.com/johnkerl/miller/v6/pkg/types"
+)
+
+// handleProcessor is implemented by the record-readers for the sniffable
+// formats.
+type handleProcessor interface {
+	processHandle(
+		handle io.Reader,
+		filename string,
+		context *types.Context,
+		readerChannel chan<- []*types.RecordAndContext,
+		errorChannel chan error,
+		downstreamDoneChannel <-chan bool,
+	)
+}
+
+type RecordReaderAuto struct {
+	readerOptions   *cli.TReaderOptions
+	recordsPerBatch int64
+}
+
+func NewRecordReaderAuto(
+	readerOptions *cli.TReaderOptions,
+	recordsPerBatch int64,
+) (*RecordReaderAuto, error) {
+	return &RecordReaderAuto{
+		readerOptions:   readerOptions,
+		recordsPerBatch: recordsPerBatch,
+	}, nil
+}
+
+func (reader *RecordReaderAuto) Read(
+	filenames []string,
+	context types.Context,
+	readerChannel chan<- []*types.RecordAndContext,
+	errorChannel chan error,
+	downstreamDoneChannel <-chan bool,
+) {
+	if filenames != nil { // nil for mlr -n
+		if len(filenames) == 0 { // read from stdin
+			handle, err := lib.OpenStdin(
+				reader.readerOptions.Prepipe,
+				reader.readerOptions.PrepipeIsRaw,
+				reader.readerOptions.FileInputEncoding,
+			)
+			if err != nil {
+				errorChannel <- err
+			} else {
+				err = reader.processHandle(handle, "(stdin)", &context, readerChannel, errorChannel, downstreamDoneChannel)
+				if err != nil {
+					errorChannel <- err
+				}
+			}
+		} else {
+			for _, filename := range filenames {
+				handle, err := lib.OpenFileForRead(
+					filename,
+					reader.readerOptions.Prepipe,
+					reader.readerOptions.PrepipeIsRaw,
+					reader.readerOptions.FileInputEncoding,
+				)
+				if err != nil {
+					errorChannel <- err
+					break
+				}
+				err = reader.processHandle(handle, filename, &context, readerChannel, errorChannel, downstreamDoneChannel)
+				_ = handle.Close()
+				if err != nil {
+					errorChannel <- err
+					break
+				}
+			}
+		}
+	}
+	readerChannel <- types.NewEndOfStreamMarkerList(&context)
+}
+
+func (reader *RecordReaderAuto) processHandle(
+	handle io.Reader,
+	filename string,
+	context *types.Context,
+	readerChannel chan<- []*types.RecordAndContext,
+	errorChannel chan error,
+	downstreamDoneChannel <-chan bool,
+) error {
+	prefix, complete, err := ReadSniffPrefix(handle)
+	if err != nil {
+		return err
+	}
+	result := Sniff(prefix, complete, reader.readerOptions)
+
+	sniffedOptions, err := result.ReaderOptions(reader.readerOptions)
+	if err != nil {
+		return fmt.Errorf("%s: %v", filename, err)
+	}
+	recordReader, err := Create(sniffedOptions, reader.recordsPerBatch)
+	if err != nil {
+		return fmt.Errorf("%s: %v", filename, err)
+	}
+	processor, ok := recordReader.(handleProcessor)
+	lib.InternalCodingErrorIf(!ok)
+
+	processor.processHandle(
+		io.MultiReader(bytes.NewReader(prefix), handle),
+		filename,
+		context,
+		readerChannel,
+		errorChannel,
+		downstreamDoneChannel,
+	)
+	return nil
+}
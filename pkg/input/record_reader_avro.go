@@ -0,0 +1,181 @@
+// Avro object-container-file record-reader. Each value in the file is a
+// record, decoded using the schema in the file's header. Logical types are
+// formatted as for Parquet: dates as YYYY-MM-DD, timestamps as ISO8601, and
+// decimals with all their digits.
+
+package input
+
+import (
+	"fmt"
+	"io"
+	"time"
+	"unicode/utf8"
+
+	"github.com/johnkerl/miller/v6/pkg/avro"
+	"github.com/johnkerl/miller/v6/pkg/cli"
+	"github.com/johnkerl/miller/v6/pkg/lib"
+	"github.com/johnkerl/miller/v6/pkg/mlrval"
+	"github.com/johnkerl/miller/v6/pkg/types"
+)
+
+type RecordReaderAvro struct {
+	readerOptions   *cli.TReaderOptions
+	recordsPerBatch int64
+}
+
+func NewRecordReaderAvro(
+	readerOptions *cli.TReaderOptions,
+	recordsPerBatch int64,
+) (*RecordReaderAvro, error) {
+	return &RecordReaderAvro{
+		readerOptions:   readerOptions,
+		recordsPerBatch: recordsPerBatch,
+	}, nil
+}
+
+func (reader *RecordReaderAvro) Read(
+	filenames []string,
+	context types.Context,
+	readerChannel chan<- []*types.RecordAndContext,
+	errorChannel chan error,
+	downstreamDoneChannel <-chan bool,
+) {
+	if filenames != nil {
+		if len(filenames) == 0 {
+			handle, err := lib.OpenStdin(
+				reader.readerOptions.Prepipe,
+				reader.readerOptions.PrepipeIsRaw,
+				reader.readerOptions.FileInputEncoding,
+			)
+			if err != nil {
+				errorChannel <- err
+			} else {
+				err = reader.processHandle(handle, "(stdin)", &context, readerChannel, downstreamDoneChannel)
+				if err != nil {
+					errorChannel <- err
+				}
+			}
+		} else {
+			for _, filename := range filenames {
+				handle, err := lib.OpenFileForRead(
+					filename,
+					reader.readerOptions.Prepipe,
+					reader.readerOptions.PrepipeIsRaw,
+					reader.readerOptions.FileInputEncoding,
+				)
+				if err != nil {
+					errorChannel <- err
+					break
+				}
+				err = reader.processHandle(handle, filename, &context, readerChannel, downstreamDoneChannel)
+				_ = handle.Close()
+				if err != nil {
+					errorChannel <- err
+					break
+				}
+			}
+		}
+	}
+	readerChannel <- types.NewEndOfStreamMarkerList(&context)
+}
+
+func (reader *RecordReaderAvro) processHandle(
+	handle io.Reader,
+	filename string,
+	context *types.Context,
+	readerChannel chan<- []*types.RecordAndContext,
+	downstreamDoneChannel <-chan bool,
+) error {
+	context.UpdateForStartOfFile(filename)
+	recordsPerBatch := reader.recordsPerBatch
+	recordsAndContexts := make([]*types.RecordAndContext, 0, recordsPerBatch)
+
+	ocfReader, err := avro.NewOCFReader(handle)
+	if err != nil {
+		return fmt.Errorf("avro: %s: %v", filename, err)
+	}
+
+	for {
+		value, err := ocfReader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("avro: %s: %v", filename, err)
+		}
+
+		mv := avroValueToMlrval(value)
+		if !mv.IsMap() {
+			return fmt.Errorf(
+				"avro: %s: valid but unmillerable data: expected record; got %s",
+				filename, mv.GetTypeName(),
+			)
+		}
+
+		context.UpdateForInputRecord()
+		recordsAndContexts = append(recordsAndContexts, types.NewRecordAndContext(mv.GetMap(), context))
+		if int64(len(recordsAndContexts)) >= recordsPerBatch {
+			readerChannel <- recordsAndContexts
+			recordsAndContexts = make([]*types.RecordAndContext, 0, recordsPerBatch)
+			select {
+			case <-downstreamDoneChannel:
+				return nil
+			default:
+			}
+		}
+	}
+
+	if len(recordsAndContexts) > 0 {
+		readerChannel <- recordsAndContexts
+	}
+	return nil
+}
+
+func avroValueToMlrval(value any) *mlrval.Mlrval {
+	switch v := value.(type) {
+	case nil:
+		return mlrval.VOID
+	case bool:
+		return mlrval.FromBool(v)
+	case int64:
+		return mlrval.FromInt(v)
+	case float32:
+		return float32ToMlrval(v)
+	case float64:
+		return mlrval.FromFloat(v)
+	case string:
+		return mlrval.FromString(v)
+	case []byte:
+		if utf8.Valid(v) {
+			return mlrval.FromString(string(v))
+		}
+		return mlrval.FromBytes(v)
+	case []any:
+		array := make([]*mlrval.Mlrval, len(v))
+		for i, element := range v {
+			array[i] = avroValueToMlrval(element)
+		}
+		return mlrval.FromArray(array)
+	case *lib.OrderedMap[any]:
+		mlrmap := mlrval.NewMlrmapAsRecord()
+		for pe := v.Head; pe != nil; pe = pe.Next {
+			mlrmap.PutReference(pe.Key, avroValueToMlrval(pe.Value))
+		}
+		return mlrval.FromMap(mlrmap)
+	case avro.Date:
+		return mlrval.FromString(time.Unix(int64(v)*86400, 0).UTC().Format("2006-01-02"))
+	case avro.TimeOfDay:
+		return mlrval.FromString(formatEpochTime(v.Ticks, v.Unit))
+	case avro.Timestamp:
+		formatted := formatEpochTimestamp(v.Ticks, v.Unit)
+		if v.Local {
+			// No time zone
+			formatted = formatted[:len(formatted)-1]
+		}
+		return mlrval.FromString(formatted)
+	case avro.Decimal:
+		return decimalToMlrval(v.Unscaled, v.Scale)
+	default:
+		return mlrval.FromString(fmt.Sprint(v))
+	}
+}
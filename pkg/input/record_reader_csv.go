@@ -42,6 +42,9 @@ func NewRecordReaderCSV(
 			return nil, fmt.Errorf("for CSV, the comment prefix must be a single character")
 		}
 	}
+	if len(readerOptions.CSVQuoteChar) > 1 {
+		return nil, fmt.Errorf("for CSV, the quote character must be a single character")
+	}
 	return &RecordReaderCSV{
 		readerOptions:       readerOptions,
 		ifs0:                readerOptions.IFS[0],
@@ -71,13 +74,8 @@ func (reader *RecordReaderCSV) Read(
 				reader.processHandle(handle, "(stdin)", &context, readerChannel, errorChannel, downstreamDoneChannel)
 			}
 		} else {
-			for _, filename := range filenames {
-				handle, err := lib.OpenFileForRead(
-					filename,
-					reader.readerOptions.Prepipe,
-					reader.readerOptions.PrepipeIsRaw,
-					reader.readerOptions.FileInputEncoding,
-				)
+			for i, filename := range filenames {
+				handle, err := openFileForRead(filenames, i, reader.readerOptions, downstreamDoneChannel)
 				if err != nil {
 					errorChannel <- err
 				} else {
@@ -111,6 +109,9 @@ func (reader *RecordReaderCSV) processHandle(
 	csvReader.Comma = rune(reader.ifs0)
 	csvReader.LazyQuotes = reader.csvLazyQuotes
 	csvReader.TrimLeadingSpace = reader.csvTrimLeadingSpace
+	if reader.readerOptions.CSVQuoteChar != "" {
+		csvReader.Quote = reader.readerOptions.CSVQuoteChar[0]
+	}
 
 	if reader.readerOptions.CommentHandling != cli.CommentsAreData {
 		if len(reader.readerOptions.CommentString) == 1 {
@@ -104,13 +104,8 @@ func (reader *RecordReaderCSVLite) Read(
 				)
 			}
 		} else {
-			for _, filename := range filenames {
-				handle, err := lib.OpenFileForRead(
-					filename,
-					reader.readerOptions.Prepipe,
-					reader.readerOptions.PrepipeIsRaw,
-					reader.readerOptions.FileInputEncoding,
-				)
+			for i, filename := range filenames {
+				handle, err := openFileForRead(filenames, i, reader.readerOptions, downstreamDoneChannel)
 				if err != nil {
 					errorChannel <- err
 				} else {
@@ -75,13 +75,8 @@ func (reader *RecordReaderDKVPNIDX) Read(
 				reader.processHandle(handle, "(stdin)", &context, readerChannel, errorChannel, downstreamDoneChannel)
 			}
 		} else {
-			for _, filename := range filenames {
-				handle, err := lib.OpenFileForRead(
-					filename,
-					reader.readerOptions.Prepipe,
-					reader.readerOptions.PrepipeIsRaw,
-					reader.readerOptions.FileInputEncoding,
-				)
+			for i, filename := range filenames {
+				handle, err := openFileForRead(filenames, i, reader.readerOptions, downstreamDoneChannel)
 				if err != nil {
 					errorChannel <- err
 				} else {
@@ -99,7 +94,7 @@ func (reader *RecordReaderDKVPNIDX) processHandle(
 	filename string,
 	context *types.Context,
 	readerChannel chan<- []*types.RecordAndContext,
-	errorChannel chan<- error,
+	errorChannel chan error,
 	downstreamDoneChannel <-chan bool, // for mlr head
 ) {
 	context.UpdateForStartOfFile(filename)
@@ -18,6 +18,8 @@ func Create(readerOptions *cli.TReaderOptions, recordsPerBatch int64) (IRecordRe
 		return NewRecordReaderDKVPX(readerOptions, recordsPerBatch)
 	case "json":
 		return NewRecordReaderJSON(readerOptions, recordsPerBatch)
+	case "geojson":
+		return NewRecordReaderGeoJSON(readerOptions, recordsPerBatch)
 	case "yaml":
 		return NewRecordReaderYAML(readerOptions, recordsPerBatch)
 	case "nidx":
@@ -26,6 +28,8 @@ func Create(readerOptions *cli.TReaderOptions, recordsPerBatch int64) (IRecordRe
 		return NewRecordReaderMarkdown(readerOptions, recordsPerBatch)
 	case "markdown":
 		return NewRecordReaderMarkdown(readerOptions, recordsPerBatch)
+	case "org":
+		return NewRecordReaderOrg(readerOptions, recordsPerBatch)
 	case "pprint":
 		return NewRecordReaderPPRINT(readerOptions, recordsPerBatch)
 	case "tsv":
@@ -36,6 +40,38 @@ func Create(readerOptions *cli.TReaderOptions, recordsPerBatch int64) (IRecordRe
 		return NewRecordReaderDCF(readerOptions, recordsPerBatch)
 	case "recutils":
 		return NewRecordReaderREC(readerOptions, recordsPerBatch)
+	case "parquet":
+		return NewRecordReaderParquet(readerOptions, recordsPerBatch)
+	case "arrow":
+		return NewRecordReaderArrow(readerOptions, recordsPerBatch)
+	case "avro":
+		return NewRecordReaderAvro(readerOptions, recordsPerBatch)
+	case "xlsx":
+		return NewRecordReaderXLSX(readerOptions, recordsPerBatch)
+	case "xml":
+		return NewRecordReaderXML(readerOptions, recordsPerBatch)
+	case "sqlite":
+		return NewRecordReaderSQLite(readerOptions, recordsPerBatch)
+	case "html":
+		return NewRecordReaderHTML(readerOptions, recordsPerBatch)
+	case "fixed":
+		return NewRecordReaderFixedWidth(readerOptions, recordsPerBatch)
+	case "msgpack":
+		return NewRecordReaderMsgpack(readerOptions, recordsPerBatch)
+	case "cbor":
+		return NewRecordReaderCBOR(readerOptions, recordsPerBatch)
+	case "logfmt":
+		return NewRecordReaderLogfmt(readerOptions, recordsPerBatch)
+	case "ltsv":
+		return NewRecordReaderLTSV(readerOptions, recordsPerBatch)
+	case "regex":
+		return NewRecordReaderRegex(readerOptions, recordsPerBatch)
+	case "toml":
+		return NewRecordReaderTOML(readerOptions, recordsPerBatch)
+	case "ini":
+		return NewRecordReaderINI(readerOptions, recordsPerBatch)
+	case "auto":
+		return NewRecordReaderAuto(readerOptions, recordsPerBatch)
 	case "gen":
 		return NewPseudoReaderGen(readerOptions, recordsPerBatch)
 	default:
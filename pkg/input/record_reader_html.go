@@ -0,0 +1,375 @@
+// HTML record-reader.
+//
+// One <table> of the document is read: the first one, or the one selected by
+// --html-table, which is either a table number starting with 1, counting in
+// document order, or the id of the table. As with CSV, the first row is the
+// header, and each row after it is a record; with --implicit-csv-header, the
+// keys are 1, 2, 3, etc. instead. Cells spanning several columns or rows via
+// colspan/rowspan are repeated in each of them. Cell text has its whitespace
+// collapsed, as a browser would show it. Empty rows are skipped. Missing
+// cells in a data row are filled with empty values, and cells beyond the
+// header get positional keys, as with XLSX.
+//
+// Tables nested within cells are not part of the enclosing table's rows, but
+// can themselves be selected by number or id.
+
+package input
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+	"golang.org/x/net/html/charset"
+
+	"github.com/johnkerl/miller/v6/pkg/cli"
+	"github.com/johnkerl/miller/v6/pkg/lib"
+	"github.com/johnkerl/miller/v6/pkg/mlrval"
+	"github.com/johnkerl/miller/v6/pkg/types"
+)
+
+// Browsers cap colspan at 1000 and rowspan at 65534; we don't need to be as
+// generous to be useful, only to not be tricked into making huge records.
+const htmlMaxColspan = 1000
+const htmlMaxRowspan = 65534
+
+type RecordReaderHTML struct {
+	readerOptions   *cli.TReaderOptions
+	recordsPerBatch int64
+}
+
+func NewRecordReaderHTML(
+	readerOptions *cli.TReaderOptions,
+	recordsPerBatch int64,
+) (*RecordReaderHTML, error) {
+	return &RecordReaderHTML{
+		readerOptions:   readerOptions,
+		recordsPerBatch: recordsPerBatch,
+	}, nil
+}
+
+func (reader *RecordReaderHTML) Read(
+	filenames []string,
+	context types.Context,
+	readerChannel chan<- []*types.RecordAndContext,
+	errorChannel chan error,
+	downstreamDoneChannel <-chan bool,
+) {
+	if filenames != nil {
+		if len(filenames) == 0 {
+			handle, err := lib.OpenStdin(
+				reader.readerOptions.Prepipe,
+				reader.readerOptions.PrepipeIsRaw,
+				reader.readerOptions.FileInputEncoding,
+			)
+			if err != nil {
+				errorChannel <- err
+			} else {
+				err = reader.processHandle(handle, "(stdin)", &context, readerChannel, downstreamDoneChannel)
+				if err != nil {
+					errorChannel <- err
+				}
+			}
+		} else {
+			for _, filename := range filenames {
+				handle, err := lib.OpenFileForRead(
+					filename,
+					reader.readerOptions.Prepipe,
+					reader.readerOptions.PrepipeIsRaw,
+					reader.readerOptions.FileInputEncoding,
+				)
+				if err != nil {
+					errorChannel <- err
+					break
+				}
+				err = reader.processHandle(handle, filename, &context, readerChannel, downstreamDoneChannel)
+				_ = handle.Close()
+				if err != nil {
+					errorChannel <- err
+					break
+				}
+			}
+		}
+	}
+	readerChannel <- types.NewEndOfStreamMarkerList(&context)
+}
+
+func (reader *RecordReaderHTML) processHandle(
+	handle io.Reader,
+	filename string,
+	context *types.Context,
+	readerChannel chan<- []*types.RecordAndContext,
+	downstreamDoneChannel <-chan bool,
+) error {
+	context.UpdateForStartOfFile(filename)
+	recordsPerBatch := reader.recordsPerBatch
+	recordsAndContexts := make([]*types.RecordAndContext, 0, recordsPerBatch)
+
+	// Honors <meta charset="..."> and the like.
+	decodedHandle, err := charset.NewReader(handle, "")
+	if err != nil {
+		return fmt.Errorf("html: %s: %v", filename, err)
+	}
+	document, err := html.Parse(decodedHandle)
+	if err != nil {
+		return fmt.Errorf("html: %s: %v", filename, err)
+	}
+
+	table, err := selectHTMLTable(document, reader.readerOptions.HTMLTable)
+	if err != nil {
+		return fmt.Errorf("html: %s: %v", filename, err)
+	}
+	if table == nil {
+		// No tables at all: no records, as with empty CSV.
+		return nil
+	}
+
+	var header []string
+	if reader.readerOptions.UseImplicitHeader {
+		header = make([]string, 0)
+	}
+	dedupeFieldNames := reader.readerOptions.DedupeFieldNames
+
+	for _, row := range expandHTMLTableRows(table) {
+		nonEmpty := false
+		for _, cell := range row {
+			if cell != "" {
+				nonEmpty = true
+				break
+			}
+		}
+		if !nonEmpty {
+			continue
+		}
+
+		if header == nil {
+			header = make([]string, len(row))
+			for i, cell := range row {
+				if cell == "" {
+					cell = strconv.Itoa(i + 1)
+				}
+				header[i] = cell
+			}
+			continue
+		}
+
+		record := mlrval.NewMlrmapAsRecord()
+		for i := 0; i < len(header) || i < len(row); i++ {
+			var key string
+			if i < len(header) {
+				key = header[i]
+			} else {
+				key = strconv.Itoa(i + 1)
+			}
+			value := mlrval.VOID
+			if i < len(row) {
+				value = mlrval.FromDeferredType(row[i])
+			}
+			if _, err := record.PutReferenceMaybeDedupe(key, value, dedupeFieldNames); err != nil {
+				return fmt.Errorf("html: %s: %v", filename, err)
+			}
+		}
+
+		context.UpdateForInputRecord()
+		recordsAndContexts = append(recordsAndContexts, types.NewRecordAndContext(record, context))
+
+		if int64(len(recordsAndContexts)) >= recordsPerBatch {
+			readerChannel <- recordsAndContexts
+			recordsAndContexts = make([]*types.RecordAndContext, 0, recordsPerBatch)
+
+			// See if downstream processors will be ignoring further data
+			// (e.g. mlr head). If so, stop reading. This makes 'mlr head
+			// hugefile' exit quickly, as it should.
+			select {
+			case <-downstreamDoneChannel:
+				return nil
+			default:
+			}
+		}
+	}
+
+	if len(recordsAndContexts) > 0 {
+		readerChannel <- recordsAndContexts
+	}
+	return nil
+}
+
+// selectHTMLTable finds the table given by number or id, or the first table
+// if the selector is empty. It returns nil without error if the document has
+// no tables and none was asked for.
+func selectHTMLTable(document *html.Node, selector string) (*html.Node, error) {
+	tables := make([]*html.Node, 0)
+	var find func(node *html.Node)
+	find = func(node *html.Node) {
+		if node.Type == html.ElementNode && node.DataAtom == atom.Table {
+			tables = append(tables, node)
+		}
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			find(child)
+		}
+	}
+	find(document)
+
+	if selector == "" {
+		if len(tables) == 0 {
+			return nil, nil
+		}
+		return tables[0], nil
+	}
+
+	if number, err := strconv.Atoi(selector); err == nil {
+		if number < 1 || number > len(tables) {
+			return nil, fmt.Errorf("table %d not found; the document has %d tables", number, len(tables))
+		}
+		return tables[number-1], nil
+	}
+
+	for _, table := range tables {
+		for _, attr := range table.Attr {
+			if attr.Namespace == "" && attr.Key == "id" && attr.Val == selector {
+				return table, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("no table with id \"%s\"", selector)
+}
+
+type tHTMLSpan struct {
+	text          string
+	remainingRows int
+}
+
+// expandHTMLTableRows returns the text of the table's cells, row by row, with
+// cells spanning several columns or rows repeated in each of them.
+func expandHTMLTableRows(table *html.Node) [][]string {
+	rows := make([][]string, 0)
+	// Cells from earlier rows spanning down into later ones, by column
+	spans := make(map[int]*tHTMLSpan)
+
+	takeSpan := func(row []string, column int) ([]string, bool) {
+		span := spans[column]
+		if span == nil {
+			return row, false
+		}
+		row = append(row, span.text)
+		span.remainingRows--
+		if span.remainingRows == 0 {
+			delete(spans, column)
+		}
+		return row, true
+	}
+
+	for _, tr := range htmlTableRowElements(table) {
+		row := make([]string, 0)
+		for cell := tr.FirstChild; cell != nil; cell = cell.NextSibling {
+			if cell.Type != html.ElementNode || (cell.DataAtom != atom.Td && cell.DataAtom != atom.Th) {
+				continue
+			}
+			for {
+				var took bool
+				row, took = takeSpan(row, len(row))
+				if !took {
+					break
+				}
+			}
+			text := htmlCellText(cell)
+			colspan := htmlSpanAttribute(cell, "colspan", htmlMaxColspan)
+			rowspan := htmlSpanAttribute(cell, "rowspan", htmlMaxRowspan)
+			for i := 0; i < colspan; i++ {
+				if rowspan > 1 {
+					spans[len(row)] = &tHTMLSpan{text: text, remainingRows: rowspan - 1}
+				}
+				row = append(row, text)
+			}
+		}
+		// Cells spanning down into the end of this row
+		for column := len(row); len(spans) > 0 && column <= maxHTMLSpanColumn(spans); column++ {
+			var took bool
+			row, took = takeSpan(row, column)
+			if !took {
+				row = append(row, "")
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+func maxHTMLSpanColumn(spans map[int]*tHTMLSpan) int {
+	maxColumn := -1
+	for column := range spans {
+		if column > maxColumn {
+			maxColumn = column
+		}
+	}
+	return maxColumn
+}
+
+// htmlTableRowElements returns the table's own rows, in order: those directly
+// within it, or within its thead, tbody, or tfoot -- but not those of tables
+// nested within its cells.
+func htmlTableRowElements(table *html.Node) []*html.Node {
+	trs := make([]*html.Node, 0)
+	for child := table.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type != html.ElementNode {
+			continue
+		}
+		switch child.DataAtom {
+		case atom.Tr:
+			trs = append(trs, child)
+		case atom.Thead, atom.Tbody, atom.Tfoot:
+			for grandchild := child.FirstChild; grandchild != nil; grandchild = grandchild.NextSibling {
+				if grandchild.Type == html.ElementNode && grandchild.DataAtom == atom.Tr {
+					trs = append(trs, grandchild)
+				}
+			}
+		}
+	}
+	return trs
+}
+
+// htmlCellText returns the text within a cell with runs of whitespace
+// collapsed to single spaces, as a browser would show it. Line breaks count as
+// whitespace; scripts, styles, and nested tables are skipped.
+func htmlCellText(cell *html.Node) string {
+	var buffer strings.Builder
+	var walk func(node *html.Node)
+	walk = func(node *html.Node) {
+		switch node.Type {
+		case html.TextNode:
+			buffer.WriteString(node.Data)
+			return
+		case html.ElementNode:
+			switch node.DataAtom {
+			case atom.Script, atom.Style, atom.Table:
+				return
+			case atom.Br:
+				buffer.WriteString(" ")
+				return
+			}
+		}
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(cell)
+	return strings.Join(strings.Fields(buffer.String()), " ")
+}
+
+// htmlSpanAttribute returns the colspan or rowspan of a cell, which is 1 if
+// absent or invalid.
+func htmlSpanAttribute(cell *html.Node, name string, limit int) int {
+	for _, attr := range cell.Attr {
+		if attr.Namespace == "" && attr.Key == name {
+			span, err := strconv.Atoi(strings.TrimSpace(attr.Val))
+			if err != nil || span < 1 {
+				return 1
+			}
+			return min(span, limit)
+		}
+	}
+	return 1
+}
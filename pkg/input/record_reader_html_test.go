@@ -0,0 +1,55 @@
+package input
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/html"
+)
+
+func parseHTMLTableForTest(t *testing.T, input string) *html.Node {
+	document, err := html.Parse(strings.NewReader(input))
+	assert.Nil(t, err)
+	table, err := selectHTMLTable(document, "")
+	assert.Nil(t, err)
+	return table
+}
+
+func TestExpandHTMLTableRowsColspan(t *testing.T) {
+	table := parseHTMLTableForTest(t, `<table>
+		<tr><td colspan="2">a</td><td>b</td></tr>
+		<tr><td>c</td><td colspan="0">d</td></tr>
+	</table>`)
+	assert.Equal(t, [][]string{{"a", "a", "b"}, {"c", "d"}}, expandHTMLTableRows(table))
+}
+
+func TestExpandHTMLTableRowsRowspan(t *testing.T) {
+	table := parseHTMLTableForTest(t, `<table>
+		<tr><td rowspan="3">a</td><td>b</td><td rowspan="2">c</td></tr>
+		<tr><td>d</td></tr>
+		<tr><td>e</td><td>f</td></tr>
+		<tr><td>g</td></tr>
+	</table>`)
+	assert.Equal(t, [][]string{
+		{"a", "b", "c"},
+		{"a", "d", "c"},
+		{"a", "e", "f"},
+		{"g"},
+	}, expandHTMLTableRows(table))
+}
+
+func TestExpandHTMLTableRowsTrailingRowspan(t *testing.T) {
+	table := parseHTMLTableForTest(t, `<table>
+		<tr><td>a</td><td>b</td><td rowspan="2">c</td></tr>
+		<tr><td>d</td></tr>
+	</table>`)
+	assert.Equal(t, [][]string{{"a", "b", "c"}, {"d", "", "c"}}, expandHTMLTableRows(table))
+}
+
+func TestHTMLCellText(t *testing.T) {
+	table := parseHTMLTableForTest(t, `<table><tr><td>
+		 One <b>two</b><br>three&amp;four<script>x = 1</script>
+	</td></tr></table>`)
+	assert.Equal(t, [][]string{{"One two three&four"}}, expandHTMLTableRows(table))
+}
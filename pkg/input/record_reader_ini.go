@@ -0,0 +1,222 @@
+// INI record-reader.
+//
+// Each section is a record, with the section name in a _section field, first
+// in the record, followed by the section's key/values in file order. The
+// key/values before the first section header, if any, are a record of their
+// own, with empty _section. Sections without key/values are records with only
+// the _section field, so that they're kept when the file is written back out.
+//
+// Key/value lines are split at the first = or :, whichever comes first, with
+// whitespace around keys and values trimmed; a line without either is a key
+// with empty value. Values in double quotes have the quotes removed and
+// backslash escapes such as \n and \" decoded; values in single quotes have
+// the quotes removed. Lines starting with ; or # are comments, as are lines
+// starting with the --pass-comments etc. comment string. Comments after
+// values on the same line are part of the value, since ; and # often appear
+// within values.
+
+package input
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/johnkerl/miller/v6/pkg/cli"
+	"github.com/johnkerl/miller/v6/pkg/lib"
+	"github.com/johnkerl/miller/v6/pkg/mlrval"
+	"github.com/johnkerl/miller/v6/pkg/types"
+)
+
+type RecordReaderINI struct {
+	readerOptions   *cli.TReaderOptions
+	recordsPerBatch int64 // distinct from readerOptions.RecordsPerBatch for join/repl
+	inputLineNumber int64
+	// The section being read, which is complete at the next section header
+	// or at end of file
+	record *mlrval.Mlrmap
+}
+
+func NewRecordReaderINI(
+	readerOptions *cli.TReaderOptions,
+	recordsPerBatch int64,
+) (*RecordReaderINI, error) {
+	if readerOptions.IRS != "\n" && readerOptions.IRS != "\r\n" {
+		return nil, fmt.Errorf("for INI, IRS cannot be altered; LF vs CR/LF is autodetected")
+	}
+	return &RecordReaderINI{
+		readerOptions:   readerOptions,
+		recordsPerBatch: recordsPerBatch,
+	}, nil
+}
+
+func (reader *RecordReaderINI) Read(
+	filenames []string,
+	context types.Context,
+	readerChannel chan<- []*types.RecordAndContext, // list of *types.RecordAndContext
+	errorChannel chan error,
+	downstreamDoneChannel <-chan bool, // for mlr head
+) {
+	if filenames != nil { // nil for mlr -n
+		if len(filenames) == 0 { // read from stdin
+			handle, err := lib.OpenStdin(
+				reader.readerOptions.Prepipe,
+				reader.readerOptions.PrepipeIsRaw,
+				reader.readerOptions.FileInputEncoding,
+			)
+			if err != nil {
+				errorChannel <- err
+			} else {
+				reader.processHandle(handle, "(stdin)", &context, readerChannel, errorChannel, downstreamDoneChannel)
+			}
+		} else {
+			for _, filename := range filenames {
+				handle, err := lib.OpenFileForRead(
+					filename,
+					reader.readerOptions.Prepipe,
+					reader.readerOptions.PrepipeIsRaw,
+					reader.readerOptions.FileInputEncoding,
+				)
+				if err != nil {
+					errorChannel <- err
+				} else {
+					reader.processHandle(handle, filename, &context, readerChannel, errorChannel, downstreamDoneChannel)
+					_ = handle.Close()
+				}
+			}
+		}
+	}
+	readerChannel <- types.NewEndOfStreamMarkerList(&context)
+}
+
+func (reader *RecordReaderINI) processHandle(
+	handle io.Reader,
+	filename string,
+	context *types.Context,
+	readerChannel chan<- []*types.RecordAndContext,
+	errorChannel chan<- error,
+	downstreamDoneChannel <-chan bool, // for mlr head
+) {
+	context.UpdateForStartOfFile(filename)
+	reader.inputLineNumber = 0
+	reader.record = mlrval.NewMlrmapAsRecord()
+	reader.record.PutReference(lib.SECTION_FIELD_NAME, mlrval.FromString(""))
+	recordsPerBatch := reader.recordsPerBatch
+
+	lineReader := NewLineReader(handle, reader.readerOptions.IRS)
+	linesChannel := make(chan []string, recordsPerBatch)
+	go channelizedLineReader(lineReader, linesChannel, downstreamDoneChannel, recordsPerBatch)
+
+	for {
+		recordsAndContexts, eof, err := reader.getRecordBatch(linesChannel, filename, context)
+		if err != nil {
+			errorChannel <- err
+			return
+		}
+		if eof && reader.record != nil {
+			recordsAndContexts = reader.maybeAppendRecord(recordsAndContexts, context)
+		}
+		if len(recordsAndContexts) > 0 {
+			readerChannel <- recordsAndContexts
+		}
+		if eof {
+			break
+		}
+	}
+}
+
+func (reader *RecordReaderINI) getRecordBatch(
+	linesChannel <-chan []string,
+	filename string,
+	context *types.Context,
+) (
+	recordsAndContexts []*types.RecordAndContext,
+	eof bool,
+	err error,
+) {
+	recordsAndContexts = []*types.RecordAndContext{}
+	dedupeFieldNames := reader.readerOptions.DedupeFieldNames
+
+	lines, more := <-linesChannel
+	if !more {
+		return recordsAndContexts, true, nil
+	}
+
+	arena := mlrval.NewRecordArena(len(lines))
+
+	for _, line := range lines {
+		reader.inputLineNumber++
+
+		// Check for comments-in-data feature
+		// TODO: function-pointer this away
+		if reader.readerOptions.CommentHandling != cli.CommentsAreData {
+			if strings.HasPrefix(line, reader.readerOptions.CommentString) {
+				if reader.readerOptions.CommentHandling == cli.PassComments {
+					recordsAndContexts = append(recordsAndContexts, types.NewOutputString(line+"\n", context))
+					continue
+				} else if reader.readerOptions.CommentHandling == cli.SkipComments {
+					continue
+				}
+				// else comments are data
+			}
+		}
+
+		line = strings.TrimSpace(line)
+		if line == "" || line[0] == ';' || line[0] == '#' {
+			continue
+		}
+
+		if line[0] == '[' {
+			if !strings.HasSuffix(line, "]") {
+				return nil, false, fmt.Errorf(
+					"ini: %s line %d: section header has no closing bracket", filename, reader.inputLineNumber,
+				)
+			}
+			recordsAndContexts = reader.maybeAppendRecord(recordsAndContexts, context)
+			reader.record = arena.NewRecord()
+			section := strings.TrimSpace(line[1 : len(line)-1])
+			reader.record.PutReference(lib.SECTION_FIELD_NAME, mlrval.FromString(section))
+			continue
+		}
+
+		key, value := splitINILine(line)
+		arena.PutDeferred(reader.record, key, value, dedupeFieldNames)
+	}
+
+	return recordsAndContexts, false, nil
+}
+
+// maybeAppendRecord appends the section just completed, except for the
+// key/values before the first section header if there were none.
+func (reader *RecordReaderINI) maybeAppendRecord(
+	recordsAndContexts []*types.RecordAndContext,
+	context *types.Context,
+) []*types.RecordAndContext {
+	record := reader.record
+	reader.record = nil
+	if record.FieldCount == 1 && record.Head.Value.String() == "" {
+		return recordsAndContexts
+	}
+	context.UpdateForInputRecord()
+	return append(recordsAndContexts, types.NewRecordAndContext(record, context))
+}
+
+// splitINILine splits a key/value line, which has been trimmed, into key and
+// value, removing quotes from the value.
+func splitINILine(line string) (key, value string) {
+	index := strings.IndexAny(line, "=:")
+	if index < 0 {
+		return line, ""
+	}
+	key = strings.TrimSpace(line[:index])
+	value = strings.TrimSpace(line[index+1:])
+
+	if len(value) >= 2 {
+		if value[0] == '"' && value[len(value)-1] == '"' {
+			value = lib.UnbackslashStringLiteral(value[1 : len(value)-1])
+		} else if value[0] == '\'' && value[len(value)-1] == '\'' {
+			value = value[1 : len(value)-1]
+		}
+	}
+	return key, value
+}
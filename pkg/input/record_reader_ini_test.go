@@ -0,0 +1,78 @@
+package input
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/johnkerl/miller/v6/pkg/cli"
+	"github.com/johnkerl/miller/v6/pkg/types"
+)
+
+func TestSplitINILine(t *testing.T) {
+	key, value := splitINILine("a = 1")
+	assert.Equal(t, "a", key)
+	assert.Equal(t, "1", value)
+
+	key, value = splitINILine("url: http://example.com/?x=1")
+	assert.Equal(t, "url", key)
+	assert.Equal(t, "http://example.com/?x=1", value)
+
+	key, value = splitINILine(`banner = "  say \"hi\"\n"`)
+	assert.Equal(t, "banner", key)
+	assert.Equal(t, "  say \"hi\"\n", value)
+
+	key, value = splitINILine(`path = 'C:\data'`)
+	assert.Equal(t, "path", key)
+	assert.Equal(t, `C:\data`, value)
+
+	key, value = splitINILine("flag")
+	assert.Equal(t, "flag", key)
+	assert.Equal(t, "", value)
+}
+
+func TestRecordReaderINI_Sections(t *testing.T) {
+	readerOptions := cli.DefaultReaderOptions()
+	readerOptions.InputFileFormat = "ini"
+	assert.NoError(t, cli.FinalizeReaderOptions(&readerOptions))
+
+	// Batch size 1, so that sections span batches
+	reader, err := NewRecordReaderINI(&readerOptions, 1)
+	assert.NoError(t, err)
+
+	ctx := types.Context{}
+	readerChannel := make(chan []*types.RecordAndContext, 10)
+	errorChannel := make(chan error, 1)
+
+	input := strings.NewReader("; comment\n[a]\nx = 1\n\ny = 2\n[ b ]\n[c]\nz=3\n")
+	reader.processHandle(input, "(test)", &ctx, readerChannel, errorChannel, nil)
+	close(readerChannel)
+
+	joined := []string{}
+	for records := range readerChannel {
+		for _, record := range records {
+			joined = append(joined, record.Record.ToDKVPString())
+		}
+	}
+	assert.Equal(t, []string{"_section=a,x=1,y=2", "_section=b", "_section=c,z=3"}, joined)
+}
+
+func TestRecordReaderINI_ErrorHasLineNumber(t *testing.T) {
+	readerOptions := cli.DefaultReaderOptions()
+	readerOptions.InputFileFormat = "ini"
+	assert.NoError(t, cli.FinalizeReaderOptions(&readerOptions))
+
+	reader, err := NewRecordReaderINI(&readerOptions, 10)
+	assert.NoError(t, err)
+
+	ctx := types.Context{}
+	readerChannel := make(chan []*types.RecordAndContext, 4)
+	errorChannel := make(chan error, 1)
+
+	input := strings.NewReader("[a]\nx = 1\n[b\n")
+	reader.processHandle(input, "(test)", &ctx, readerChannel, errorChannel, nil)
+
+	err = <-errorChannel
+	assert.Contains(t, err.Error(), "(test) line 3")
+}
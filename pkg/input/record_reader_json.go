@@ -3,6 +3,7 @@ package input
 import (
 	"fmt"
 	"io"
+	"strconv"
 	"strings"
 
 	"encoding/json"
@@ -17,15 +18,40 @@ type RecordReaderJSON struct {
 	readerOptions   *cli.TReaderOptions
 	recordsPerBatch int64 // distinct from readerOptions.RecordsPerBatch for join/repl
 	sawBrackets     bool
+	// For GeoJSON, records are the features of FeatureCollections; see
+	// recordsFromGeoJSON.
+	isGeoJSON bool
+	// From --json-records-path, if given: records are streamed from the
+	// array at this path; see processHandleWithRecordsPath.
+	recordsPath []string
 }
 
 func NewRecordReaderJSON(
 	readerOptions *cli.TReaderOptions,
 	recordsPerBatch int64,
+) (*RecordReaderJSON, error) {
+	reader := &RecordReaderJSON{
+		readerOptions:   readerOptions,
+		recordsPerBatch: recordsPerBatch,
+	}
+	if readerOptions.JSONRecordsPath != "" {
+		recordsPath, err := lib.ParseJSONPointer(readerOptions.JSONRecordsPath)
+		if err != nil {
+			return nil, fmt.Errorf("mlr: --json-records-path: %v", err)
+		}
+		reader.recordsPath = recordsPath
+	}
+	return reader, nil
+}
+
+func NewRecordReaderGeoJSON(
+	readerOptions *cli.TReaderOptions,
+	recordsPerBatch int64,
 ) (*RecordReaderJSON, error) {
 	return &RecordReaderJSON{
 		readerOptions:   readerOptions,
 		recordsPerBatch: recordsPerBatch,
+		isGeoJSON:       true,
 	}, nil
 }
 
@@ -49,13 +75,8 @@ func (reader *RecordReaderJSON) Read(
 				reader.processHandle(handle, "(stdin)", &context, readerChannel, errorChannel, downstreamDoneChannel)
 			}
 		} else {
-			for _, filename := range filenames {
-				handle, err := lib.OpenFileForRead(
-					filename,
-					reader.readerOptions.Prepipe,
-					reader.readerOptions.PrepipeIsRaw,
-					reader.readerOptions.FileInputEncoding,
-				)
+			for i, filename := range filenames {
+				handle, err := openFileForRead(filenames, i, reader.readerOptions, downstreamDoneChannel)
 				if err != nil {
 					errorChannel <- err
 				} else {
@@ -85,6 +106,15 @@ func (reader *RecordReaderJSON) processHandle(
 		handle = NewJSONCommentEnabledReader(handle, reader.readerOptions, readerChannel)
 	}
 	decoder := json.NewDecoder(handle)
+
+	if reader.recordsPath != nil {
+		err := reader.processHandleWithRecordsPath(decoder, filename, context, readerChannel, downstreamDoneChannel)
+		if err != nil {
+			errorChannel <- err
+		}
+		return
+	}
+
 	recordsAndContexts := make([]*types.RecordAndContext, 0, recordsPerBatch)
 
 	eof := false
@@ -117,6 +147,24 @@ func (reader *RecordReaderJSON) processHandle(
 			return
 		}
 
+		if reader.isGeoJSON {
+			records, err := recordsFromGeoJSON(mlrval)
+			if err != nil {
+				errorChannel <- fmt.Errorf("geojson: %s: %v", filename, err)
+				return
+			}
+			for _, record := range records {
+				context.UpdateForInputRecord()
+				recordsAndContexts = append(recordsAndContexts, types.NewRecordAndContext(record, context))
+
+				if int64(len(recordsAndContexts)) >= recordsPerBatch {
+					readerChannel <- recordsAndContexts
+					recordsAndContexts = make([]*types.RecordAndContext, 0, recordsPerBatch)
+				}
+			}
+			continue
+		}
+
 		// Find out what we got.
 		// * Map is an input record: deliver it.
 		// * Array is OK if it's array of input record: deliver them.
@@ -184,6 +232,284 @@ func (reader *RecordReaderJSON) processHandle(
 	}
 }
 
+// processHandleWithRecordsPath is for --json-records-path: records are the
+// elements of the array at that path within each top-level value, such as
+// data in {"meta": {...}, "data": [...]}. The array is streamed, one element
+// at a time, so that the whole document need not fit in memory; values not
+// on the path are read and discarded, or kept as siblings, along the way.
+func (reader *RecordReaderJSON) processHandleWithRecordsPath(
+	decoder *json.Decoder,
+	filename string,
+	context *types.Context,
+	readerChannel chan<- []*types.RecordAndContext,
+	downstreamDoneChannel <-chan bool,
+) error {
+	recordsPerBatch := reader.recordsPerBatch
+	recordsAndContexts := make([]*types.RecordAndContext, 0, recordsPerBatch)
+	reader.sawBrackets = true
+
+	// Returns false if downstream processors will be ignoring further data
+	// (e.g. mlr head), so we should stop reading.
+	addRecord := func(record *mlrval.Mlrmap) bool {
+		context.UpdateForInputRecord()
+		recordsAndContexts = append(recordsAndContexts, types.NewRecordAndContext(record, context))
+		if int64(len(recordsAndContexts)) >= recordsPerBatch {
+			readerChannel <- recordsAndContexts
+			recordsAndContexts = make([]*types.RecordAndContext, 0, recordsPerBatch)
+			select {
+			case <-downstreamDoneChannel:
+				return false
+			default:
+			}
+		}
+		return true
+	}
+
+	decoder.UseNumber()
+	for {
+		startToken, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("json: %s: %v", filename, err)
+		}
+		found, keepGoing, err := reader.streamRecordsAtPath(decoder, startToken, reader.recordsPath, nil, addRecord)
+		if err != nil {
+			return fmt.Errorf("json: %s: records path %s: %v", filename, reader.readerOptions.JSONRecordsPath, err)
+		}
+		if !keepGoing {
+			return nil
+		}
+		if !found {
+			return fmt.Errorf("json: %s: records path %s not found", filename, reader.readerOptions.JSONRecordsPath)
+		}
+	}
+
+	if len(recordsAndContexts) > 0 {
+		readerChannel <- recordsAndContexts
+	}
+	return nil
+}
+
+// streamRecordsAtPath reads the value starting with the given token, which
+// has already been read, through to its end, following the path within it.
+// At the end of the path, each element of the array there is passed to
+// addRecord, with the siblings, if any, appended to it: these are the other
+// fields of the object holding the array, when --json-records-siblings is
+// given, which were before the array in the input. The return values tell
+// whether the path was found, and whether to keep reading, which is false
+// when addRecord says not to.
+func (reader *RecordReaderJSON) streamRecordsAtPath(
+	decoder *json.Decoder,
+	startToken json.Token,
+	path []string,
+	siblings *mlrval.Mlrmap,
+	addRecord func(*mlrval.Mlrmap) bool,
+) (found bool, keepGoing bool, err error) {
+	delimiter, isDelim := startToken.(json.Delim)
+
+	if len(path) == 0 {
+		if !isDelim || delimiter != '[' {
+			return false, false, fmt.Errorf("expected array; got %s", describeJSONToken(startToken))
+		}
+		for decoder.More() {
+			value, _, err := mlrval.MlrvalDecodeFromJSON(decoder)
+			if err != nil {
+				return false, false, err
+			}
+			if !value.IsMap() {
+				return false, false, fmt.Errorf(
+					"valid but unmillerable JSON. Expected map (JSON object); got %s",
+					value.GetTypeName(),
+				)
+			}
+			record := value.GetMap()
+			if siblings != nil {
+				for pe := siblings.Head; pe != nil; pe = pe.Next {
+					if !record.Has(pe.Key) {
+						record.PutCopy(pe.Key, pe.Value)
+					}
+				}
+			}
+			if !addRecord(record) {
+				return true, false, nil
+			}
+		}
+		_, err := decoder.Token() // closing bracket
+		return true, true, err
+	}
+
+	if !isDelim {
+		// A scalar, so the path isn't within it
+		return false, true, nil
+	}
+
+	if delimiter == '{' {
+		if reader.readerOptions.JSONRecordsSiblings {
+			siblings = mlrval.NewMlrmap()
+		} else {
+			siblings = nil
+		}
+		for decoder.More() {
+			keyToken, err := decoder.Token()
+			if err != nil {
+				return false, false, err
+			}
+			key, _ := keyToken.(string)
+			if key == path[0] {
+				valueToken, err := decoder.Token()
+				if err != nil {
+					return false, false, err
+				}
+				foundHere, keepGoing, err := reader.streamRecordsAtPath(decoder, valueToken, path[1:], siblings, addRecord)
+				if err != nil || !keepGoing {
+					return found || foundHere, keepGoing, err
+				}
+				found = found || foundHere
+			} else {
+				value, _, err := mlrval.MlrvalDecodeFromJSON(decoder)
+				if err != nil {
+					return false, false, err
+				}
+				if siblings != nil {
+					siblings.PutReference(key, value)
+				}
+			}
+		}
+	} else {
+		// Array elements are selected by index; other path segments aren't
+		// within arrays.
+		index, err := strconv.Atoi(path[0])
+		if err != nil {
+			index = -1
+		}
+		for i := 0; decoder.More(); i++ {
+			if i == index {
+				elementToken, err := decoder.Token()
+				if err != nil {
+					return false, false, err
+				}
+				foundHere, keepGoing, err := reader.streamRecordsAtPath(decoder, elementToken, path[1:], nil, addRecord)
+				if err != nil || !keepGoing {
+					return foundHere, keepGoing, err
+				}
+				found = foundHere
+			} else {
+				if _, _, err := mlrval.MlrvalDecodeFromJSON(decoder); err != nil {
+					return false, false, err
+				}
+			}
+		}
+	}
+
+	_, err = decoder.Token() // closing brace or bracket
+	return found, true, err
+}
+
+// describeJSONToken is for error messages about a JSON value, given its
+// first token.
+func describeJSONToken(token json.Token) string {
+	switch t := token.(type) {
+	case json.Delim:
+		if t == '{' {
+			return "map (JSON object)"
+		}
+		return "array"
+	case string:
+		return "string"
+	case json.Number:
+		return "number"
+	case bool:
+		return "boolean"
+	case nil:
+		return "null"
+	default:
+		return fmt.Sprintf("%v", t)
+	}
+}
+
+// recordsFromGeoJSON returns the records for a top-level GeoJSON object,
+// which is a FeatureCollection or a single Feature, as in newline-delimited
+// GeoJSON. Each feature is a record: its properties are the fields, followed
+// by its geometry, as a map, in a geometry field. A feature's id, if any, is
+// put first, in an _id field. Other members of features, such as bbox, are
+// not kept.
+func recordsFromGeoJSON(value *mlrval.Mlrval) ([]*mlrval.Mlrmap, error) {
+	geoJSONType := ""
+	if value.IsMap() {
+		if typeValue := value.GetMap().Get("type"); typeValue != nil {
+			geoJSONType = typeValue.String()
+		}
+	}
+
+	switch geoJSONType {
+	case "FeatureCollection":
+		features := value.GetMap().Get("features")
+		if features == nil || !features.IsArray() {
+			return nil, fmt.Errorf("FeatureCollection has no features array")
+		}
+		records := make([]*mlrval.Mlrmap, 0, len(features.GetArray()))
+		for _, feature := range features.GetArray() {
+			record, err := recordFromGeoJSONFeature(feature)
+			if err != nil {
+				return nil, err
+			}
+			records = append(records, record)
+		}
+		return records, nil
+
+	case "Feature":
+		record, err := recordFromGeoJSONFeature(value)
+		if err != nil {
+			return nil, err
+		}
+		return []*mlrval.Mlrmap{record}, nil
+
+	default:
+		return nil, fmt.Errorf("expected FeatureCollection or Feature; got %s", describeGeoJSONValue(value))
+	}
+}
+
+func recordFromGeoJSONFeature(value *mlrval.Mlrval) (*mlrval.Mlrmap, error) {
+	if !value.IsMap() || value.GetMap().Get("type") == nil || value.GetMap().Get("type").String() != "Feature" {
+		return nil, fmt.Errorf("expected Feature; got %s", describeGeoJSONValue(value))
+	}
+	feature := value.GetMap()
+	record := mlrval.NewMlrmapAsRecord()
+
+	if id := feature.Get("id"); id != nil {
+		record.PutReference(lib.GEOJSON_ID_FIELD_NAME, id)
+	}
+
+	properties := feature.Get("properties")
+	if properties != nil && properties.IsMap() {
+		for pe := properties.GetMap().Head; pe != nil; pe = pe.Next {
+			record.PutReference(pe.Key, pe.Value)
+		}
+	} else if properties != nil && !properties.IsNull() {
+		return nil, fmt.Errorf("Feature properties must be an object or null; got %s", properties.GetTypeName())
+	}
+
+	geometry := feature.Get("geometry")
+	if geometry == nil {
+		return nil, fmt.Errorf("Feature has no geometry")
+	}
+	record.PutReference(lib.GEOJSON_GEOMETRY_FIELD_NAME, geometry)
+
+	return record, nil
+}
+
+func describeGeoJSONValue(value *mlrval.Mlrval) string {
+	if value.IsMap() {
+		if typeValue := value.GetMap().Get("type"); typeValue != nil {
+			return "type " + typeValue.String()
+		}
+		return "map without type"
+	}
+	return value.GetTypeName()
+}
+
 // JSON comment-stripping
 //
 // Miller lets users (on an opt-in basis) have comments in their data files,
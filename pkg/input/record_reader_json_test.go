@@ -0,0 +1,131 @@
+package input
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/johnkerl/miller/v6/pkg/cli"
+	"github.com/johnkerl/miller/v6/pkg/lib"
+	"github.com/johnkerl/miller/v6/pkg/mlrval"
+)
+
+func decodeJSONForTest(t *testing.T, s string) *mlrval.Mlrval {
+	value, err := mlrval.TryUnmarshalJSON([]byte(s))
+	assert.NoError(t, err)
+	return value
+}
+
+func TestRecordsFromGeoJSON_FeatureCollection(t *testing.T) {
+	records, err := recordsFromGeoJSON(decodeJSONForTest(t, `{
+		"type": "FeatureCollection",
+		"features": [
+			{"type": "Feature", "id": 7, "bbox": [0, 0, 1, 1],
+			 "geometry": {"type": "Point", "coordinates": [1, 2]},
+			 "properties": {"name": "a"}},
+			{"type": "Feature", "geometry": null, "properties": null}
+		]
+	}`))
+	assert.NoError(t, err)
+	assert.Len(t, records, 2)
+	assert.Equal(t, "_id,name,geometry", records[0].GetKeysJoined())
+	assert.Equal(t, "7", records[0].Get("_id").String())
+	assert.True(t, records[0].Get("geometry").IsMap())
+	assert.Equal(t, "geometry", records[1].GetKeysJoined())
+	assert.True(t, records[1].Get("geometry").IsNull())
+}
+
+func TestRecordsFromGeoJSON_Feature(t *testing.T) {
+	records, err := recordsFromGeoJSON(decodeJSONForTest(t,
+		`{"type": "Feature", "geometry": null, "properties": {"a": 1}}`,
+	))
+	assert.NoError(t, err)
+	assert.Len(t, records, 1)
+	assert.Equal(t, "a,geometry", records[0].GetKeysJoined())
+}
+
+func TestRecordsFromGeoJSON_Errors(t *testing.T) {
+	for _, s := range []string{
+		`{"type": "Point", "coordinates": [1, 2]}`,
+		`{"type": "FeatureCollection"}`,
+		`{"type": "FeatureCollection", "features": [{"type": "Point"}]}`,
+		`{"type": "Feature", "properties": {}}`,
+		`{"type": "Feature", "geometry": null, "properties": 3}`,
+		`[1, 2]`,
+	} {
+		_, err := recordsFromGeoJSON(decodeJSONForTest(t, s))
+		assert.Error(t, err, s)
+	}
+}
+
+func streamRecordsAtPathForTest(
+	t *testing.T,
+	s string,
+	pointer string,
+	withSiblings bool,
+) ([]*mlrval.Mlrmap, bool, error) {
+	path, err := lib.ParseJSONPointer(pointer)
+	assert.NoError(t, err)
+	reader := &RecordReaderJSON{
+		readerOptions: &cli.TReaderOptions{JSONRecordsSiblings: withSiblings},
+		recordsPath:   path,
+	}
+	decoder := json.NewDecoder(strings.NewReader(s))
+	decoder.UseNumber()
+	startToken, err := decoder.Token()
+	assert.NoError(t, err)
+	records := make([]*mlrval.Mlrmap, 0)
+	found, _, err := reader.streamRecordsAtPath(decoder, startToken, path, nil,
+		func(record *mlrval.Mlrmap) bool {
+			records = append(records, record)
+			return true
+		},
+	)
+	return records, found, err
+}
+
+func TestStreamRecordsAtPath(t *testing.T) {
+	input := `{"meta": {"n": 2}, "id": 9, "data": [{"a": 1}, {"a": 2, "id": 3}], "after": 1}`
+
+	records, found, err := streamRecordsAtPathForTest(t, input, "data", false)
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Len(t, records, 2)
+	assert.Equal(t, "a", records[0].GetKeysJoined())
+
+	records, found, err = streamRecordsAtPathForTest(t, input, "/data", true)
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Len(t, records, 2)
+	assert.Equal(t, "a,meta,id", records[0].GetKeysJoined())
+	assert.Equal(t, "9", records[0].Get("id").String())
+	assert.Equal(t, "a,id,meta", records[1].GetKeysJoined())
+	assert.Equal(t, "3", records[1].Get("id").String())
+
+	records, found, err = streamRecordsAtPathForTest(t, `[{"x": []}, {"x": [{"a": 1}]}]`, "/1/x", false)
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Len(t, records, 1)
+
+	_, found, err = streamRecordsAtPathForTest(t, input, "/nosuch", false)
+	assert.NoError(t, err)
+	assert.False(t, found)
+
+	_, found, err = streamRecordsAtPathForTest(t, input, "/meta/n/x", false)
+	assert.NoError(t, err)
+	assert.False(t, found)
+}
+
+func TestStreamRecordsAtPath_Errors(t *testing.T) {
+	for _, s := range []string{
+		`{"data": {"a": 1}}`,
+		`{"data": 3}`,
+		`{"data": [{"a": 1}, 2]}`,
+		`{"data": [{"a": 1}`,
+	} {
+		_, _, err := streamRecordsAtPathForTest(t, s, "/data", false)
+		assert.Error(t, err, s)
+	}
+}
@@ -0,0 +1,231 @@
+// This is mostly-identical code for the logfmt and LTSV record-readers. Both
+// are line-oriented, with one record per line; blank lines are skipped, as
+// they carry no record in either format.
+//
+// * logfmt: space-separated key=value pairs, with double-quoted values where
+//   needed; see the logfmt package.
+// * LTSV (http://ltsv.org): tab-separated label:value pairs, split at the
+//   first colon. LTSV has no quoting, so, as with TSV, \t, \n, \r, and \\ in
+//   labels and values are decoded as tab, newline, carriage return, and
+//   backslash.
+
+package input
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/johnkerl/miller/v6/pkg/cli"
+	"github.com/johnkerl/miller/v6/pkg/lib"
+	"github.com/johnkerl/miller/v6/pkg/logfmt"
+	"github.com/johnkerl/miller/v6/pkg/mlrval"
+	"github.com/johnkerl/miller/v6/pkg/types"
+)
+
+// line_splitter_logfmt_LTSV is a function type for the one bit of code
+// differing between the logfmt reader and the LTSV reader, namely, how it
+// splits lines.
+type line_splitter_logfmt_LTSV func(reader *RecordReaderLogfmtLTSV, line string) (*mlrval.Mlrmap, error)
+
+type RecordReaderLogfmtLTSV struct {
+	readerOptions   *cli.TReaderOptions
+	recordsPerBatch int64 // distinct from readerOptions.RecordsPerBatch for join/repl
+	formatName      string
+	lineSplitter    line_splitter_logfmt_LTSV
+	inputLineNumber int64
+	// recordArena batch-allocates field entries/values; reset per getRecordBatch.
+	recordArena *mlrval.RecordArena
+}
+
+func NewRecordReaderLogfmt(
+	readerOptions *cli.TReaderOptions,
+	recordsPerBatch int64,
+) (*RecordReaderLogfmtLTSV, error) {
+	if readerOptions.IFS != " " {
+		return nil, fmt.Errorf("for logfmt, IFS cannot be altered; spaces and tabs separate pairs")
+	}
+	if readerOptions.IPS != "=" {
+		return nil, fmt.Errorf("for logfmt, IPS cannot be altered")
+	}
+	if readerOptions.IRS != "\n" && readerOptions.IRS != "\r\n" {
+		return nil, fmt.Errorf("for logfmt, IRS cannot be altered; LF vs CR/LF is autodetected")
+	}
+	return &RecordReaderLogfmtLTSV{
+		readerOptions:   readerOptions,
+		recordsPerBatch: recordsPerBatch,
+		formatName:      "logfmt",
+		lineSplitter:    recordFromLogfmtLine,
+		recordArena:     mlrval.NewRecordArena(64),
+	}, nil
+}
+
+func NewRecordReaderLTSV(
+	readerOptions *cli.TReaderOptions,
+	recordsPerBatch int64,
+) (*RecordReaderLogfmtLTSV, error) {
+	if readerOptions.IFS != "\t" {
+		return nil, fmt.Errorf("for LTSV, IFS cannot be altered")
+	}
+	if readerOptions.IPS != ":" {
+		return nil, fmt.Errorf("for LTSV, IPS cannot be altered")
+	}
+	if readerOptions.IRS != "\n" && readerOptions.IRS != "\r\n" {
+		return nil, fmt.Errorf("for LTSV, IRS cannot be altered; LF vs CR/LF is autodetected")
+	}
+	return &RecordReaderLogfmtLTSV{
+		readerOptions:   readerOptions,
+		recordsPerBatch: recordsPerBatch,
+		formatName:      "ltsv",
+		lineSplitter:    recordFromLTSVLine,
+		recordArena:     mlrval.NewRecordArena(64),
+	}, nil
+}
+
+func (reader *RecordReaderLogfmtLTSV) Read(
+	filenames []string,
+	context types.Context,
+	readerChannel chan<- []*types.RecordAndContext, // list of *types.RecordAndContext
+	errorChannel chan error,
+	downstreamDoneChannel <-chan bool, // for mlr head
+) {
+	if filenames != nil { // nil for mlr -n
+		if len(filenames) == 0 { // read from stdin
+			handle, err := lib.OpenStdin(
+				reader.readerOptions.Prepipe,
+				reader.readerOptions.PrepipeIsRaw,
+				reader.readerOptions.FileInputEncoding,
+			)
+			if err != nil {
+				errorChannel <- err
+			} else {
+				reader.processHandle(handle, "(stdin)", &context, readerChannel, errorChannel, downstreamDoneChannel)
+			}
+		} else {
+			for i, filename := range filenames {
+				handle, err := openFileForRead(filenames, i, reader.readerOptions, downstreamDoneChannel)
+				if err != nil {
+					errorChannel <- err
+				} else {
+					reader.processHandle(handle, filename, &context, readerChannel, errorChannel, downstreamDoneChannel)
+					_ = handle.Close()
+				}
+			}
+		}
+	}
+	readerChannel <- types.NewEndOfStreamMarkerList(&context)
+}
+
+func (reader *RecordReaderLogfmtLTSV) processHandle(
+	handle io.Reader,
+	filename string,
+	context *types.Context,
+	readerChannel chan<- []*types.RecordAndContext,
+	errorChannel chan<- error,
+	downstreamDoneChannel <-chan bool, // for mlr head
+) {
+	context.UpdateForStartOfFile(filename)
+	reader.inputLineNumber = 0
+	recordsPerBatch := reader.recordsPerBatch
+
+	lineReader := NewLineReader(handle, reader.readerOptions.IRS)
+	linesChannel := make(chan []string, recordsPerBatch)
+	go channelizedLineReader(lineReader, linesChannel, downstreamDoneChannel, recordsPerBatch)
+
+	for {
+		recordsAndContexts, eof := reader.getRecordBatch(linesChannel, filename, errorChannel, context)
+		if len(recordsAndContexts) > 0 {
+			readerChannel <- recordsAndContexts
+		}
+		if eof {
+			break
+		}
+	}
+}
+
+func (reader *RecordReaderLogfmtLTSV) getRecordBatch(
+	linesChannel <-chan []string,
+	filename string,
+	errorChannel chan<- error,
+	context *types.Context,
+) (
+	recordsAndContexts []*types.RecordAndContext,
+	eof bool,
+) {
+	recordsAndContexts = []*types.RecordAndContext{}
+
+	lines, more := <-linesChannel
+	if !more {
+		return recordsAndContexts, true
+	}
+
+	reader.recordArena = mlrval.NewRecordArena(len(lines) * 8)
+
+	for _, line := range lines {
+		reader.inputLineNumber++
+
+		// Check for comments-in-data feature
+		// TODO: function-pointer this away
+		if reader.readerOptions.CommentHandling != cli.CommentsAreData {
+			if strings.HasPrefix(line, reader.readerOptions.CommentString) {
+				if reader.readerOptions.CommentHandling == cli.PassComments {
+					recordsAndContexts = append(recordsAndContexts, types.NewOutputString(line+"\n", context))
+					continue
+				} else if reader.readerOptions.CommentHandling == cli.SkipComments {
+					continue
+				}
+				// else comments are data
+			}
+		}
+
+		if strings.TrimLeft(line, " \t") == "" {
+			continue
+		}
+
+		record, err := reader.lineSplitter(reader, line)
+		if err != nil {
+			errorChannel <- fmt.Errorf(
+				"%s: %s line %d: %v", reader.formatName, filename, reader.inputLineNumber, err,
+			)
+			return
+		}
+		context.UpdateForInputRecord()
+		recordAndContext := types.NewRecordAndContext(record, context)
+		recordsAndContexts = append(recordsAndContexts, recordAndContext)
+	}
+
+	return recordsAndContexts, false
+}
+
+func recordFromLogfmtLine(reader *RecordReaderLogfmtLTSV, line string) (*mlrval.Mlrmap, error) {
+	keys, values, err := logfmt.ParseLine(line)
+	if err != nil {
+		return nil, err
+	}
+	record := reader.recordArena.NewRecord()
+	dedupeFieldNames := reader.readerOptions.DedupeFieldNames
+	for i, key := range keys {
+		reader.recordArena.PutDeferred(record, key, values[i], dedupeFieldNames)
+	}
+	return record, nil
+}
+
+func recordFromLTSVLine(reader *RecordReaderLogfmtLTSV, line string) (*mlrval.Mlrmap, error) {
+	record := reader.recordArena.NewRecord()
+	dedupeFieldNames := reader.readerOptions.DedupeFieldNames
+
+	for i, field := range strings.Split(line, "\t") {
+		if field == "" {
+			continue
+		}
+		label, value, found := strings.Cut(field, ":")
+		if !found {
+			// As with DKVP, use the 1-up positional index as the label.
+			reader.recordArena.PutDeferred(record, strconv.Itoa(i+1), lib.TSVDecodeField(field), dedupeFieldNames)
+		} else {
+			reader.recordArena.PutDeferred(record, lib.TSVDecodeField(label), lib.TSVDecodeField(value), dedupeFieldNames)
+		}
+	}
+	return record, nil
+}
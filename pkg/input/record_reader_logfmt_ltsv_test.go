@@ -0,0 +1,88 @@
+package input
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/johnkerl/miller/v6/pkg/cli"
+	"github.com/johnkerl/miller/v6/pkg/types"
+)
+
+func TestNewRecordReaderLogfmt_AlteredIFSRejected(t *testing.T) {
+	readerOptions := cli.DefaultReaderOptions()
+	readerOptions.InputFileFormat = "logfmt"
+	assert.NoError(t, cli.FinalizeReaderOptions(&readerOptions))
+	readerOptions.IFS = ","
+
+	reader, err := NewRecordReaderLogfmt(&readerOptions, 1)
+	assert.Nil(t, reader)
+	assert.Error(t, err)
+}
+
+func TestRecordReaderLogfmt_QuotingAndBlankLines(t *testing.T) {
+	readerOptions := cli.DefaultReaderOptions()
+	readerOptions.InputFileFormat = "logfmt"
+	assert.NoError(t, cli.FinalizeReaderOptions(&readerOptions))
+
+	reader, err := NewRecordReaderLogfmt(&readerOptions, 10)
+	assert.NoError(t, err)
+
+	ctx := types.Context{}
+	readerChannel := make(chan []*types.RecordAndContext, 4)
+	errorChannel := make(chan error, 1)
+
+	input := strings.NewReader("\nmsg=\"a \\\"b\\\"\" n=1 n=2 flag\n\n")
+	go reader.processHandle(input, "(test)", &ctx, readerChannel, errorChannel, nil)
+
+	records := <-readerChannel
+	assert.Len(t, records, 1)
+	record := records[0].Record
+	assert.Equal(t, "msg,n,n_2,flag", record.GetKeysJoined())
+	assert.Equal(t, `a "b"`, record.Get("msg").String())
+	assert.Equal(t, "", record.Get("flag").String())
+}
+
+func TestRecordReaderLogfmt_ErrorHasLineNumber(t *testing.T) {
+	readerOptions := cli.DefaultReaderOptions()
+	readerOptions.InputFileFormat = "logfmt"
+	assert.NoError(t, cli.FinalizeReaderOptions(&readerOptions))
+
+	reader, err := NewRecordReaderLogfmt(&readerOptions, 10)
+	assert.NoError(t, err)
+
+	ctx := types.Context{}
+	readerChannel := make(chan []*types.RecordAndContext, 4)
+	errorChannel := make(chan error, 1)
+
+	input := strings.NewReader("a=1\nmsg=\"unterminated\n")
+	go reader.processHandle(input, "(test)", &ctx, readerChannel, errorChannel, nil)
+
+	err = <-errorChannel
+	assert.Contains(t, err.Error(), "(test) line 2")
+}
+
+func TestRecordReaderLTSV_FirstColonAndEscapes(t *testing.T) {
+	readerOptions := cli.DefaultReaderOptions()
+	readerOptions.InputFileFormat = "ltsv"
+	assert.NoError(t, cli.FinalizeReaderOptions(&readerOptions))
+
+	reader, err := NewRecordReaderLTSV(&readerOptions, 10)
+	assert.NoError(t, err)
+
+	ctx := types.Context{}
+	readerChannel := make(chan []*types.RecordAndContext, 4)
+	errorChannel := make(chan error, 1)
+
+	input := strings.NewReader("time:03:04:05\treq:a\\tb\tbare\n")
+	go reader.processHandle(input, "(test)", &ctx, readerChannel, errorChannel, nil)
+
+	records := <-readerChannel
+	assert.Len(t, records, 1)
+	record := records[0].Record
+	assert.Equal(t, "time,req,3", record.GetKeysJoined())
+	assert.Equal(t, "03:04:05", record.Get("time").String())
+	assert.Equal(t, "a\tb", record.Get("req").String())
+	assert.Equal(t, "bare", record.Get("3").String())
+}
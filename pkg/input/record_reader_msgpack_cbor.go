@@ -0,0 +1,187 @@
+// This is mostly-identical code for the MessagePack and CBOR record-readers.
+// Each top-level map in the input is a record, as is each map in a top-level
+// array, as with JSON. Binary values are read as bytes, and ints and floats
+// are kept distinct, as they are in the input.
+
+package input
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/vmihailenco/msgpack/v5"
+
+	"github.com/johnkerl/miller/v6/pkg/cli"
+	"github.com/johnkerl/miller/v6/pkg/lib"
+	"github.com/johnkerl/miller/v6/pkg/mlrval"
+	"github.com/johnkerl/miller/v6/pkg/types"
+)
+
+// valueDecoderMsgpackCBOR decodes the next top-level value, returning eof true
+// at end of input.
+type valueDecoderMsgpackCBOR func() (value *mlrval.Mlrval, eof bool, err error)
+
+// decoderMakerMsgpackCBOR is the one bit of code differing between the
+// MessagePack reader and the CBOR reader.
+type decoderMakerMsgpackCBOR func(handle io.Reader) valueDecoderMsgpackCBOR
+
+type RecordReaderMsgpackCBOR struct {
+	readerOptions   *cli.TReaderOptions
+	recordsPerBatch int64
+	formatName      string
+	decoderMaker    decoderMakerMsgpackCBOR
+}
+
+func NewRecordReaderMsgpack(
+	readerOptions *cli.TReaderOptions,
+	recordsPerBatch int64,
+) (*RecordReaderMsgpackCBOR, error) {
+	return &RecordReaderMsgpackCBOR{
+		readerOptions:   readerOptions,
+		recordsPerBatch: recordsPerBatch,
+		formatName:      "msgpack",
+		decoderMaker: func(handle io.Reader) valueDecoderMsgpackCBOR {
+			decoder := msgpack.NewDecoder(bufio.NewReader(handle))
+			return func() (*mlrval.Mlrval, bool, error) {
+				return mlrval.MlrvalDecodeFromMsgpack(decoder)
+			}
+		},
+	}, nil
+}
+
+func NewRecordReaderCBOR(
+	readerOptions *cli.TReaderOptions,
+	recordsPerBatch int64,
+) (*RecordReaderMsgpackCBOR, error) {
+	return &RecordReaderMsgpackCBOR{
+		readerOptions:   readerOptions,
+		recordsPerBatch: recordsPerBatch,
+		formatName:      "cbor",
+		decoderMaker: func(handle io.Reader) valueDecoderMsgpackCBOR {
+			decoder := cbor.NewDecoder(bufio.NewReader(handle))
+			return func() (*mlrval.Mlrval, bool, error) {
+				return mlrval.MlrvalDecodeFromCBOR(decoder)
+			}
+		},
+	}, nil
+}
+
+func (reader *RecordReaderMsgpackCBOR) Read(
+	filenames []string,
+	context types.Context,
+	readerChannel chan<- []*types.RecordAndContext,
+	errorChannel chan error,
+	downstreamDoneChannel <-chan bool,
+) {
+	if filenames != nil {
+		if len(filenames) == 0 {
+			handle, err := lib.OpenStdin(
+				reader.readerOptions.Prepipe,
+				reader.readerOptions.PrepipeIsRaw,
+				reader.readerOptions.FileInputEncoding,
+			)
+			if err != nil {
+				errorChannel <- err
+			} else {
+				err = reader.processHandle(handle, "(stdin)", &context, readerChannel, downstreamDoneChannel)
+				if err != nil {
+					errorChannel <- err
+				}
+			}
+		} else {
+			for _, filename := range filenames {
+				handle, err := lib.OpenFileForRead(
+					filename,
+					reader.readerOptions.Prepipe,
+					reader.readerOptions.PrepipeIsRaw,
+					reader.readerOptions.FileInputEncoding,
+				)
+				if err != nil {
+					errorChannel <- err
+					break
+				}
+				err = reader.processHandle(handle, filename, &context, readerChannel, downstreamDoneChannel)
+				_ = handle.Close()
+				if err != nil {
+					errorChannel <- err
+					break
+				}
+			}
+		}
+	}
+	readerChannel <- types.NewEndOfStreamMarkerList(&context)
+}
+
+func (reader *RecordReaderMsgpackCBOR) processHandle(
+	handle io.Reader,
+	filename string,
+	context *types.Context,
+	readerChannel chan<- []*types.RecordAndContext,
+	downstreamDoneChannel <-chan bool,
+) error {
+	context.UpdateForStartOfFile(filename)
+	recordsPerBatch := reader.recordsPerBatch
+	recordsAndContexts := make([]*types.RecordAndContext, 0, recordsPerBatch)
+	decode := reader.decoderMaker(handle)
+
+	// Returns false if downstream processors will be ignoring further data
+	// (e.g. mlr head), so we should stop reading.
+	addRecord := func(value *mlrval.Mlrval) (bool, error) {
+		if !value.IsMap() {
+			return false, fmt.Errorf(
+				"%s: %s: valid but unmillerable data: expected map; got %s",
+				reader.formatName, filename, value.GetTypeName(),
+			)
+		}
+		context.UpdateForInputRecord()
+		recordsAndContexts = append(recordsAndContexts, types.NewRecordAndContext(value.GetMap(), context))
+		if int64(len(recordsAndContexts)) >= recordsPerBatch {
+			readerChannel <- recordsAndContexts
+			recordsAndContexts = make([]*types.RecordAndContext, 0, recordsPerBatch)
+			select {
+			case <-downstreamDoneChannel:
+				return false, nil
+			default:
+			}
+		}
+		return true, nil
+	}
+
+	for {
+		value, eof, err := decode()
+		if eof {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %s: %v", reader.formatName, filename, err)
+		}
+
+		more := true
+		if value.IsArray() {
+			for _, element := range value.GetArray() {
+				more, err = addRecord(element)
+				if err != nil {
+					return err
+				}
+				if !more {
+					break
+				}
+			}
+		} else {
+			more, err = addRecord(value)
+			if err != nil {
+				return err
+			}
+		}
+		if !more {
+			return nil
+		}
+	}
+
+	if len(recordsAndContexts) > 0 {
+		readerChannel <- recordsAndContexts
+	}
+	return nil
+}
@@ -0,0 +1,241 @@
+// Emacs org-mode table record-reader.
+//
+// Table lines are those starting with |, after any indentation. The first
+// row of each table is the header, and hlines, such as |---+---|, are
+// skipped, as are rows of column-width and alignment cookies such as <l> or
+// <10>. Any other line, such as headings, text, or #+TBLFM formulas, ends the
+// table, so an org file with several tables gives records with each table's
+// schema in turn. Cells are trimmed, and \vert{} within them is |, as written
+// by the org-mode record-writer.
+
+package input
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/johnkerl/miller/v6/pkg/cli"
+	"github.com/johnkerl/miller/v6/pkg/lib"
+	"github.com/johnkerl/miller/v6/pkg/mlrval"
+	"github.com/johnkerl/miller/v6/pkg/types"
+)
+
+type RecordReaderOrg struct {
+	readerOptions   *cli.TReaderOptions
+	recordsPerBatch int64 // distinct from readerOptions.RecordsPerBatch for join/repl
+	inputLineNumber int64
+	headerStrings   []string
+}
+
+var orgCookieRegex = regexp.MustCompile(`^<[lcr]?[0-9]*>$`)
+
+func NewRecordReaderOrg(
+	readerOptions *cli.TReaderOptions,
+	recordsPerBatch int64,
+) (*RecordReaderOrg, error) {
+	return &RecordReaderOrg{
+		readerOptions:   readerOptions,
+		recordsPerBatch: recordsPerBatch,
+	}, nil
+}
+
+func (reader *RecordReaderOrg) Read(
+	filenames []string,
+	context types.Context,
+	readerChannel chan<- []*types.RecordAndContext, // list of *types.RecordAndContext
+	errorChannel chan error,
+	downstreamDoneChannel <-chan bool, // for mlr head
+) {
+	if filenames != nil { // nil for mlr -n
+		if len(filenames) == 0 { // read from stdin
+			handle, err := lib.OpenStdin(
+				reader.readerOptions.Prepipe,
+				reader.readerOptions.PrepipeIsRaw,
+				reader.readerOptions.FileInputEncoding,
+			)
+			if err != nil {
+				errorChannel <- err
+			} else {
+				reader.processHandle(handle, "(stdin)", &context, readerChannel, errorChannel, downstreamDoneChannel)
+			}
+		} else {
+			for _, filename := range filenames {
+				handle, err := lib.OpenFileForRead(
+					filename,
+					reader.readerOptions.Prepipe,
+					reader.readerOptions.PrepipeIsRaw,
+					reader.readerOptions.FileInputEncoding,
+				)
+				if err != nil {
+					errorChannel <- err
+				} else {
+					reader.processHandle(handle, filename, &context, readerChannel, errorChannel, downstreamDoneChannel)
+					_ = handle.Close()
+				}
+			}
+		}
+	}
+	readerChannel <- types.NewEndOfStreamMarkerList(&context)
+}
+
+func (reader *RecordReaderOrg) processHandle(
+	handle io.Reader,
+	filename string,
+	context *types.Context,
+	readerChannel chan<- []*types.RecordAndContext,
+	errorChannel chan<- error,
+	downstreamDoneChannel <-chan bool, // for mlr head
+) {
+	context.UpdateForStartOfFile(filename)
+	reader.inputLineNumber = 0
+	reader.headerStrings = nil
+	recordsPerBatch := reader.recordsPerBatch
+
+	lineReader := NewLineReader(handle, reader.readerOptions.IRS)
+	linesChannel := make(chan []string, recordsPerBatch)
+	go channelizedLineReader(lineReader, linesChannel, downstreamDoneChannel, recordsPerBatch)
+
+	for {
+		recordsAndContexts, eof, err := reader.getRecordBatch(linesChannel, filename, context)
+		if err != nil {
+			errorChannel <- err
+			return
+		}
+		if len(recordsAndContexts) > 0 {
+			readerChannel <- recordsAndContexts
+		}
+		if eof {
+			break
+		}
+	}
+}
+
+func (reader *RecordReaderOrg) getRecordBatch(
+	linesChannel <-chan []string,
+	filename string,
+	context *types.Context,
+) (
+	recordsAndContexts []*types.RecordAndContext,
+	eof bool,
+	err error,
+) {
+	recordsAndContexts = []*types.RecordAndContext{}
+	dedupeFieldNames := reader.readerOptions.DedupeFieldNames
+
+	lines, more := <-linesChannel
+	if !more {
+		return recordsAndContexts, true, nil
+	}
+
+	arena := mlrval.NewRecordArena(len(lines) * 8)
+
+	for _, line := range lines {
+		reader.inputLineNumber++
+
+		// Check for comments-in-data feature
+		// TODO: function-pointer this away
+		if reader.readerOptions.CommentHandling != cli.CommentsAreData {
+			if strings.HasPrefix(line, reader.readerOptions.CommentString) {
+				if reader.readerOptions.CommentHandling == cli.PassComments {
+					recordsAndContexts = append(recordsAndContexts, types.NewOutputString(line+"\n", context))
+					continue
+				} else if reader.readerOptions.CommentHandling == cli.SkipComments {
+					continue
+				}
+				// else comments are data
+			}
+		}
+
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "|") {
+			// End of table, if any: reset to new schema
+			reader.headerStrings = nil
+			continue
+		}
+		if strings.HasPrefix(line, "|-") {
+			continue
+		}
+
+		fields := splitOrgTableLine(line)
+		if isOrgCookieRow(fields) {
+			continue
+		}
+
+		if reader.headerStrings == nil {
+			if reader.readerOptions.UseImplicitHeader {
+				reader.headerStrings = make([]string, len(fields))
+				for i := range fields {
+					reader.headerStrings[i] = strconv.Itoa(i + 1)
+				}
+			} else {
+				reader.headerStrings = fields
+				continue
+			}
+		}
+
+		nh := len(reader.headerStrings)
+		nd := len(fields)
+		if nh != nd && !reader.readerOptions.AllowRaggedCSVInput {
+			return nil, false, fmt.Errorf(
+				"org: header/data length mismatch %d != %d at filename %s line %d",
+				nh, nd, filename, reader.inputLineNumber,
+			)
+		}
+
+		record := arena.NewRecord()
+		for i := 0; i < nd; i++ {
+			key := ""
+			if i < nh {
+				key = reader.headerStrings[i]
+			} else {
+				// If header shorter than data: use 1-up itoa keys
+				key = strconv.Itoa(i + 1)
+			}
+			arena.PutDeferred(record, key, fields[i], dedupeFieldNames)
+		}
+		for i := nd; i < nh; i++ {
+			// If header longer than data: use "" values
+			arena.PutDeferred(record, reader.headerStrings[i], "", dedupeFieldNames)
+		}
+
+		context.UpdateForInputRecord()
+		recordsAndContexts = append(recordsAndContexts, types.NewRecordAndContext(record, context))
+	}
+
+	return recordsAndContexts, false, nil
+}
+
+// splitOrgTableLine splits a table line, which has been trimmed and starts
+// with |, into its cells. Org-mode allows the closing | to be omitted.
+func splitOrgTableLine(line string) []string {
+	line = strings.TrimPrefix(line, "|")
+	line = strings.TrimSuffix(line, "|")
+	fields := strings.Split(line, "|")
+	for i, field := range fields {
+		field = strings.TrimSpace(field)
+		if strings.Contains(field, `\vert`) {
+			field = strings.ReplaceAll(strings.ReplaceAll(field, `\vert{}`, "|"), `\vert`, "|")
+		}
+		fields[i] = field
+	}
+	return fields
+}
+
+// isOrgCookieRow tells whether the row has only column-width and alignment
+// cookies, such as <l>, <r10>, or <20>, and empty cells.
+func isOrgCookieRow(fields []string) bool {
+	sawCookie := false
+	for _, field := range fields {
+		if field == "" {
+			continue
+		}
+		if !orgCookieRegex.MatchString(field) {
+			return false
+		}
+		sawCookie = true
+	}
+	return sawCookie
+}
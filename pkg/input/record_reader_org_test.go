@@ -0,0 +1,20 @@
+package input
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitOrgTableLine(t *testing.T) {
+	assert.Equal(t, []string{"a", "b"}, splitOrgTableLine("| a | b |"))
+	assert.Equal(t, []string{"a", "b"}, splitOrgTableLine("|a|b"))
+	assert.Equal(t, []string{"", "x|y"}, splitOrgTableLine(`|   | x\vert{}y |`))
+	assert.Equal(t, []string{"x|y"}, splitOrgTableLine(`| x\verty |`))
+}
+
+func TestIsOrgCookieRow(t *testing.T) {
+	assert.True(t, isOrgCookieRow([]string{"<l>", "", "<r10>", "<20>"}))
+	assert.False(t, isOrgCookieRow([]string{"<l>", "x"}))
+	assert.False(t, isOrgCookieRow([]string{"", ""}))
+}
@@ -20,8 +20,8 @@ func NewRecordReaderPPRINT(
 	if readerOptions.BarredPprintInput {
 		// Implemented in this file
 
-		if readerOptions.FixedWidthSpec != "" {
-			return nil, fmt.Errorf("--fixed or --fw not allowed with barred input")
+		if readerOptions.FixedWidthSpec != "" || readerOptions.FixedWidthSpecFile != "" {
+			return nil, fmt.Errorf("--fixed, --fw, or --fixed-spec not allowed with barred input")
 		}
 
 		readerOptions.IFS = "|"
@@ -40,13 +40,8 @@ func NewRecordReaderPPRINT(
 		}
 		return reader, nil
 
-	} else if readerOptions.FixedWidthSpec != "" {
-		reader := &RecordReaderPprintFixedSplit{
-			readerOptions:    readerOptions,
-			recordsPerBatch:  recordsPerBatch,
-			separatorMatcher: regexp.MustCompile(`^[-=─ ]*$`),
-		}
-		return reader, nil
+	} else if readerOptions.FixedWidthSpec != "" || readerOptions.FixedWidthSpecFile != "" {
+		return newRecordReaderPprintFixedSplit(readerOptions, recordsPerBatch)
 	}
 	// Use the CSVLite record-reader, which is implemented in another file,
 	// with multiple spaces instead of commas
@@ -66,17 +61,55 @@ func NewRecordReaderPPRINT(
 	return reader, nil
 }
 
+// NewRecordReaderFixedWidth is for --ifixed: columns are as given by the
+// column-spec file, else as found from the header line by --fixed, which
+// defaults to left-align-multi-word as with --fw.
+func NewRecordReaderFixedWidth(
+	readerOptions *cli.TReaderOptions,
+	recordsPerBatch int64,
+) (IRecordReader, error) {
+	if readerOptions.FixedWidthSpec == "" && readerOptions.FixedWidthSpecFile == "" {
+		readerOptions.FixedWidthSpec = "left-align-multi-word"
+	}
+	return newRecordReaderPprintFixedSplit(readerOptions, recordsPerBatch)
+}
+
 type RecordReaderPprintFixedSplit struct {
 	readerOptions   *cli.TReaderOptions
 	recordsPerBatch int64
 
 	separatorMatcher *regexp.Regexp
 	fieldSplitter    *fixedWidthSplitter
+	// From --fixed-spec: if non-nil, there is no header line, and the
+	// separator matcher and field splitter aren't used.
+	spec *lib.FixedWidthSpec
 
 	inputLineNumber int64
 	headerStrings   []string
 }
 
+func newRecordReaderPprintFixedSplit(
+	readerOptions *cli.TReaderOptions,
+	recordsPerBatch int64,
+) (*RecordReaderPprintFixedSplit, error) {
+	reader := &RecordReaderPprintFixedSplit{
+		readerOptions:    readerOptions,
+		recordsPerBatch:  recordsPerBatch,
+		separatorMatcher: regexp.MustCompile(`^[-=─ ]*$`),
+	}
+	if readerOptions.FixedWidthSpecFile != "" {
+		if readerOptions.FixedWidthSpec != "" {
+			return nil, fmt.Errorf("--fixed or --fw not allowed with --fixed-spec")
+		}
+		spec, err := lib.ReadFixedWidthSpecFile(readerOptions.FixedWidthSpecFile)
+		if err != nil {
+			return nil, err
+		}
+		reader.spec = spec
+	}
+	return reader, nil
+}
+
 func (reader *RecordReaderPprintFixedSplit) Read(
 	filenames []string,
 	context types.Context,
@@ -104,13 +137,8 @@ func (reader *RecordReaderPprintFixedSplit) Read(
 				)
 			}
 		} else {
-			for _, filename := range filenames {
-				handle, err := lib.OpenFileForRead(
-					filename,
-					reader.readerOptions.Prepipe,
-					reader.readerOptions.PrepipeIsRaw,
-					reader.readerOptions.FileInputEncoding,
-				)
+			for i, filename := range filenames {
+				handle, err := openFileForRead(filenames, i, reader.readerOptions, downstreamDoneChannel)
 				if err != nil {
 					errorChannel <- err
 				} else {
@@ -188,6 +216,20 @@ func (reader *RecordReaderPprintFixedSplit) getRecords(
 			}
 		}
 
+		if reader.spec != nil {
+			if line == "" {
+				continue
+			}
+			record, err := reader.recordFromSpec(line, filename, arena, dedupeFieldNames)
+			if err != nil {
+				errorChannel <- err
+				return
+			}
+			context.UpdateForInputRecord()
+			recordsAndContexts = append(recordsAndContexts, types.NewRecordAndContext(record, context))
+			continue
+		}
+
 		if line == "" {
 			reader.headerStrings = nil
 			reader.fieldSplitter = nil
@@ -281,6 +323,42 @@ func (reader *RecordReaderPprintFixedSplit) getRecords(
 	return recordsAndContexts, false
 }
 
+// recordFromSpec splits a line by the column spec. Short lines have empty
+// values for the missing columns. Anything beyond the spec's total width other
+// than whitespace is an error, unless ragged input is allowed, in which case
+// it's the value of one more field with positional key.
+func (reader *RecordReaderPprintFixedSplit) recordFromSpec(
+	line string,
+	filename string,
+	arena *mlrval.RecordArena,
+	dedupeFieldNames bool,
+) (*mlrval.Mlrmap, error) {
+	fields, remainder := reader.spec.Split(line)
+	record := arena.NewRecord()
+	for i, column := range reader.spec.Columns {
+		key := column.Name
+		if reader.readerOptions.UseImplicitHeader {
+			key = strconv.Itoa(i + 1)
+		}
+		value := ""
+		if i < len(fields) {
+			value = column.Trim(fields[i])
+		}
+		arena.PutDeferred(record, key, value, dedupeFieldNames)
+	}
+	if strings.TrimSpace(remainder) != "" {
+		if !reader.readerOptions.AllowRaggedCSVInput {
+			return nil, fmt.Errorf(
+				"fixed-width data longer than the column spec at filename %s line %d",
+				filename, reader.inputLineNumber,
+			)
+		}
+		key := strconv.Itoa(len(reader.spec.Columns) + 1)
+		arena.PutDeferred(record, key, strings.TrimSpace(remainder), dedupeFieldNames)
+	}
+	return record, nil
+}
+
 type RecordReaderPprintBarredOrMarkdown struct {
 	readerOptions   *cli.TReaderOptions
 	recordsPerBatch int64 // distinct from readerOptions.RecordsPerBatch for join/repl
@@ -333,13 +411,8 @@ func (reader *RecordReaderPprintBarredOrMarkdown) Read(
 				)
 			}
 		} else {
-			for _, filename := range filenames {
-				handle, err := lib.OpenFileForRead(
-					filename,
-					reader.readerOptions.Prepipe,
-					reader.readerOptions.PrepipeIsRaw,
-					reader.readerOptions.FileInputEncoding,
-				)
+			for i, filename := range filenames {
+				handle, err := openFileForRead(filenames, i, reader.readerOptions, downstreamDoneChannel)
 				if err != nil {
 					errorChannel <- err
 				} else {
@@ -0,0 +1,236 @@
+// RecordReaderRegex reads arbitrary line-oriented text, such as web-server and
+// syslog logs, using a regex with capture groups: each line matching the regex
+// is a record, with fields named by the capture groups. Unnamed groups are
+// named by their position, starting at 1, as with "\1" in the DSL. The regex
+// may instead be the name of a preset; see lib.REGEX_PRESETS.
+//
+// Lines not matching the regex are skipped, passed along as records with the
+// line in a "_raw" field, treated as errors, or written to a reject file,
+// according to --regex-nonmatch and --regex-reject-file.
+
+package input
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/johnkerl/miller/v6/pkg/cli"
+	"github.com/johnkerl/miller/v6/pkg/lib"
+	"github.com/johnkerl/miller/v6/pkg/mlrval"
+	"github.com/johnkerl/miller/v6/pkg/types"
+)
+
+const regexRawFieldName = "_raw"
+
+type RecordReaderRegex struct {
+	readerOptions   *cli.TReaderOptions
+	recordsPerBatch int64 // distinct from readerOptions.RecordsPerBatch for join/repl
+	regex           *regexp.Regexp
+	// Field names for capture groups 1 and up
+	fieldNames      []string
+	nonMatch        string
+	rejectWriter    *bufio.Writer
+	inputLineNumber int64
+}
+
+func NewRecordReaderRegex(
+	readerOptions *cli.TReaderOptions,
+	recordsPerBatch int64,
+) (*RecordReaderRegex, error) {
+	if readerOptions.RegexPattern == "" {
+		return nil, fmt.Errorf("for regex input, please specify a regex or preset name with --iregex")
+	}
+	if readerOptions.IRS != "\n" && readerOptions.IRS != "\r\n" {
+		return nil, fmt.Errorf("for regex input, IRS cannot be altered; LF vs CR/LF is autodetected")
+	}
+
+	regexString, isPreset := lib.GetRegexPreset(readerOptions.RegexPattern)
+	if !isPreset {
+		regexString = readerOptions.RegexPattern
+	}
+	regex, err := lib.CompileMillerRegex(regexString)
+	if err != nil {
+		return nil, fmt.Errorf("regex: %v", err)
+	}
+	if regex.NumSubexp() == 0 {
+		return nil, fmt.Errorf(
+			"regex: \"%s\" has no capture groups; please use groups such as (?P<name>...) for the fields",
+			readerOptions.RegexPattern,
+		)
+	}
+
+	fieldNames := make([]string, regex.NumSubexp())
+	for i, name := range regex.SubexpNames()[1:] {
+		if name == "" {
+			name = strconv.Itoa(i + 1)
+		}
+		fieldNames[i] = name
+	}
+
+	nonMatch := readerOptions.RegexNonMatch
+	if nonMatch == "" {
+		nonMatch = "skip"
+	}
+
+	return &RecordReaderRegex{
+		readerOptions:   readerOptions,
+		recordsPerBatch: recordsPerBatch,
+		regex:           regex,
+		fieldNames:      fieldNames,
+		nonMatch:        nonMatch,
+	}, nil
+}
+
+func (reader *RecordReaderRegex) Read(
+	filenames []string,
+	context types.Context,
+	readerChannel chan<- []*types.RecordAndContext, // list of *types.RecordAndContext
+	errorChannel chan error,
+	downstreamDoneChannel <-chan bool, // for mlr head
+) {
+	if filenames != nil && reader.nonMatch == "reject" {
+		rejectHandle, err := os.Create(reader.readerOptions.RegexRejectFile)
+		if err != nil {
+			errorChannel <- err
+			return
+		}
+		reader.rejectWriter = bufio.NewWriter(rejectHandle)
+		defer func() {
+			if err := reader.rejectWriter.Flush(); err != nil {
+				fmt.Fprintf(os.Stderr, "mlr: %v\n", err)
+			}
+			_ = rejectHandle.Close()
+		}()
+	}
+
+	if filenames != nil { // nil for mlr -n
+		if len(filenames) == 0 { // read from stdin
+			handle, err := lib.OpenStdin(
+				reader.readerOptions.Prepipe,
+				reader.readerOptions.PrepipeIsRaw,
+				reader.readerOptions.FileInputEncoding,
+			)
+			if err != nil {
+				errorChannel <- err
+			} else {
+				reader.processHandle(handle, "(stdin)", &context, readerChannel, errorChannel, downstreamDoneChannel)
+			}
+		} else {
+			for i, filename := range filenames {
+				handle, err := openFileForRead(filenames, i, reader.readerOptions, downstreamDoneChannel)
+				if err != nil {
+					errorChannel <- err
+				} else {
+					reader.processHandle(handle, filename, &context, readerChannel, errorChannel, downstreamDoneChannel)
+					_ = handle.Close()
+				}
+			}
+		}
+	}
+	readerChannel <- types.NewEndOfStreamMarkerList(&context)
+}
+
+func (reader *RecordReaderRegex) processHandle(
+	handle io.Reader,
+	filename string,
+	context *types.Context,
+	readerChannel chan<- []*types.RecordAndContext,
+	errorChannel chan<- error,
+	downstreamDoneChannel <-chan bool, // for mlr head
+) {
+	context.UpdateForStartOfFile(filename)
+	reader.inputLineNumber = 0
+	recordsPerBatch := reader.recordsPerBatch
+
+	lineReader := NewLineReader(handle, reader.readerOptions.IRS)
+	linesChannel := make(chan []string, recordsPerBatch)
+	go channelizedLineReader(lineReader, linesChannel, downstreamDoneChannel, recordsPerBatch)
+
+	for {
+		recordsAndContexts, eof := reader.getRecordBatch(linesChannel, filename, errorChannel, context)
+		if len(recordsAndContexts) > 0 {
+			readerChannel <- recordsAndContexts
+		}
+		if eof {
+			break
+		}
+	}
+}
+
+func (reader *RecordReaderRegex) getRecordBatch(
+	linesChannel <-chan []string,
+	filename string,
+	errorChannel chan<- error,
+	context *types.Context,
+) (
+	recordsAndContexts []*types.RecordAndContext,
+	eof bool,
+) {
+	recordsAndContexts = []*types.RecordAndContext{}
+	dedupeFieldNames := reader.readerOptions.DedupeFieldNames
+
+	lines, more := <-linesChannel
+	if !more {
+		return recordsAndContexts, true
+	}
+
+	arena := mlrval.NewRecordArena(len(lines) * len(reader.fieldNames))
+
+	for _, line := range lines {
+		reader.inputLineNumber++
+
+		// Check for comments-in-data feature
+		// TODO: function-pointer this away
+		if reader.readerOptions.CommentHandling != cli.CommentsAreData {
+			if strings.HasPrefix(line, reader.readerOptions.CommentString) {
+				if reader.readerOptions.CommentHandling == cli.PassComments {
+					recordsAndContexts = append(recordsAndContexts, types.NewOutputString(line+"\n", context))
+					continue
+				} else if reader.readerOptions.CommentHandling == cli.SkipComments {
+					continue
+				}
+				// else comments are data
+			}
+		}
+
+		record := arena.NewRecord()
+		match := reader.regex.FindStringSubmatchIndex(line)
+
+		if match == nil {
+			switch reader.nonMatch {
+			case "skip":
+				continue
+			case "reject":
+				reader.rejectWriter.WriteString(line)
+				reader.rejectWriter.WriteString("\n")
+				continue
+			case "error":
+				errorChannel <- fmt.Errorf(
+					"regex: %s line %d: line does not match the regex", filename, reader.inputLineNumber,
+				)
+				return
+			}
+			record.PutReference(regexRawFieldName, mlrval.FromString(line))
+
+		} else {
+			for i, name := range reader.fieldNames {
+				start, end := match[2*i+2], match[2*i+3]
+				value := ""
+				if start >= 0 {
+					value = line[start:end]
+				}
+				arena.PutDeferred(record, name, value, dedupeFieldNames)
+			}
+		}
+
+		context.UpdateForInputRecord()
+		recordsAndContexts = append(recordsAndContexts, types.NewRecordAndContext(record, context))
+	}
+
+	return recordsAndContexts, false
+}
@@ -0,0 +1,332 @@
+// SQLite record-reader.
+//
+// Each row of the result of --sqlite-query is one record. Without a query,
+// the rows of the --sqlite-table table are the records; without that either,
+// the database must have just one table, which is used.
+//
+// Column types follow SQLite's dynamic typing: each value's storage class
+// decides its type here, not the column's declared type.
+//
+// * INTEGER -> int, or boolean if the column is declared BOOLEAN
+// * REAL -> float
+// * TEXT -> string; text in columns declared DATE, DATETIME, or TIMESTAMP
+//   comes back formatted as YYYY-MM-DD or YYYY-MM-DD HH:MM:SS
+// * BLOB -> string if valid UTF-8, else binary
+// * NULL -> empty
+//
+// SQLite needs a file to open, so input from standard input, or through
+// --prepipe or decompression, is copied to a temporary file first.
+
+package input
+
+import (
+	"bytes"
+	"database/sql"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	_ "modernc.org/sqlite"
+
+	"github.com/johnkerl/miller/v6/pkg/cli"
+	"github.com/johnkerl/miller/v6/pkg/lib"
+	"github.com/johnkerl/miller/v6/pkg/mlrval"
+	"github.com/johnkerl/miller/v6/pkg/types"
+)
+
+const sqliteMagic = "SQLite format 3\x00"
+
+type RecordReaderSQLite struct {
+	readerOptions   *cli.TReaderOptions
+	recordsPerBatch int64
+}
+
+func NewRecordReaderSQLite(
+	readerOptions *cli.TReaderOptions,
+	recordsPerBatch int64,
+) (*RecordReaderSQLite, error) {
+	return &RecordReaderSQLite{
+		readerOptions:   readerOptions,
+		recordsPerBatch: recordsPerBatch,
+	}, nil
+}
+
+func (reader *RecordReaderSQLite) Read(
+	filenames []string,
+	context types.Context,
+	readerChannel chan<- []*types.RecordAndContext,
+	errorChannel chan error,
+	downstreamDoneChannel <-chan bool,
+) {
+	if filenames != nil {
+		if len(filenames) == 0 {
+			handle, err := lib.OpenStdin(
+				reader.readerOptions.Prepipe,
+				reader.readerOptions.PrepipeIsRaw,
+				reader.readerOptions.FileInputEncoding,
+			)
+			if err != nil {
+				errorChannel <- err
+			} else {
+				err = reader.processHandle(handle, "(stdin)", &context, readerChannel, downstreamDoneChannel)
+				if err != nil {
+					errorChannel <- err
+				}
+			}
+		} else {
+			for _, filename := range filenames {
+				handle, err := lib.OpenFileForRead(
+					filename,
+					reader.readerOptions.Prepipe,
+					reader.readerOptions.PrepipeIsRaw,
+					reader.readerOptions.FileInputEncoding,
+				)
+				if err != nil {
+					errorChannel <- err
+					break
+				}
+				err = reader.processHandle(handle, filename, &context, readerChannel, downstreamDoneChannel)
+				_ = handle.Close()
+				if err != nil {
+					errorChannel <- err
+					break
+				}
+			}
+		}
+	}
+	readerChannel <- types.NewEndOfStreamMarkerList(&context)
+}
+
+func (reader *RecordReaderSQLite) processHandle(
+	handle io.Reader,
+	filename string,
+	context *types.Context,
+	readerChannel chan<- []*types.RecordAndContext,
+	downstreamDoneChannel <-chan bool,
+) error {
+	context.UpdateForStartOfFile(filename)
+
+	path, cleanup, err := getSQLiteDatabasePath(handle)
+	if err != nil {
+		return fmt.Errorf("sqlite: %s: %v", filename, err)
+	}
+	defer cleanup()
+	if path == "" {
+		// Empty input: no records, as with CSV.
+		return nil
+	}
+
+	db, err := sql.Open("sqlite", "file:"+path+"?mode=ro&_pragma=query_only(1)")
+	if err != nil {
+		return fmt.Errorf("sqlite: %s: %v", filename, err)
+	}
+	defer db.Close()
+
+	query := reader.readerOptions.SQLiteQuery
+	if query == "" {
+		table := reader.readerOptions.SQLiteTable
+		if table == "" {
+			table, err = getSoleSQLiteTable(db)
+			if err != nil {
+				return fmt.Errorf("sqlite: %s: %v", filename, err)
+			}
+		}
+		query = "SELECT * FROM " + lib.SQLiteQuoteIdentifier(table)
+	}
+
+	rows, err := db.Query(query)
+	if err != nil {
+		return fmt.Errorf("sqlite: %s: %v", filename, err)
+	}
+	defer rows.Close()
+
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return fmt.Errorf("sqlite: %s: %v", filename, err)
+	}
+	keys := make([]string, len(columnTypes))
+	declaredTypes := make([]string, len(columnTypes))
+	for i, columnType := range columnTypes {
+		keys[i] = columnType.Name()
+		declaredTypes[i] = strings.ToUpper(columnType.DatabaseTypeName())
+	}
+
+	values := make([]any, len(columnTypes))
+	pointers := make([]any, len(columnTypes))
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+
+	dedupeFieldNames := reader.readerOptions.DedupeFieldNames
+	recordsPerBatch := reader.recordsPerBatch
+	recordsAndContexts := make([]*types.RecordAndContext, 0, recordsPerBatch)
+
+	for rows.Next() {
+		if err := rows.Scan(pointers...); err != nil {
+			return fmt.Errorf("sqlite: %s: %v", filename, err)
+		}
+		record := mlrval.NewMlrmapAsRecord()
+		for i, value := range values {
+			_, err := record.PutReferenceMaybeDedupe(
+				keys[i], sqliteValueToMlrval(value, declaredTypes[i]), dedupeFieldNames,
+			)
+			if err != nil {
+				return fmt.Errorf("sqlite: %s: %v", filename, err)
+			}
+		}
+
+		context.UpdateForInputRecord()
+		recordsAndContexts = append(recordsAndContexts, types.NewRecordAndContext(record, context))
+
+		if int64(len(recordsAndContexts)) >= recordsPerBatch {
+			readerChannel <- recordsAndContexts
+			recordsAndContexts = make([]*types.RecordAndContext, 0, recordsPerBatch)
+
+			// See if downstream processors will be ignoring further data
+			// (e.g. mlr head). If so, stop reading. This makes 'mlr head
+			// hugefile' exit quickly, as it should.
+			select {
+			case <-downstreamDoneChannel:
+				return nil
+			default:
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("sqlite: %s: %v", filename, err)
+	}
+
+	if len(recordsAndContexts) > 0 {
+		readerChannel <- recordsAndContexts
+	}
+	return nil
+}
+
+// getSQLiteDatabasePath returns a path SQLite can open for the input: the
+// file itself if it's a regular file, else a temporary copy, which the cleanup
+// function removes. The path is "" for empty input.
+func getSQLiteDatabasePath(handle io.Reader) (string, func(), error) {
+	noCleanup := func() {}
+
+	if file, ok := handle.(*os.File); ok {
+		fileInfo, err := file.Stat()
+		if err == nil && fileInfo.Mode().IsRegular() {
+			if fileInfo.Size() == 0 {
+				return "", noCleanup, nil
+			}
+			header := make([]byte, len(sqliteMagic))
+			if _, err := file.ReadAt(header, 0); err != nil || string(header) != sqliteMagic {
+				return "", noCleanup, fmt.Errorf("not an SQLite database")
+			}
+			return file.Name(), noCleanup, nil
+		}
+	}
+
+	header := make([]byte, len(sqliteMagic))
+	n, err := io.ReadFull(handle, header)
+	if n == 0 && (err == io.EOF || err == io.ErrUnexpectedEOF) {
+		return "", noCleanup, nil
+	}
+	if string(header[:n]) != sqliteMagic {
+		return "", noCleanup, fmt.Errorf("not an SQLite database")
+	}
+
+	tempFile, err := os.CreateTemp("", "mlr-sqlite-*.db")
+	if err != nil {
+		return "", noCleanup, err
+	}
+	cleanup := func() { _ = os.Remove(tempFile.Name()) }
+	_, err = io.Copy(tempFile, io.MultiReader(bytes.NewReader(header), handle))
+	if closeErr := tempFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		cleanup()
+		return "", noCleanup, err
+	}
+	return tempFile.Name(), cleanup, nil
+}
+
+// getSoleSQLiteTable returns the name of the database's one table, for when
+// neither --sqlite-query nor --sqlite-table is given.
+func getSoleSQLiteTable(db *sql.DB) (string, error) {
+	rows, err := db.Query(
+		"SELECT name FROM sqlite_schema WHERE type = 'table' AND name NOT LIKE 'sqlite_%' ORDER BY name",
+	)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+
+	tables := make([]string, 0)
+	for rows.Next() {
+		var table string
+		if err := rows.Scan(&table); err != nil {
+			return "", err
+		}
+		tables = append(tables, table)
+	}
+	if err := rows.Err(); err != nil {
+		return "", err
+	}
+
+	switch len(tables) {
+	case 0:
+		return "", fmt.Errorf("database has no tables")
+	case 1:
+		return tables[0], nil
+	default:
+		return "", fmt.Errorf(
+			"database has tables %s; please use --sqlite-table or --sqlite-query",
+			strings.Join(tables, ", "),
+		)
+	}
+}
+
+func sqliteValueToMlrval(value any, declaredType string) *mlrval.Mlrval {
+	switch v := value.(type) {
+	case nil:
+		return mlrval.VOID
+	case int64:
+		if strings.Contains(declaredType, "BOOL") && (v == 0 || v == 1) {
+			return mlrval.FromBool(v == 1)
+		}
+		return mlrval.FromInt(v)
+	case float64:
+		return mlrval.FromFloat(v)
+	case bool:
+		return mlrval.FromBool(v)
+	case string:
+		return mlrval.FromString(v)
+	case []byte:
+		if utf8.Valid(v) {
+			return mlrval.FromString(string(v))
+		}
+		return mlrval.FromBytes(v)
+	case time.Time:
+		return mlrval.FromString(formatSQLiteTime(v, declaredType))
+	default:
+		return mlrval.FromString(fmt.Sprintf("%v", v))
+	}
+}
+
+// formatSQLiteTime undoes the driver's parsing of text in DATE, DATETIME, and
+// TIMESTAMP columns, giving back dates and times in SQLite's own format.
+func formatSQLiteTime(t time.Time, declaredType string) string {
+	isMidnight := t.Hour() == 0 && t.Minute() == 0 && t.Second() == 0 && t.Nanosecond() == 0
+	if declaredType == "DATE" && isMidnight {
+		return t.Format("2006-01-02")
+	}
+	layout := "2006-01-02 15:04:05"
+	if t.Nanosecond() != 0 {
+		layout += ".999999999"
+	}
+	_, offset := t.Zone()
+	if offset != 0 {
+		layout += "-07:00"
+	}
+	return t.Format(layout)
+}
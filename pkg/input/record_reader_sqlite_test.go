@@ -0,0 +1,35 @@
+package input
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatSQLiteTime(t *testing.T) {
+	midnight := time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, "2024-03-15", formatSQLiteTime(midnight, "DATE"))
+	assert.Equal(t, "2024-03-15 00:00:00", formatSQLiteTime(midnight, "DATETIME"))
+
+	morning := time.Date(2024, 3, 15, 9, 30, 0, 0, time.UTC)
+	assert.Equal(t, "2024-03-15 09:30:00", formatSQLiteTime(morning, "DATE"))
+	assert.Equal(t, "2024-03-15 09:30:00", formatSQLiteTime(morning, "TIMESTAMP"))
+
+	fractional := time.Date(2024, 3, 15, 9, 30, 0, 250000000, time.UTC)
+	assert.Equal(t, "2024-03-15 09:30:00.25", formatSQLiteTime(fractional, "DATETIME"))
+
+	offset := time.Date(2024, 3, 15, 9, 30, 0, 0, time.FixedZone("", 2*3600))
+	assert.Equal(t, "2024-03-15 09:30:00+02:00", formatSQLiteTime(offset, "DATETIME"))
+}
+
+func TestSQLiteValueToMlrval(t *testing.T) {
+	assert.Equal(t, "int", sqliteValueToMlrval(int64(1), "INTEGER").GetTypeName())
+	assert.Equal(t, "bool", sqliteValueToMlrval(int64(1), "BOOLEAN").GetTypeName())
+	assert.Equal(t, "int", sqliteValueToMlrval(int64(2), "BOOLEAN").GetTypeName())
+	assert.Equal(t, "float", sqliteValueToMlrval(2.5, "REAL").GetTypeName())
+	assert.Equal(t, "string", sqliteValueToMlrval("0xff", "TEXT").GetTypeName())
+	assert.Equal(t, "string", sqliteValueToMlrval([]byte("hello"), "BLOB").GetTypeName())
+	assert.Equal(t, "bytes", sqliteValueToMlrval([]byte{0, 0xff}, "BLOB").GetTypeName())
+	assert.Equal(t, "empty", sqliteValueToMlrval(nil, "").GetTypeName())
+}
@@ -0,0 +1,184 @@
+// TOML record-reader.
+//
+// Each table, or array-of-tables entry, is a record, with its name in a
+// _section field, first in the record: for example, "server" for [server],
+// "server.tls" for [server.tls], and "products[]" for each [[products]]. The
+// key/values before the first table header, if any, are a record of their
+// own, with empty _section. Dotted keys and inline tables within a table are
+// maps, and arrays are arrays.
+//
+// The whole input is read and validated before any records are produced,
+// since, for example, a key defined twice is an error anywhere in the file.
+
+package input
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"github.com/pelletier/go-toml/v2/unstable"
+
+	"github.com/johnkerl/miller/v6/pkg/cli"
+	"github.com/johnkerl/miller/v6/pkg/lib"
+	"github.com/johnkerl/miller/v6/pkg/mlrval"
+	"github.com/johnkerl/miller/v6/pkg/types"
+)
+
+type RecordReaderTOML struct {
+	readerOptions   *cli.TReaderOptions
+	recordsPerBatch int64
+}
+
+func NewRecordReaderTOML(
+	readerOptions *cli.TReaderOptions,
+	recordsPerBatch int64,
+) (*RecordReaderTOML, error) {
+	return &RecordReaderTOML{
+		readerOptions:   readerOptions,
+		recordsPerBatch: recordsPerBatch,
+	}, nil
+}
+
+func (reader *RecordReaderTOML) Read(
+	filenames []string,
+	context types.Context,
+	readerChannel chan<- []*types.RecordAndContext,
+	errorChannel chan error,
+	downstreamDoneChannel <-chan bool,
+) {
+	if filenames != nil {
+		if len(filenames) == 0 {
+			handle, err := lib.OpenStdin(
+				reader.readerOptions.Prepipe,
+				reader.readerOptions.PrepipeIsRaw,
+				reader.readerOptions.FileInputEncoding,
+			)
+			if err != nil {
+				errorChannel <- err
+			} else {
+				err = reader.processHandle(handle, "(stdin)", &context, readerChannel, downstreamDoneChannel)
+				if err != nil {
+					errorChannel <- err
+				}
+			}
+		} else {
+			for _, filename := range filenames {
+				handle, err := lib.OpenFileForRead(
+					filename,
+					reader.readerOptions.Prepipe,
+					reader.readerOptions.PrepipeIsRaw,
+					reader.readerOptions.FileInputEncoding,
+				)
+				if err != nil {
+					errorChannel <- err
+					break
+				}
+				err = reader.processHandle(handle, filename, &context, readerChannel, downstreamDoneChannel)
+				_ = handle.Close()
+				if err != nil {
+					errorChannel <- err
+					break
+				}
+			}
+		}
+	}
+	readerChannel <- types.NewEndOfStreamMarkerList(&context)
+}
+
+func (reader *RecordReaderTOML) processHandle(
+	handle io.Reader,
+	filename string,
+	context *types.Context,
+	readerChannel chan<- []*types.RecordAndContext,
+	downstreamDoneChannel <-chan bool,
+) error {
+	context.UpdateForStartOfFile(filename)
+	recordsPerBatch := reader.recordsPerBatch
+	recordsAndContexts := make([]*types.RecordAndContext, 0, recordsPerBatch)
+
+	data, err := io.ReadAll(handle)
+	if err != nil {
+		return fmt.Errorf("toml: %s: %v", filename, err)
+	}
+
+	// The parser below checks syntax but not semantics, such as keys defined
+	// twice, so we check by decoding first.
+	var decoded map[string]interface{}
+	err = toml.Unmarshal(data, &decoded)
+	if err != nil {
+		message := strings.TrimPrefix(err.Error(), "toml: ")
+		var decodeError *toml.DecodeError
+		if errors.As(err, &decodeError) {
+			row, column := decodeError.Position()
+			return fmt.Errorf("toml: %s line %d column %d: %s", filename, row, column, message)
+		}
+		return fmt.Errorf("toml: %s: %s", filename, message)
+	}
+
+	// Returns false if downstream processors will be ignoring further data
+	// (e.g. mlr head), so we should stop reading.
+	addRecord := func(record *mlrval.Mlrmap) bool {
+		context.UpdateForInputRecord()
+		recordsAndContexts = append(recordsAndContexts, types.NewRecordAndContext(record, context))
+		if int64(len(recordsAndContexts)) >= recordsPerBatch {
+			readerChannel <- recordsAndContexts
+			recordsAndContexts = make([]*types.RecordAndContext, 0, recordsPerBatch)
+			select {
+			case <-downstreamDoneChannel:
+				return false
+			default:
+			}
+		}
+		return true
+	}
+
+	record := mlrval.NewMlrmapAsRecord()
+	record.PutReference(lib.SECTION_FIELD_NAME, mlrval.FromString(""))
+
+	parser := unstable.Parser{}
+	parser.Reset(data)
+	for parser.NextExpression() {
+		expression := parser.Expression()
+
+		switch expression.Kind {
+		case unstable.KeyValue:
+			err := mlrval.MlrmapPutTOMLKeyValue(record, expression)
+			if err != nil {
+				return fmt.Errorf("toml: %s: %v", filename, err)
+			}
+
+		case unstable.Table, unstable.ArrayTable:
+			// The top-level record is only for key/values before the first
+			// table header, if there are any.
+			if record.FieldCount > 1 || record.Get(lib.SECTION_FIELD_NAME).String() != "" {
+				if !addRecord(record) {
+					return nil
+				}
+			}
+			keys := make([]string, 0, 1)
+			for it := expression.Key(); it.Next(); {
+				keys = append(keys, string(it.Node().Data))
+			}
+			section := lib.TOMLFormatDottedKey(keys)
+			if expression.Kind == unstable.ArrayTable {
+				section += "[]"
+			}
+			record = mlrval.NewMlrmapAsRecord()
+			record.PutReference(lib.SECTION_FIELD_NAME, mlrval.FromString(section))
+		}
+	}
+	if err := parser.Error(); err != nil {
+		return fmt.Errorf("toml: %s: %v", filename, err)
+	}
+
+	if record.FieldCount > 1 || record.Get(lib.SECTION_FIELD_NAME).String() != "" {
+		addRecord(record)
+	}
+	if len(recordsAndContexts) > 0 {
+		readerChannel <- recordsAndContexts
+	}
+	return nil
+}
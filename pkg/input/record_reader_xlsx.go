@@ -0,0 +1,855 @@
+// XLSX record-reader.
+//
+// An XLSX file is a zip archive of XML documents (Office Open XML,
+// ECMA-376). We read:
+//
+// * _rels/.rels to find the workbook, and the workbook's own relationships to
+//   find its worksheets, shared-strings table, and styles;
+// * the workbook, for sheet names and the date system;
+// * the shared-strings table, where most cell text lives;
+// * the styles, to know which numeric cells are formatted as dates/times;
+// * one worksheet, streaming through its rows.
+//
+// The first row (after --xlsx-header-offset, within --xlsx-range if given)
+// is the header, as with CSV. Empty rows are skipped. Missing cells in a data
+// row which the header has are filled with empty values; cells in columns
+// beyond the header get positional keys, as with implicit header.
+//
+// Cell types:
+// * numbers -> int or float; numbers formatted as dates/times -> strings
+//   formatted as YYYY-MM-DD, YYYY-MM-DD HH:MM:SS, or HH:MM:SS
+// * booleans -> boolean
+// * strings, formula string results, and error values such as #N/A -> string
+
+package input
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/johnkerl/miller/v6/pkg/cli"
+	"github.com/johnkerl/miller/v6/pkg/lib"
+	"github.com/johnkerl/miller/v6/pkg/mlrval"
+	"github.com/johnkerl/miller/v6/pkg/types"
+)
+
+type RecordReaderXLSX struct {
+	readerOptions   *cli.TReaderOptions
+	recordsPerBatch int64
+
+	// From --xlsx-range: zero-up columns and one-up rows, inclusive. The last
+	// ones are -1 if unbounded.
+	firstColumn int
+	lastColumn  int
+	firstRow    int
+	lastRow     int
+}
+
+func NewRecordReaderXLSX(
+	readerOptions *cli.TReaderOptions,
+	recordsPerBatch int64,
+) (*RecordReaderXLSX, error) {
+	reader := &RecordReaderXLSX{
+		readerOptions:   readerOptions,
+		recordsPerBatch: recordsPerBatch,
+		firstColumn:     0,
+		lastColumn:      -1,
+		firstRow:        1,
+		lastRow:         -1,
+	}
+	if readerOptions.XLSXRange != "" {
+		if err := reader.parseRange(readerOptions.XLSXRange); err != nil {
+			return nil, err
+		}
+	}
+	return reader, nil
+}
+
+// parseRange accepts ranges such as "B2:F100", "B:F" for whole columns, and
+// "B2" for everything below and to the right of a cell.
+func (reader *RecordReaderXLSX) parseRange(xlsxRange string) error {
+	rangeError := fmt.Errorf(
+		"xlsx range \"%s\" is not of the form A1:D20, A:D, or A1", xlsxRange,
+	)
+	first, last, hasLast := strings.Cut(xlsxRange, ":")
+
+	column, row, ok := lib.XLSXParseCellRef(first)
+	if !ok {
+		return rangeError
+	}
+	reader.firstColumn = column
+	if row > 0 {
+		reader.firstRow = row
+	}
+
+	if hasLast {
+		column, row, ok = lib.XLSXParseCellRef(last)
+		if !ok || column < reader.firstColumn || (row > 0 && row < reader.firstRow) {
+			return rangeError
+		}
+		reader.lastColumn = column
+		if row > 0 {
+			reader.lastRow = row
+		}
+	}
+	return nil
+}
+
+func (reader *RecordReaderXLSX) Read(
+	filenames []string,
+	context types.Context,
+	readerChannel chan<- []*types.RecordAndContext,
+	errorChannel chan error,
+	downstreamDoneChannel <-chan bool,
+) {
+	if filenames != nil {
+		if len(filenames) == 0 {
+			handle, err := lib.OpenStdin(
+				reader.readerOptions.Prepipe,
+				reader.readerOptions.PrepipeIsRaw,
+				reader.readerOptions.FileInputEncoding,
+			)
+			if err != nil {
+				errorChannel <- err
+			} else {
+				err = reader.processHandle(handle, "(stdin)", &context, readerChannel, downstreamDoneChannel)
+				if err != nil {
+					errorChannel <- err
+				}
+			}
+		} else {
+			for _, filename := range filenames {
+				handle, err := lib.OpenFileForRead(
+					filename,
+					reader.readerOptions.Prepipe,
+					reader.readerOptions.PrepipeIsRaw,
+					reader.readerOptions.FileInputEncoding,
+				)
+				if err != nil {
+					errorChannel <- err
+					break
+				}
+				err = reader.processHandle(handle, filename, &context, readerChannel, downstreamDoneChannel)
+				_ = handle.Close()
+				if err != nil {
+					errorChannel <- err
+					break
+				}
+			}
+		}
+	}
+	readerChannel <- types.NewEndOfStreamMarkerList(&context)
+}
+
+func (reader *RecordReaderXLSX) processHandle(
+	handle io.Reader,
+	filename string,
+	context *types.Context,
+	readerChannel chan<- []*types.RecordAndContext,
+	downstreamDoneChannel <-chan bool,
+) error {
+	context.UpdateForStartOfFile(filename)
+
+	readerAt, size, err := getRandomAccessReader(handle)
+	if err != nil {
+		return fmt.Errorf("xlsx: %s: %v", filename, err)
+	}
+	if size == 0 {
+		// Empty input: no records, as with CSV.
+		return nil
+	}
+	zipReader, err := zip.NewReader(readerAt, size)
+	if err != nil {
+		if isOLECompoundFile(readerAt) {
+			return fmt.Errorf(
+				"xlsx: %s: encrypted workbooks and legacy .xls files are not supported", filename,
+			)
+		}
+		return fmt.Errorf("xlsx: %s: %v", filename, err)
+	}
+
+	workbook, err := openXLSXWorkbook(zipReader)
+	if err != nil {
+		return fmt.Errorf("xlsx: %s: %v", filename, err)
+	}
+	sheetPath, err := workbook.selectSheet(reader.readerOptions.XLSXSheet)
+	if err != nil {
+		return fmt.Errorf("xlsx: %s: %v", filename, err)
+	}
+
+	sheetFile, err := workbook.open(sheetPath)
+	if err != nil {
+		return fmt.Errorf("xlsx: %s: %v", filename, err)
+	}
+	defer sheetFile.Close()
+
+	assembler := newXLSXRecordAssembler(reader, context, readerChannel, downstreamDoneChannel)
+	err = workbook.streamRows(sheetFile, reader.firstColumn, reader.lastColumn, assembler.processRow)
+	if err != nil && err != errXLSXStop {
+		return fmt.Errorf("xlsx: %s: %v", filename, err)
+	}
+	assembler.flush()
+	return nil
+}
+
+// isOLECompoundFile checks for the signature of the pre-2007 binary container
+// format, which is used for .xls files and for password-protected .xlsx
+// files.
+func isOLECompoundFile(readerAt io.ReaderAt) bool {
+	magic := make([]byte, 8)
+	if _, err := readerAt.ReadAt(magic, 0); err != nil {
+		return false
+	}
+	return string(magic) == "\xd0\xcf\x11\xe0\xa1\xb1\x1a\xe1"
+}
+
+// errXLSXStop is returned by row callbacks to end the sheet early: after the
+// last row of the range, or when downstream processing has finished.
+var errXLSXStop = errors.New("stop")
+
+// ----------------------------------------------------------------
+// RECORD ASSEMBLY
+
+type tXLSXCell struct {
+	column int
+	value  *mlrval.Mlrval
+}
+
+type tXLSXRecordAssembler struct {
+	reader                *RecordReaderXLSX
+	context               *types.Context
+	readerChannel         chan<- []*types.RecordAndContext
+	downstreamDoneChannel <-chan bool
+
+	headerRow int
+	// Keyed by column; nil until the header row has been read
+	header             map[int]string
+	headerColumns      []int
+	recordsAndContexts []*types.RecordAndContext
+}
+
+func newXLSXRecordAssembler(
+	reader *RecordReaderXLSX,
+	context *types.Context,
+	readerChannel chan<- []*types.RecordAndContext,
+	downstreamDoneChannel <-chan bool,
+) *tXLSXRecordAssembler {
+	assembler := &tXLSXRecordAssembler{
+		reader:                reader,
+		context:               context,
+		readerChannel:         readerChannel,
+		downstreamDoneChannel: downstreamDoneChannel,
+		headerRow:             reader.firstRow + int(reader.readerOptions.XLSXHeaderOffset),
+		recordsAndContexts:    make([]*types.RecordAndContext, 0, reader.recordsPerBatch),
+	}
+	if reader.readerOptions.UseImplicitHeader {
+		assembler.header = make(map[int]string)
+	}
+	return assembler
+}
+
+func (assembler *tXLSXRecordAssembler) processRow(row int, cells []tXLSXCell) error {
+	reader := assembler.reader
+	if row < assembler.headerRow {
+		return nil
+	}
+	if reader.lastRow > 0 && row > reader.lastRow {
+		return errXLSXStop
+	}
+
+	nonEmpty := false
+	for _, cell := range cells {
+		if !cell.value.IsVoid() {
+			nonEmpty = true
+			break
+		}
+	}
+	if !nonEmpty {
+		return nil
+	}
+
+	if assembler.header == nil {
+		assembler.header = make(map[int]string)
+		for _, cell := range cells {
+			key := cell.value.String()
+			if key == "" {
+				key = strconv.Itoa(cell.column - reader.firstColumn + 1)
+			}
+			assembler.header[cell.column] = key
+			assembler.headerColumns = append(assembler.headerColumns, cell.column)
+		}
+		return nil
+	}
+
+	// Header columns, with data cells merged in by column
+	record := mlrval.NewMlrmapAsRecord()
+	dedupeFieldNames := reader.readerOptions.DedupeFieldNames
+	i, j := 0, 0
+	for i < len(assembler.headerColumns) || j < len(cells) {
+		var key string
+		var value *mlrval.Mlrval
+		if j >= len(cells) || (i < len(assembler.headerColumns) && assembler.headerColumns[i] < cells[j].column) {
+			key = assembler.header[assembler.headerColumns[i]]
+			value = mlrval.VOID
+			i++
+		} else {
+			column := cells[j].column
+			var ok bool
+			key, ok = assembler.header[column]
+			if !ok {
+				key = strconv.Itoa(column - reader.firstColumn + 1)
+			}
+			value = cells[j].value
+			if i < len(assembler.headerColumns) && assembler.headerColumns[i] == column {
+				i++
+			}
+			j++
+		}
+		if _, err := record.PutReferenceMaybeDedupe(key, value, dedupeFieldNames); err != nil {
+			return err
+		}
+	}
+
+	assembler.context.UpdateForInputRecord()
+	assembler.recordsAndContexts = append(
+		assembler.recordsAndContexts,
+		types.NewRecordAndContext(record, assembler.context),
+	)
+	if int64(len(assembler.recordsAndContexts)) >= reader.recordsPerBatch {
+		assembler.flush()
+		select {
+		case <-assembler.downstreamDoneChannel:
+			return errXLSXStop
+		default:
+		}
+	}
+	return nil
+}
+
+func (assembler *tXLSXRecordAssembler) flush() {
+	if len(assembler.recordsAndContexts) > 0 {
+		assembler.readerChannel <- assembler.recordsAndContexts
+		assembler.recordsAndContexts = make([]*types.RecordAndContext, 0, assembler.reader.recordsPerBatch)
+	}
+}
+
+// ----------------------------------------------------------------
+// WORKBOOK
+
+type tXLSXSheet struct {
+	name string
+	path string
+}
+
+type tXLSXWorkbook struct {
+	// Keyed by lowercased name, since part names are case-insensitive
+	files         map[string]*zip.File
+	sheets        []tXLSXSheet
+	date1904      bool
+	sharedStrings []string
+	// Indexed by cell style (the s attribute of cells)
+	dateKinds []tXLSXDateKind
+}
+
+type tXLSXDateKind int
+
+const (
+	xlsxNotDate tXLSXDateKind = iota
+	xlsxDate
+	xlsxTime
+	xlsxDateTime
+)
+
+type tXLSXRelationships struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Type   string `xml:"Type,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+type tXLSXWorkbookXML struct {
+	WorkbookPr struct {
+		Date1904 string `xml:"date1904,attr"`
+	} `xml:"workbookPr"`
+	Sheets []struct {
+		Name  string     `xml:"name,attr"`
+		Attrs []xml.Attr `xml:",any,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type tXLSXStylesXML struct {
+	NumFmts []struct {
+		ID   int    `xml:"numFmtId,attr"`
+		Code string `xml:"formatCode,attr"`
+	} `xml:"numFmts>numFmt"`
+	CellXfs []struct {
+		NumFmtID int `xml:"numFmtId,attr"`
+	} `xml:"cellXfs>xf"`
+}
+
+func openXLSXWorkbook(zipReader *zip.Reader) (*tXLSXWorkbook, error) {
+	workbook := &tXLSXWorkbook{
+		files: make(map[string]*zip.File),
+	}
+	for _, file := range zipReader.File {
+		workbook.files[strings.ToLower(file.Name)] = file
+	}
+
+	// The package relationships say where the workbook is.
+	workbookPath := "xl/workbook.xml"
+	var packageRels tXLSXRelationships
+	if err := workbook.unmarshal("_rels/.rels", &packageRels); err == nil {
+		for _, rel := range packageRels.Relationships {
+			if strings.HasSuffix(rel.Type, "/officeDocument") {
+				workbookPath = resolveXLSXTarget("", rel.Target)
+			}
+		}
+	}
+
+	var workbookXML tXLSXWorkbookXML
+	if err := workbook.unmarshal(workbookPath, &workbookXML); err != nil {
+		return nil, err
+	}
+	workbook.date1904 = workbookXML.WorkbookPr.Date1904 == "1" || workbookXML.WorkbookPr.Date1904 == "true"
+
+	workbookDir := path.Dir(workbookPath)
+	var workbookRels tXLSXRelationships
+	relsPath := path.Join(workbookDir, "_rels", path.Base(workbookPath)+".rels")
+	if err := workbook.unmarshal(relsPath, &workbookRels); err != nil {
+		return nil, err
+	}
+	targets := make(map[string]string)
+	for _, rel := range workbookRels.Relationships {
+		target := resolveXLSXTarget(workbookDir, rel.Target)
+		targets[rel.ID] = target
+		if !workbook.has(target) {
+			// Some writers list parts they don't write, such as an empty
+			// shared-strings table.
+			continue
+		}
+		if strings.HasSuffix(rel.Type, "/sharedStrings") {
+			if err := workbook.readSharedStrings(target); err != nil {
+				return nil, err
+			}
+		} else if strings.HasSuffix(rel.Type, "/styles") {
+			if err := workbook.readStyles(target); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for _, sheet := range workbookXML.Sheets {
+		// The relationship ID is r:id, with a namespace which differs between
+		// transitional and strict OOXML.
+		for _, attr := range sheet.Attrs {
+			if attr.Name.Local == "id" {
+				workbook.sheets = append(workbook.sheets, tXLSXSheet{
+					name: sheet.Name,
+					path: targets[attr.Value],
+				})
+			}
+		}
+	}
+	if len(workbook.sheets) == 0 {
+		return nil, fmt.Errorf("workbook has no sheets")
+	}
+	return workbook, nil
+}
+
+// resolveXLSXTarget resolves a relationship target, which is relative to the
+// directory of the part having the relationship unless it starts with a
+// slash.
+func resolveXLSXTarget(dir string, target string) string {
+	if strings.HasPrefix(target, "/") {
+		return path.Clean(target[1:])
+	}
+	return path.Clean(path.Join(dir, target))
+}
+
+func (workbook *tXLSXWorkbook) has(name string) bool {
+	_, ok := workbook.files[strings.ToLower(name)]
+	return ok
+}
+
+func (workbook *tXLSXWorkbook) open(name string) (io.ReadCloser, error) {
+	file, ok := workbook.files[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("%s not found in archive", name)
+	}
+	return file.Open()
+}
+
+func (workbook *tXLSXWorkbook) unmarshal(name string, v any) error {
+	handle, err := workbook.open(name)
+	if err != nil {
+		return err
+	}
+	defer handle.Close()
+	if err := xml.NewDecoder(handle).Decode(v); err != nil {
+		return fmt.Errorf("%s: %v", name, err)
+	}
+	return nil
+}
+
+// selectSheet finds a sheet by name, or by one-up index. The default is the
+// first sheet.
+func (workbook *tXLSXWorkbook) selectSheet(nameOrIndex string) (string, error) {
+	if nameOrIndex == "" {
+		return workbook.sheets[0].path, nil
+	}
+	for _, sheet := range workbook.sheets {
+		if sheet.name == nameOrIndex {
+			return sheet.path, nil
+		}
+	}
+	if index, err := strconv.Atoi(nameOrIndex); err == nil && index >= 1 && index <= len(workbook.sheets) {
+		return workbook.sheets[index-1].path, nil
+	}
+	names := make([]string, len(workbook.sheets))
+	for i, sheet := range workbook.sheets {
+		names[i] = "\"" + sheet.name + "\""
+	}
+	return "", fmt.Errorf(
+		"sheet \"%s\" not found; sheets are %s, or use 1 to %d",
+		nameOrIndex, strings.Join(names, ", "), len(workbook.sheets),
+	)
+}
+
+// readSharedStrings reads the shared-strings table. Each string item is
+// either plain text, or rich text with runs whose text is concatenated.
+// Phonetic runs are annotations, not part of the text.
+func (workbook *tXLSXWorkbook) readSharedStrings(name string) error {
+	handle, err := workbook.open(name)
+	if err != nil {
+		return err
+	}
+	defer handle.Close()
+
+	decoder := xml.NewDecoder(handle)
+	var buffer strings.Builder
+	inText := false
+	phoneticDepth := 0
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "si":
+				buffer.Reset()
+			case "t":
+				inText = true
+			case "rPh":
+				phoneticDepth++
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "si":
+				workbook.sharedStrings = append(workbook.sharedStrings, lib.XLSXDecodeText(buffer.String()))
+			case "t":
+				inText = false
+			case "rPh":
+				phoneticDepth--
+			}
+		case xml.CharData:
+			if inText && phoneticDepth == 0 {
+				buffer.Write(t)
+			}
+		}
+	}
+}
+
+func (workbook *tXLSXWorkbook) readStyles(name string) error {
+	var styles tXLSXStylesXML
+	if err := workbook.unmarshal(name, &styles); err != nil {
+		return err
+	}
+	customFormats := make(map[int]string)
+	for _, numFmt := range styles.NumFmts {
+		customFormats[numFmt.ID] = numFmt.Code
+	}
+	workbook.dateKinds = make([]tXLSXDateKind, len(styles.CellXfs))
+	for i, xf := range styles.CellXfs {
+		if code, ok := customFormats[xf.NumFmtID]; ok {
+			workbook.dateKinds[i] = classifyXLSXFormatCode(code)
+		} else {
+			workbook.dateKinds[i] = classifyXLSXBuiltinFormat(xf.NumFmtID)
+		}
+	}
+	return nil
+}
+
+// classifyXLSXBuiltinFormat knows the date and time formats among the
+// built-in number formats, including the ones for East Asian locales.
+func classifyXLSXBuiltinFormat(numFmtID int) tXLSXDateKind {
+	switch {
+	case numFmtID >= 14 && numFmtID <= 17:
+		return xlsxDate
+	case numFmtID >= 18 && numFmtID <= 21:
+		return xlsxTime
+	case numFmtID == 22:
+		return xlsxDateTime
+	case numFmtID >= 27 && numFmtID <= 31, numFmtID >= 34 && numFmtID <= 36, numFmtID >= 50 && numFmtID <= 58:
+		return xlsxDate
+	case numFmtID == 32, numFmtID == 33, numFmtID >= 45 && numFmtID <= 47:
+		return xlsxTime
+	default:
+		return xlsxNotDate
+	}
+}
+
+// classifyXLSXFormatCode looks for date and time placeholders in a custom
+// number format such as "yyyy-mm-dd hh:mm", skipping quoted literals,
+// escaped characters, and bracketed colors and conditions. An "m" is minutes
+// if there are hours or seconds, else months.
+func classifyXLSXFormatCode(code string) tXLSXDateKind {
+	hasDate, hasTime, hasMonthOrMinute := false, false, false
+	for i := 0; i < len(code); i++ {
+		c := code[i]
+		switch c {
+		case ';':
+			// Only the first section, for positive numbers, matters here.
+			i = len(code)
+		case '"':
+			for i++; i < len(code) && code[i] != '"'; i++ {
+			}
+		case '\\', '_', '*':
+			i++
+		case '[':
+			end := strings.IndexByte(code[i:], ']')
+			if end < 0 {
+				i = len(code)
+				break
+			}
+			// Elapsed time such as [h] or [mm]
+			bracketed := strings.ToLower(code[i+1 : i+end])
+			if bracketed != "" && strings.Trim(bracketed, "hms") == "" {
+				hasTime = true
+			}
+			i += end
+		case 'y', 'Y', 'd', 'D':
+			hasDate = true
+		case 'h', 'H', 's', 'S':
+			hasTime = true
+		case 'm', 'M':
+			hasMonthOrMinute = true
+		}
+	}
+	if hasMonthOrMinute && !hasTime {
+		hasDate = true
+	}
+	switch {
+	case hasDate && hasTime:
+		return xlsxDateTime
+	case hasDate:
+		return xlsxDate
+	case hasTime:
+		return xlsxTime
+	default:
+		return xlsxNotDate
+	}
+}
+
+func (workbook *tXLSXWorkbook) dateKind(style int) tXLSXDateKind {
+	if style < 0 || style >= len(workbook.dateKinds) {
+		return xlsxNotDate
+	}
+	return workbook.dateKinds[style]
+}
+
+// ----------------------------------------------------------------
+// WORKSHEET
+
+// streamRows calls processRow for each row of the sheet with at least one
+// cell within the given columns, in row order, with its cells in column
+// order. Cells are only ever absent, never out of order, in worksheets
+// written by the spreadsheet programs we know of.
+func (workbook *tXLSXWorkbook) streamRows(
+	handle io.Reader,
+	firstColumn int,
+	lastColumn int,
+	processRow func(row int, cells []tXLSXCell) error,
+) error {
+	decoder := xml.NewDecoder(handle)
+
+	row := 0
+	column := -1
+	var cells []tXLSXCell
+	var cellType string
+	cellStyle := 0
+	var valueBuffer, inlineBuffer strings.Builder
+	inValue, inInlineText := false, false
+	phoneticDepth := 0
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "row":
+				row++
+				column = -1
+				cells = cells[:0]
+				for _, attr := range t.Attr {
+					if attr.Name.Local == "r" {
+						if r, err := strconv.Atoi(attr.Value); err == nil {
+							row = r
+						}
+					}
+				}
+			case "c":
+				column++
+				cellType = ""
+				cellStyle = 0
+				valueBuffer.Reset()
+				inlineBuffer.Reset()
+				for _, attr := range t.Attr {
+					switch attr.Name.Local {
+					case "r":
+						if c, _, ok := lib.XLSXParseCellRef(attr.Value); ok {
+							column = c
+						}
+					case "t":
+						cellType = attr.Value
+					case "s":
+						cellStyle, _ = strconv.Atoi(attr.Value)
+					}
+				}
+			case "v":
+				inValue = true
+			case "t":
+				inInlineText = true
+			case "rPh":
+				phoneticDepth++
+			}
+
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "row":
+				if len(cells) > 0 {
+					if err := processRow(row, cells); err != nil {
+						return err
+					}
+				}
+			case "c":
+				if column >= firstColumn && (lastColumn < 0 || column <= lastColumn) {
+					value, err := workbook.cellValue(cellType, cellStyle, valueBuffer.String(), inlineBuffer.String())
+					if err != nil {
+						return fmt.Errorf("cell %s%d: %v", lib.XLSXColumnName(column), row, err)
+					}
+					cells = append(cells, tXLSXCell{column: column, value: value})
+				}
+			case "v":
+				inValue = false
+			case "t":
+				inInlineText = false
+			case "rPh":
+				phoneticDepth--
+			}
+
+		case xml.CharData:
+			if inValue {
+				valueBuffer.Write(t)
+			} else if inInlineText && phoneticDepth == 0 {
+				inlineBuffer.Write(t)
+			}
+		}
+	}
+}
+
+func (workbook *tXLSXWorkbook) cellValue(
+	cellType string,
+	cellStyle int,
+	value string,
+	inlineText string,
+) (*mlrval.Mlrval, error) {
+	switch cellType {
+	case "s":
+		index, err := strconv.Atoi(value)
+		if err != nil || index < 0 || index >= len(workbook.sharedStrings) {
+			return nil, fmt.Errorf("shared-string index \"%s\" out of bounds", value)
+		}
+		return mlrval.FromString(workbook.sharedStrings[index]), nil
+	case "inlineStr":
+		return mlrval.FromString(lib.XLSXDecodeText(inlineText)), nil
+	case "str", "e", "d":
+		// Formula string result, error such as #DIV/0!, or ISO 8601 date
+		return mlrval.FromString(lib.XLSXDecodeText(value)), nil
+	case "b":
+		return mlrval.FromBool(value == "1" || value == "true"), nil
+	}
+
+	// Number
+	if value == "" {
+		return mlrval.VOID, nil
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return mlrval.FromInferredType(value), nil
+	}
+	if dateKind := workbook.dateKind(cellStyle); dateKind != xlsxNotDate {
+		return mlrval.FromString(formatXLSXSerial(f, dateKind, workbook.date1904)), nil
+	}
+	if f == math.Trunc(f) && math.Abs(f) < 1<<53 {
+		return mlrval.FromInt(int64(f)), nil
+	}
+	// Keep the cell's own formatting, such as 1E+20, as with CSV.
+	return mlrval.FromPrevalidatedFloatString(value, f), nil
+}
+
+// formatXLSXSerial converts a spreadsheet date/time serial number -- days
+// since the epoch, with the time of day as the fractional part -- to a
+// string, rounding to the nearest millisecond.
+func formatXLSXSerial(serial float64, dateKind tXLSXDateKind, date1904 bool) string {
+	millis := int64(math.Round(serial * 86400000))
+
+	if dateKind == xlsxTime {
+		// Elapsed-time formats such as [h]:mm:ss can exceed 24 hours.
+		seconds := millis / 1000
+		formatted := fmt.Sprintf("%02d:%02d:%02d", seconds/3600, seconds/60%60, seconds%60)
+		if millis%1000 != 0 {
+			formatted += fmt.Sprintf(".%03d", millis%1000)
+		}
+		return formatted
+	}
+
+	epoch := time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+	if date1904 {
+		epoch = time.Date(1904, 1, 1, 0, 0, 0, 0, time.UTC)
+	} else if serial < 60 {
+		// The 1900 date system counts the nonexistent 1900-02-29, as Lotus
+		// 1-2-3 did.
+		epoch = epoch.AddDate(0, 0, 1)
+	}
+	t := epoch.Add(time.Duration(millis) * time.Millisecond)
+
+	if dateKind == xlsxDate {
+		return t.Format("2006-01-02")
+	}
+	formatted := t.Format("2006-01-02 15:04:05")
+	if millis%1000 != 0 {
+		formatted += fmt.Sprintf(".%03d", t.Nanosecond()/1000000)
+	}
+	return formatted
+}
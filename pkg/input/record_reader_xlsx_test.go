@@ -0,0 +1,70 @@
+package input
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/johnkerl/miller/v6/pkg/cli"
+)
+
+func TestXLSXParseRange(t *testing.T) {
+	readerOptions := cli.DefaultReaderOptions()
+
+	readerOptions.XLSXRange = "B2:F100"
+	reader, err := NewRecordReaderXLSX(&readerOptions, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, reader.firstColumn)
+	assert.Equal(t, 5, reader.lastColumn)
+	assert.Equal(t, 2, reader.firstRow)
+	assert.Equal(t, 100, reader.lastRow)
+
+	readerOptions.XLSXRange = "B:F"
+	reader, err = NewRecordReaderXLSX(&readerOptions, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, reader.firstColumn)
+	assert.Equal(t, 5, reader.lastColumn)
+	assert.Equal(t, 1, reader.firstRow)
+	assert.Equal(t, -1, reader.lastRow)
+
+	readerOptions.XLSXRange = "C3"
+	reader, err = NewRecordReaderXLSX(&readerOptions, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, reader.firstColumn)
+	assert.Equal(t, -1, reader.lastColumn)
+	assert.Equal(t, 3, reader.firstRow)
+	assert.Equal(t, -1, reader.lastRow)
+
+	for _, bad := range []string{":", "F:B", "B5:F2", "B2:", "1:2", "B2:F100:G"} {
+		readerOptions.XLSXRange = bad
+		_, err = NewRecordReaderXLSX(&readerOptions, 1)
+		assert.NotNil(t, err, bad)
+	}
+}
+
+func TestClassifyXLSXFormatCode(t *testing.T) {
+	assert.Equal(t, xlsxNotDate, classifyXLSXFormatCode("General"))
+	assert.Equal(t, xlsxNotDate, classifyXLSXFormatCode("0.00"))
+	assert.Equal(t, xlsxNotDate, classifyXLSXFormatCode(`0" days"`))
+	assert.Equal(t, xlsxNotDate, classifyXLSXFormatCode(`[Red]0.00`))
+	assert.Equal(t, xlsxNotDate, classifyXLSXFormatCode(`0\d`))
+	assert.Equal(t, xlsxDate, classifyXLSXFormatCode("yyyy-mm-dd"))
+	assert.Equal(t, xlsxDate, classifyXLSXFormatCode("mmm yyyy"))
+	assert.Equal(t, xlsxDate, classifyXLSXFormatCode(`[$-409]d-mmm;@`))
+	assert.Equal(t, xlsxTime, classifyXLSXFormatCode("h:mm:ss AM/PM"))
+	assert.Equal(t, xlsxTime, classifyXLSXFormatCode("[h]:mm"))
+	assert.Equal(t, xlsxTime, classifyXLSXFormatCode("mm:ss"))
+	assert.Equal(t, xlsxDateTime, classifyXLSXFormatCode(`yyyy\-mm\-dd\ hh:mm:ss`))
+}
+
+func TestFormatXLSXSerial(t *testing.T) {
+	assert.Equal(t, "2024-03-15", formatXLSXSerial(45366, xlsxDate, false))
+	assert.Equal(t, "2024-03-15 09:30:00", formatXLSXSerial(45366.395833333336, xlsxDateTime, false))
+	assert.Equal(t, "2024-03-15 00:00:00.500", formatXLSXSerial(45366+0.5/86400, xlsxDateTime, false))
+	assert.Equal(t, "1900-01-01", formatXLSXSerial(1, xlsxDate, false))
+	assert.Equal(t, "1900-02-28", formatXLSXSerial(59, xlsxDate, false))
+	assert.Equal(t, "1900-03-01", formatXLSXSerial(61, xlsxDate, false))
+	assert.Equal(t, "1904-01-02", formatXLSXSerial(1, xlsxDate, true))
+	assert.Equal(t, "12:00:00", formatXLSXSerial(0.5, xlsxTime, false))
+	assert.Equal(t, "36:00:00", formatXLSXSerial(1.5, xlsxTime, false))
+}
@@ -0,0 +1,257 @@
+// XML record-reader.
+//
+// Many XML documents are really lists of records, such as the entries of an
+// Atom feed. We stream through the document, and each element matched by
+// --xml-record-path is one record. Everything outside the record elements is
+// skipped.
+//
+// The record has one field, named for the record element, whose value is
+// the element's contents. Within that:
+//
+// * Attributes are keyed by their names with a leading @.
+// * Child elements are keyed by their names. Repeated child elements with
+//   the same name become an array.
+// * An element having neither attributes nor child elements is just its text.
+//   Otherwise any text, such as the "Hello" of <a href="...">Hello</a>, is
+//   keyed by #text.
+//
+// So, for example,
+//
+//   <feed>
+//     <entry id="1"><title>First</title><tag>a</tag><tag>b</tag></entry>
+//   </feed>
+//
+// with --xml-record-path /feed/entry is the record
+//
+//   {"entry": {"@id": 1, "title": "First", "tag": ["a", "b"]}}
+//
+// which auto-flattens to entry.@id, entry.title, entry.tag.1, entry.tag.2 for
+// non-JSON output. Namespace prefixes are not part of the names.
+
+package input
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/text/encoding/htmlindex"
+
+	"github.com/johnkerl/miller/v6/pkg/cli"
+	"github.com/johnkerl/miller/v6/pkg/lib"
+	"github.com/johnkerl/miller/v6/pkg/mlrval"
+	"github.com/johnkerl/miller/v6/pkg/types"
+)
+
+type RecordReaderXML struct {
+	readerOptions   *cli.TReaderOptions
+	recordsPerBatch int64
+	recordPath      *lib.XMLRecordPath
+}
+
+func NewRecordReaderXML(
+	readerOptions *cli.TReaderOptions,
+	recordsPerBatch int64,
+) (*RecordReaderXML, error) {
+	recordPath, err := lib.NewXMLRecordPath(readerOptions.XMLRecordPath)
+	if err != nil {
+		return nil, err
+	}
+	return &RecordReaderXML{
+		readerOptions:   readerOptions,
+		recordsPerBatch: recordsPerBatch,
+		recordPath:      recordPath,
+	}, nil
+}
+
+func (reader *RecordReaderXML) Read(
+	filenames []string,
+	context types.Context,
+	readerChannel chan<- []*types.RecordAndContext,
+	errorChannel chan error,
+	downstreamDoneChannel <-chan bool,
+) {
+	if filenames != nil {
+		if len(filenames) == 0 {
+			handle, err := lib.OpenStdin(
+				reader.readerOptions.Prepipe,
+				reader.readerOptions.PrepipeIsRaw,
+				reader.readerOptions.FileInputEncoding,
+			)
+			if err != nil {
+				errorChannel <- err
+			} else {
+				err = reader.processHandle(handle, "(stdin)", &context, readerChannel, downstreamDoneChannel)
+				if err != nil {
+					errorChannel <- err
+				}
+			}
+		} else {
+			for _, filename := range filenames {
+				handle, err := lib.OpenFileForRead(
+					filename,
+					reader.readerOptions.Prepipe,
+					reader.readerOptions.PrepipeIsRaw,
+					reader.readerOptions.FileInputEncoding,
+				)
+				if err != nil {
+					errorChannel <- err
+					break
+				}
+				err = reader.processHandle(handle, filename, &context, readerChannel, downstreamDoneChannel)
+				_ = handle.Close()
+				if err != nil {
+					errorChannel <- err
+					break
+				}
+			}
+		}
+	}
+	readerChannel <- types.NewEndOfStreamMarkerList(&context)
+}
+
+// tXMLElement is an element being read within a record.
+type tXMLElement struct {
+	name string
+	// Attributes and child elements; nil until there are any
+	fields *mlrval.Mlrmap
+	text   strings.Builder
+}
+
+func (reader *RecordReaderXML) processHandle(
+	handle io.Reader,
+	filename string,
+	context *types.Context,
+	readerChannel chan<- []*types.RecordAndContext,
+	downstreamDoneChannel <-chan bool,
+) error {
+	context.UpdateForStartOfFile(filename)
+	recordsPerBatch := reader.recordsPerBatch
+	recordsAndContexts := make([]*types.RecordAndContext, 0, recordsPerBatch)
+
+	decoder := xml.NewDecoder(handle)
+	decoder.Entity = xml.HTMLEntity
+	decoder.CharsetReader = xmlCharsetReader
+
+	// Names of the elements enclosing the current position, outside of any
+	// record
+	names := make([]string, 0)
+	// Elements within the current record, outermost first; empty when not in
+	// a record
+	elements := make([]*tXMLElement, 0)
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("xml: %s: %v", filename, err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			if len(elements) == 0 {
+				names = append(names, t.Name.Local)
+				if !reader.recordPath.Matches(names) {
+					continue
+				}
+			}
+			element := &tXMLElement{name: t.Name.Local}
+			for _, attr := range t.Attr {
+				if attr.Name.Space == "xmlns" || (attr.Name.Space == "" && attr.Name.Local == "xmlns") {
+					continue
+				}
+				element.putField("@"+attr.Name.Local, mlrval.FromDeferredType(attr.Value))
+			}
+			elements = append(elements, element)
+
+		case xml.EndElement:
+			if len(elements) == 0 {
+				names = names[:len(names)-1]
+				continue
+			}
+			element := elements[len(elements)-1]
+			elements = elements[:len(elements)-1]
+			if len(elements) > 0 {
+				elements[len(elements)-1].putField(element.name, element.value())
+				continue
+			}
+
+			// End of a record element
+			names = names[:len(names)-1]
+			record := mlrval.NewMlrmapAsRecord()
+			record.PutReference(element.name, element.value())
+			context.UpdateForInputRecord()
+			recordsAndContexts = append(recordsAndContexts, types.NewRecordAndContext(record, context))
+
+			if int64(len(recordsAndContexts)) >= recordsPerBatch {
+				readerChannel <- recordsAndContexts
+				recordsAndContexts = make([]*types.RecordAndContext, 0, recordsPerBatch)
+
+				// See if downstream processors will be ignoring further data
+				// (e.g. mlr head). If so, stop reading. This makes 'mlr head
+				// hugefile' exit quickly, as it should.
+				select {
+				case <-downstreamDoneChannel:
+					return nil
+				default:
+				}
+			}
+
+		case xml.CharData:
+			if len(elements) > 0 {
+				elements[len(elements)-1].text.Write(t)
+			} else if len(names) == 0 && len(bytes.TrimSpace(t)) > 0 {
+				// The decoder allows this, but it means the input isn't XML.
+				return fmt.Errorf("xml: %s: text outside of the root element", filename)
+			}
+		}
+	}
+
+	if len(recordsAndContexts) > 0 {
+		readerChannel <- recordsAndContexts
+	}
+	return nil
+}
+
+// putField adds an attribute or child element. A repeated child element name
+// makes an array.
+func (element *tXMLElement) putField(key string, value *mlrval.Mlrval) {
+	if element.fields == nil {
+		element.fields = mlrval.NewMlrmap()
+	}
+	existing := element.fields.Get(key)
+	if existing == nil {
+		element.fields.PutReference(key, value)
+	} else if existing.IsArray() {
+		arrayval := existing.GetArray()
+		element.fields.PutReference(key, mlrval.FromArray(append(arrayval, value)))
+	} else {
+		element.fields.PutReference(key, mlrval.FromArray([]*mlrval.Mlrval{existing, value}))
+	}
+}
+
+func (element *tXMLElement) value() *mlrval.Mlrval {
+	text := element.text.String()
+	if element.fields == nil {
+		return mlrval.FromDeferredType(text)
+	}
+	// Text between child elements is usually just indentation.
+	if text = strings.TrimSpace(text); text != "" {
+		element.fields.PutReference("#text", mlrval.FromDeferredType(text))
+	}
+	return mlrval.FromMap(element.fields)
+}
+
+// xmlCharsetReader handles documents declaring encodings other than UTF-8,
+// such as <?xml version="1.0" encoding="ISO-8859-1"?>.
+func xmlCharsetReader(label string, input io.Reader) (io.Reader, error) {
+	encoding, err := htmlindex.Get(label)
+	if err != nil {
+		return nil, fmt.Errorf("unsupported encoding \"%s\"", label)
+	}
+	return encoding.NewDecoder().Reader(input), nil
+}
@@ -12,6 +12,9 @@ import (
 	"github.com/johnkerl/miller/v6/pkg/types"
 )
 
+// yamlCommentField holds the comments before a record, with --yaml-comments.
+const yamlCommentField = "_comment"
+
 type RecordReaderYAML struct {
 	readerOptions   *cli.TReaderOptions
 	recordsPerBatch int64
@@ -90,8 +93,9 @@ func (reader *RecordReaderYAML) processHandle(
 			}
 		}
 
-		mlrval, eof, err := mlrval.MlrvalDecodeFromYAML(decoder)
-		if eof {
+		var document yaml.Node
+		err := decoder.Decode(&document)
+		if err == io.EOF {
 			break
 		}
 		if err != nil {
@@ -99,50 +103,68 @@ func (reader *RecordReaderYAML) processHandle(
 			return
 		}
 
-		if mlrval.IsMap() {
-			record := mlrval.GetMap()
+		// Each document of a multi-document stream is a record, or an array
+		// of records. Empty documents, such as from a trailing "---", and
+		// comment-only ones are skipped.
+		if len(document.Content) == 0 {
+			continue
+		}
+		top := yamlResolveAlias(document.Content[0])
+		if top.Kind == yaml.ScalarNode && top.Tag == "!!null" {
+			continue
+		}
+
+		var recordNodes []*yaml.Node
+		var commentNodes [][]*yaml.Node
+		if top.Kind == yaml.MappingNode {
+			recordNodes = []*yaml.Node{top}
+			commentNodes = [][]*yaml.Node{{&document, top, yamlFirstKey(top)}}
+		} else if top.Kind == yaml.SequenceNode {
+			recordNodes = make([]*yaml.Node, len(top.Content))
+			commentNodes = make([][]*yaml.Node, len(top.Content))
+			for j, element := range top.Content {
+				element = yamlResolveAlias(element)
+				recordNodes[j] = element
+				commentNodes[j] = []*yaml.Node{element, yamlFirstKey(element)}
+			}
+			if len(commentNodes) > 0 {
+				commentNodes[0] = append([]*yaml.Node{&document}, commentNodes[0]...)
+			}
+		} else {
+			recordNodes = []*yaml.Node{top}
+			commentNodes = [][]*yaml.Node{nil}
+		}
+
+		for j, recordNode := range recordNodes {
+			mv, err := mlrval.MlrvalFromYAMLNode(recordNode)
+			if err != nil {
+				errorChannel <- err
+				return
+			}
+			if !mv.IsMap() {
+				errorChannel <- fmt.Errorf(
+					"valid but unmillerable YAML: expected map (object); got %s",
+					mv.GetTypeName(),
+				)
+				return
+			}
+			record := mv.GetMap()
 			if record == nil {
 				errorChannel <- fmt.Errorf("internal coding error in YAML record-reader")
 				return
 			}
+			if reader.readerOptions.YAMLComments {
+				comment := mlrval.YAMLCommentText(commentNodes[j]...)
+				if comment != "" {
+					record.PrependReference(yamlCommentField, mlrval.FromString(comment))
+				}
+			}
 			context.UpdateForInputRecord()
 			recordsAndContexts = append(recordsAndContexts, types.NewRecordAndContext(record, context))
 			if int64(len(recordsAndContexts)) >= recordsPerBatch {
 				readerChannel <- recordsAndContexts
 				recordsAndContexts = make([]*types.RecordAndContext, 0, recordsPerBatch)
 			}
-		} else if mlrval.IsArray() {
-			records := mlrval.GetArray()
-			if records == nil {
-				errorChannel <- fmt.Errorf("internal coding error in YAML record-reader")
-				return
-			}
-			for _, mv := range records {
-				if !mv.IsMap() {
-					errorChannel <- fmt.Errorf(
-						"valid but unmillerable YAML: expected map (object); got %s",
-						mv.GetTypeName(),
-					)
-					return
-				}
-				record := mv.GetMap()
-				if record == nil {
-					errorChannel <- fmt.Errorf("internal coding error in YAML record-reader")
-					return
-				}
-				context.UpdateForInputRecord()
-				recordsAndContexts = append(recordsAndContexts, types.NewRecordAndContext(record, context))
-				if int64(len(recordsAndContexts)) >= recordsPerBatch {
-					readerChannel <- recordsAndContexts
-					recordsAndContexts = make([]*types.RecordAndContext, 0, recordsPerBatch)
-				}
-			}
-		} else {
-			errorChannel <- fmt.Errorf(
-				"valid but unmillerable YAML: expected map (object); got %s",
-				mlrval.GetTypeName(),
-			)
-			return
 		}
 	}
 
@@ -151,3 +173,19 @@ flush:
 		readerChannel <- recordsAndContexts
 	}
 }
+
+// yamlResolveAlias returns the anchored node for an alias such as *name.
+func yamlResolveAlias(node *yaml.Node) *yaml.Node {
+	for node.Kind == yaml.AliasNode && node.Alias != nil {
+		node = node.Alias
+	}
+	return node
+}
+
+// yamlFirstKey returns the first key of a map node, or nil.
+func yamlFirstKey(node *yaml.Node) *yaml.Node {
+	if node.Kind != yaml.MappingNode || len(node.Content) == 0 {
+		return nil
+	}
+	return node.Content[0]
+}
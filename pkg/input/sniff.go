@@ -0,0 +1,396 @@
+// Format sniffing for -i auto (or --ifmt auto) and mlr sniff: from the start
+// of the input, decide which of CSV, TSV, JSON, JSON Lines, DKVP, NIDX, or
+// XTAB it is, along with the field separator, the CSV quote character, and
+// whether there is a header line.
+//
+// The checks are, in order:
+//
+// * JSON if the data starts with { or [, and JSON Lines if each line is an
+//   object;
+// * XTAB if there are blank-line-separated blocks of "key value" lines, with
+//   the same first key in each;
+// * DKVP if each field of each line is key=value, for some field separator;
+// * CSV if some separator and quote character give the same number of fields,
+//   two or more, on (nearly) all lines -- or TSV if that's tab with no quotes;
+// * otherwise NIDX.
+//
+// For CSV and TSV, the first line is taken as a header unless it looks like
+// data: numbers over columns of numbers, or empty or repeated names.
+
+package input
+
+import (
+	"bytes"
+	"io"
+	"strings"
+
+	csv "github.com/johnkerl/miller/v6/pkg/go-csv"
+
+	"github.com/johnkerl/miller/v6/pkg/cli"
+	"github.com/johnkerl/miller/v6/pkg/mlrval"
+)
+
+// SniffPrefixSize is how much of the input is read for sniffing.
+const SniffPrefixSize = 64 * 1024
+
+// SniffResult is what sniffing decided about the input.
+type SniffResult struct {
+	// csv, tsv, json, dkvp, nidx, or xtab
+	Format string
+	// For JSON: whether it's one object per line
+	JSONLines bool
+	// For CSV and DKVP: the field separator, if not the format's default
+	IFS string
+	// For CSV: the quote character, if not double quote
+	Quote string
+	// For CSV and TSV: whether the first line is data, not a header
+	ImplicitHeader bool
+}
+
+var sniffIFSNames = map[string]string{
+	",":  "comma",
+	"\t": "tab",
+	";":  "semicolon",
+	"|":  "pipe",
+	" ":  "space",
+}
+
+// Flags returns the main-flags which select the sniffed format, such as
+// "--icsv --ifs semicolon".
+func (result *SniffResult) Flags() []string {
+	flags := []string{"--i" + result.Format}
+	if result.JSONLines {
+		flags[0] = "--ijsonl"
+	}
+	if result.IFS != "" {
+		flags = append(flags, "--ifs", sniffIFSNames[result.IFS])
+	}
+	if result.Quote == "'" {
+		flags = append(flags, "--csv-quote-char", "squote")
+	}
+	if result.ImplicitHeader {
+		if result.Format == "tsv" {
+			flags = append(flags, "--implicit-tsv-header")
+		} else {
+			flags = append(flags, "--implicit-csv-header")
+		}
+	}
+	return flags
+}
+
+// ReaderOptions returns a copy of the reader options for the sniffed format.
+// Separators and other settings from the command line take precedence over
+// sniffed ones.
+func (result *SniffResult) ReaderOptions(readerOptions *cli.TReaderOptions) (*cli.TReaderOptions, error) {
+	sniffedOptions, err := cli.FinalizeSniffedReaderOptions(readerOptions, result.Format, result.IFS)
+	if err != nil {
+		return nil, err
+	}
+	if sniffedOptions.CSVQuoteChar == "" {
+		sniffedOptions.CSVQuoteChar = result.Quote
+	}
+	if result.ImplicitHeader {
+		sniffedOptions.UseImplicitHeader = true
+	}
+	return sniffedOptions, nil
+}
+
+// ReadSniffPrefix reads up to SniffPrefixSize bytes from the handle. The
+// boolean says whether that's all of the input.
+func ReadSniffPrefix(handle io.Reader) ([]byte, bool, error) {
+	prefix := make([]byte, SniffPrefixSize)
+	n, err := io.ReadFull(handle, prefix)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return prefix[:n], true, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return prefix, false, nil
+}
+
+// Sniff decides the format from the start of the input. If complete is false,
+// the prefix is only part of the input, so its last line may be cut short.
+func Sniff(prefix []byte, complete bool, readerOptions *cli.TReaderOptions) *SniffResult {
+	prefix = bytes.TrimPrefix(prefix, []byte(CSV_BOM))
+	if !complete {
+		if i := bytes.LastIndexByte(prefix, '\n'); i >= 0 {
+			prefix = prefix[:i+1]
+		}
+	}
+
+	// Lines, without comments; blank lines are kept as block separators.
+	lines := make([]string, 0)
+	for _, line := range strings.Split(string(prefix), "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		if readerOptions.CommentHandling != cli.CommentsAreData &&
+			strings.HasPrefix(line, readerOptions.CommentString) {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	nonBlankLines := make([]string, 0, len(lines))
+	for _, line := range lines {
+		if strings.TrimSpace(line) != "" {
+			nonBlankLines = append(nonBlankLines, line)
+		}
+	}
+	if len(nonBlankLines) == 0 {
+		return &SniffResult{Format: "csv"}
+	}
+
+	if result := sniffJSON(nonBlankLines); result != nil {
+		return result
+	}
+	if sniffXTAB(lines) {
+		return &SniffResult{Format: "xtab"}
+	}
+	if result := sniffDKVP(nonBlankLines); result != nil {
+		return result
+	}
+	if result := sniffCSV(nonBlankLines); result != nil {
+		return result
+	}
+	return &SniffResult{Format: "nidx"}
+}
+
+func sniffJSON(lines []string) *SniffResult {
+	text := strings.TrimSpace(strings.Join(lines, "\n"))
+	switch text[0] {
+	case '{':
+	case '[':
+		// As opposed to, say, a CSV header "[id],name"
+		rest := strings.TrimSpace(text[1:])
+		if rest != "" && !strings.ContainsRune("{[]\"-0123456789tfn", rune(rest[0])) {
+			return nil
+		}
+		return &SniffResult{Format: "json"}
+	default:
+		return nil
+	}
+
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "{") || !strings.HasSuffix(line, "}") {
+			return &SniffResult{Format: "json"}
+		}
+	}
+	return &SniffResult{Format: "json", JSONLines: true}
+}
+
+// sniffXTAB looks for two or more blocks of "key value" lines, separated by
+// blank lines, with the same first key and no repeated keys within a block.
+func sniffXTAB(lines []string) bool {
+	blocks := make([][]string, 0)
+	var block []string
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			if block != nil {
+				blocks = append(blocks, block)
+				block = nil
+			}
+			continue
+		}
+		if line[0] == ' ' || line[0] == '\t' {
+			return false
+		}
+		key, _, _ := strings.Cut(strings.ReplaceAll(line, "\t", " "), " ")
+		block = append(block, key)
+	}
+	if block != nil {
+		blocks = append(blocks, block)
+	}
+	if len(blocks) < 2 {
+		return false
+	}
+
+	for _, block := range blocks {
+		if block[0] != blocks[0][0] {
+			return false
+		}
+		seen := make(map[string]bool)
+		for _, key := range block {
+			if seen[key] || strings.ContainsAny(key, ",;|=\"") {
+				return false
+			}
+			seen[key] = true
+		}
+	}
+	return true
+}
+
+// sniffDKVP looks for a field separator for which every field is key=value,
+// preferring the one giving the most fields.
+func sniffDKVP(lines []string) *SniffResult {
+	bestIFS := ""
+	bestFieldCount := 0
+	for _, ifs := range []string{",", "\t", ";", "|", " "} {
+		fieldCount := 0
+		for _, line := range lines {
+			for _, field := range strings.Split(line, ifs) {
+				key, _, found := strings.Cut(field, "=")
+				if !found || key == "" || strings.ContainsAny(key, " \t\"'") {
+					fieldCount = -1
+					break
+				}
+				fieldCount++
+			}
+			if fieldCount < 0 {
+				break
+			}
+		}
+		if fieldCount > bestFieldCount {
+			bestIFS = ifs
+			bestFieldCount = fieldCount
+		}
+	}
+	if bestFieldCount == 0 {
+		return nil
+	}
+	if bestIFS == "," {
+		bestIFS = ""
+	}
+	return &SniffResult{Format: "dkvp", IFS: bestIFS}
+}
+
+// sniffCSV looks for the separator and quote character which give the most
+// consistent field count, of two or more.
+func sniffCSV(lines []string) *SniffResult {
+	var best *SniffResult
+	var bestRecords [][]string
+	bestConsistency := 0.0
+	bestFieldCount := 0
+
+	for _, ifs := range []string{",", "\t", ";", "|"} {
+		quote := sniffQuote(lines, ifs)
+		records := sniffCSVRecords(lines, ifs, quote)
+		fieldCount, consistency := modalFieldCount(records)
+		if fieldCount < 2 || consistency < 0.9 {
+			continue
+		}
+		if consistency > bestConsistency || (consistency == bestConsistency && fieldCount > bestFieldCount) {
+			best = &SniffResult{Format: "csv", IFS: ifs}
+			if quote != '"' {
+				best.Quote = string(quote)
+			}
+			bestRecords = records
+			bestConsistency = consistency
+			bestFieldCount = fieldCount
+		}
+	}
+	if best == nil {
+		return nil
+	}
+
+	if best.IFS == "\t" && best.Quote == "" && !strings.Contains(strings.Join(lines, "\n"), `"`) {
+		best.Format = "tsv"
+		best.IFS = ""
+	} else if best.IFS == "," {
+		best.IFS = ""
+	}
+	best.ImplicitHeader = !sniffHeader(bestRecords, bestFieldCount)
+	return best
+}
+
+// sniffQuote returns single quote if fields start and end with it, and not
+// with double quote; else double quote.
+func sniffQuote(lines []string, ifs string) byte {
+	counts := make(map[byte]int)
+	for _, line := range lines {
+		for _, field := range strings.Split(line, ifs) {
+			field = strings.TrimSpace(field)
+			if len(field) >= 2 && (field[0] == '"' || field[0] == '\'') && field[len(field)-1] == field[0] {
+				counts[field[0]]++
+			}
+		}
+	}
+	if counts['\''] > 0 && counts['"'] == 0 {
+		return '\''
+	}
+	return '"'
+}
+
+// sniffCSVRecords parses the lines as CSV, up to the first parse error.
+func sniffCSVRecords(lines []string, ifs string, quote byte) [][]string {
+	csvReader := csv.NewReader(strings.NewReader(strings.Join(lines, "\n") + "\n"))
+	csvReader.Comma = rune(ifs[0])
+	csvReader.Quote = quote
+	csvReader.FieldsPerRecord = -1
+	records := make([][]string, 0, len(lines))
+	for {
+		record, err := csvReader.Read()
+		if err != nil {
+			break
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+// modalFieldCount returns the most common number of fields, and the fraction
+// of records having it.
+func modalFieldCount(records [][]string) (int, float64) {
+	if len(records) == 0 {
+		return 0, 0.0
+	}
+	counts := make(map[int]int)
+	modalCount := 0
+	for _, record := range records {
+		n := len(record)
+		counts[n]++
+		if counts[n] > counts[modalCount] || (counts[n] == counts[modalCount] && n > modalCount) {
+			modalCount = n
+		}
+	}
+	return modalCount, float64(counts[modalCount]) / float64(len(records))
+}
+
+// sniffHeader votes, column by column, on whether the first record is a
+// header: a name over a column of numbers is one, a number over a column of
+// numbers isn't, and empty or repeated names aren't.
+func sniffHeader(records [][]string, fieldCount int) bool {
+	first := records[0]
+	data := make([][]string, 0, len(records)-1)
+	for _, record := range records[1:] {
+		if len(record) == fieldCount {
+			data = append(data, record)
+		}
+	}
+
+	votes := 0
+	seen := make(map[string]bool)
+	for j, name := range first {
+		if name == "" || seen[name] {
+			votes--
+		}
+		seen[name] = true
+		if j >= fieldCount {
+			continue
+		}
+
+		nameIsNumeric := mlrval.FromInferredType(name).IsNumeric()
+		if len(data) == 0 {
+			if nameIsNumeric {
+				votes--
+			}
+			continue
+		}
+		dataAreNumeric := true
+		for _, record := range data {
+			if !mlrval.FromInferredType(record[j]).IsNumeric() {
+				dataAreNumeric = false
+				break
+			}
+		}
+		if dataAreNumeric {
+			if nameIsNumeric {
+				votes--
+			} else {
+				votes++
+			}
+		} else if nameIsNumeric {
+			votes--
+		}
+	}
+	return votes >= 0
+}
@@ -0,0 +1,60 @@
+package input
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/johnkerl/miller/v6/pkg/cli"
+)
+
+func TestSniff(t *testing.T) {
+	readerOptions := cli.DefaultReaderOptions()
+
+	cases := []struct {
+		data  string
+		flags string
+	}{
+		{"a,b,c\n1,2,3\n4,5,6\n", "--icsv"},
+		{"\xef\xbb\xbfa,b\n1,2\n", "--icsv"},
+		{"a;b;c\n1;2;3\n", "--icsv --ifs semicolon"},
+		{"a|b\n\"x|y\"|2\n", "--icsv --ifs pipe"},
+		{"a\tb\n1\t2\n", "--itsv"},
+		{"a\tb\n\"x y\"\t2\n", "--icsv --ifs tab"},
+		{"id,label\n1,'a,b'\n2,'c'\n", "--icsv --csv-quote-char squote"},
+		{"1,2,3\n4,5,6\n", "--icsv --implicit-csv-header"},
+		{"1\t2\n3\t4\n", "--itsv --implicit-tsv-header"},
+		{"x,x\nabc,def\n", "--icsv --implicit-csv-header"},
+		{"host,status\nweb1,up\n", "--icsv"},
+		{"a=1,b=2\na=3,b=4\n", "--idkvp"},
+		{"a=1 b=2\n", "--idkvp --ifs space"},
+		{"a=1;b=x,y\n", "--idkvp --ifs semicolon"},
+		{"[\n{\"a\": 1}\n]\n", "--ijson"},
+		{"{\n  \"a\": 1\n}\n", "--ijson"},
+		{"{\"a\": 1}\n{\"a\": 2}\n", "--ijsonl"},
+		{"[id],name\n1,x\n", "--icsv"},
+		{"a 1\nb 2\n\na 3\nb 4\n", "--ixtab"},
+		{"hello world\nfoo\n", "--inidx"},
+		{"", "--icsv"},
+	}
+	for _, c := range cases {
+		result := Sniff([]byte(c.data), true, &readerOptions)
+		assert.Equal(t, c.flags, strings.Join(result.Flags(), " "), "data %q", c.data)
+	}
+}
+
+func TestSniffPartialPrefix(t *testing.T) {
+	readerOptions := cli.DefaultReaderOptions()
+	// The last line is cut short, which would otherwise be a ragged row.
+	result := Sniff([]byte("a,b,c\n1,2,3\n4,5,6\n7,8"), false, &readerOptions)
+	assert.Equal(t, "--icsv", strings.Join(result.Flags(), " "))
+}
+
+func TestSniffComments(t *testing.T) {
+	readerOptions := cli.DefaultReaderOptions()
+	readerOptions.CommentHandling = cli.SkipComments
+	readerOptions.CommentString = "#"
+	result := Sniff([]byte("# a=1\nx;y\n1;2\n"), true, &readerOptions)
+	assert.Equal(t, "--icsv --ifs semicolon", strings.Join(result.Flags(), " "))
+}
@@ -0,0 +1,380 @@
+// Reading members of zip and tar archives as input files.
+//
+// An input filename can address an archive member, as in data.zip#2024/jan.csv,
+// or members matching a glob, as in data.tar.gz#*.csv. A pattern without a
+// slash matches member base names, so *.csv matches 2024/jan.csv; one with a
+// slash matches whole member paths. An archive name with no member means all
+// its members, in the order they're stored in the archive. Directories and
+// other non-regular members are skipped.
+//
+// Before reading, ExpandArchiveFilenames replaces each archive name or member
+// glob with the names of the members, like data.zip#2024/jan.csv, so the
+// record-readers see each member as a separate file: FILENAME is the member's
+// name, and FILENUM and FNR are per member. OpenFileForRead then opens a member
+// by that name.
+
+package lib
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"sync"
+)
+
+// ArchiveMemberSeparator separates the archive name from the member name.
+const ArchiveMemberSeparator = "#"
+
+var zipSuffixes = []string{".zip"}
+var tarSuffixes = []string{
+	".tar", ".tar.gz", ".tgz", ".tar.bz2", ".tbz2", ".tar.zst", ".tzst", ".tar.xz", ".txz", ".tar.lz4",
+}
+
+// IsArchiveFilename tells if the filename ends in .zip, .tar, .tar.gz, etc.
+func IsArchiveFilename(filename string) bool {
+	return isZipFilename(filename) || isTarFilename(filename)
+}
+
+func isZipFilename(filename string) bool {
+	return hasAnySuffix(filename, zipSuffixes)
+}
+
+func isTarFilename(filename string) bool {
+	return hasAnySuffix(filename, tarSuffixes)
+}
+
+func hasAnySuffix(filename string, suffixes []string) bool {
+	for _, suffix := range suffixes {
+		if strings.HasSuffix(filename, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// SplitArchiveFilename splits a name like data.zip#2024/jan.csv into archive
+// name and member name or glob. If the name doesn't address an archive, the
+// boolean return is false. Otherwise the member name is empty for a bare
+// archive name.
+//
+// A # in a name is only taken as the separator if what precedes it is an
+// archive name, so that other filenames with # in them are left alone.
+func SplitArchiveFilename(filename string) (string, string, bool) {
+	offset := 0
+	for {
+		i := strings.Index(filename[offset:], ArchiveMemberSeparator)
+		if i < 0 {
+			break
+		}
+		archiveName := filename[:offset+i]
+		if IsArchiveFilename(archiveName) {
+			return archiveName, filename[offset+i+len(ArchiveMemberSeparator):], true
+		}
+		offset += i + len(ArchiveMemberSeparator)
+	}
+	if IsArchiveFilename(filename) {
+		return filename, "", true
+	}
+	return filename, "", false
+}
+
+// ExpandArchiveFilenames replaces archive names, and archive names with member
+// globs, by the names of their members. Other names are passed through. With a
+// prepipe, names are passed through as well since the prepipe command is
+// handling the files.
+func ExpandArchiveFilenames(filenames []string, prepipe string) ([]string, error) {
+	if prepipe != "" {
+		return filenames, nil
+	}
+	var expanded []string = nil
+	for i, filename := range filenames {
+		archiveName, pattern, ok := SplitArchiveFilename(filename)
+		if !ok || (pattern != "" && !isGlob(pattern)) {
+			if expanded != nil {
+				expanded = append(expanded, filename)
+			}
+			continue
+		}
+		if expanded == nil {
+			expanded = append([]string{}, filenames[:i]...)
+		}
+
+		memberNames, err := listArchiveMembers(archiveName)
+		if err != nil {
+			return nil, err
+		}
+		count := 0
+		for _, memberName := range memberNames {
+			if pattern == "" || archiveMemberMatches(pattern, memberName) {
+				expanded = append(expanded, archiveName+ArchiveMemberSeparator+memberName)
+				count++
+			}
+		}
+		if count == 0 && pattern != "" {
+			return nil, fmt.Errorf("%s: no members match \"%s\"", archiveName, pattern)
+		}
+	}
+	if expanded == nil {
+		return filenames, nil
+	}
+	return expanded, nil
+}
+
+func isGlob(pattern string) bool {
+	return strings.ContainsAny(pattern, "*?[")
+}
+
+// archiveMemberMatches matches a pattern without a slash against the member's
+// base name, and one with a slash against its whole path.
+func archiveMemberMatches(pattern string, memberName string) bool {
+	if !strings.Contains(pattern, "/") {
+		memberName = path.Base(memberName)
+	}
+	matched, _ := path.Match(pattern, memberName)
+	return matched
+}
+
+func listArchiveMembers(archiveName string) ([]string, error) {
+	memberNames := make([]string, 0)
+	if isZipFilename(archiveName) {
+		zipReader, closer, err := openZipArchive(archiveName)
+		if err != nil {
+			return nil, err
+		}
+		defer closer.Close()
+		for _, file := range zipReader.File {
+			if file.Mode().IsRegular() {
+				memberNames = append(memberNames, file.Name)
+			}
+		}
+		return memberNames, nil
+	}
+
+	cursor, err := openTarArchive(archiveName)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.close()
+	for {
+		header, err := cursor.tarReader.Next()
+		if err == io.EOF {
+			return memberNames, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", archiveName, err)
+		}
+		if header.Typeflag == tar.TypeReg {
+			memberNames = append(memberNames, header.Name)
+		}
+	}
+}
+
+// openArchiveMemberForRead opens the member of the archive, decompressing it
+// if its name ends in .gz etc.
+func openArchiveMemberForRead(
+	archiveName string,
+	memberName string,
+	encoding TFileInputEncoding,
+) (io.ReadCloser, error) {
+	var handle io.ReadCloser
+	var err error
+	if isZipFilename(archiveName) {
+		handle, err = openZipMember(archiveName, memberName)
+	} else {
+		handle, err = openTarMember(archiveName, memberName)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return openEncodedHandleForRead(handle, encoding, memberName)
+}
+
+// ----------------------------------------------------------------
+// ZIP
+
+// openZipArchive uses the central directory at the end of the file. If the
+// archive isn't a local file, such as for an http:// URL, it's read into
+// memory.
+func openZipArchive(archiveName string) (*zip.Reader, io.Closer, error) {
+	handle, err := PathToHandle(archiveName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var zipReader *zip.Reader
+	if file, ok := handle.(*os.File); ok {
+		fileInfo, err := file.Stat()
+		if err != nil {
+			_ = handle.Close()
+			return nil, nil, err
+		}
+		zipReader, err = zip.NewReader(file, fileInfo.Size())
+		if err != nil {
+			_ = handle.Close()
+			return nil, nil, fmt.Errorf("%s: %v", archiveName, err)
+		}
+	} else {
+		contents, err := io.ReadAll(handle)
+		if err != nil {
+			_ = handle.Close()
+			return nil, nil, err
+		}
+		zipReader, err = zip.NewReader(bytes.NewReader(contents), int64(len(contents)))
+		if err != nil {
+			_ = handle.Close()
+			return nil, nil, fmt.Errorf("%s: %v", archiveName, err)
+		}
+	}
+	return zipReader, handle, nil
+}
+
+func openZipMember(archiveName string, memberName string) (io.ReadCloser, error) {
+	zipReader, closer, err := openZipArchive(archiveName)
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range zipReader.File {
+		if file.Name == memberName && file.Mode().IsRegular() {
+			memberHandle, err := file.Open()
+			if err != nil {
+				_ = closer.Close()
+				return nil, fmt.Errorf("%s: %s: %v", archiveName, memberName, err)
+			}
+			return &archiveMemberReadCloser{
+				Reader: memberHandle,
+				closeFunc: func() error {
+					_ = memberHandle.Close()
+					return closer.Close()
+				},
+			}, nil
+		}
+	}
+	_ = closer.Close()
+	return nil, fmt.Errorf("%s: no member \"%s\"", archiveName, memberName)
+}
+
+// ----------------------------------------------------------------
+// TAR
+
+// A tar archive can only be read from the start, so to read its members in
+// turn without rescanning it for each one, the tarCursor for an archive is
+// kept open, positioned after the member last read, between opening one member
+// and the next. It's checked out while a member is being read, so concurrent
+// readers of the same archive each get their own.
+type tarCursor struct {
+	archiveName string
+	handle      io.ReadCloser
+	tarReader   *tar.Reader
+}
+
+var idleTarCursorLock sync.Mutex
+var idleTarCursor *tarCursor
+
+func openTarArchive(archiveName string) (*tarCursor, error) {
+	handle, err := PathToHandle(archiveName)
+	if err != nil {
+		return nil, err
+	}
+
+	// .tar.gz etc. are found by suffix; .tgz etc. by magic number.
+	decompressed, err := openEncodedHandleForRead(handle, FileInputEncodingDefault, archiveName)
+	if err != nil {
+		_ = handle.Close()
+		return nil, fmt.Errorf("%s: %v", archiveName, err)
+	}
+
+	return &tarCursor{
+		archiveName: archiveName,
+		handle:      decompressed,
+		tarReader:   tar.NewReader(decompressed),
+	}, nil
+}
+
+func (cursor *tarCursor) close() error {
+	return cursor.handle.Close()
+}
+
+// seek advances to the named member, returning false if it's not found before
+// the end of the archive.
+func (cursor *tarCursor) seek(memberName string) (bool, error) {
+	for {
+		header, err := cursor.tarReader.Next()
+		if err == io.EOF {
+			return false, nil
+		}
+		if err != nil {
+			return false, fmt.Errorf("%s: %v", cursor.archiveName, err)
+		}
+		if header.Name == memberName && header.Typeflag == tar.TypeReg {
+			return true, nil
+		}
+	}
+}
+
+func openTarMember(archiveName string, memberName string) (io.ReadCloser, error) {
+	// Try the idle cursor, if it's for this archive, from where it is.
+	idleTarCursorLock.Lock()
+	cursor := idleTarCursor
+	if cursor != nil && cursor.archiveName == archiveName {
+		idleTarCursor = nil
+	} else {
+		cursor = nil
+	}
+	idleTarCursorLock.Unlock()
+
+	if cursor != nil {
+		found, err := cursor.seek(memberName)
+		if err == nil && found {
+			return cursor.memberHandle(), nil
+		}
+		_ = cursor.close()
+	}
+
+	// Else from the start.
+	cursor, err := openTarArchive(archiveName)
+	if err != nil {
+		return nil, err
+	}
+	found, err := cursor.seek(memberName)
+	if err != nil || !found {
+		_ = cursor.close()
+		if err == nil {
+			err = fmt.Errorf("%s: no member \"%s\"", archiveName, memberName)
+		}
+		return nil, err
+	}
+	return cursor.memberHandle(), nil
+}
+
+// memberHandle reads the current member. Closing it makes the cursor the idle
+// one, closing the previous idle one if any.
+func (cursor *tarCursor) memberHandle() io.ReadCloser {
+	return &archiveMemberReadCloser{
+		Reader: cursor.tarReader,
+		closeFunc: func() error {
+			idleTarCursorLock.Lock()
+			previous := idleTarCursor
+			idleTarCursor = cursor
+			idleTarCursorLock.Unlock()
+			if previous != nil {
+				return previous.close()
+			}
+			return nil
+		},
+	}
+}
+
+// archiveMemberReadCloser reads from one thing and closes another, or others.
+type archiveMemberReadCloser struct {
+	io.Reader
+	closeFunc func() error
+}
+
+func (rc *archiveMemberReadCloser) Close() error {
+	return rc.closeFunc()
+}
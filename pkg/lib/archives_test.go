@@ -0,0 +1,76 @@
+// Most Miller tests (thousands of them) are command-line-driven via
+// mlr regtest. Here are some cases needing special focus.
+
+package lib
+
+import (
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitArchiveFilename(t *testing.T) {
+	archiveName, memberName, ok := SplitArchiveFilename("data.zip#2024/jan.csv")
+	assert.True(t, ok)
+	assert.Equal(t, "data.zip", archiveName)
+	assert.Equal(t, "2024/jan.csv", memberName)
+
+	archiveName, memberName, ok = SplitArchiveFilename("data.tar.gz")
+	assert.True(t, ok)
+	assert.Equal(t, "data.tar.gz", archiveName)
+	assert.Equal(t, "", memberName)
+
+	// The # is only a separator after an archive name
+	archiveName, memberName, ok = SplitArchiveFilename("take#2/data.zip#a#b.csv")
+	assert.True(t, ok)
+	assert.Equal(t, "take#2/data.zip", archiveName)
+	assert.Equal(t, "a#b.csv", memberName)
+
+	_, _, ok = SplitArchiveFilename("notes#1.csv")
+	assert.False(t, ok)
+	_, _, ok = SplitArchiveFilename("data.csv.gz")
+	assert.False(t, ok)
+}
+
+func TestArchiveMemberMatches(t *testing.T) {
+	assert.True(t, archiveMemberMatches("*.csv", "2024/jan.csv"))
+	assert.True(t, archiveMemberMatches("2024/*.csv", "2024/jan.csv"))
+	assert.False(t, archiveMemberMatches("2023/*.csv", "2024/jan.csv"))
+	assert.False(t, archiveMemberMatches("*.csv", "2024/jan.csv.gz"))
+}
+
+// Reading tar members in order reuses the open archive; reading them out of
+// order reopens it.
+func TestOpenTarMember(t *testing.T) {
+	archiveName := "../../test/input/archives/vendor.tar.gz"
+	for _, memberName := range []string{"2024/jan.csv", "2024/feb.csv", "2024/jan.csv"} {
+		handle, err := OpenFileForRead(archiveName+"#"+memberName, "", false, FileInputEncodingDefault)
+		assert.Nil(t, err)
+		contents, err := io.ReadAll(handle)
+		assert.Nil(t, err)
+		assert.Contains(t, string(contents), "id,item,amount\n")
+		assert.Nil(t, handle.Close())
+	}
+
+	idleTarCursorLock.Lock()
+	cursor := idleTarCursor
+	idleTarCursorLock.Unlock()
+	assert.NotNil(t, cursor)
+
+	handle, err := OpenFileForRead(archiveName+"#summary.csv", "", false, FileInputEncodingDefault)
+	assert.Nil(t, err)
+	idleTarCursorLock.Lock()
+	assert.Nil(t, idleTarCursor) // checked out
+	idleTarCursorLock.Unlock()
+	contents, err := io.ReadAll(handle)
+	assert.Nil(t, err)
+	assert.Equal(t, "month,total\njan,8\nfeb,7\n", string(contents))
+	assert.Nil(t, handle.Close())
+	idleTarCursorLock.Lock()
+	assert.Same(t, cursor, idleTarCursor) // reused, not reopened
+	idleTarCursorLock.Unlock()
+
+	_, err = OpenFileForRead(archiveName+"#nonesuch.csv", "", false, FileInputEncodingDefault)
+	assert.NotNil(t, err)
+}
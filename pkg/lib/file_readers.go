@@ -1,6 +1,6 @@
 // Wrapper for os.Open which maps string filename to *os.File, which in turn
 // implements io.ReadCloser, and optional in turn wrapping that in a
-// gzip/zlib/bunzip2 reader. Shared across record-readers for all the various
+// gzip/zlib/bunzip2/zstd/xz/lz4/brotli reader. Shared across record-readers for all the various
 // input-file formats (CSV, JSON, XTAB, DKVP, NIDX, PPRINT, DCF) which Miller
 // supports.
 //
@@ -12,22 +12,30 @@
 // * An indication to use an in-process encoding reader (gzip or bzip2, etc).
 //
 // If a prepipe is specified, it is used; else if an encoding is specified, it
-// is used; otherwise the file suffix (.bz2, .gz, .z) is consulted; otherwise
-// the file is treated as text.
+// is used; otherwise the data's magic number is consulted, so mislabeled files
+// still decode; otherwise the file suffix (.bz2, .gz, .z, .zst, .xz, .lz4,
+// .br) is consulted; otherwise the file is treated as text. Brotli has no
+// magic number, so it's found only by suffix -- or, for http:// and https://
+// URLs, by the response's Content-Encoding.
 
 package lib
 
 import (
+	"bufio"
 	"bytes"
 	"compress/bzip2"
 	"compress/gzip"
 	"compress/zlib"
 	"fmt"
-	"github.com/klauspost/compress/zstd"
 	"io"
 	"net/http"
 	"os"
 	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4/v4"
+	"github.com/ulikunitz/xz"
 )
 
 type TFileInputEncoding int
@@ -38,6 +46,9 @@ const (
 	FileInputEncodingGzip
 	FileInputEncodingZlib
 	FileInputEncodingZstd
+	FileInputEncodingXz
+	FileInputEncodingLz4
+	FileInputEncodingBrotli
 )
 
 // OpenFileForRead: If prepipe is non-empty, popens "{prepipe} < {filename}"
@@ -45,7 +56,8 @@ const (
 // "gunzip", "cat", etc.  Otherwise, delegates to an in-process reader which
 // can natively handle gzip/bzip2/zlib depending on the specified encoding.  If
 // the encoding isn't a compression encoding, this ends up being simply
-// os.Open.
+// os.Open. Filenames like data.zip#jan.csv are members of archives; see
+// archives.go.
 func OpenFileForRead(
 	filename string,
 	prepipe string,
@@ -55,6 +67,9 @@ func OpenFileForRead(
 	if prepipe != "" {
 		return openPrepipedHandleForRead(filename, prepipe, prepipeIsRaw)
 	}
+	if archiveName, memberName, ok := SplitArchiveFilename(filename); ok && memberName != "" {
+		return openArchiveMemberForRead(archiveName, memberName, encoding)
+	}
 	handle, err := PathToHandle(filename)
 	if err != nil {
 		return nil, err
@@ -62,27 +77,68 @@ func OpenFileForRead(
 	return openEncodedHandleForRead(handle, encoding, filename)
 }
 
+// ExpandInputFilenames replaces s3:// prefixes and globs by the names of the
+// matching objects, then archive names and member globs by the names of the
+// members. With a prepipe, names are passed through since the prepipe command
+// is handling the files.
+func ExpandInputFilenames(filenames []string, prepipe string) ([]string, error) {
+	if prepipe != "" {
+		return filenames, nil
+	}
+	filenames, err := ExpandS3Filenames(filenames)
+	if err != nil {
+		return nil, err
+	}
+	return ExpandArchiveFilenames(filenames, prepipe)
+}
+
 // PathToHandle maps various back-ends to a stream. As of 2021-07-07, the
 // following URI schemes are supported:
 // * https://... and http://...
+// * s3://... (see s3.go)
 // * file://...
 // * plain disk files
 func PathToHandle(
 	path string,
 ) (io.ReadCloser, error) {
 	if strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://") {
-		resp, err := http.Get(path)
-		if err != nil {
-			return nil, err
-		}
-		handle := resp.Body
-		return handle, err
+		return httpGet(path)
+	} else if IsS3Path(path) {
+		return openS3ObjectForRead(path)
 	} else if strings.HasPrefix(path, "file://") {
 		return os.Open(strings.Replace(path, "file://", "", 1))
 	}
 	return os.Open(path)
 }
 
+// httpGet asks for, and undoes, gzip or brotli content encoding. (Go's HTTP
+// client does this for gzip by itself, but not if we ask for brotli too.)
+func httpGet(url string) (io.ReadCloser, error) {
+	request, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Accept-Encoding", "gzip, br")
+	resp, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+
+	switch resp.Header.Get("Content-Encoding") {
+	case "gzip":
+		gzipHandle, err := gzip.NewReader(resp.Body)
+		if err != nil {
+			_ = resp.Body.Close()
+			return nil, err
+		}
+		return &readCloserWrapper{Reader: gzipHandle, closer: resp.Body}, nil
+	case "br":
+		return &readCloserWrapper{Reader: brotli.NewReader(resp.Body), closer: resp.Body}, nil
+	default:
+		return resp.Body, nil
+	}
+}
+
 // OpenStdin: if prepipe is non-empty, popens "{prepipe}" and returns a handle
 // to that where prepipe is nominally things like "gunzip", "cat", etc.
 // Otherwise, delegates to an in-process reader which can natively handle
@@ -147,12 +203,21 @@ func escapeFileNameForPopen(filename string) string {
 	return buffer.String()
 }
 
-// TODO: comment
+// openEncodedHandleForRead wraps the handle in a decompressor, if the
+// encoding says to, else if the data's magic number or else the filename
+// suffix does.
 func openEncodedHandleForRead(
 	handle io.ReadCloser,
 	encoding TFileInputEncoding,
 	filename string,
 ) (io.ReadCloser, error) {
+	if encoding == FileInputEncodingDefault {
+		handle, encoding = peekEncodingFromMagic(handle)
+		if encoding == FileInputEncodingDefault {
+			encoding = findEncodingFromSuffix(filename)
+		}
+	}
+
 	switch encoding {
 	case FileInputEncodingBzip2:
 		return NewBZip2ReadCloser(handle), nil
@@ -162,25 +227,114 @@ func openEncodedHandleForRead(
 		return zlib.NewReader(handle)
 	case FileInputEncodingZstd:
 		return NewZstdReadCloser(handle)
+	case FileInputEncodingXz:
+		xzHandle, err := xz.NewReader(handle)
+		if err != nil {
+			return nil, err
+		}
+		return &readCloserWrapper{Reader: xzHandle, closer: handle}, nil
+	case FileInputEncodingLz4:
+		return &readCloserWrapper{Reader: lz4.NewReader(handle), closer: handle}, nil
+	case FileInputEncodingBrotli:
+		return &readCloserWrapper{Reader: brotli.NewReader(handle), closer: handle}, nil
 	}
 
-	InternalCodingErrorIf(encoding != FileInputEncodingDefault)
+	// Pass along os.Stdin or os.Open(filename)
+	return handle, nil
+}
 
+func findEncodingFromSuffix(filename string) TFileInputEncoding {
 	if strings.HasSuffix(filename, ".bz2") {
-		return NewBZip2ReadCloser(handle), nil
+		return FileInputEncodingBzip2
 	}
 	if strings.HasSuffix(filename, ".gz") {
-		return gzip.NewReader(handle)
+		return FileInputEncodingGzip
 	}
 	if strings.HasSuffix(filename, ".z") {
-		return zlib.NewReader(handle)
+		return FileInputEncodingZlib
 	}
 	if strings.HasSuffix(filename, ".zst") {
-		return NewZstdReadCloser(handle)
+		return FileInputEncodingZstd
+	}
+	if strings.HasSuffix(filename, ".xz") {
+		return FileInputEncodingXz
+	}
+	if strings.HasSuffix(filename, ".lz4") {
+		return FileInputEncodingLz4
 	}
+	if strings.HasSuffix(filename, ".br") {
+		return FileInputEncodingBrotli
+	}
+	return FileInputEncodingDefault
+}
 
-	// Pass along os.Stdin or os.Open(filename)
-	return handle, nil
+// Magic numbers at the start of compressed data. For zlib, the second byte
+// depends on the compression level, and we leave out 0x5e -- the only one
+// which is a printable character -- since it'd match text starting with x^.
+// For bzip2, the magic is followed by the start of the first block, or the
+// end of the stream if empty.
+var compressionMagics = []struct {
+	encoding TFileInputEncoding
+	magics   [][]byte
+}{
+	{FileInputEncodingGzip, [][]byte{{0x1f, 0x8b}}},
+	{FileInputEncodingZstd, [][]byte{{0x28, 0xb5, 0x2f, 0xfd}}},
+	{FileInputEncodingXz, [][]byte{{0xfd, '7', 'z', 'X', 'Z', 0x00}}},
+	{FileInputEncodingLz4, [][]byte{{0x04, 0x22, 0x4d, 0x18}}},
+	{FileInputEncodingZlib, [][]byte{{0x78, 0x01}, {0x78, 0x9c}, {0x78, 0xda}}},
+}
+
+const compressionMagicLength = 10
+
+// peekEncodingFromMagic looks at the start of the data without consuming it.
+// A plain file is read at offset zero, and returned as-is so that readers
+// for formats like Parquet and SQLite can still use it as a file. Anything
+// else, such as stdin, is buffered, and only what one read returns is looked
+// at, so that this doesn't wait on more input from, say, a pipe with a few
+// short lines in it.
+func peekEncodingFromMagic(handle io.ReadCloser) (io.ReadCloser, TFileInputEncoding) {
+	if file, ok := handle.(*os.File); ok {
+		if fileInfo, err := file.Stat(); err == nil && fileInfo.Mode().IsRegular() {
+			start := make([]byte, compressionMagicLength)
+			n, _ := file.ReadAt(start, 0)
+			return handle, findEncodingFromMagic(start[:n])
+		}
+	}
+
+	bufferedHandle := bufio.NewReader(handle)
+	_, _ = bufferedHandle.Peek(1)
+	start, _ := bufferedHandle.Peek(bufferedHandle.Buffered())
+	encoding := findEncodingFromMagic(start)
+	return &readCloserWrapper{Reader: bufferedHandle, closer: handle}, encoding
+}
+
+// findEncodingFromMagic says which compression, if any, the data starts with.
+func findEncodingFromMagic(start []byte) TFileInputEncoding {
+	for _, entry := range compressionMagics {
+		for _, magic := range entry.magics {
+			if bytes.HasPrefix(start, magic) {
+				return entry.encoding
+			}
+		}
+	}
+	if len(start) >= compressionMagicLength && bytes.HasPrefix(start, []byte("BZh")) && start[3] >= '1' && start[3] <= '9' {
+		if bytes.Equal(start[4:10], []byte{0x31, 0x41, 0x59, 0x26, 0x53, 0x59}) ||
+			bytes.Equal(start[4:10], []byte{0x17, 0x72, 0x45, 0x38, 0x50, 0x90}) {
+			return FileInputEncodingBzip2
+		}
+	}
+	return FileInputEncodingDefault
+}
+
+// readCloserWrapper reads from one thing, such as a decompressor, and closes
+// another, such as the file underneath it.
+type readCloserWrapper struct {
+	io.Reader
+	closer io.Closer
+}
+
+func (rc *readCloserWrapper) Close() error {
+	return rc.closer.Close()
 }
 
 // BZip2ReadCloser remedies the fact that bzip2.NewReader does not implement io.ReadCloser.
@@ -257,17 +411,22 @@ func IsUpdateableInPlace(
 ) error {
 	if strings.HasPrefix(filename, "http://") ||
 		strings.HasPrefix(filename, "https://") ||
-		strings.HasPrefix(filename, "file://") {
-		return fmt.Errorf("http://, https://, and file:// URLs are not updateable in place")
+		strings.HasPrefix(filename, "file://") ||
+		IsS3Path(filename) {
+		return fmt.Errorf("http://, https://, s3://, and file:// URLs are not updateable in place")
 	}
 	if prepipe != "" {
 		return fmt.Errorf("input with --prepipe or --prepipex is not updateable in place")
 	}
+	if _, _, ok := SplitArchiveFilename(filename); ok {
+		return fmt.Errorf("zip and tar archives and their members are not updateable in place")
+	}
 	return nil
 }
 
 // FindInputEncoding determines the input encoding (compression), whether from
-// a flag like --gzin, or from filename suffix like ".gz".  If the user did
+// a flag like --gzin, or from the file's magic number, or from filename suffix
+// like ".gz".  If the user did
 // --gzin on the command line, TFileInputEncoding will be
 // FileInputEncodingGzip.  If they didn't, but the filename ends in ".gz", then
 // we auto-infer FileInputEncodingGzip.  Either way, this function tells if we
@@ -280,35 +439,12 @@ func FindInputEncoding(
 	if inputFileInputEncoding != FileInputEncodingDefault {
 		return inputFileInputEncoding
 	}
-	if strings.HasSuffix(filename, ".bz2") {
-		return FileInputEncodingBzip2
-	}
-	if strings.HasSuffix(filename, ".gz") {
-		return FileInputEncodingGzip
-	}
-	if strings.HasSuffix(filename, ".z") {
-		return FileInputEncodingZlib
-	}
-	return FileInputEncodingDefault
-}
-
-// WrapOutputHandle wraps a file-write handle with a decompressor.  The first
-// return value is the wrapped handle. The second is true if the returned
-// handle needs to be closed separately from the original.  The third is for
-// in-process compression we can't undo: namely, as of September 2021 the gzip
-// and zlib libraries support write-closers, but the bzip2 library does not.
-func WrapOutputHandle(
-	fileWriteHandle io.WriteCloser,
-	inputFileEncoding TFileInputEncoding,
-) (io.WriteCloser, bool, error) {
-	switch inputFileEncoding {
-	case FileInputEncodingBzip2:
-		return fileWriteHandle, false, fmt.Errorf("bzip2 is not currently supported for in-place mode")
-	case FileInputEncodingGzip:
-		return gzip.NewWriter(fileWriteHandle), true, nil
-	case FileInputEncodingZlib:
-		return zlib.NewWriter(fileWriteHandle), true, nil
-	default:
-		return fileWriteHandle, false, nil
+	if handle, err := os.Open(filename); err == nil {
+		_, encoding := peekEncodingFromMagic(handle)
+		_ = handle.Close()
+		if encoding != FileInputEncodingDefault {
+			return encoding
+		}
 	}
+	return findEncodingFromSuffix(filename)
 }
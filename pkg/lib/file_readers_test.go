@@ -0,0 +1,49 @@
+// Most Miller tests (thousands of them) are command-line-driven via
+// mlr regtest. Here are some cases needing special focus.
+
+package lib
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindEncodingFromMagic(t *testing.T) {
+	var buffer bytes.Buffer
+	gzipWriter := gzip.NewWriter(&buffer)
+	_, _ = gzipWriter.Write([]byte("a=1\n"))
+	_ = gzipWriter.Close()
+	assert.Equal(t, FileInputEncodingGzip, findEncodingFromMagic(buffer.Bytes()))
+
+	assert.Equal(t, FileInputEncodingXz, findEncodingFromMagic([]byte("\xfd7zXZ\x00\x00\x04")))
+	assert.Equal(t, FileInputEncodingLz4, findEncodingFromMagic([]byte("\x04\x22\x4d\x18\x64")))
+	assert.Equal(t, FileInputEncodingZstd, findEncodingFromMagic([]byte("\x28\xb5\x2f\xfd\x24")))
+	assert.Equal(t, FileInputEncodingZlib, findEncodingFromMagic([]byte("\x78\x9c\x4b")))
+	assert.Equal(t, FileInputEncodingBzip2, findEncodingFromMagic([]byte("BZh91AY&SY\x00")))
+
+	// Text which starts like some magic numbers
+	assert.Equal(t, FileInputEncodingDefault, findEncodingFromMagic([]byte("x^2,y\n")))
+	assert.Equal(t, FileInputEncodingDefault, findEncodingFromMagic([]byte("BZh9 is a name\n")))
+	assert.Equal(t, FileInputEncodingDefault, findEncodingFromMagic([]byte("")))
+}
+
+// Sniffing the magic number of streaming input mustn't wait for more than
+// what's been written so far.
+func TestOpenEncodedHandleForReadDoesNotBlock(t *testing.T) {
+	pipeReader, pipeWriter := io.Pipe()
+	go func() {
+		_, _ = pipeWriter.Write([]byte("a=1\n"))
+	}()
+
+	handle, err := openEncodedHandleForRead(pipeReader, FileInputEncodingDefault, "")
+	assert.Nil(t, err)
+	line, err := bufio.NewReader(handle).ReadString('\n')
+	assert.Nil(t, err)
+	assert.Equal(t, "a=1\n", line)
+	assert.Nil(t, handle.Close())
+}
@@ -0,0 +1,227 @@
+// In-process compression of output: for the main record stream, for mlr -I,
+// and for files written by the tee and split verbs and by DSL redirects such as
+// tee > and emit >. Files may also be s3:// objects; see s3.go.
+//
+// If a flag such as --gzout is given, it is used; else for files, the file
+// suffix (.bz2, .gz, .z, .zst, .xz, .lz4, .br) is consulted; otherwise the
+// output is written as is.
+
+package lib
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/dsnet/compress/bzip2"
+	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4/v4"
+	"github.com/ulikunitz/xz"
+)
+
+type TFileOutputEncoding int
+
+const (
+	// From the filename suffix, if any, else none
+	FileOutputEncodingDefault TFileOutputEncoding = iota
+	// None, regardless of the filename suffix
+	FileOutputEncodingNone
+	FileOutputEncodingBzip2
+	FileOutputEncodingGzip
+	FileOutputEncodingZlib
+	FileOutputEncodingZstd
+	// These have no flags, but are for files ending in .xz etc., and for
+	// mlr -I on xz etc. input.
+	FileOutputEncodingXz
+	FileOutputEncodingLz4
+	FileOutputEncodingBrotli
+)
+
+// FindOutputEncoding determines the output encoding (compression), whether
+// from a flag like --gzout, or from filename suffix like ".gz".
+func FindOutputEncoding(
+	filename string,
+	encoding TFileOutputEncoding,
+) TFileOutputEncoding {
+	if encoding != FileOutputEncodingDefault {
+		return encoding
+	}
+	if strings.HasSuffix(filename, ".bz2") {
+		return FileOutputEncodingBzip2
+	}
+	if strings.HasSuffix(filename, ".gz") {
+		return FileOutputEncodingGzip
+	}
+	if strings.HasSuffix(filename, ".z") {
+		return FileOutputEncodingZlib
+	}
+	if strings.HasSuffix(filename, ".zst") {
+		return FileOutputEncodingZstd
+	}
+	if strings.HasSuffix(filename, ".xz") {
+		return FileOutputEncodingXz
+	}
+	if strings.HasSuffix(filename, ".lz4") {
+		return FileOutputEncodingLz4
+	}
+	if strings.HasSuffix(filename, ".br") {
+		return FileOutputEncodingBrotli
+	}
+	return FileOutputEncodingNone
+}
+
+// OutputEncodingForInputEncoding is for mlr -I: compressed input is
+// recompressed the same way on output.
+func OutputEncodingForInputEncoding(inputEncoding TFileInputEncoding) TFileOutputEncoding {
+	switch inputEncoding {
+	case FileInputEncodingBzip2:
+		return FileOutputEncodingBzip2
+	case FileInputEncodingGzip:
+		return FileOutputEncodingGzip
+	case FileInputEncodingZlib:
+		return FileOutputEncodingZlib
+	case FileInputEncodingZstd:
+		return FileOutputEncodingZstd
+	case FileInputEncodingXz:
+		return FileOutputEncodingXz
+	case FileInputEncodingLz4:
+		return FileOutputEncodingLz4
+	case FileInputEncodingBrotli:
+		return FileOutputEncodingBrotli
+	default:
+		return FileOutputEncodingNone
+	}
+}
+
+// OutputEncodingSuffix returns the filename suffix for the encoding, such as
+// ".gz", or "" for none.
+func OutputEncodingSuffix(encoding TFileOutputEncoding) string {
+	switch encoding {
+	case FileOutputEncodingBzip2:
+		return ".bz2"
+	case FileOutputEncodingGzip:
+		return ".gz"
+	case FileOutputEncodingZlib:
+		return ".z"
+	case FileOutputEncodingZstd:
+		return ".zst"
+	case FileOutputEncodingXz:
+		return ".xz"
+	case FileOutputEncodingLz4:
+		return ".lz4"
+	case FileOutputEncodingBrotli:
+		return ".br"
+	default:
+		return ""
+	}
+}
+
+// OpenFileForWrite opens the file for write, or for append, with a compressor
+// around it depending on the encoding or else on the filename suffix. Closing
+// the returned handle closes the compressor, if any, as well as the file.
+//
+// Appending to compressed files is fine for gzip, bzip2, zstd, xz, and lz4,
+// whose decompressors read concatenated streams, but not for zlib or brotli.
+func OpenFileForWrite(
+	filename string,
+	encoding TFileOutputEncoding,
+	doAppend bool,
+) (io.WriteCloser, error) {
+	var handle io.WriteCloser
+	var err error
+	if IsS3Path(filename) {
+		if doAppend {
+			return nil, fmt.Errorf("%s: S3 objects can't be appended to", filename)
+		}
+		handle, err = openS3ObjectForWrite(filename)
+	} else {
+		flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+		if doAppend {
+			flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+		}
+		handle, err = os.OpenFile(
+			filename,
+			flags,
+			0644, // TODO: let users parameterize this
+		)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	wrappedHandle, isNew, err := WrapOutputHandle(handle, FindOutputEncoding(filename, encoding))
+	if err != nil {
+		_ = handle.Close()
+		return nil, err
+	}
+	if !isNew {
+		return handle, nil
+	}
+	return &compressedWriteCloser{
+		compressor: wrappedHandle,
+		handle:     handle,
+	}, nil
+}
+
+// WrapOutputHandle wraps a file-write handle with a compressor. The first
+// return value is the wrapped handle. The second is true if the returned
+// handle needs to be closed separately from the original, which it does not
+// close.
+func WrapOutputHandle(
+	fileWriteHandle io.WriteCloser,
+	encoding TFileOutputEncoding,
+) (io.WriteCloser, bool, error) {
+	switch encoding {
+	case FileOutputEncodingBzip2:
+		compressor, err := bzip2.NewWriter(fileWriteHandle, nil)
+		if err != nil {
+			return fileWriteHandle, false, err
+		}
+		return compressor, true, nil
+	case FileOutputEncodingGzip:
+		return gzip.NewWriter(fileWriteHandle), true, nil
+	case FileOutputEncodingZlib:
+		return zlib.NewWriter(fileWriteHandle), true, nil
+	case FileOutputEncodingZstd:
+		compressor, err := zstd.NewWriter(fileWriteHandle)
+		if err != nil {
+			return fileWriteHandle, false, err
+		}
+		return compressor, true, nil
+	case FileOutputEncodingXz:
+		compressor, err := xz.NewWriter(fileWriteHandle)
+		if err != nil {
+			return fileWriteHandle, false, err
+		}
+		return compressor, true, nil
+	case FileOutputEncodingLz4:
+		return lz4.NewWriter(fileWriteHandle), true, nil
+	case FileOutputEncodingBrotli:
+		return brotli.NewWriter(fileWriteHandle), true, nil
+	default:
+		return fileWriteHandle, false, nil
+	}
+}
+
+// compressedWriteCloser closes the compressor, which writes out the end of
+// the compressed stream, and then the file.
+type compressedWriteCloser struct {
+	compressor io.WriteCloser
+	handle     io.WriteCloser
+}
+
+func (wc *compressedWriteCloser) Write(p []byte) (int, error) {
+	return wc.compressor.Write(p)
+}
+
+func (wc *compressedWriteCloser) Close() error {
+	err := wc.compressor.Close()
+	if cerr := wc.handle.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
@@ -0,0 +1,60 @@
+// Most Miller tests (thousands of them) are command-line-driven via
+// mlr regtest. Here are some cases needing special focus.
+
+package lib
+
+import (
+	"io"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindOutputEncoding(t *testing.T) {
+	assert.Equal(t, FileOutputEncodingGzip, FindOutputEncoding("a.csv.gz", FileOutputEncodingDefault))
+	assert.Equal(t, FileOutputEncodingBzip2, FindOutputEncoding("a.csv.bz2", FileOutputEncodingDefault))
+	assert.Equal(t, FileOutputEncodingZlib, FindOutputEncoding("a.csv.z", FileOutputEncodingDefault))
+	assert.Equal(t, FileOutputEncodingZstd, FindOutputEncoding("a.csv.zst", FileOutputEncodingDefault))
+	assert.Equal(t, FileOutputEncodingXz, FindOutputEncoding("a.csv.xz", FileOutputEncodingDefault))
+	assert.Equal(t, FileOutputEncodingLz4, FindOutputEncoding("a.csv.lz4", FileOutputEncodingDefault))
+	assert.Equal(t, FileOutputEncodingBrotli, FindOutputEncoding("a.csv.br", FileOutputEncodingDefault))
+	assert.Equal(t, FileOutputEncodingNone, FindOutputEncoding("a.csv", FileOutputEncodingDefault))
+	assert.Equal(t, FileOutputEncodingZstd, FindOutputEncoding("a.csv", FileOutputEncodingZstd))
+	assert.Equal(t, FileOutputEncodingNone, FindOutputEncoding("a.csv.gz", FileOutputEncodingNone))
+}
+
+// Writes compressed files, appending to each, and reads them back.
+func TestOpenFileForWrite(t *testing.T) {
+	dir := t.TempDir()
+	for _, suffix := range []string{".gz", ".bz2", ".zst", ".xz", ".lz4", ""} {
+		filename := filepath.Join(dir, "out.txt"+suffix)
+		for i, text := range []string{"hello\n", "world\n"} {
+			handle, err := OpenFileForWrite(filename, FileOutputEncodingDefault, i > 0)
+			assert.Nil(t, err)
+			_, err = io.WriteString(handle, text)
+			assert.Nil(t, err)
+			assert.Nil(t, handle.Close())
+		}
+
+		handle, err := OpenFileForRead(filename, "", false, FileInputEncodingDefault)
+		assert.Nil(t, err)
+		contents, err := io.ReadAll(handle)
+		assert.Nil(t, err)
+		assert.Nil(t, handle.Close())
+		assert.Equal(t, "hello\nworld\n", string(contents), suffix)
+	}
+
+	// Explicit encoding, regardless of suffix
+	filename := filepath.Join(dir, "out.dat")
+	handle, err := OpenFileForWrite(filename, FileOutputEncodingZlib, false)
+	assert.Nil(t, err)
+	_, err = io.WriteString(handle, "hello\n")
+	assert.Nil(t, err)
+	assert.Nil(t, handle.Close())
+	readHandle, err := OpenFileForRead(filename, "", false, FileInputEncodingZlib)
+	assert.Nil(t, err)
+	contents, err := io.ReadAll(readHandle)
+	assert.Nil(t, err)
+	assert.Equal(t, "hello\n", string(contents))
+}
@@ -0,0 +1,212 @@
+package lib
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// FixedWidthSpec is a column spec for fixed-width data, as read from a spec
+// file by the fixed-width record-reader and record-writer alike. A spec file
+// has one column per line, in order:
+//
+//	# name      width  options
+//	id          6      align=right pad=0
+//	name        20
+//	"Seq No"    4      align=right overflow=error
+//
+// Options are align=left (the default) or align=right; pad=C for the pad
+// character, default space; and overflow=truncate (the default) or
+// overflow=error for what to do on output with values longer than the width.
+// Names may be double-quoted, with backslash escapes, if they have spaces.
+// Blank lines and lines starting with # are ignored. Widths count characters,
+// not bytes.
+type FixedWidthSpec struct {
+	Columns []*FixedWidthColumn
+}
+
+type FixedWidthColumn struct {
+	Name             string
+	Width            int
+	AlignRight       bool
+	Pad              rune
+	TruncateOverflow bool
+}
+
+// ReadFixedWidthSpecFile reads and parses a spec file.
+func ReadFixedWidthSpecFile(filename string) (*FixedWidthSpec, error) {
+	contents, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("fixed-width spec: %v", err)
+	}
+	return ParseFixedWidthSpec(string(contents), filename)
+}
+
+// ParseFixedWidthSpec parses the contents of a spec file; the source name is
+// for error messages.
+func ParseFixedWidthSpec(text string, sourceName string) (*FixedWidthSpec, error) {
+	spec := &FixedWidthSpec{
+		Columns: make([]*FixedWidthColumn, 0),
+	}
+	for i, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		column, err := parseFixedWidthColumn(line)
+		if err != nil {
+			return nil, fmt.Errorf("fixed-width spec %s line %d: %v", sourceName, i+1, err)
+		}
+		spec.Columns = append(spec.Columns, column)
+	}
+	if len(spec.Columns) == 0 {
+		return nil, fmt.Errorf("fixed-width spec %s has no columns", sourceName)
+	}
+	return spec, nil
+}
+
+func parseFixedWidthColumn(line string) (*FixedWidthColumn, error) {
+	column := &FixedWidthColumn{
+		Pad:              ' ',
+		TruncateOverflow: true,
+	}
+
+	var rest string
+	if strings.HasPrefix(line, `"`) {
+		quoted, err := strconv.QuotedPrefix(line)
+		if err != nil {
+			return nil, fmt.Errorf("unterminated quoted name")
+		}
+		column.Name, _ = strconv.Unquote(quoted)
+		rest = line[len(quoted):]
+		if rest != "" && rest[0] != ' ' && rest[0] != '\t' {
+			return nil, fmt.Errorf("no space after quoted name")
+		}
+	} else {
+		end := strings.IndexAny(line, " \t")
+		if end < 0 {
+			end = len(line)
+		}
+		column.Name = line[:end]
+		rest = line[end:]
+	}
+
+	words := strings.Fields(rest)
+	if len(words) == 0 {
+		return nil, fmt.Errorf("no width for column \"%s\"", column.Name)
+	}
+	width, err := strconv.Atoi(words[0])
+	if err != nil || width <= 0 {
+		return nil, fmt.Errorf("width \"%s\" of column \"%s\" is not a positive integer", words[0], column.Name)
+	}
+	column.Width = width
+
+	for _, word := range words[1:] {
+		key, value, ok := strings.Cut(word, "=")
+		if !ok {
+			return nil, fmt.Errorf("option \"%s\" is not of the form key=value", word)
+		}
+		switch key {
+		case "align":
+			switch value {
+			case "left":
+				column.AlignRight = false
+			case "right":
+				column.AlignRight = true
+			default:
+				return nil, fmt.Errorf("align must be left or right; got \"%s\"", value)
+			}
+		case "pad":
+			if utf8.RuneCountInString(value) != 1 {
+				return nil, fmt.Errorf("pad must be a single character; got \"%s\"", value)
+			}
+			column.Pad, _ = utf8.DecodeRuneInString(value)
+		case "overflow":
+			switch value {
+			case "truncate":
+				column.TruncateOverflow = true
+			case "error":
+				column.TruncateOverflow = false
+			default:
+				return nil, fmt.Errorf("overflow must be truncate or error; got \"%s\"", value)
+			}
+		default:
+			return nil, fmt.Errorf("unknown option \"%s\"", key)
+		}
+	}
+	return column, nil
+}
+
+// Split splits a line into one field per column, not yet trimmed. Lines
+// shorter than the spec give fewer fields; anything beyond the total width is
+// returned as the remainder.
+func (spec *FixedWidthSpec) Split(line string) (fields []string, remainder string) {
+	fields = make([]string, 0, len(spec.Columns))
+	for _, column := range spec.Columns {
+		if line == "" {
+			break
+		}
+		end := len(line)
+		n := 0
+		for i := range line {
+			if n == column.Width {
+				end = i
+				break
+			}
+			n++
+		}
+		fields = append(fields, line[:end])
+		line = line[end:]
+	}
+	return fields, line
+}
+
+// Format pads a value to the column's width, or truncates it, or returns an
+// error if it's too long and the column's overflow policy is error. With
+// right-alignment and zero-padding, a leading sign stays in front, as in
+// -00042.
+func (column *FixedWidthColumn) Format(value string) (string, error) {
+	n := utf8.RuneCountInString(value)
+	if n > column.Width {
+		if !column.TruncateOverflow {
+			return "", fmt.Errorf(
+				"value \"%s\" of field \"%s\" is longer than its width %d",
+				value, column.Name, column.Width,
+			)
+		}
+		return string([]rune(value)[:column.Width]), nil
+	}
+
+	padding := strings.Repeat(string(column.Pad), column.Width-n)
+	if !column.AlignRight {
+		return value + padding, nil
+	}
+	if column.Pad == '0' && padding != "" && (strings.HasPrefix(value, "-") || strings.HasPrefix(value, "+")) {
+		return value[:1] + padding + value[1:], nil
+	}
+	return padding + value, nil
+}
+
+// Trim undoes Format's padding: it removes pad characters from the right of a
+// left-aligned field, or from the left of a right-aligned one. A zero-padded
+// field of all zeros is 0.
+func (column *FixedWidthColumn) Trim(field string) string {
+	pad := string(column.Pad)
+	if !column.AlignRight {
+		return strings.TrimRight(field, pad)
+	}
+	if column.Pad != '0' {
+		return strings.TrimLeft(field, pad)
+	}
+	sign := ""
+	if strings.HasPrefix(field, "-") || strings.HasPrefix(field, "+") {
+		sign, field = field[:1], field[1:]
+	}
+	trimmed := strings.TrimLeft(field, pad)
+	if trimmed == "" && field != "" {
+		trimmed = "0"
+	}
+	return sign + trimmed
+}
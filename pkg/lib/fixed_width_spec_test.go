@@ -0,0 +1,111 @@
+package lib
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFixedWidthSpec(t *testing.T) {
+	spec, err := ParseFixedWidthSpec(`
+# name  width options
+id      6     align=right pad=0
+name    10
+"Seq No"	4 align=right overflow=error
+`, "test")
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(spec.Columns))
+
+	assert.Equal(t, "id", spec.Columns[0].Name)
+	assert.Equal(t, 6, spec.Columns[0].Width)
+	assert.True(t, spec.Columns[0].AlignRight)
+	assert.Equal(t, '0', spec.Columns[0].Pad)
+	assert.True(t, spec.Columns[0].TruncateOverflow)
+
+	assert.Equal(t, "name", spec.Columns[1].Name)
+	assert.False(t, spec.Columns[1].AlignRight)
+	assert.Equal(t, ' ', spec.Columns[1].Pad)
+
+	assert.Equal(t, "Seq No", spec.Columns[2].Name)
+	assert.Equal(t, 4, spec.Columns[2].Width)
+	assert.False(t, spec.Columns[2].TruncateOverflow)
+}
+
+func TestParseFixedWidthSpecErrors(t *testing.T) {
+	for _, text := range []string{
+		"",
+		"# only a comment",
+		"a",
+		"a 0",
+		"a x",
+		"a 3 align=center",
+		"a 3 pad=",
+		"a 3 pad=ab",
+		"a 3 overflow=wrap",
+		"a 3 right",
+		"a 3 color=red",
+		`"a 3`,
+		`"a"3`,
+	} {
+		_, err := ParseFixedWidthSpec(text, "test")
+		assert.NotNil(t, err, text)
+	}
+}
+
+func TestFixedWidthColumnFormat(t *testing.T) {
+	left := &FixedWidthColumn{Name: "a", Width: 5, Pad: ' ', TruncateOverflow: true}
+	right := &FixedWidthColumn{Name: "b", Width: 5, AlignRight: true, Pad: '0'}
+
+	output, err := left.Format("abc")
+	assert.Nil(t, err)
+	assert.Equal(t, "abc  ", output)
+	output, err = left.Format("abcdefg")
+	assert.Nil(t, err)
+	assert.Equal(t, "abcde", output)
+	output, err = left.Format("héllo")
+	assert.Nil(t, err)
+	assert.Equal(t, "héllo", output)
+
+	output, err = right.Format("42")
+	assert.Nil(t, err)
+	assert.Equal(t, "00042", output)
+	output, err = right.Format("-42")
+	assert.Nil(t, err)
+	assert.Equal(t, "-0042", output)
+	_, err = right.Format("123456")
+	assert.NotNil(t, err)
+}
+
+func TestFixedWidthColumnTrim(t *testing.T) {
+	left := &FixedWidthColumn{Width: 5, Pad: ' '}
+	right := &FixedWidthColumn{Width: 5, AlignRight: true, Pad: ' '}
+	zeros := &FixedWidthColumn{Width: 5, AlignRight: true, Pad: '0'}
+
+	assert.Equal(t, " ab", left.Trim(" ab  "))
+	assert.Equal(t, "ab ", right.Trim("  ab "))
+	assert.Equal(t, "42", zeros.Trim("00042"))
+	assert.Equal(t, "-42", zeros.Trim("-0042"))
+	assert.Equal(t, "0", zeros.Trim("00000"))
+	assert.Equal(t, "", zeros.Trim(""))
+}
+
+func TestFixedWidthSpecSplit(t *testing.T) {
+	spec := &FixedWidthSpec{
+		Columns: []*FixedWidthColumn{
+			{Width: 2},
+			{Width: 3},
+		},
+	}
+
+	fields, remainder := spec.Split("abcde")
+	assert.Equal(t, []string{"ab", "cde"}, fields)
+	assert.Equal(t, "", remainder)
+
+	fields, remainder = spec.Split("héllo world")
+	assert.Equal(t, []string{"hé", "llo"}, fields)
+	assert.Equal(t, " world", remainder)
+
+	fields, remainder = spec.Split("abc")
+	assert.Equal(t, []string{"ab", "c"}, fields)
+	assert.Equal(t, "", remainder)
+}
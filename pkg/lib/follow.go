@@ -0,0 +1,223 @@
+// ================================================================
+// Support for mlr --follow: like tail -F, a followed file is read from the
+// start, and then read some more as it grows, rather than being treated as
+// having ended when its current end is reached. If the file is renamed away
+// and a new one is created in its place (log rotation), the rest of the old
+// file is read and then the new file is opened. If the file is truncated
+// (copy-and-truncate log rotation), reading resumes from its start.
+//
+// For formats with header lines, such as CSV, the header from the first file
+// is remembered. When a rotated-to or truncated file starts with the same
+// header lines, they're skipped, so the record-reader sees one header
+// followed by all the data lines.
+// ================================================================
+
+package lib
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// FollowPollInterval is how often a followed file is checked for new data,
+// rotation, and truncation once its current end has been reached.
+var FollowPollInterval = 250 * time.Millisecond
+
+type followReader struct {
+	path        string
+	handle      *os.File
+	info        os.FileInfo // of the open handle, for rotation checks
+	offset      int64
+	doneChannel <-chan bool
+
+	headerLineCount int
+	header          []byte // first headerLineCount lines of the first file, with line endings
+	headerComplete  bool
+
+	// After rotation or truncation, the bytes read so far which might still
+	// turn out to be a repeat of the header.
+	checkingHeader bool
+	pending        []byte
+	ready          []byte
+}
+
+// OpenFileForFollow opens a local file to be followed as it grows. The
+// header-line count is the number of lines at the start of each file which
+// are a header, and is zero for formats without one. The done-channel is as
+// for record-readers: when something is sent on it (e.g. by mlr head), reads
+// stop waiting for more data and return end of file.
+func OpenFileForFollow(
+	path string,
+	headerLineCount int,
+	doneChannel <-chan bool,
+) (io.ReadCloser, error) {
+	path = strings.TrimPrefix(path, "file://")
+	handle, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := handle.Stat()
+	if err != nil {
+		_ = handle.Close()
+		return nil, err
+	}
+	return &followReader{
+		path:            path,
+		handle:          handle,
+		info:            info,
+		doneChannel:     doneChannel,
+		headerLineCount: headerLineCount,
+		headerComplete:  headerLineCount == 0,
+	}, nil
+}
+
+// IsFollowable says whether a file can be followed: it needs to be local,
+// and read without decompression or a prepipe.
+func IsFollowable(
+	filename string,
+	prepipe string,
+	encoding TFileInputEncoding,
+) error {
+	if strings.HasPrefix(filename, "http://") ||
+		strings.HasPrefix(filename, "https://") ||
+		IsS3Path(filename) {
+		return fmt.Errorf("%s: http://, https://, and s3:// URLs can't be followed", filename)
+	}
+	if prepipe != "" {
+		return fmt.Errorf("input with --prepipe or --prepipex can't be followed")
+	}
+	if _, _, ok := SplitArchiveFilename(filename); ok || IsArchiveFilename(filename) {
+		return fmt.Errorf("%s: zip and tar archives and their members can't be followed", filename)
+	}
+	if encoding != FileInputEncodingDefault || findEncodingFromSuffix(filename) != FileInputEncodingDefault {
+		return fmt.Errorf("%s: compressed input can't be followed", filename)
+	}
+	return nil
+}
+
+func (reader *followReader) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	for {
+		if len(reader.ready) > 0 {
+			n := copy(p, reader.ready)
+			reader.ready = reader.ready[n:]
+			return n, nil
+		}
+
+		n, err := reader.handle.Read(p)
+		reader.offset += int64(n)
+		if n > 0 {
+			if reader.checkingHeader {
+				reader.pending = append(reader.pending, p[:n]...)
+				reader.checkPendingHeader()
+				continue
+			}
+			reader.rememberHeader(p[:n])
+			return n, nil
+		}
+		if err != nil && err != io.EOF {
+			return 0, err
+		}
+
+		reopened, err := reader.checkRotationOrTruncation()
+		if err != nil {
+			return 0, err
+		}
+		if reopened {
+			continue
+		}
+
+		select {
+		case <-reader.doneChannel:
+			return 0, io.EOF
+		case <-time.After(FollowPollInterval):
+		}
+	}
+}
+
+func (reader *followReader) Close() error {
+	return reader.handle.Close()
+}
+
+// rememberHeader saves the start of the first file, up to the end of its
+// header lines.
+func (reader *followReader) rememberHeader(data []byte) {
+	for _, b := range data {
+		if reader.headerComplete {
+			return
+		}
+		reader.header = append(reader.header, b)
+		if b == '\n' && bytes.Count(reader.header, []byte{'\n'}) == reader.headerLineCount {
+			reader.headerComplete = true
+		}
+	}
+}
+
+// checkPendingHeader decides, once enough of a new file has been read, whether
+// it starts with the remembered header. If so the header is dropped.
+func (reader *followReader) checkPendingHeader() {
+	if len(reader.pending) >= len(reader.header) {
+		reader.ready = bytes.TrimPrefix(reader.pending, reader.header)
+	} else if bytes.HasPrefix(reader.header, reader.pending) {
+		return // Could still be the header: wait for more
+	} else {
+		reader.ready = reader.pending
+	}
+	reader.pending = nil
+	reader.checkingHeader = false
+}
+
+// checkRotationOrTruncation is called at end of file. If the path now names a
+// different file, that one is opened in place of the current one; if the
+// current one has shrunk, it's read again from the start.
+func (reader *followReader) checkRotationOrTruncation() (bool, error) {
+	pathInfo, err := os.Stat(reader.path)
+	if err != nil {
+		// E.g. renamed away and not yet recreated: keep waiting.
+		return false, nil
+	}
+
+	if !os.SameFile(pathInfo, reader.info) {
+		handle, err := os.Open(reader.path)
+		if err != nil {
+			return false, nil
+		}
+		info, err := handle.Stat()
+		if err != nil {
+			_ = handle.Close()
+			return false, nil
+		}
+		_ = reader.handle.Close()
+		reader.handle = handle
+		reader.info = info
+		reader.startNewFile()
+		return true, nil
+	}
+
+	info, err := reader.handle.Stat()
+	if err != nil {
+		return false, err
+	}
+	if info.Size() < reader.offset {
+		if _, err := reader.handle.Seek(0, io.SeekStart); err != nil {
+			return false, err
+		}
+		reader.startNewFile()
+		return true, nil
+	}
+	return false, nil
+}
+
+func (reader *followReader) startNewFile() {
+	reader.offset = 0
+	if reader.headerLineCount > 0 && reader.headerComplete {
+		reader.checkingHeader = true
+		reader.pending = nil
+	}
+}
@@ -0,0 +1,119 @@
+package lib
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// followLines follows the file on a goroutine, sending its lines on the
+// returned channel, which is closed at end of file.
+func followLines(t *testing.T, path string, headerLineCount int, doneChannel <-chan bool) <-chan string {
+	oldInterval := FollowPollInterval
+	FollowPollInterval = 5 * time.Millisecond
+	t.Cleanup(func() { FollowPollInterval = oldInterval })
+
+	handle, err := OpenFileForFollow(path, headerLineCount, doneChannel)
+	assert.Nil(t, err)
+	lines := make(chan string, 100)
+	go func() {
+		defer close(lines)
+		defer handle.Close()
+		scanner := bufio.NewScanner(handle)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+	}()
+	return lines
+}
+
+func nextLine(t *testing.T, lines <-chan string) string {
+	select {
+	case line := <-lines:
+		return line
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for followed line")
+		return ""
+	}
+}
+
+func appendToFile(t *testing.T, path string, text string) {
+	handle, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	assert.Nil(t, err)
+	_, err = io.WriteString(handle, text)
+	assert.Nil(t, err)
+	assert.Nil(t, handle.Close())
+}
+
+func TestFollowGrowthAndRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.csv")
+	appendToFile(t, path, "a,b\n1,2\n")
+	doneChannel := make(chan bool, 1)
+	lines := followLines(t, path, 1, doneChannel)
+
+	assert.Equal(t, "a,b", nextLine(t, lines))
+	assert.Equal(t, "1,2", nextLine(t, lines))
+
+	appendToFile(t, path, "3,4\n")
+	assert.Equal(t, "3,4", nextLine(t, lines))
+
+	// Rotation: the rest of the old file is read, then the new one, without
+	// its repeated header.
+	appendToFile(t, path, "5,6\n")
+	assert.Nil(t, os.Rename(path, path+".1"))
+	appendToFile(t, path, "a,b\n7,8\n")
+	assert.Equal(t, "5,6", nextLine(t, lines))
+	assert.Equal(t, "7,8", nextLine(t, lines))
+
+	// Truncation: read again from the start.
+	assert.Nil(t, os.Truncate(path, 0))
+	time.Sleep(50 * time.Millisecond)
+	appendToFile(t, path, "a,b\n9,10\n")
+	assert.Equal(t, "9,10", nextLine(t, lines))
+
+	// A new file without the header, or with a different first line, is
+	// passed through as-is.
+	assert.Nil(t, os.Rename(path, path+".2"))
+	appendToFile(t, path, "11,12\n")
+	assert.Equal(t, "11,12", nextLine(t, lines))
+
+	doneChannel <- true
+	_, ok := <-lines
+	assert.False(t, ok)
+}
+
+func TestFollowHeaderArrivingInPieces(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.md")
+	appendToFile(t, path, "| a |\n| --- |\n| 1 |\n")
+	doneChannel := make(chan bool, 1)
+	lines := followLines(t, path, 2, doneChannel)
+	assert.Equal(t, "| a |", nextLine(t, lines))
+	assert.Equal(t, "| --- |", nextLine(t, lines))
+	assert.Equal(t, "| 1 |", nextLine(t, lines))
+
+	assert.Nil(t, os.Rename(path, path+".1"))
+	appendToFile(t, path, "| a |\n| -")
+	time.Sleep(20 * time.Millisecond)
+	appendToFile(t, path, "-- |\n| 2 |\n")
+	assert.Equal(t, "| 2 |", nextLine(t, lines))
+
+	doneChannel <- true
+	_, ok := <-lines
+	assert.False(t, ok)
+}
+
+func TestIsFollowable(t *testing.T) {
+	assert.Nil(t, IsFollowable("app.csv", "", FileInputEncodingDefault))
+	assert.NotNil(t, IsFollowable("https://example.com/app.csv", "", FileInputEncodingDefault))
+	assert.NotNil(t, IsFollowable("s3://bucket/app.csv", "", FileInputEncodingDefault))
+	assert.NotNil(t, IsFollowable("app.csv", "gunzip", FileInputEncodingDefault))
+	assert.NotNil(t, IsFollowable("app.csv", "", FileInputEncodingGzip))
+	assert.NotNil(t, IsFollowable("app.csv.gz", "", FileInputEncodingDefault))
+	assert.NotNil(t, IsFollowable("logs.zip", "", FileInputEncodingDefault))
+	assert.NotNil(t, IsFollowable("logs.zip#app.csv", "", FileInputEncodingDefault))
+}
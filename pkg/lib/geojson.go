@@ -0,0 +1,12 @@
+// Field names shared by the GeoJSON record-reader and record-writer.
+
+package lib
+
+// GEOJSON_ID_FIELD_NAME is the field holding a feature's id, if it has one.
+// It isn't simply id, since features' properties often have an id of their
+// own.
+const GEOJSON_ID_FIELD_NAME = "_id"
+
+// GEOJSON_GEOMETRY_FIELD_NAME is the field holding a feature's geometry, as
+// a map, or as null for features without a location.
+const GEOJSON_GEOMETRY_FIELD_NAME = "geometry"
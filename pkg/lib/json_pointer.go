@@ -0,0 +1,29 @@
+// JSON Pointer (RFC 6901) parsing, for the JSON record-reader's
+// --json-records-path.
+
+package lib
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ParseJSONPointer splits a JSON Pointer such as /data/items into its
+// reference tokens, here data and items, with ~1 and ~0 unescaped to / and ~.
+// The leading slash is optional, so data/items is the same. The empty
+// pointer, for the whole document, is an error, since that's the default.
+func ParseJSONPointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, fmt.Errorf("JSON Pointer is empty; please give a path such as /data")
+	}
+	tokens := strings.Split(strings.TrimPrefix(pointer, "/"), "/")
+	for i, token := range tokens {
+		for j := 0; j < len(token); j++ {
+			if token[j] == '~' && (j+1 == len(token) || (token[j+1] != '0' && token[j+1] != '1')) {
+				return nil, fmt.Errorf("JSON Pointer \"%s\" has ~ not followed by 0 or 1", pointer)
+			}
+		}
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
@@ -0,0 +1,30 @@
+package lib
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseJSONPointer(t *testing.T) {
+	tokens, err := ParseJSONPointer("/data")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"data"}, tokens)
+
+	tokens, err = ParseJSONPointer("results/0/items")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"results", "0", "items"}, tokens)
+
+	tokens, err = ParseJSONPointer("/a~1b/c~0d/~01")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a/b", "c~d", "~1"}, tokens)
+
+	tokens, err = ParseJSONPointer("/")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{""}, tokens)
+
+	for _, s := range []string{"", "/a~", "/a~2"} {
+		_, err = ParseJSONPointer(s)
+		assert.Error(t, err, s)
+	}
+}
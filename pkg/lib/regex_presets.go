@@ -0,0 +1,64 @@
+// Built-in regexes for the regex record-reader, for common log formats. Each
+// has named capture groups, which are the field names.
+
+package lib
+
+import (
+	"strings"
+)
+
+type RegexPreset struct {
+	Name        string
+	Description string
+	Regex       string
+}
+
+// Quoted fields in access logs may have backslash-escaped quotes within.
+const regexPresetCommon = `^(?P<host>\S+) (?P<ident>\S+) (?P<user>\S+) \[(?P<time>[^\]]+)\] ` +
+	`"(?P<request>(?:[^"\\]|\\.)*)" (?P<status>\d{3}|-) (?P<bytes>\d+|-)`
+
+var REGEX_PRESETS = []RegexPreset{
+	{
+		Name:        "common",
+		Description: "Apache/nginx Common Log Format",
+		Regex:       regexPresetCommon + `$`,
+	},
+	{
+		Name:        "combined",
+		Description: "Apache/nginx Combined Log Format, which is Common Log Format with referer and user agent",
+		Regex: regexPresetCommon +
+			` "(?P<referer>(?:[^"\\]|\\.)*)" "(?P<user_agent>(?:[^"\\]|\\.)*)"$`,
+	},
+	{
+		Name:        "syslog-rfc3164",
+		Description: "BSD syslog, as in /var/log/syslog; the priority is optional",
+		Regex: `^(?:<(?P<pri>\d{1,3})>)?(?P<timestamp>[A-Z][a-z]{2} [ \d]\d \d{2}:\d{2}:\d{2}) ` +
+			`(?P<host>\S+) (?P<tag>[^:\[\s]+)(?:\[(?P<pid>\d+)\])?: ?(?P<message>.*)$`,
+	},
+	{
+		Name:        "syslog-rfc5424",
+		Description: "IETF syslog; nil values are -",
+		Regex: `^<(?P<pri>\d{1,3})>(?P<version>\d{1,2}) (?P<timestamp>\S+) (?P<host>\S+) ` +
+			`(?P<app_name>\S+) (?P<procid>\S+) (?P<msgid>\S+) ` +
+			`(?P<structured_data>-|(?:\[(?:[^\]\\]|\\.)*\])+)(?: (?P<message>.*))?$`,
+	},
+}
+
+// GetRegexPreset returns the regex for the named preset, if there is one.
+func GetRegexPreset(name string) (string, bool) {
+	for _, preset := range REGEX_PRESETS {
+		if preset.Name == name {
+			return preset.Regex, true
+		}
+	}
+	return "", false
+}
+
+// GetRegexPresetNames returns the preset names, comma-separated, for help output.
+func GetRegexPresetNames() string {
+	names := make([]string, len(REGEX_PRESETS))
+	for i, preset := range REGEX_PRESETS {
+		names[i] = preset.Name
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,32 @@
+package lib
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegexPresetsCompileWithNamedGroups(t *testing.T) {
+	for _, preset := range REGEX_PRESETS {
+		regex, err := CompileMillerRegex(preset.Regex)
+		assert.NoError(t, err, preset.Name)
+		for _, name := range regex.SubexpNames()[1:] {
+			assert.NotEqual(t, "", name, preset.Name)
+		}
+	}
+}
+
+func TestRegexPresetCombined(t *testing.T) {
+	regexString, ok := GetRegexPreset("combined")
+	assert.True(t, ok)
+	regex := CompileMillerRegexOrDie(regexString)
+	line := `10.0.0.7 - - [02/Jan/2026:03:04:09 +0000] "GET /q=\"x\" HTTP/1.1" 404 - "-" "Mozilla/5.0"`
+	match := regex.FindStringSubmatch(line)
+	assert.NotNil(t, match)
+	assert.Equal(t, `GET /q=\"x\" HTTP/1.1`, match[regex.SubexpIndex("request")])
+	assert.Equal(t, "-", match[regex.SubexpIndex("bytes")])
+	assert.Equal(t, "Mozilla/5.0", match[regex.SubexpIndex("user_agent")])
+
+	_, ok = GetRegexPreset("nonesuch")
+	assert.False(t, ok)
+}
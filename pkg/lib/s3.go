@@ -0,0 +1,583 @@
+// Reading and writing objects in S3, or S3-compatible object storage, given
+// names like s3://bucket/key.
+//
+// Credentials are from the standard environment variables AWS_ACCESS_KEY_ID,
+// AWS_SECRET_ACCESS_KEY, and AWS_SESSION_TOKEN; requests are signed using AWS
+// Signature Version 4. Without credentials, requests are sent unsigned, as for
+// public buckets. The region is from AWS_REGION or AWS_DEFAULT_REGION, else
+// us-east-1. The endpoint is from AWS_ENDPOINT_URL_S3 or AWS_ENDPOINT_URL --
+// which the --s3-endpoint flag sets -- for a local stand-in such as MinIO;
+// else it's AWS's. For an endpoint other than AWS's, buckets are addressed by
+// path, as in http://localhost:9000/bucket/key.
+//
+// For input, a name whose key ends in / or has *, ?, or [...] in it is
+// expanded, by listing the bucket, to the names of the objects with that
+// prefix or matching that glob. As with filenames, * and ? don't match /.
+//
+// Output is written by multipart upload, in parts of s3PartSize, or in one
+// upload if it's smaller than that. The object appears in the bucket only once
+// the output is closed. Objects can't be appended to.
+
+package lib
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// S3Prefix starts the names of S3 objects.
+const S3Prefix = "s3://"
+
+// S3 requires parts other than the last to be at least 5 MiB.
+const s3PartSize = 8 << 20
+
+// sha256 of the empty string, for requests without a body
+const s3EmptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// IsS3Path tells if the name is of the form s3://bucket/key.
+func IsS3Path(name string) bool {
+	return strings.HasPrefix(name, S3Prefix)
+}
+
+// splitS3Path splits s3://bucket/key into bucket and key.
+func splitS3Path(name string) (string, string, error) {
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(name, S3Prefix), "/")
+	if bucket == "" {
+		return "", "", fmt.Errorf("%s: no bucket name", name)
+	}
+	return bucket, key, nil
+}
+
+type s3Client struct {
+	endpoint        *url.URL
+	pathStyle       bool
+	region          string
+	accessKeyID     string
+	secretAccessKey string
+	sessionToken    string
+	now             func() time.Time
+}
+
+func newS3ClientFromEnvironment() (*s3Client, error) {
+	region := firstNonEmptyEnv("AWS_REGION", "AWS_DEFAULT_REGION")
+	if region == "" {
+		region = "us-east-1"
+	}
+	client := &s3Client{
+		region:          region,
+		accessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		secretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		sessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+		now:             time.Now,
+	}
+
+	endpoint := firstNonEmptyEnv("AWS_ENDPOINT_URL_S3", "AWS_ENDPOINT_URL")
+	if endpoint == "" {
+		endpoint = "https://s3." + region + ".amazonaws.com"
+	} else {
+		client.pathStyle = true
+	}
+	endpointURL, err := url.Parse(endpoint)
+	if err != nil || endpointURL.Host == "" {
+		return nil, fmt.Errorf("S3 endpoint \"%s\" is not a URL", endpoint)
+	}
+	endpointURL.Path = strings.TrimSuffix(endpointURL.Path, "/")
+	endpointURL.RawPath = ""
+	client.endpoint = endpointURL
+	return client, nil
+}
+
+func firstNonEmptyEnv(names ...string) string {
+	for _, name := range names {
+		if value := os.Getenv(name); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// objectURL is for the bucket, or for an object in it if the key is
+// non-empty. The path is escaped as S3 wants it for signing.
+func (client *s3Client) objectURL(bucket string, key string, query map[string]string) *url.URL {
+	objectURL := *client.endpoint
+	unescapedPath := objectURL.Path + "/"
+	if client.pathStyle || strings.Contains(bucket, ".") {
+		unescapedPath += bucket + "/"
+	} else {
+		objectURL.Host = bucket + "." + objectURL.Host
+	}
+	unescapedPath += key
+	objectURL.Path = unescapedPath
+	objectURL.RawPath = s3URIEncode(unescapedPath, false)
+
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = s3URIEncode(name, true) + "=" + s3URIEncode(query[name], true)
+	}
+	objectURL.RawQuery = strings.Join(pairs, "&")
+	return &objectURL
+}
+
+// s3URIEncode escapes all but unreserved characters -- and slashes, unless
+// asked to -- as Signature Version 4 wants.
+func s3URIEncode(s string, encodeSlash bool) string {
+	var buffer strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') ||
+			c == '-' || c == '_' || c == '.' || c == '~' || (c == '/' && !encodeSlash) {
+			buffer.WriteByte(c)
+		} else {
+			fmt.Fprintf(&buffer, "%%%02X", c)
+		}
+	}
+	return buffer.String()
+}
+
+// do sends the request, signed if there are credentials.
+func (client *s3Client) do(
+	method string,
+	bucket string,
+	key string,
+	query map[string]string,
+	body []byte,
+) (*http.Response, error) {
+	var bodyReader io.Reader = http.NoBody
+	payloadHash := s3EmptyPayloadHash
+	if body != nil {
+		bodyReader = bytes.NewReader(body)
+		payloadHash = sha256Hex(body)
+	}
+	request, err := http.NewRequest(method, client.objectURL(bucket, key, query).String(), bodyReader)
+	if err != nil {
+		return nil, err
+	}
+	client.sign(request, payloadHash)
+	return http.DefaultClient.Do(request)
+}
+
+// sign adds the Signature Version 4 headers. All the request's headers, and
+// the host, are signed.
+func (client *s3Client) sign(request *http.Request, payloadHash string) {
+	if client.accessKeyID == "" {
+		return
+	}
+	now := client.now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	request.Header.Set("X-Amz-Date", amzDate)
+	request.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if client.sessionToken != "" {
+		request.Header.Set("X-Amz-Security-Token", client.sessionToken)
+	}
+
+	headerValues := map[string]string{"host": request.Host}
+	headerNames := []string{"host"}
+	for name, values := range request.Header {
+		name = strings.ToLower(name)
+		headerValues[name] = strings.TrimSpace(strings.Join(values, ","))
+		headerNames = append(headerNames, name)
+	}
+	sort.Strings(headerNames)
+	var canonicalHeaders strings.Builder
+	for _, name := range headerNames {
+		canonicalHeaders.WriteString(name + ":" + headerValues[name] + "\n")
+	}
+	signedHeaders := strings.Join(headerNames, ";")
+
+	canonicalPath := request.URL.EscapedPath()
+	if canonicalPath == "" {
+		canonicalPath = "/"
+	}
+	canonicalRequest := strings.Join([]string{
+		request.Method,
+		canonicalPath,
+		request.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + client.region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+	signingKey := hmacSHA256([]byte("AWS4"+client.secretAccessKey), date)
+	signingKey = hmacSHA256(signingKey, client.region)
+	signingKey = hmacSHA256(signingKey, "s3")
+	signingKey = hmacSHA256(signingKey, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+
+	request.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		client.accessKeyID, scope, signedHeaders, signature,
+	))
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// s3ResponseError is for a response other than 200, or for a 200 with an
+// error in the body as S3 sometimes sends. It uses S3's error code and
+// message, if any. It closes the response body.
+func s3ResponseError(name string, response *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(response.Body, 64*1024))
+	_ = response.Body.Close()
+	var s3Error struct {
+		XMLName xml.Name
+		Code    string
+		Message string
+	}
+	if xml.Unmarshal(body, &s3Error) == nil && s3Error.XMLName.Local == "Error" && s3Error.Code != "" {
+		return fmt.Errorf("%s: %s: %s", name, s3Error.Code, s3Error.Message)
+	}
+	return fmt.Errorf("%s: %s", name, response.Status)
+}
+
+// ----------------------------------------------------------------
+// READING
+
+// openS3ObjectForRead streams the object's contents.
+func openS3ObjectForRead(name string) (io.ReadCloser, error) {
+	bucket, key, err := splitS3Path(name)
+	if err != nil {
+		return nil, err
+	}
+	client, err := newS3ClientFromEnvironment()
+	if err != nil {
+		return nil, err
+	}
+	response, err := client.do("GET", bucket, key, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode != http.StatusOK {
+		return nil, s3ResponseError(name, response)
+	}
+	return response.Body, nil
+}
+
+// listObjects returns the keys with the prefix, in the order S3 gives them,
+// which is lexical.
+func (client *s3Client) listObjects(bucket string, prefix string) ([]string, error) {
+	keys := make([]string, 0)
+	continuationToken := ""
+	for {
+		query := map[string]string{"list-type": "2", "prefix": prefix}
+		if continuationToken != "" {
+			query["continuation-token"] = continuationToken
+		}
+		response, err := client.do("GET", bucket, "", query, nil)
+		if err != nil {
+			return nil, err
+		}
+		if response.StatusCode != http.StatusOK {
+			return nil, s3ResponseError(S3Prefix+bucket, response)
+		}
+
+		var result struct {
+			IsTruncated           bool
+			NextContinuationToken string
+			Contents              []struct {
+				Key string
+			}
+		}
+		err = xml.NewDecoder(response.Body).Decode(&result)
+		_ = response.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s%s: %v", S3Prefix, bucket, err)
+		}
+		for _, contents := range result.Contents {
+			keys = append(keys, contents.Key)
+		}
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return keys, nil
+		}
+		continuationToken = result.NextContinuationToken
+	}
+}
+
+// ExpandS3Filenames replaces s3:// names whose keys end in / or have glob
+// characters by the names of the matching objects. Other names are passed
+// through. Keys ending in /, as made by some tools for folders, are skipped.
+// In an archive-member name like s3://bucket/*.zip#*.csv, the archive part is
+// expanded here and the member part is left for ExpandArchiveFilenames.
+func ExpandS3Filenames(filenames []string) ([]string, error) {
+	var expanded []string = nil
+	var client *s3Client = nil
+	for i, filename := range filenames {
+		objectName, memberSuffix := filename, ""
+		if archiveName, memberName, ok := SplitArchiveFilename(filename); ok && memberName != "" {
+			objectName, memberSuffix = archiveName, ArchiveMemberSeparator+memberName
+		}
+		if !isS3PrefixOrGlob(objectName) {
+			if expanded != nil {
+				expanded = append(expanded, filename)
+			}
+			continue
+		}
+		if expanded == nil {
+			expanded = append([]string{}, filenames[:i]...)
+		}
+
+		bucket, key, err := splitS3Path(objectName)
+		if err != nil {
+			return nil, err
+		}
+		if client == nil {
+			client, err = newS3ClientFromEnvironment()
+			if err != nil {
+				return nil, err
+			}
+		}
+		prefix := key
+		if i := strings.IndexAny(key, "*?["); i >= 0 {
+			prefix = key[:i]
+		}
+		keys, err := client.listObjects(bucket, prefix)
+		if err != nil {
+			return nil, err
+		}
+		count := 0
+		for _, objectKey := range keys {
+			if strings.HasSuffix(objectKey, "/") {
+				continue
+			}
+			if isGlob(key) {
+				if matched, _ := path.Match(key, objectKey); !matched {
+					continue
+				}
+			}
+			expanded = append(expanded, S3Prefix+bucket+"/"+objectKey+memberSuffix)
+			count++
+		}
+		if count == 0 {
+			return nil, fmt.Errorf("%s: no objects match", objectName)
+		}
+	}
+	if expanded == nil {
+		return filenames, nil
+	}
+	return expanded, nil
+}
+
+func isS3PrefixOrGlob(name string) bool {
+	if !IsS3Path(name) {
+		return false
+	}
+	_, key, _ := strings.Cut(strings.TrimPrefix(name, S3Prefix), "/")
+	return key == "" || strings.HasSuffix(key, "/") || isGlob(key)
+}
+
+// ----------------------------------------------------------------
+// WRITING
+
+// s3Writer buffers output, uploading each part as it fills.
+type s3Writer struct {
+	client   *s3Client
+	name     string
+	bucket   string
+	key      string
+	buffer   []byte
+	uploadID string
+	parts    []s3CompletedPart
+	err      error
+}
+
+type s3CompletedPart struct {
+	PartNumber int
+	ETag       string
+}
+
+// openS3ObjectForWrite starts writing the object, which appears in the bucket
+// when the returned handle is closed.
+func openS3ObjectForWrite(name string) (io.WriteCloser, error) {
+	bucket, key, err := splitS3Path(name)
+	if err != nil {
+		return nil, err
+	}
+	if key == "" || strings.HasSuffix(key, "/") {
+		return nil, fmt.Errorf("%s: no object name", name)
+	}
+	client, err := newS3ClientFromEnvironment()
+	if err != nil {
+		return nil, err
+	}
+	return &s3Writer{
+		client: client,
+		name:   name,
+		bucket: bucket,
+		key:    key,
+		buffer: make([]byte, 0),
+		parts:  make([]s3CompletedPart, 0),
+	}, nil
+}
+
+func (writer *s3Writer) Write(p []byte) (int, error) {
+	if writer.err != nil {
+		return 0, writer.err
+	}
+	n := len(p)
+	for len(p) > 0 {
+		room := min(s3PartSize-len(writer.buffer), len(p))
+		writer.buffer = append(writer.buffer, p[:room]...)
+		p = p[room:]
+		if len(writer.buffer) == s3PartSize {
+			if err := writer.uploadPart(); err != nil {
+				writer.abort(err)
+				return 0, err
+			}
+		}
+	}
+	return n, nil
+}
+
+// Close uploads what's left, and completes the multipart upload if there is
+// one.
+func (writer *s3Writer) Close() error {
+	if writer.err != nil {
+		return writer.err
+	}
+
+	if writer.uploadID == "" {
+		response, err := writer.client.do("PUT", writer.bucket, writer.key, nil, writer.buffer)
+		if err != nil {
+			writer.err = err
+			return err
+		}
+		if response.StatusCode != http.StatusOK {
+			writer.err = s3ResponseError(writer.name, response)
+			return writer.err
+		}
+		_ = response.Body.Close()
+		writer.err = fmt.Errorf("%s: already closed", writer.name)
+		return nil
+	}
+
+	if len(writer.buffer) > 0 {
+		if err := writer.uploadPart(); err != nil {
+			writer.abort(err)
+			return err
+		}
+	}
+	if err := writer.completeUpload(); err != nil {
+		writer.abort(err)
+		return err
+	}
+	writer.err = fmt.Errorf("%s: already closed", writer.name)
+	return nil
+}
+
+func (writer *s3Writer) uploadPart() error {
+	if writer.uploadID == "" {
+		if err := writer.createUpload(); err != nil {
+			return err
+		}
+	}
+
+	partNumber := len(writer.parts) + 1
+	query := map[string]string{
+		"partNumber": strconv.Itoa(partNumber),
+		"uploadId":   writer.uploadID,
+	}
+	response, err := writer.client.do("PUT", writer.bucket, writer.key, query, writer.buffer)
+	if err != nil {
+		return err
+	}
+	if response.StatusCode != http.StatusOK {
+		return s3ResponseError(writer.name, response)
+	}
+	_ = response.Body.Close()
+
+	writer.parts = append(writer.parts, s3CompletedPart{
+		PartNumber: partNumber,
+		ETag:       response.Header.Get("ETag"),
+	})
+	writer.buffer = writer.buffer[:0]
+	return nil
+}
+
+func (writer *s3Writer) createUpload() error {
+	response, err := writer.client.do("POST", writer.bucket, writer.key, map[string]string{"uploads": ""}, nil)
+	if err != nil {
+		return err
+	}
+	if response.StatusCode != http.StatusOK {
+		return s3ResponseError(writer.name, response)
+	}
+	var result struct {
+		UploadId string
+	}
+	err = xml.NewDecoder(response.Body).Decode(&result)
+	_ = response.Body.Close()
+	if err != nil {
+		return fmt.Errorf("%s: %v", writer.name, err)
+	}
+	if result.UploadId == "" {
+		return fmt.Errorf("%s: no upload ID in response", writer.name)
+	}
+	writer.uploadID = result.UploadId
+	return nil
+}
+
+func (writer *s3Writer) completeUpload() error {
+	body, err := xml.Marshal(struct {
+		XMLName xml.Name          `xml:"CompleteMultipartUpload"`
+		Parts   []s3CompletedPart `xml:"Part"`
+	}{Parts: writer.parts})
+	if err != nil {
+		return err
+	}
+	response, err := writer.client.do("POST", writer.bucket, writer.key, map[string]string{"uploadId": writer.uploadID}, body)
+	if err != nil {
+		return err
+	}
+	// Errors can come after a 200 status, once S3 has done the work.
+	responseBody, err := io.ReadAll(response.Body)
+	_ = response.Body.Close()
+	if err != nil {
+		return fmt.Errorf("%s: %v", writer.name, err)
+	}
+	response.Body = io.NopCloser(bytes.NewReader(responseBody))
+	if response.StatusCode != http.StatusOK || bytes.Contains(responseBody, []byte("<Error>")) {
+		return s3ResponseError(writer.name, response)
+	}
+	return nil
+}
+
+// abort discards the uploaded parts, if any, so they don't linger in the
+// bucket. The original error is the one kept.
+func (writer *s3Writer) abort(err error) {
+	writer.err = err
+	if writer.uploadID == "" {
+		return
+	}
+	response, abortErr := writer.client.do("DELETE", writer.bucket, writer.key, map[string]string{"uploadId": writer.uploadID}, nil)
+	if abortErr == nil {
+		_ = response.Body.Close()
+	}
+}
@@ -0,0 +1,12 @@
+package lib
+
+import (
+	"strings"
+)
+
+// SQLiteQuoteIdentifier quotes a table or column name for use in SQL
+// statements, so that names with spaces, punctuation, or SQL keywords such
+// as "order" work.
+func SQLiteQuoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
@@ -0,0 +1,71 @@
+// Helpers shared by the TOML and INI record-readers and record-writers.
+
+package lib
+
+import (
+	"strconv"
+	"strings"
+)
+
+// SECTION_FIELD_NAME is the field holding the TOML table name, or INI section
+// name, of each record. TOML table names are as in the table header, with
+// quotes if needed, and with [] appended for array-of-tables entries.
+const SECTION_FIELD_NAME = "_section"
+
+// TOMLFormatKey returns the key as written in TOML: bare if it consists only
+// of ASCII letters, digits, underscores, and dashes, else double-quoted.
+func TOMLFormatKey(key string) string {
+	if key != "" && !strings.ContainsFunc(key, isNotTOMLBareKeyRune) {
+		return key
+	}
+	return TOMLQuoteString(key)
+}
+
+// TOMLFormatDottedKey joins the parts of a dotted key such as a.b."c.d",
+// quoting them as needed.
+func TOMLFormatDottedKey(parts []string) string {
+	formatted := make([]string, len(parts))
+	for i, part := range parts {
+		formatted[i] = TOMLFormatKey(part)
+	}
+	return strings.Join(formatted, ".")
+}
+
+func isNotTOMLBareKeyRune(r rune) bool {
+	return !(r >= 'A' && r <= 'Z' || r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '_' || r == '-')
+}
+
+// TOMLQuoteString returns the string as a TOML basic string, in double quotes
+// with backslash escapes.
+func TOMLQuoteString(s string) string {
+	var buffer strings.Builder
+	buffer.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			buffer.WriteString(`\"`)
+		case '\\':
+			buffer.WriteString(`\\`)
+		case '\n':
+			buffer.WriteString(`\n`)
+		case '\r':
+			buffer.WriteString(`\r`)
+		case '\t':
+			buffer.WriteString(`\t`)
+		case '\b':
+			buffer.WriteString(`\b`)
+		case '\f':
+			buffer.WriteString(`\f`)
+		default:
+			if r < ' ' || r == 0x7f {
+				buffer.WriteString(`\u00`)
+				buffer.WriteString(strconv.FormatInt(int64(r)>>4, 16))
+				buffer.WriteString(strconv.FormatInt(int64(r)&0xf, 16))
+			} else {
+				buffer.WriteRune(r)
+			}
+		}
+	}
+	buffer.WriteByte('"')
+	return buffer.String()
+}
@@ -0,0 +1,28 @@
+package lib
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTOMLFormatKey(t *testing.T) {
+	assert.Equal(t, "server", TOMLFormatKey("server"))
+	assert.Equal(t, "a-b_1", TOMLFormatKey("a-b_1"))
+	assert.Equal(t, `"a b"`, TOMLFormatKey("a b"))
+	assert.Equal(t, `"a.b"`, TOMLFormatKey("a.b"))
+	assert.Equal(t, `""`, TOMLFormatKey(""))
+}
+
+func TestTOMLFormatDottedKey(t *testing.T) {
+	assert.Equal(t, "server.tls", TOMLFormatDottedKey([]string{"server", "tls"}))
+	assert.Equal(t, `site."example.com"`, TOMLFormatDottedKey([]string{"site", "example.com"}))
+}
+
+func TestTOMLQuoteString(t *testing.T) {
+	assert.Equal(t, `"plain"`, TOMLQuoteString("plain"))
+	assert.Equal(t, `"say \"hi\"\n"`, TOMLQuoteString("say \"hi\"\n"))
+	assert.Equal(t, `"C:\\data"`, TOMLQuoteString(`C:\data`))
+	assert.Equal(t, `"\u0001\u007f"`, TOMLQuoteString("\x01\x7f"))
+	assert.Equal(t, `"é"`, TOMLQuoteString("é"))
+}
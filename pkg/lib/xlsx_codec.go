@@ -0,0 +1,114 @@
+package lib
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Cell references and text escapes for the XLSX record-reader and
+// record-writer. See ECMA-376 Part 1, sections 18.3.1.4 (cell references)
+// and 22.9.2.19 (ST_Xstring), for the latter: characters which can't appear
+// in XML, such as most control characters, are written as _xHHHH_, and a
+// literal underscore starting something which looks like that is written as
+// _x005F_.
+
+// XLSXColumnName maps 0 to "A", 25 to "Z", 26 to "AA", and so on.
+func XLSXColumnName(index int) string {
+	name := ""
+	for index >= 0 {
+		name = string(rune('A'+index%26)) + name
+		index = index/26 - 1
+	}
+	return name
+}
+
+// XLSXParseCellRef parses a cell reference such as "B3" into a zero-up column
+// index and a one-up row number. The row number may be omitted, as in "B",
+// in which case it is returned as zero.
+func XLSXParseCellRef(ref string) (column int, row int, ok bool) {
+	i := 0
+	column = 0
+	for i < len(ref) {
+		c := ref[i]
+		if c >= 'a' && c <= 'z' {
+			c -= 'a' - 'A'
+		}
+		if c < 'A' || c > 'Z' {
+			break
+		}
+		column = column*26 + int(c-'A'+1)
+		i++
+	}
+	if i == 0 || i > 3 {
+		return 0, 0, false
+	}
+	column--
+	if i == len(ref) {
+		return column, 0, true
+	}
+	row, err := strconv.Atoi(ref[i:])
+	if err != nil || row < 1 || ref[i] == '+' || ref[i] == '-' {
+		return 0, 0, false
+	}
+	return column, row, true
+}
+
+func isXLSXEscape(input string, i int) bool {
+	if i+7 > len(input) || input[i] != '_' || input[i+1] != 'x' || input[i+6] != '_' {
+		return false
+	}
+	for _, c := range input[i+2 : i+6] {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", c) {
+			return false
+		}
+	}
+	return true
+}
+
+// XLSXDecodeText is for the XLSX record-reader.
+func XLSXDecodeText(input string) string {
+	if !strings.Contains(input, "_x") {
+		return input
+	}
+	var buffer strings.Builder
+	for i := 0; i < len(input); /* increment in loop */ {
+		if isXLSXEscape(input, i) {
+			code, _ := strconv.ParseUint(input[i+2:i+6], 16, 32)
+			buffer.WriteRune(rune(code))
+			i += 7
+		} else {
+			buffer.WriteByte(input[i])
+			i++
+		}
+	}
+	return buffer.String()
+}
+
+// XLSXEncodeText is for the XLSX record-writer.
+func XLSXEncodeText(input string) string {
+	needsEncoding := false
+	for i := 0; i < len(input); i++ {
+		c := input[i]
+		if (c < 0x20 && c != '\t' && c != '\n' && c != '\r') || (c == '_' && isXLSXEscape(input, i)) {
+			needsEncoding = true
+			break
+		}
+	}
+	if !needsEncoding {
+		return input
+	}
+
+	var buffer strings.Builder
+	for i := 0; i < len(input); i++ {
+		c := input[i]
+		if c < 0x20 && c != '\t' && c != '\n' && c != '\r' {
+			buffer.WriteString(fmt.Sprintf("_x%04X_", c))
+		} else if c == '_' && isXLSXEscape(input, i) {
+			buffer.WriteString("_x005F_")
+		} else {
+			buffer.WriteByte(c)
+		}
+	}
+	return buffer.String()
+}
@@ -0,0 +1,70 @@
+package lib
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestXLSXColumnName(t *testing.T) {
+	assert.Equal(t, "A", XLSXColumnName(0))
+	assert.Equal(t, "Z", XLSXColumnName(25))
+	assert.Equal(t, "AA", XLSXColumnName(26))
+	assert.Equal(t, "AZ", XLSXColumnName(51))
+	assert.Equal(t, "BA", XLSXColumnName(52))
+	assert.Equal(t, "ZZ", XLSXColumnName(701))
+	assert.Equal(t, "AAA", XLSXColumnName(702))
+	assert.Equal(t, "XFD", XLSXColumnName(16383))
+}
+
+func TestXLSXParseCellRef(t *testing.T) {
+	column, row, ok := XLSXParseCellRef("A1")
+	assert.True(t, ok)
+	assert.Equal(t, 0, column)
+	assert.Equal(t, 1, row)
+
+	column, row, ok = XLSXParseCellRef("xfd1048576")
+	assert.True(t, ok)
+	assert.Equal(t, 16383, column)
+	assert.Equal(t, 1048576, row)
+
+	column, row, ok = XLSXParseCellRef("AB")
+	assert.True(t, ok)
+	assert.Equal(t, 27, column)
+	assert.Equal(t, 0, row)
+
+	_, _, ok = XLSXParseCellRef("")
+	assert.False(t, ok)
+	_, _, ok = XLSXParseCellRef("12")
+	assert.False(t, ok)
+	_, _, ok = XLSXParseCellRef("A0")
+	assert.False(t, ok)
+	_, _, ok = XLSXParseCellRef("A-1")
+	assert.False(t, ok)
+	_, _, ok = XLSXParseCellRef("A1B")
+	assert.False(t, ok)
+	_, _, ok = XLSXParseCellRef("ABCD1")
+	assert.False(t, ok)
+}
+
+func TestXLSXDecodeText(t *testing.T) {
+	assert.Equal(t, "", XLSXDecodeText(""))
+	assert.Equal(t, "abc", XLSXDecodeText("abc"))
+	assert.Equal(t, "a\x01b", XLSXDecodeText("a_x0001_b"))
+	assert.Equal(t, "_x0001_", XLSXDecodeText("_x005F_x0001_"))
+	assert.Equal(t, "_x00_", XLSXDecodeText("_x00_"))
+	assert.Equal(t, "_xGHIJ_", XLSXDecodeText("_xGHIJ_"))
+}
+
+func TestXLSXEncodeText(t *testing.T) {
+	assert.Equal(t, "", XLSXEncodeText(""))
+	assert.Equal(t, "abc", XLSXEncodeText("abc"))
+	assert.Equal(t, "a\tb\nc", XLSXEncodeText("a\tb\nc"))
+	assert.Equal(t, "a_x0001_b", XLSXEncodeText("a\x01b"))
+	assert.Equal(t, "_x005F_x0001_", XLSXEncodeText("_x0001_"))
+	assert.Equal(t, "_x00_", XLSXEncodeText("_x00_"))
+
+	for _, input := range []string{"a\x01b", "_x0001_", "x_x_y", "\x1f_x001F_"} {
+		assert.Equal(t, input, XLSXDecodeText(XLSXEncodeText(input)))
+	}
+}
@@ -0,0 +1,77 @@
+package lib
+
+import (
+	"fmt"
+	"strings"
+)
+
+// XMLRecordPath is for the XML record-reader and record-writer's
+// --xml-record-path, which says which elements are records. A path such as
+// /feed/entry is absolute, from the document root. A path without a leading
+// slash, such as entry or feed/entry, is relative, matching at any depth; a
+// leading // as in XPath means the same. Components may be * to match any
+// element name.
+type XMLRecordPath struct {
+	Components []string
+	IsAbsolute bool
+}
+
+func NewXMLRecordPath(path string) (*XMLRecordPath, error) {
+	isAbsolute := false
+	trimmed := path
+	if strings.HasPrefix(trimmed, "//") {
+		trimmed = trimmed[2:]
+	} else if strings.HasPrefix(trimmed, "/") {
+		trimmed = trimmed[1:]
+		isAbsolute = true
+	}
+	components := strings.Split(trimmed, "/")
+	for _, component := range components {
+		if component == "" {
+			return nil, fmt.Errorf("XML record path \"%s\" has an empty component", path)
+		}
+	}
+	return &XMLRecordPath{
+		Components: components,
+		IsAbsolute: isAbsolute,
+	}, nil
+}
+
+// Matches says whether an element is a record element, given the names of
+// the element and its ancestors, outermost first.
+func (path *XMLRecordPath) Matches(names []string) bool {
+	n := len(path.Components)
+	if len(names) < n || (path.IsAbsolute && len(names) != n) {
+		return false
+	}
+	tail := names[len(names)-n:]
+	for i, component := range path.Components {
+		if component != "*" && component != tail[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Enclosing returns the names of the elements around the records, for
+// writing: all but the last component, with * as the given default name.
+func (path *XMLRecordPath) Enclosing(defaultName string) []string {
+	enclosing := make([]string, 0, len(path.Components))
+	for _, component := range path.Components[:len(path.Components)-1] {
+		if component == "*" {
+			component = defaultName
+		}
+		enclosing = append(enclosing, component)
+	}
+	return enclosing
+}
+
+// RecordName returns the name of the record elements, for writing, or "" if
+// any name matches.
+func (path *XMLRecordPath) RecordName() string {
+	name := path.Components[len(path.Components)-1]
+	if name == "*" {
+		return ""
+	}
+	return name
+}
@@ -0,0 +1,47 @@
+package lib
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestXMLRecordPathAbsolute(t *testing.T) {
+	path, err := NewXMLRecordPath("/feed/entry")
+	assert.Nil(t, err)
+	assert.True(t, path.Matches([]string{"feed", "entry"}))
+	assert.False(t, path.Matches([]string{"feed"}))
+	assert.False(t, path.Matches([]string{"feed", "title"}))
+	assert.False(t, path.Matches([]string{"x", "feed", "entry"}))
+	assert.Equal(t, []string{"feed"}, path.Enclosing("records"))
+	assert.Equal(t, "entry", path.RecordName())
+}
+
+func TestXMLRecordPathRelative(t *testing.T) {
+	for _, spec := range []string{"entry", "//entry"} {
+		path, err := NewXMLRecordPath(spec)
+		assert.Nil(t, err)
+		assert.True(t, path.Matches([]string{"entry"}))
+		assert.True(t, path.Matches([]string{"feed", "entry"}))
+		assert.True(t, path.Matches([]string{"a", "b", "entry"}))
+		assert.False(t, path.Matches([]string{"entry", "title"}))
+		assert.Equal(t, []string{}, path.Enclosing("records"))
+	}
+}
+
+func TestXMLRecordPathWildcards(t *testing.T) {
+	path, err := NewXMLRecordPath("/*/*")
+	assert.Nil(t, err)
+	assert.True(t, path.Matches([]string{"feed", "entry"}))
+	assert.False(t, path.Matches([]string{"feed"}))
+	assert.False(t, path.Matches([]string{"feed", "entry", "title"}))
+	assert.Equal(t, []string{"records"}, path.Enclosing("records"))
+	assert.Equal(t, "", path.RecordName())
+}
+
+func TestXMLRecordPathErrors(t *testing.T) {
+	for _, spec := range []string{"", "/", "//", "/a//b", "a/"} {
+		_, err := NewXMLRecordPath(spec)
+		assert.NotNil(t, err, spec)
+	}
+}
@@ -0,0 +1,217 @@
+// Package logfmt reads and writes logfmt lines: space-separated key=value
+// pairs, as written by many Go logging libraries.
+//
+// Input format examples:
+//
+//	level=info msg=started port=8080    -> level->info, msg->started, port->8080
+//	msg="hello, world" err="said \"no\"" -> msg->hello, world, err->said "no"
+//	debug ts=1                           -> debug->(empty), ts->1
+//
+// Pairs are separated by runs of spaces and/or tabs. Keys run up to the
+// equals sign; values run up to the next space or tab, unless double-quoted,
+// in which case they may contain anything, with backslash escapes as in JSON
+// strings. A key with no equals sign has an empty value, as does a key with
+// an equals sign and nothing after it.
+package logfmt
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// ParseLine splits one logfmt line into its keys and values, in order.
+// Repeated keys are returned as they are.
+func ParseLine(line string) (keys []string, values []string, err error) {
+	i := 0
+	n := len(line)
+
+	for {
+		for i < n && isSpace(line[i]) {
+			i++
+		}
+		if i >= n {
+			return keys, values, nil
+		}
+
+		start := i
+		for i < n && !isSpace(line[i]) && line[i] != '=' {
+			if line[i] == '"' {
+				return nil, nil, fmt.Errorf("unexpected '\"' in key at column %d", i+1)
+			}
+			i++
+		}
+		key := line[start:i]
+		if key == "" {
+			return nil, nil, fmt.Errorf("missing key at column %d", i+1)
+		}
+
+		if i >= n || line[i] != '=' {
+			keys = append(keys, key)
+			values = append(values, "")
+			continue
+		}
+		i++ // the equals sign
+
+		if i < n && line[i] == '"' {
+			value, length, err := unquote(line[i:])
+			if err != nil {
+				return nil, nil, fmt.Errorf("%v at column %d", err, i+1)
+			}
+			i += length
+			if i < n && !isSpace(line[i]) {
+				return nil, nil, fmt.Errorf("unexpected '%c' after closing quote at column %d", line[i], i+1)
+			}
+			keys = append(keys, key)
+			values = append(values, value)
+			continue
+		}
+
+		start = i
+		for i < n && !isSpace(line[i]) {
+			i++
+		}
+		keys = append(keys, key)
+		values = append(values, line[start:i])
+	}
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t'
+}
+
+// unquote decodes the double-quoted string at the start of the input,
+// returning it along with the number of bytes it took up, quotes included.
+// Backslash escapes are as in JSON; other backslashes are kept as-is.
+func unquote(input string) (string, int, error) {
+	var buffer strings.Builder
+	n := len(input)
+	for i := 1; i < n; {
+		c := input[i]
+		if c == '"' {
+			return buffer.String(), i + 1, nil
+		}
+		if c != '\\' || i+1 >= n {
+			buffer.WriteByte(c)
+			i++
+			continue
+		}
+		switch d := input[i+1]; d {
+		case '"', '\\', '/':
+			buffer.WriteByte(d)
+		case 'b':
+			buffer.WriteByte('\b')
+		case 'f':
+			buffer.WriteByte('\f')
+		case 'n':
+			buffer.WriteByte('\n')
+		case 'r':
+			buffer.WriteByte('\r')
+		case 't':
+			buffer.WriteByte('\t')
+		case 'u':
+			r, length, ok := unquoteUnicode(input[i:])
+			if !ok {
+				return "", 0, fmt.Errorf("invalid \\u escape")
+			}
+			buffer.WriteRune(r)
+			i += length
+			continue
+		default:
+			buffer.WriteByte(c)
+			buffer.WriteByte(d)
+		}
+		i += 2
+	}
+	return "", 0, fmt.Errorf("unterminated quoted value")
+}
+
+// unquoteUnicode decodes a \uXXXX escape, or a surrogate pair of them.
+func unquoteUnicode(input string) (rune, int, bool) {
+	r, ok := hex4(input)
+	if !ok {
+		return 0, 0, false
+	}
+	if utf16.IsSurrogate(r) {
+		if r2, ok := hex4(input[6:]); ok {
+			if decoded := utf16.DecodeRune(r, r2); decoded != utf8.RuneError {
+				return decoded, 12, true
+			}
+		}
+		return utf8.RuneError, 6, true
+	}
+	return r, 6, true
+}
+
+func hex4(input string) (rune, bool) {
+	if len(input) < 6 || input[0] != '\\' || input[1] != 'u' {
+		return 0, false
+	}
+	i, err := strconv.ParseUint(input[2:6], 16, 16)
+	if err != nil {
+		return 0, false
+	}
+	return rune(i), true
+}
+
+// FormatKey returns the key as written in logfmt output. Since keys can't be
+// quoted, spaces, equals signs, double quotes, and control characters are
+// replaced by underscores, as is the empty key.
+func FormatKey(key string) string {
+	if key == "" {
+		return "_"
+	}
+	if !strings.ContainsFunc(key, isKeyUnsafe) {
+		return key
+	}
+	return strings.Map(func(r rune) rune {
+		if isKeyUnsafe(r) {
+			return '_'
+		}
+		return r
+	}, key)
+}
+
+func isKeyUnsafe(r rune) bool {
+	return r <= ' ' || r == '=' || r == '"' || r == utf8.RuneError || !unicode.IsPrint(r)
+}
+
+// FormatValue returns the value as written in logfmt output: double-quoted,
+// with JSON-style backslash escapes, if it contains spaces, equals signs,
+// double quotes, backslashes, or control characters; otherwise unchanged.
+func FormatValue(value string) string {
+	if !strings.ContainsFunc(value, isValueUnsafe) {
+		return value
+	}
+	var buffer strings.Builder
+	buffer.WriteByte('"')
+	for _, r := range value {
+		switch r {
+		case '"':
+			buffer.WriteString(`\"`)
+		case '\\':
+			buffer.WriteString(`\\`)
+		case '\n':
+			buffer.WriteString(`\n`)
+		case '\r':
+			buffer.WriteString(`\r`)
+		case '\t':
+			buffer.WriteString(`\t`)
+		default:
+			if r < ' ' || r == 0x7f {
+				fmt.Fprintf(&buffer, `\u%04x`, r)
+			} else {
+				buffer.WriteRune(r)
+			}
+		}
+	}
+	buffer.WriteByte('"')
+	return buffer.String()
+}
+
+func isValueUnsafe(r rune) bool {
+	return r <= ' ' || r == '=' || r == '"' || r == '\\' || r == 0x7f
+}
@@ -0,0 +1,63 @@
+package logfmt
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLine_Basic(t *testing.T) {
+	keys, values, err := ParseLine("level=info  msg=started\tport=8080 level=debug")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"level", "msg", "port", "level"}, keys)
+	assert.Equal(t, []string{"info", "started", "8080", "debug"}, values)
+}
+
+func TestParseLine_Quoted(t *testing.T) {
+	keys, values, err := ParseLine(`msg="hello, world" err="said \"no\"\n" path="C:\data" u="\u00e9\ud83d\ude00"`)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"msg", "err", "path", "u"}, keys)
+	assert.Equal(t, []string{"hello, world", "said \"no\"\n", `C:\data`, "é😀"}, values)
+}
+
+func TestParseLine_EmptyValues(t *testing.T) {
+	keys, values, err := ParseLine(`debug a= b="" url=/x?y=z`)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"debug", "a", "b", "url"}, keys)
+	assert.Equal(t, []string{"", "", "", "/x?y=z"}, values)
+}
+
+func TestParseLine_Errors(t *testing.T) {
+	_, _, err := ParseLine(`msg="unterminated`)
+	assert.Error(t, err)
+	_, _, err = ParseLine(`a=1 =2`)
+	assert.Error(t, err)
+	_, _, err = ParseLine(`a"b=1`)
+	assert.Error(t, err)
+	_, _, err = ParseLine(`a="x"y`)
+	assert.Error(t, err)
+}
+
+func TestFormatKey(t *testing.T) {
+	assert.Equal(t, "level", FormatKey("level"))
+	assert.Equal(t, "a_b_c_d", FormatKey("a b=c\"d"))
+	assert.Equal(t, "_", FormatKey(""))
+}
+
+func TestFormatValue(t *testing.T) {
+	assert.Equal(t, "info", FormatValue("info"))
+	assert.Equal(t, "", FormatValue(""))
+	assert.Equal(t, `"hello world"`, FormatValue("hello world"))
+	assert.Equal(t, `"a=b"`, FormatValue("a=b"))
+	assert.Equal(t, `"said \"no\"\n"`, FormatValue("said \"no\"\n"))
+	assert.Equal(t, `"C:\\temp"`, FormatValue(`C:\temp`))
+	assert.Equal(t, `"\u0001"`, FormatValue("\x01"))
+}
+
+func TestFormatValue_RoundTrip(t *testing.T) {
+	for _, value := range []string{"", "x", "a b", `q"q`, `b\s`, "t\tn\nr\r", "\x01\x7f", "é"} {
+		_, values, err := ParseLine("k=" + FormatValue(value))
+		assert.NoError(t, err)
+		assert.Equal(t, []string{value}, values)
+	}
+}
@@ -0,0 +1,254 @@
+// CBOR decode/encode for Mlrval and Mlrmap.
+// Converts between CBOR (RFC 8949) and Miller's record model, keeping map-key
+// order, which is why maps and arrays are walked here rather than decoded by
+// github.com/fxamacker/cbor/v2 into Go maps; scalars are decoded by it.
+// Integers and floats stay integers and floats; byte strings are bytes.
+// Tags are skipped over, except for bignums, which are ints if they fit and
+// floats otherwise; so epoch-time tags are their seconds, for example.
+// Non-string map keys are stringified. Used by the CBOR record reader and
+// writer.
+
+package mlrval
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+const (
+	cborMajorUint        = 0
+	cborMajorNegativeInt = 1
+	cborMajorBytes       = 2
+	cborMajorText        = 3
+	cborMajorArray       = 4
+	cborMajorMap         = 5
+	cborMajorTag         = 6
+
+	cborTagPositiveBignum = 2
+	cborTagNegativeBignum = 3
+
+	cborBreak = 0xff
+)
+
+// MlrvalDecodeFromCBOR decodes one top-level CBOR data item from the decoder
+// into an *Mlrval. Returns (nil, true, nil) on EOF.
+func MlrvalDecodeFromCBOR(decoder *cbor.Decoder) (*Mlrval, bool, error) {
+	var raw cbor.RawMessage
+	err := decoder.Decode(&raw)
+	if err == io.EOF {
+		return nil, true, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	mv, _, err := mlrvalFromCBOR(raw)
+	if err != nil {
+		return nil, false, err
+	}
+	return mv, false, nil
+}
+
+// mlrvalFromCBOR decodes the data item at the start of the data, returning
+// the rest of the data after it.
+func mlrvalFromCBOR(data []byte) (*Mlrval, []byte, error) {
+	if len(data) == 0 {
+		return nil, nil, fmt.Errorf("unexpected end of CBOR data")
+	}
+	major := data[0] >> 5
+
+	switch major {
+	case cborMajorMap:
+		n, indefinite, headLength, err := cborHead(data)
+		if err != nil {
+			return nil, nil, err
+		}
+		data = data[headLength:]
+		out := FromEmptyMap()
+		for i := uint64(0); indefinite || i < n; i++ {
+			if indefinite && len(data) > 0 && data[0] == cborBreak {
+				data = data[1:]
+				break
+			}
+			var key, value *Mlrval
+			key, data, err = mlrvalFromCBOR(data)
+			if err != nil {
+				return nil, nil, err
+			}
+			value, data, err = mlrvalFromCBOR(data)
+			if err != nil {
+				return nil, nil, err
+			}
+			out.MapPut(FromString(key.String()), value)
+		}
+		return out, data, nil
+
+	case cborMajorArray:
+		n, indefinite, headLength, err := cborHead(data)
+		if err != nil {
+			return nil, nil, err
+		}
+		data = data[headLength:]
+		out := FromEmptyArray()
+		for i := uint64(0); indefinite || i < n; i++ {
+			if indefinite && len(data) > 0 && data[0] == cborBreak {
+				data = data[1:]
+				break
+			}
+			var element *Mlrval
+			element, data, err = mlrvalFromCBOR(data)
+			if err != nil {
+				return nil, nil, err
+			}
+			out.ArrayAppend(element)
+		}
+		return out, data, nil
+
+	case cborMajorTag:
+		tag, _, headLength, err := cborHead(data)
+		if err != nil {
+			return nil, nil, err
+		}
+		if tag != cborTagPositiveBignum && tag != cborTagNegativeBignum {
+			return mlrvalFromCBOR(data[headLength:])
+		}
+	}
+
+	var native interface{}
+	rest, err := cbor.UnmarshalFirst(data, &native)
+	if err != nil {
+		return nil, nil, err
+	}
+	return mlrvalFromCBORNative(native), rest, nil
+}
+
+// cborHead decodes the initial byte and argument of a data item: the count
+// for arrays and maps, or the number for tags.
+func cborHead(data []byte) (argument uint64, indefinite bool, headLength int, err error) {
+	additional := data[0] & 0x1f
+	switch {
+	case additional < 24:
+		return uint64(additional), false, 1, nil
+	case additional == 31:
+		return 0, true, 1, nil
+	case additional > 27:
+		return 0, false, 0, fmt.Errorf("invalid CBOR additional information %d", additional)
+	}
+	headLength = 1 + 1<<(additional-24)
+	if len(data) < headLength {
+		return 0, false, 0, fmt.Errorf("unexpected end of CBOR data")
+	}
+	for _, b := range data[1:headLength] {
+		argument = argument<<8 | uint64(b)
+	}
+	return argument, false, headLength, nil
+}
+
+func mlrvalFromCBORNative(native interface{}) *Mlrval {
+	switch val := native.(type) {
+	case nil:
+		return NULL
+	case bool:
+		return FromBool(val)
+	case int64:
+		return FromInt(val)
+	case uint64:
+		if val <= 1<<63-1 {
+			return FromInt(int64(val))
+		}
+		return FromFloat(float64(val))
+	case float64:
+		return FromFloat(val)
+	case string:
+		return FromString(val)
+	case []byte:
+		return FromBytes(val)
+	case big.Int:
+		return mlrvalFromBigInt(&val)
+	case *big.Int:
+		return mlrvalFromBigInt(val)
+	default:
+		return FromString(fmt.Sprint(val))
+	}
+}
+
+// mlrvalFromBigInt returns an int if it fits, else a float, as for unsigned
+// ints which are too large.
+func mlrvalFromBigInt(i *big.Int) *Mlrval {
+	if i.IsInt64() {
+		return FromInt(i.Int64())
+	}
+	f, _ := new(big.Float).SetInt(i).Float64()
+	return FromFloat(f)
+}
+
+// MlrmapAppendCBOR appends a record to the buffer as a CBOR map, in key order.
+func MlrmapAppendCBOR(buffer []byte, mlrmap *Mlrmap) []byte {
+	buffer = cborAppendHead(buffer, cborMajorMap, uint64(mlrmap.FieldCount))
+	for pe := mlrmap.Head; pe != nil; pe = pe.Next {
+		buffer = cborAppendHead(buffer, cborMajorText, uint64(len(pe.Key)))
+		buffer = append(buffer, pe.Key...)
+		buffer = mlrvalAppendCBOR(buffer, pe.Value)
+	}
+	return buffer
+}
+
+func mlrvalAppendCBOR(buffer []byte, mv *Mlrval) []byte {
+	switch mv.Type() {
+	case MT_ABSENT, MT_NULL:
+		return append(buffer, 0xf6)
+	case MT_INT:
+		i := mv.intf.(int64)
+		if i >= 0 {
+			return cborAppendHead(buffer, cborMajorUint, uint64(i))
+		}
+		return cborAppendHead(buffer, cborMajorNegativeInt, uint64(-1-i))
+	case MT_FLOAT:
+		buffer = append(buffer, 0xfb)
+		return binary.BigEndian.AppendUint64(buffer, math.Float64bits(mv.intf.(float64)))
+	case MT_BOOL:
+		if mv.intf.(bool) {
+			return append(buffer, 0xf5)
+		}
+		return append(buffer, 0xf4)
+	case MT_BYTES:
+		b := mv.intf.([]byte)
+		buffer = cborAppendHead(buffer, cborMajorBytes, uint64(len(b)))
+		return append(buffer, b...)
+	case MT_ARRAY:
+		array := mv.intf.([]*Mlrval)
+		buffer = cborAppendHead(buffer, cborMajorArray, uint64(len(array)))
+		for _, element := range array {
+			buffer = mlrvalAppendCBOR(buffer, element)
+		}
+		return buffer
+	case MT_MAP:
+		return MlrmapAppendCBOR(buffer, mv.intf.(*Mlrmap))
+	default:
+		s := mv.String()
+		buffer = cborAppendHead(buffer, cborMajorText, uint64(len(s)))
+		return append(buffer, s...)
+	}
+}
+
+// cborAppendHead appends the initial byte and argument of a data item, in
+// the shortest form.
+func cborAppendHead(buffer []byte, major byte, argument uint64) []byte {
+	major <<= 5
+	switch {
+	case argument < 24:
+		return append(buffer, major|byte(argument))
+	case argument <= math.MaxUint8:
+		return append(buffer, major|24, byte(argument))
+	case argument <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(buffer, major|25), uint16(argument))
+	case argument <= math.MaxUint32:
+		return binary.BigEndian.AppendUint32(append(buffer, major|26), uint32(argument))
+	default:
+		return binary.BigEndian.AppendUint64(append(buffer, major|27), argument)
+	}
+}
@@ -0,0 +1,172 @@
+// MessagePack decode/encode for Mlrval and Mlrmap.
+// Converts between MessagePack (via github.com/vmihailenco/msgpack/v5) and
+// Miller's record model, keeping map-key order. Integers and floats stay
+// integers and floats; bin values are bytes; timestamps are epoch seconds.
+// Non-string map keys are stringified. Used by the MessagePack record reader
+// and writer.
+
+package mlrval
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/vmihailenco/msgpack/v5"
+	"github.com/vmihailenco/msgpack/v5/msgpcode"
+)
+
+// MlrvalDecodeFromMsgpack decodes one top-level MessagePack value from the
+// decoder into an *Mlrval. Returns (nil, true, nil) on EOF.
+func MlrvalDecodeFromMsgpack(decoder *msgpack.Decoder) (*Mlrval, bool, error) {
+	_, err := decoder.PeekCode()
+	if err == io.EOF {
+		return nil, true, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	mv, err := mlrvalFromMsgpack(decoder)
+	if err == io.EOF {
+		// Within a value
+		return nil, false, io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return mv, false, nil
+}
+
+func mlrvalFromMsgpack(decoder *msgpack.Decoder) (*Mlrval, error) {
+	code, err := decoder.PeekCode()
+	if err != nil {
+		return nil, err
+	}
+
+	if msgpcode.IsFixedMap(code) || code == msgpcode.Map16 || code == msgpcode.Map32 {
+		n, err := decoder.DecodeMapLen()
+		if err != nil {
+			return nil, err
+		}
+		out := FromEmptyMap()
+		for i := 0; i < n; i++ {
+			key, err := mlrvalFromMsgpack(decoder)
+			if err != nil {
+				return nil, err
+			}
+			value, err := mlrvalFromMsgpack(decoder)
+			if err != nil {
+				return nil, err
+			}
+			out.MapPut(FromString(key.String()), value)
+		}
+		return out, nil
+	}
+
+	if msgpcode.IsFixedArray(code) || code == msgpcode.Array16 || code == msgpcode.Array32 {
+		n, err := decoder.DecodeArrayLen()
+		if err != nil {
+			return nil, err
+		}
+		out := FromEmptyArray()
+		for i := 0; i < n; i++ {
+			element, err := mlrvalFromMsgpack(decoder)
+			if err != nil {
+				return nil, err
+			}
+			out.ArrayAppend(element)
+		}
+		return out, nil
+	}
+
+	native, err := decoder.DecodeInterface()
+	if err != nil {
+		return nil, err
+	}
+	switch val := native.(type) {
+	case nil:
+		return NULL, nil
+	case bool:
+		return FromBool(val), nil
+	case int8:
+		return FromInt(int64(val)), nil
+	case int16:
+		return FromInt(int64(val)), nil
+	case int32:
+		return FromInt(int64(val)), nil
+	case int64:
+		return FromInt(val), nil
+	case uint8:
+		return FromInt(int64(val)), nil
+	case uint16:
+		return FromInt(int64(val)), nil
+	case uint32:
+		return FromInt(int64(val)), nil
+	case uint64:
+		if val <= 1<<63-1 {
+			return FromInt(int64(val)), nil
+		}
+		return FromFloat(float64(val)), nil
+	case float32:
+		return FromFloat(float64(val)), nil
+	case float64:
+		return FromFloat(val), nil
+	case string:
+		return FromString(val), nil
+	case []byte:
+		return FromBytes(val), nil
+	case time.Time:
+		if val.Nanosecond() == 0 {
+			return FromInt(val.Unix()), nil
+		}
+		return FromFloat(float64(val.UnixNano()) / 1e9), nil
+	default:
+		return FromString(fmt.Sprint(val)), nil
+	}
+}
+
+// MlrmapEncodeToMsgpack writes a record as a MessagePack map, in key order.
+func MlrmapEncodeToMsgpack(mlrmap *Mlrmap, encoder *msgpack.Encoder) error {
+	if err := encoder.EncodeMapLen(int(mlrmap.FieldCount)); err != nil {
+		return err
+	}
+	for pe := mlrmap.Head; pe != nil; pe = pe.Next {
+		if err := encoder.EncodeString(pe.Key); err != nil {
+			return err
+		}
+		if err := mlrvalEncodeToMsgpack(pe.Value, encoder); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func mlrvalEncodeToMsgpack(mv *Mlrval, encoder *msgpack.Encoder) error {
+	switch mv.Type() {
+	case MT_ABSENT, MT_NULL:
+		return encoder.EncodeNil()
+	case MT_INT:
+		return encoder.EncodeInt(mv.intf.(int64))
+	case MT_FLOAT:
+		return encoder.EncodeFloat64(mv.intf.(float64))
+	case MT_BOOL:
+		return encoder.EncodeBool(mv.intf.(bool))
+	case MT_BYTES:
+		return encoder.EncodeBytes(mv.intf.([]byte))
+	case MT_ARRAY:
+		array := mv.intf.([]*Mlrval)
+		if err := encoder.EncodeArrayLen(len(array)); err != nil {
+			return err
+		}
+		for _, element := range array {
+			if err := mlrvalEncodeToMsgpack(element, encoder); err != nil {
+				return err
+			}
+		}
+		return nil
+	case MT_MAP:
+		return MlrmapEncodeToMsgpack(mv.intf.(*Mlrmap), encoder)
+	default:
+		return encoder.EncodeString(mv.String())
+	}
+}
@@ -0,0 +1,123 @@
+// Tests for MessagePack and CBOR decode/encode.
+
+package mlrval
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+func newMsgpackCBORTestRecord() *Mlrmap {
+	inner := NewMlrmap()
+	inner.PutCopy("array", FromArray([]*Mlrval{FromInt(1), FromFloat(2.5), FromString("s")}))
+	record := NewMlrmap()
+	record.PutCopy("z", FromInt(-7))
+	record.PutCopy("float", FromFloat(3.0))
+	record.PutCopy("big", FromInt(1<<62))
+	record.PutCopy("bytes", FromBytes([]byte{0x00, 0xff}))
+	record.PutCopy("string", FromString("hello"))
+	record.PutCopy("empty", FromString(""))
+	record.PutCopy("bool", FromBool(true))
+	record.PutCopy("inferred", FromInferredType("0x10"))
+	record.PutCopy("map", FromMap(inner))
+	return record
+}
+
+func checkMsgpackCBORTestRecord(t *testing.T, mv *Mlrval) {
+	assert.True(t, mv.IsMap())
+	record := mv.GetMap()
+	assert.Equal(t, "z,float,big,bytes,string,empty,bool,inferred,map", record.GetKeysJoined())
+
+	assert.Equal(t, MT_INT, record.Get("z").Type())
+	assert.Equal(t, int64(-7), record.Get("z").AcquireIntValue())
+	assert.Equal(t, MT_FLOAT, record.Get("float").Type())
+	assert.Equal(t, 3.0, record.Get("float").AcquireFloatValue())
+	assert.Equal(t, int64(1<<62), record.Get("big").AcquireIntValue())
+	assert.Equal(t, MT_BYTES, record.Get("bytes").Type())
+	assert.Equal(t, []byte{0x00, 0xff}, record.Get("bytes").AcquireBytesValue())
+	assert.Equal(t, "hello", record.Get("string").String())
+	assert.Equal(t, MT_VOID, record.Get("empty").Type())
+	assert.Equal(t, MT_BOOL, record.Get("bool").Type())
+	assert.Equal(t, int64(16), record.Get("inferred").AcquireIntValue())
+
+	inner := record.Get("map").GetMap()
+	array := inner.Get("array").GetArray()
+	assert.Equal(t, 3, len(array))
+	assert.Equal(t, MT_INT, array[0].Type())
+	assert.Equal(t, MT_FLOAT, array[1].Type())
+	assert.Equal(t, MT_STRING, array[2].Type())
+}
+
+func TestMsgpackRoundTrip(t *testing.T) {
+	var buffer bytes.Buffer
+	encoder := msgpack.NewEncoder(&buffer)
+	assert.NoError(t, MlrmapEncodeToMsgpack(newMsgpackCBORTestRecord(), encoder))
+	assert.NoError(t, MlrmapEncodeToMsgpack(newMsgpackCBORTestRecord(), encoder))
+
+	decoder := msgpack.NewDecoder(&buffer)
+	for i := 0; i < 2; i++ {
+		mv, eof, err := MlrvalDecodeFromMsgpack(decoder)
+		assert.NoError(t, err)
+		assert.False(t, eof)
+		checkMsgpackCBORTestRecord(t, mv)
+	}
+	_, eof, err := MlrvalDecodeFromMsgpack(decoder)
+	assert.NoError(t, err)
+	assert.True(t, eof)
+}
+
+func TestCBORRoundTrip(t *testing.T) {
+	data := MlrmapAppendCBOR(nil, newMsgpackCBORTestRecord())
+	data = MlrmapAppendCBOR(data, newMsgpackCBORTestRecord())
+
+	decoder := cbor.NewDecoder(bytes.NewReader(data))
+	for i := 0; i < 2; i++ {
+		mv, eof, err := MlrvalDecodeFromCBOR(decoder)
+		assert.NoError(t, err)
+		assert.False(t, eof)
+		checkMsgpackCBORTestRecord(t, mv)
+	}
+	_, eof, err := MlrvalDecodeFromCBOR(decoder)
+	assert.NoError(t, err)
+	assert.True(t, eof)
+}
+
+func TestCBOREncodingIsStandard(t *testing.T) {
+	// What the library makes of our encoding
+	record := NewMlrmap()
+	record.PutCopy("a", FromInt(-1000))
+	record.PutCopy("b", FromFloat(1.5))
+	record.PutCopy("c", FromBytes([]byte("xyz")))
+	record.PutCopy("d", FromInt(1<<40))
+	var native map[string]interface{}
+	assert.NoError(t, cbor.Unmarshal(MlrmapAppendCBOR(nil, record), &native))
+	assert.Equal(t, int64(-1000), native["a"])
+	assert.Equal(t, 1.5, native["b"])
+	assert.Equal(t, []byte("xyz"), native["c"])
+	assert.Equal(t, uint64(1<<40), native["d"])
+}
+
+func TestCBORIndefiniteLengthAndTags(t *testing.T) {
+	// 55799(self-describe) {_ "a": [_ 1, 2], "b": 1(1700000000), "c": 2(h'010000000000000000')}
+	data := []byte{
+		0xd9, 0xd9, 0xf7,
+		0xbf,
+		0x61, 'a', 0x9f, 0x01, 0x02, 0xff,
+		0x61, 'b', 0xc1, 0x1a, 0x65, 0x53, 0xf1, 0x00,
+		0x61, 'c', 0xc2, 0x49, 0x01, 0, 0, 0, 0, 0, 0, 0, 0,
+		0xff,
+	}
+	mv, eof, err := MlrvalDecodeFromCBOR(cbor.NewDecoder(bytes.NewReader(data)))
+	assert.NoError(t, err)
+	assert.False(t, eof)
+	record := mv.GetMap()
+	assert.Equal(t, "a,b,c", record.GetKeysJoined())
+	assert.Equal(t, 2, len(record.Get("a").GetArray()))
+	assert.Equal(t, int64(1700000000), record.Get("b").AcquireIntValue())
+	assert.Equal(t, MT_FLOAT, record.Get("c").Type())
+	assert.Equal(t, 18446744073709551616.0, record.Get("c").AcquireFloatValue())
+}
@@ -0,0 +1,230 @@
+// TOML decode/encode for Mlrval and Mlrmap. Values are converted from the
+// nodes of github.com/pelletier/go-toml/v2/unstable's parser, rather than
+// unmarshaled into Go maps, in order to keep key order. Strings stay strings;
+// integers and floats are type-inferred from their text, as with JSON, so
+// 0xff and the like keep their formatting; dates and times are strings.
+// Tables within values, via dotted keys or inline tables, are maps. Used by
+// the TOML record reader and writer.
+
+package mlrval
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2/unstable"
+
+	"github.com/johnkerl/miller/v6/pkg/lib"
+)
+
+// MlrvalFromTOMLValue converts a value node: anything on the right-hand side
+// of a key/value expression.
+func MlrvalFromTOMLValue(node *unstable.Node) (*Mlrval, error) {
+	switch node.Kind {
+	case unstable.String:
+		return FromString(string(node.Data)), nil
+	case unstable.Bool:
+		return FromBool(string(node.Data) == "true"), nil
+	case unstable.Integer:
+		return FromInferredType(strings.ReplaceAll(string(node.Data), "_", "")), nil
+	case unstable.Float:
+		text := strings.ReplaceAll(string(node.Data), "_", "")
+		switch strings.TrimLeft(text, "+-") {
+		case "inf":
+			if strings.HasPrefix(text, "-") {
+				return FromFloat(math.Inf(-1)), nil
+			}
+			return FromFloat(math.Inf(1)), nil
+		case "nan":
+			return FromFloat(math.NaN()), nil
+		}
+		return FromInferredType(text), nil
+	case unstable.LocalDate, unstable.LocalTime, unstable.LocalDateTime, unstable.DateTime:
+		return FromString(string(node.Data)), nil
+	case unstable.Array:
+		array := make([]*Mlrval, 0)
+		for it := node.Children(); it.Next(); {
+			element, err := MlrvalFromTOMLValue(it.Node())
+			if err != nil {
+				return nil, err
+			}
+			array = append(array, element)
+		}
+		return FromArray(array), nil
+	case unstable.InlineTable:
+		mlrmap := NewMlrmap()
+		for it := node.Children(); it.Next(); {
+			if err := MlrmapPutTOMLKeyValue(mlrmap, it.Node()); err != nil {
+				return nil, err
+			}
+		}
+		return FromMap(mlrmap), nil
+	default:
+		return nil, fmt.Errorf("unexpected TOML node kind %s", node.Kind)
+	}
+}
+
+// MlrmapPutTOMLKeyValue puts the value of a key/value node into the map. A
+// dotted key such as a.b.c = 1 puts 1 at c within a map at b within a map at
+// a, creating the maps as needed.
+func MlrmapPutTOMLKeyValue(mlrmap *Mlrmap, node *unstable.Node) error {
+	value, err := MlrvalFromTOMLValue(node.Value())
+	if err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, 1)
+	for it := node.Key(); it.Next(); {
+		keys = append(keys, string(it.Node().Data))
+	}
+
+	for _, key := range keys[:len(keys)-1] {
+		existing := mlrmap.Get(key)
+		if existing == nil {
+			existing = FromEmptyMap()
+			mlrmap.PutReference(key, existing)
+		} else if !existing.IsMap() {
+			return fmt.Errorf("TOML key %s is not a table", lib.TOMLFormatDottedKey(keys))
+		}
+		mlrmap = existing.GetMap()
+	}
+	mlrmap.PutReference(keys[len(keys)-1], value)
+	return nil
+}
+
+// MlrvalAppendTOMLKeyValue appends a field as a TOML key/value line. A map is
+// written using dotted keys, one line per field within it, so that a record
+// read from a TOML table with dotted keys is written back the same way.
+func MlrvalAppendTOMLKeyValue(buffer []byte, key string, value *Mlrval) []byte {
+	return mlrvalAppendTOMLKeyValue(buffer, lib.TOMLFormatKey(key), value)
+}
+
+func mlrvalAppendTOMLKeyValue(buffer []byte, formattedKey string, value *Mlrval) []byte {
+	if value.IsMap() && !value.GetMap().IsEmpty() {
+		for pe := value.GetMap().Head; pe != nil; pe = pe.Next {
+			buffer = mlrvalAppendTOMLKeyValue(buffer, formattedKey+"."+lib.TOMLFormatKey(pe.Key), pe.Value)
+		}
+		return buffer
+	}
+	buffer = append(buffer, formattedKey...)
+	buffer = append(buffer, " = "...)
+	buffer = mlrvalAppendTOML(buffer, value)
+	return append(buffer, '\n')
+}
+
+// mlrvalAppendTOML appends the value as written on the right-hand side of a
+// key/value line, with maps as inline tables.
+func mlrvalAppendTOML(buffer []byte, mv *Mlrval) []byte {
+	switch mv.Type() {
+	case MT_INT:
+		s := mv.String()
+		if !tomlIntegerRegex.MatchString(s) {
+			// E.g. leading zeroes or a negative hex number, which TOML doesn't allow
+			s = strconv.FormatInt(mv.intf.(int64), 10)
+		}
+		return append(buffer, s...)
+	case MT_FLOAT:
+		f := mv.intf.(float64)
+		if math.IsInf(f, 1) {
+			return append(buffer, "inf"...)
+		} else if math.IsInf(f, -1) {
+			return append(buffer, "-inf"...)
+		} else if math.IsNaN(f) {
+			return append(buffer, "nan"...)
+		}
+		s := mv.String()
+		if !tomlFloatRegex.MatchString(s) {
+			// E.g. .5 or 5., which TOML doesn't allow, or formatting from --ofmt
+			s = strconv.FormatFloat(f, 'g', -1, 64)
+			if !strings.ContainsAny(s, ".e") {
+				s += ".0"
+			}
+		}
+		return append(buffer, s...)
+	case MT_BOOL:
+		return strconv.AppendBool(buffer, mv.intf.(bool))
+	case MT_ARRAY:
+		buffer = append(buffer, '[')
+		for i, element := range mv.intf.([]*Mlrval) {
+			if i > 0 {
+				buffer = append(buffer, ", "...)
+			}
+			buffer = mlrvalAppendTOML(buffer, element)
+		}
+		return append(buffer, ']')
+	case MT_MAP:
+		mlrmap := mv.intf.(*Mlrmap)
+		if mlrmap.IsEmpty() {
+			return append(buffer, "{}"...)
+		}
+		buffer = append(buffer, "{ "...)
+		for pe := mlrmap.Head; pe != nil; pe = pe.Next {
+			if pe != mlrmap.Head {
+				buffer = append(buffer, ", "...)
+			}
+			buffer = append(buffer, lib.TOMLFormatKey(pe.Key)...)
+			buffer = append(buffer, " = "...)
+			buffer = mlrvalAppendTOML(buffer, pe.Value)
+		}
+		return append(buffer, " }"...)
+	default:
+		s := mv.String()
+		if isTOMLDatetime(s) {
+			// Dates and times are read as strings; write them back as they were.
+			return append(buffer, s...)
+		}
+		return append(buffer, lib.TOMLQuoteString(s)...)
+	}
+}
+
+var tomlIntegerRegex = regexp.MustCompile(
+	`^([+-]?(0|[1-9](_?[0-9])*)|0x[0-9A-Fa-f](_?[0-9A-Fa-f])*|0o[0-7](_?[0-7])*|0b[01](_?[01])*)$`,
+)
+
+var tomlFloatRegex = regexp.MustCompile(
+	`^[+-]?(0|[1-9](_?[0-9])*)(\.[0-9](_?[0-9])*)?([eE][+-]?[0-9](_?[0-9])*)?$`,
+)
+
+var tomlDatetimeRegex = regexp.MustCompile(
+	`^(\d{4}-\d{2}-\d{2})?([Tt ]?\d{2}:\d{2}:\d{2}(\.\d+)?)?([Zz]|[+-]\d{2}:\d{2})?$`,
+)
+
+// isTOMLDatetime tells whether the string is a TOML offset or local
+// date-time, local date, or local time, as opposed to a string which merely
+// looks like one, such as 2024-02-30.
+func isTOMLDatetime(s string) bool {
+	matches := tomlDatetimeRegex.FindStringSubmatch(s)
+	if matches == nil {
+		return false
+	}
+	date, clock, offset := matches[1], matches[2], matches[4]
+	if clock == "" {
+		// A date alone, without offset
+		if date == "" || offset != "" {
+			return false
+		}
+	} else if date == "" {
+		// A time alone, without separator or offset
+		if clock[0] < '0' || clock[0] > '9' || offset != "" {
+			return false
+		}
+	} else if clock[0] >= '0' && clock[0] <= '9' {
+		return false
+	}
+	if date != "" {
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			return false
+		}
+	}
+	if clock != "" {
+		clock = strings.TrimLeft(clock, "Tt ")
+		if _, err := time.Parse("15:04:05", clock[:8]); err != nil {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,103 @@
+// Tests for TOML decode/encode (MlrmapPutTOMLKeyValue, MlrvalAppendTOMLKeyValue).
+
+package mlrval
+
+import (
+	"math"
+	"testing"
+
+	"github.com/pelletier/go-toml/v2/unstable"
+	"github.com/stretchr/testify/assert"
+)
+
+// mlrmapFromTOML puts the key/values of a TOML document without tables into
+// a map.
+func mlrmapFromTOML(t *testing.T, document string) *Mlrmap {
+	mlrmap := NewMlrmap()
+	parser := unstable.Parser{}
+	parser.Reset([]byte(document))
+	for parser.NextExpression() {
+		expression := parser.Expression()
+		if expression.Kind == unstable.KeyValue {
+			assert.NoError(t, MlrmapPutTOMLKeyValue(mlrmap, expression))
+		}
+	}
+	assert.NoError(t, parser.Error())
+	return mlrmap
+}
+
+func TestMlrmapPutTOMLKeyValueScalars(t *testing.T) {
+	mlrmap := mlrmapFromTOML(t, `
+s = "1"
+i = 1_000
+h = 0xff
+f = 2.5
+inf = -inf
+b = true
+d = 1979-05-27
+`)
+	assert.True(t, mlrmap.Get("s").IsString())
+	i, _ := mlrmap.Get("i").GetIntValue()
+	assert.Equal(t, int64(1000), i)
+	h, _ := mlrmap.Get("h").GetIntValue()
+	assert.Equal(t, int64(255), h)
+	assert.Equal(t, "0xff", mlrmap.Get("h").String())
+	assert.True(t, mlrmap.Get("f").IsFloat())
+	assert.Equal(t, "-Inf", mlrmap.Get("inf").String())
+	assert.True(t, mlrmap.Get("b").IsBool())
+	assert.Equal(t, "1979-05-27", mlrmap.Get("d").String())
+}
+
+func TestMlrmapPutTOMLKeyValueNested(t *testing.T) {
+	mlrmap := mlrmapFromTOML(t, `
+a.b = 1
+a.c = { d = [1, "x"] }
+`)
+	a := mlrmap.Get("a").GetMap()
+	assert.Equal(t, "b,c", a.GetKeysJoined())
+	d := a.Get("c").GetMap().Get("d").GetArray()
+	assert.Len(t, d, 2)
+	assert.True(t, d[0].IsInt())
+	assert.True(t, d[1].IsString())
+}
+
+func TestMlrvalAppendTOMLKeyValue(t *testing.T) {
+	mlrmap := mlrmapFromTOML(t, `
+"key with space" = "say \"hi\""
+tls.cert = "a.pem"
+arr = [{ x = 1 }, []]
+empty = {}
+when = 2024-05-01T12:30:00Z
+`)
+	buffer := []byte{}
+	for pe := mlrmap.Head; pe != nil; pe = pe.Next {
+		buffer = MlrvalAppendTOMLKeyValue(buffer, pe.Key, pe.Value)
+	}
+	assert.Equal(t, `"key with space" = "say \"hi\""
+tls.cert = "a.pem"
+arr = [{ x = 1 }, []]
+empty = {}
+when = 2024-05-01T12:30:00Z
+`, string(buffer))
+}
+
+func TestMlrvalAppendTOMLNumbers(t *testing.T) {
+	assert.Equal(t, "0.5", string(mlrvalAppendTOML(nil, FromInferredType(".5"))))
+	assert.Equal(t, "5.0", string(mlrvalAppendTOML(nil, FromInferredType("5."))))
+	assert.Equal(t, "-255", string(mlrvalAppendTOML(nil, FromInferredType("-0xff"))))
+	assert.Equal(t, "1e5", string(mlrvalAppendTOML(nil, FromInferredType("1e5"))))
+	assert.Equal(t, "nan", string(mlrvalAppendTOML(nil, FromFloat(math.NaN()))))
+}
+
+func TestIsTOMLDatetime(t *testing.T) {
+	assert.True(t, isTOMLDatetime("1979-05-27"))
+	assert.True(t, isTOMLDatetime("1979-05-27T07:32:00"))
+	assert.True(t, isTOMLDatetime("1979-05-27 07:32:00.999-07:00"))
+	assert.True(t, isTOMLDatetime("07:32:00"))
+	assert.False(t, isTOMLDatetime("2024-02-30"))
+	assert.False(t, isTOMLDatetime("07:32:00Z"))
+	assert.False(t, isTOMLDatetime("1979-05-27Z"))
+	assert.False(t, isTOMLDatetime("1979-05-2707:32:00"))
+	assert.False(t, isTOMLDatetime("25:00:00"))
+	assert.False(t, isTOMLDatetime(""))
+}
@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"io"
 	"sort"
+	"strings"
 
 	"gopkg.in/yaml.v3"
 )
@@ -35,6 +36,51 @@ func MlrvalDecodeFromYAML(decoder *yaml.Decoder) (*Mlrval, bool, error) {
 	return mv, false, nil
 }
 
+// MlrvalFromYAMLNode converts a node from yaml.Decoder, such as a document's
+// top-level map or an element of its top-level array, into *Mlrval. Aliases
+// and merge keys are resolved as for MlrvalDecodeFromYAML.
+func MlrvalFromYAMLNode(node *yaml.Node) (*Mlrval, error) {
+	var doc interface{}
+	if err := node.Decode(&doc); err != nil {
+		return nil, err
+	}
+	return mlrvalFromYAMLNative(doc)
+}
+
+// YAMLCommentText returns the head comments of the given nodes, one per
+// line, without their leading "#" and the space after it. For a record's
+// comment, these are its document's node, its map's node, and its map's first
+// key's node, which is where yaml.v3 puts comments before a document's first
+// key.
+func YAMLCommentText(nodes ...*yaml.Node) string {
+	lines := make([]string, 0)
+	for _, node := range nodes {
+		if node == nil || node.HeadComment == "" {
+			continue
+		}
+		for _, line := range strings.Split(node.HeadComment, "\n") {
+			line = strings.TrimPrefix(line, "#")
+			line = strings.TrimPrefix(line, " ")
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// YAMLHeadComment is the inverse of YAMLCommentText: it makes each line of
+// the text into a YAML comment line.
+func YAMLHeadComment(text string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if line == "" {
+			lines[i] = "#"
+		} else {
+			lines[i] = "# " + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
 // mlrvalFromYAMLNative converts a YAML-decoded value (map[interface{}]interface{},
 // []interface{}, or scalar) into *Mlrval.
 func mlrvalFromYAMLNative(v interface{}) (*Mlrval, error) {
@@ -224,3 +224,29 @@ func TestMlrmapToYAMLNativeNested(t *testing.T) {
 	assert.True(t, strings.Contains(string(out), "nested:"))
 	assert.True(t, strings.Contains(string(out), "p: 1"))
 }
+
+func TestMlrvalFromYAMLNodeAliasesAndMerges(t *testing.T) {
+	input := "base: &base {x: 1, y: 2}\ncopy: *base\nmerged:\n  <<: *base\n  y: 3\n"
+	var node yaml.Node
+	assert.NoError(t, yaml.Unmarshal([]byte(input), &node))
+
+	mv, err := MlrvalFromYAMLNode(node.Content[0])
+	assert.NoError(t, err)
+	record := mv.GetMap()
+	assert.Equal(t, "x,y", record.Get("copy").GetMap().GetKeysJoined())
+	merged := record.Get("merged").GetMap()
+	assert.Equal(t, "1", merged.Get("x").String())
+	assert.Equal(t, "3", merged.Get("y").String())
+}
+
+func TestYAMLCommentText(t *testing.T) {
+	input := "# top\n\n# first\n#\n#  indented\na: 1\n"
+	var node yaml.Node
+	assert.NoError(t, yaml.Unmarshal([]byte(input), &node))
+	mapping := node.Content[0]
+
+	text := YAMLCommentText(&node, mapping, mapping.Content[0], nil)
+	assert.Equal(t, "top\nfirst\n\n indented", text)
+	assert.Equal(t, "# top\n# first\n#\n#  indented", YAMLHeadComment(text))
+	assert.Equal(t, "", YAMLCommentText(mapping))
+}
@@ -0,0 +1,166 @@
+// Schema inference for the record-writers needing a schema (Parquet, Arrow, Avro).
+//
+// Those formats need a schema up front, while Miller records are schema-free.
+// The writers buffer some records and infer a schema from them: the union of
+// their keys in first-seen order, with each column's type being the narrowest
+// one which holds all of that column's values.
+
+package output
+
+import (
+	"fmt"
+
+	"github.com/johnkerl/miller/v6/pkg/mlrval"
+)
+
+type tColumnKind int
+
+const (
+	columnKindUnknown tColumnKind = iota // only empty values seen
+	columnKindInt
+	columnKindFloat
+	columnKindBool
+	columnKindString
+	columnKindBytes
+	columnKindMap
+	columnKindArray
+)
+
+var columnKindNames = map[tColumnKind]string{
+	columnKindUnknown: "empty",
+	columnKindInt:     "int",
+	columnKindFloat:   "float",
+	columnKindBool:    "boolean",
+	columnKindString:  "string",
+	columnKindBytes:   "bytes",
+	columnKindMap:     "map",
+	columnKindArray:   "array",
+}
+
+type columnTypeInferrer struct {
+	kind tColumnKind
+
+	// For maps
+	keys     []string
+	children map[string]*columnTypeInferrer
+
+	// For arrays
+	element *columnTypeInferrer
+}
+
+func newColumnTypeInferrer() *columnTypeInferrer {
+	return &columnTypeInferrer{
+		kind:     columnKindMap,
+		keys:     make([]string, 0),
+		children: make(map[string]*columnTypeInferrer),
+	}
+}
+
+func (inferrer *columnTypeInferrer) addRecord(record *mlrval.Mlrmap) error {
+	for pe := record.Head; pe != nil; pe = pe.Next {
+		if err := inferrer.addField(pe.Key, pe.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (inferrer *columnTypeInferrer) addField(key string, value *mlrval.Mlrval) error {
+	child, ok := inferrer.children[key]
+	if !ok {
+		child = &columnTypeInferrer{}
+		inferrer.keys = append(inferrer.keys, key)
+		inferrer.children[key] = child
+	}
+	if err := child.addValue(value); err != nil {
+		return fmt.Errorf("field \"%s\": %v", key, err)
+	}
+	return nil
+}
+
+func (inferrer *columnTypeInferrer) addValue(value *mlrval.Mlrval) error {
+	kind := columnKindOf(value)
+	if kind == columnKindUnknown {
+		return nil
+	}
+
+	if inferrer.kind == columnKindUnknown {
+		inferrer.kind = kind
+		if kind == columnKindMap {
+			inferrer.keys = make([]string, 0)
+			inferrer.children = make(map[string]*columnTypeInferrer)
+		} else if kind == columnKindArray {
+			inferrer.element = &columnTypeInferrer{}
+		}
+	} else if inferrer.kind != kind {
+		merged, ok := mergeColumnKinds(inferrer.kind, kind)
+		if !ok {
+			return fmt.Errorf(
+				"cannot write both %s and %s values in the same column",
+				columnKindNames[inferrer.kind], columnKindNames[kind],
+			)
+		}
+		inferrer.kind = merged
+	}
+
+	if kind == columnKindMap {
+		for pe := value.GetMap().Head; pe != nil; pe = pe.Next {
+			if err := inferrer.addField(pe.Key, pe.Value); err != nil {
+				return err
+			}
+		}
+	} else if kind == columnKindArray {
+		for _, element := range value.GetArray() {
+			if err := inferrer.element.addValue(element); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func columnKindOf(value *mlrval.Mlrval) tColumnKind {
+	switch value.Type() {
+	case mlrval.MT_INT:
+		return columnKindInt
+	case mlrval.MT_FLOAT:
+		return columnKindFloat
+	case mlrval.MT_BOOL:
+		return columnKindBool
+	case mlrval.MT_STRING:
+		return columnKindString
+	case mlrval.MT_BYTES:
+		return columnKindBytes
+	case mlrval.MT_MAP:
+		// Parquet has no empty groups, so empty maps are treated as nulls.
+		if value.GetMap().IsEmpty() {
+			return columnKindUnknown
+		}
+		return columnKindMap
+	case mlrval.MT_ARRAY:
+		return columnKindArray
+	default:
+		return columnKindUnknown
+	}
+}
+
+// mergeColumnKinds widens int and float to float, and mixed scalars to
+// string. Maps and arrays don't mix with anything else.
+func mergeColumnKinds(a, b tColumnKind) (tColumnKind, bool) {
+	if a == columnKindMap || a == columnKindArray || b == columnKindMap || b == columnKindArray {
+		return columnKindUnknown, false
+	}
+	if (a == columnKindInt && b == columnKindFloat) || (a == columnKindFloat && b == columnKindInt) {
+		return columnKindFloat, true
+	}
+	return columnKindString, true
+}
+
+// columnTypeError is for values, after the schema has been inferred, which
+// don't fit it.
+func columnTypeError(formatName string, name string, kind tColumnKind, value *mlrval.Mlrval) error {
+	return fmt.Errorf(
+		"%s: field \"%s\": %s value \"%s\" does not match the %s type inferred from earlier records",
+		formatName, name, value.GetTypeName(), value.String(), columnKindNames[kind],
+	)
+}
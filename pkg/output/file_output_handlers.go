@@ -1,6 +1,7 @@
 // These are handlers for print, dump, emit, etc in the put/filter verbs.
 //
-// * For "> filename" ">> filename", these handle the open/write/close file operations.
+// * For "> filename" ">> filename", these handle the open/write/close file operations,
+//   compressing if there's a flag like --gzout or a filename suffix like ".gz".
 // * For "| command", these handle open/write/close pipe operations.
 // * For stderr, these write to stderr immediately.
 // * For stdout, these write to the main record-output Go channel.
@@ -313,6 +314,9 @@ type FileOutputHandler struct {
 	recordOutputChannel  chan []*types.RecordAndContext // list of *types.RecordAndContext
 	recordDoneChannel    chan bool
 	recordErroredChannel chan error
+	// Set once the record-writer has errored, after which its goroutine is
+	// no longer reading from the output channel.
+	recordWriterError error
 }
 
 func newOutputHandlerCommon(
@@ -350,10 +354,10 @@ func NewFileWriteOutputHandler(
 	filename string,
 	recordWriterOptions *cli.TWriterOptions,
 ) (*FileOutputHandler, error) {
-	handle, err := os.OpenFile(
+	handle, err := lib.OpenFileForWrite(
 		filename,
-		os.O_CREATE|os.O_WRONLY|os.O_TRUNC,
-		0644, // TODO: let users parameterize this
+		recordWriterOptions.FileOutputEncoding,
+		false,
 	)
 	if err != nil {
 		return nil, err
@@ -370,10 +374,10 @@ func NewFileAppendOutputHandler(
 	filename string,
 	recordWriterOptions *cli.TWriterOptions,
 ) (*FileOutputHandler, error) {
-	handle, err := os.OpenFile(
+	handle, err := lib.OpenFileForWrite(
 		filename,
-		os.O_CREATE|os.O_WRONLY|os.O_APPEND,
-		0644, // TODO: let users parameterize this
+		recordWriterOptions.FileOutputEncoding,
+		true,
 	)
 	if err != nil {
 		return nil, err
@@ -441,9 +445,18 @@ func (handler *FileOutputHandler) WriteRecordAndContext(
 		}
 	}
 
+	if handler.recordWriterError != nil {
+		return handler.recordWriterError
+	}
+
 	// TODO: myybe refactor to batch better
-	handler.recordOutputChannel <- []*types.RecordAndContext{outrecAndContext}
-	return nil
+	select {
+	case handler.recordOutputChannel <- []*types.RecordAndContext{outrecAndContext}:
+		return nil
+	case werr := <-handler.recordErroredChannel:
+		handler.recordWriterError = werr // details already printed
+		return werr
+	}
 }
 
 func (handler *FileOutputHandler) setUpRecordWriter() error {
@@ -477,6 +490,10 @@ func (handler *FileOutputHandler) setUpRecordWriter() error {
 func (handler *FileOutputHandler) Close() (retval error) {
 	retval = nil
 
+	if handler.recordWriterError != nil {
+		return handler.recordWriterError
+	}
+
 	if handler.recordOutputChannel != nil {
 		// TODO: see if we need a real context
 		emptyContext := types.Context{}
@@ -0,0 +1,126 @@
+package output
+
+import (
+	"bufio"
+	"strings"
+
+	"github.com/johnkerl/miller/v6/pkg/lib"
+	"github.com/johnkerl/miller/v6/pkg/mlrval"
+)
+
+// tMarkupTableBatcher groups records by having the same keys, as the PPRINT
+// writer does, for the writers of markup-language tables (LaTeX, RST,
+// AsciiDoc) which write one table per homogeneous batch. No output can be
+// made until the end of a batch, since column widths depend on every value.
+type tMarkupTableBatcher struct {
+	lastJoinedHeader *string
+	batch            []*mlrval.Mlrmap
+	numTablesOutput  int
+}
+
+// add adds a record to the batch, first calling writeTable on the batch if
+// the record's keys differ. A nil record is end of stream.
+func (batcher *tMarkupTableBatcher) add(
+	outrec *mlrval.Mlrmap,
+	bufferedOutputStream *bufio.Writer,
+	writeTable func(records []*mlrval.Mlrmap),
+) {
+	if outrec != nil {
+		joinedHeader := outrec.GetKeysJoined()
+		if batcher.lastJoinedHeader != nil && *batcher.lastJoinedHeader == joinedHeader {
+			batcher.batch = append(batcher.batch, outrec)
+			return
+		}
+		batcher.lastJoinedHeader = &joinedHeader
+	}
+
+	if len(batcher.batch) > 0 && batcher.batch[0].FieldCount > 0 {
+		if batcher.numTablesOutput > 0 {
+			bufferedOutputStream.WriteString("\n")
+		}
+		writeTable(batcher.batch)
+		batcher.numTablesOutput++
+	}
+
+	if outrec != nil {
+		batcher.batch = []*mlrval.Mlrmap{outrec}
+	} else {
+		batcher.batch = nil
+	}
+}
+
+// tMarkupTable is a homogeneous batch of records with keys and values escaped
+// for a markup language, and with the display width of each column. Cells
+// may have several lines, for markup languages which allow that.
+type tMarkupTable struct {
+	header       [][]string
+	rows         [][][]string
+	widths       []int
+	rightAligned []bool
+}
+
+// newMarkupTable escapes the keys and values of the records, which all have
+// the same keys. With rightAlignNumeric, columns whose every value is numeric
+// are marked as right-aligned, as for PPRINT's --right-align-numeric.
+func newMarkupTable(
+	records []*mlrval.Mlrmap,
+	escape func(s string) []string,
+	rightAlignNumeric bool,
+) *tMarkupTable {
+	numColumns := int(records[0].FieldCount)
+	table := &tMarkupTable{
+		header:       make([][]string, 0, numColumns),
+		rows:         make([][][]string, 0, len(records)),
+		widths:       make([]int, numColumns),
+		rightAligned: make([]bool, numColumns),
+	}
+
+	for pe := records[0].Head; pe != nil; pe = pe.Next {
+		table.header = append(table.header, escape(pe.Key))
+	}
+	for i := range table.rightAligned {
+		table.rightAligned[i] = rightAlignNumeric
+	}
+	for _, outrec := range records {
+		row := make([][]string, 0, numColumns)
+		i := 0
+		for pe := outrec.Head; pe != nil; pe = pe.Next {
+			row = append(row, escape(pe.Value.String()))
+			if !pe.Value.IsNumeric() {
+				table.rightAligned[i] = false
+			}
+			i++
+		}
+		table.rows = append(table.rows, row)
+	}
+
+	for _, row := range append([][][]string{table.header}, table.rows...) {
+		for i, cell := range row {
+			for _, line := range cell {
+				table.widths[i] = max(table.widths[i], lib.DisplayWidth(line))
+			}
+		}
+	}
+	return table
+}
+
+// pad pads a line of a cell to the width of its column.
+func (table *tMarkupTable) pad(line string, column int) string {
+	padding := table.widths[column] - lib.DisplayWidth(line)
+	if padding <= 0 {
+		return line
+	}
+	if table.rightAligned[column] {
+		return strings.Repeat(" ", padding) + line
+	}
+	return line + strings.Repeat(" ", padding)
+}
+
+// markupTableSingleLine is for markup languages whose table cells are a single
+// line: line breaks in the value become spaces.
+func markupTableSingleLine(s string) string {
+	if !strings.ContainsAny(s, "\r\n") {
+		return s
+	}
+	return strings.Join(strings.Fields(s), " ")
+}
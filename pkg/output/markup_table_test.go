@@ -0,0 +1,39 @@
+package output
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEscapeLaTeX(t *testing.T) {
+	assert.Equal(t, []string{"plain"}, escapeLaTeX("plain"))
+	assert.Equal(t, []string{`50\% \& \$5 \#1 a\_b \{x\}`}, escapeLaTeX("50% & $5 #1 a_b {x}"))
+	assert.Equal(t, []string{`\textbackslash{}n \textasciitilde{}\textasciicircum{}`}, escapeLaTeX(`\n ~^`))
+	assert.Equal(t, []string{`\textless{}a\textbar{}b\textgreater{}`}, escapeLaTeX("<a|b>"))
+	assert.Equal(t, []string{"two lines"}, escapeLaTeX("two\nlines"))
+}
+
+func TestEscapeRST(t *testing.T) {
+	assert.Equal(t, []string{"plain"}, escapeRST("plain"))
+	assert.Equal(t, []string{"\\*bold\\* \\`code\\` a\\_b \\|x\\| \\\\"}, escapeRST("*bold* `code` a_b |x| \\"))
+	assert.Equal(t, []string{"two", "lines"}, escapeRST("two\r\nlines"))
+	assert.Equal(t, []string{`\-`}, escapeRST("-"))
+	assert.Equal(t, []string{`\- item`}, escapeRST("- item"))
+	assert.Equal(t, []string{`\3. item`}, escapeRST("3. item"))
+	assert.Equal(t, []string{`\(a) item`}, escapeRST("(a) item"))
+	assert.Equal(t, []string{"-0.75"}, escapeRST("-0.75"))
+	assert.Equal(t, []string{"3.14"}, escapeRST("3.14"))
+}
+
+func TestEscapeAsciiDoc(t *testing.T) {
+	assert.Equal(t, []string{"plain"}, escapeAsciiDoc("plain"))
+	assert.Equal(t, []string{"a_b"}, escapeAsciiDoc("a_b"))
+	assert.Equal(t, []string{`a\|b`}, escapeAsciiDoc("a|b"))
+	assert.Equal(t, []string{"pass:c[*bold*]"}, escapeAsciiDoc("*bold*"))
+	assert.Equal(t, []string{"pass:c[_it_]"}, escapeAsciiDoc("_it_"))
+	assert.Equal(t, []string{"pass:c[{attr}]"}, escapeAsciiDoc("{attr}"))
+	assert.Equal(t, []string{`pass:c[a[1\]]`}, escapeAsciiDoc("a[1]"))
+	assert.Equal(t, []string{`pass:c[#1\ ]`}, escapeAsciiDoc(`#1\`))
+	assert.Equal(t, []string{"two lines"}, escapeAsciiDoc("two\nlines"))
+}
@@ -0,0 +1,316 @@
+// Arrow IPC record-writer.
+//
+// We write the Arrow IPC stream format. As with Parquet, the schema is
+// inferred from the first batch of records, which are buffered until then.
+// Records are accumulated into record batches of --arrow-batch-size records
+// each, and records after the first batch must fit the schema.
+//
+// Type mapping:
+// * int -> int64
+// * float, or a mix of int and float -> float64
+// * boolean -> bool
+// * string, or a mix of other scalar types -> utf8, or dictionary<int32, utf8>
+//   with --arrow-dictionary-strings
+// * bytes -> binary
+// * map -> struct
+// * array -> list
+// * empty, or empty map -> null
+//
+// All fields are nullable, so records lacking some fields are written with
+// nulls for them.
+
+package output
+
+import (
+	"bufio"
+	"fmt"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/ipc"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+
+	"github.com/johnkerl/miller/v6/pkg/cli"
+	"github.com/johnkerl/miller/v6/pkg/mlrval"
+	"github.com/johnkerl/miller/v6/pkg/types"
+)
+
+type RecordWriterArrow struct {
+	writerOptions      *cli.TWriterOptions
+	compressionOptions []ipc.Option
+	batchSize          int64
+
+	// Used until the schema is known
+	bufferedRecords []*mlrval.Mlrmap
+
+	// Used after the schema is known
+	root          *columnTypeInferrer
+	recordBuilder *array.RecordBuilder
+	ipcWriter     *ipc.Writer
+	numBatched    int64
+}
+
+func NewRecordWriterArrow(writerOptions *cli.TWriterOptions) (*RecordWriterArrow, error) {
+	compressionOptions, err := arrowCompressionOptions(writerOptions.ArrowCompression)
+	if err != nil {
+		return nil, err
+	}
+	batchSize := writerOptions.ArrowBatchSize
+	if batchSize <= 0 {
+		return nil, fmt.Errorf("arrow batch size must be positive; got %d", batchSize)
+	}
+	return &RecordWriterArrow{
+		writerOptions:      writerOptions,
+		compressionOptions: compressionOptions,
+		batchSize:          batchSize,
+		bufferedRecords:    make([]*mlrval.Mlrmap, 0),
+	}, nil
+}
+
+func arrowCompressionOptions(name string) ([]ipc.Option, error) {
+	switch name {
+	case "none", "uncompressed":
+		return nil, nil
+	case "lz4":
+		return []ipc.Option{ipc.WithLZ4()}, nil
+	case "zstd":
+		return []ipc.Option{ipc.WithZstd()}, nil
+	default:
+		return nil, fmt.Errorf(
+			"arrow compression \"%s\" not found; please use one of none, lz4, zstd",
+			name,
+		)
+	}
+}
+
+func (writer *RecordWriterArrow) Write(
+	outrec *mlrval.Mlrmap,
+	_ *types.Context,
+	bufferedOutputStream *bufio.Writer,
+	outputIsStdout bool,
+) error {
+	if writer.ipcWriter == nil {
+		if outrec != nil {
+			writer.bufferedRecords = append(writer.bufferedRecords, outrec)
+			if int64(len(writer.bufferedRecords)) < writer.batchSize {
+				return nil
+			}
+		} else if len(writer.bufferedRecords) == 0 {
+			// No records at all: no output at all, as with CSV.
+			return nil
+		}
+
+		if err := writer.startWriting(bufferedOutputStream); err != nil {
+			return err
+		}
+		bufferedRecords := writer.bufferedRecords
+		writer.bufferedRecords = nil
+		for _, record := range bufferedRecords {
+			if err := writer.writeRecord(record); err != nil {
+				return err
+			}
+		}
+		if outrec != nil {
+			return nil
+		}
+	} else if outrec != nil {
+		return writer.writeRecord(outrec)
+	}
+
+	// End of record stream
+	if err := writer.flushRecordBatch(); err != nil {
+		return err
+	}
+	writer.recordBuilder.Release()
+	return writer.ipcWriter.Close()
+}
+
+func (writer *RecordWriterArrow) startWriting(bufferedOutputStream *bufio.Writer) error {
+	inferrer := newColumnTypeInferrer()
+	for _, record := range writer.bufferedRecords {
+		if err := inferrer.addRecord(record); err != nil {
+			return fmt.Errorf("arrow: %v", err)
+		}
+	}
+	if len(inferrer.keys) == 0 {
+		return fmt.Errorf("arrow: cannot write records having no fields")
+	}
+
+	writer.root = inferrer
+	schema := arrow.NewSchema(inferrer.toArrowFields(writer.writerOptions.ArrowDictionaryStrings), nil)
+	writer.recordBuilder = array.NewRecordBuilder(memory.DefaultAllocator, schema)
+	options := append([]ipc.Option{ipc.WithSchema(schema)}, writer.compressionOptions...)
+	writer.ipcWriter = ipc.NewWriter(bufferedOutputStream, options...)
+	return nil
+}
+
+func (writer *RecordWriterArrow) writeRecord(record *mlrval.Mlrmap) error {
+	for pe := record.Head; pe != nil; pe = pe.Next {
+		if _, ok := writer.root.children[pe.Key]; !ok {
+			return fmt.Errorf(
+				"arrow: field \"%s\" was not present in the first %d records, from which the schema was inferred",
+				pe.Key, writer.batchSize,
+			)
+		}
+	}
+
+	for i, key := range writer.root.keys {
+		err := appendArrowValue(writer.recordBuilder.Field(i), writer.root.children[key], record.Get(key), key)
+		if err != nil {
+			return err
+		}
+	}
+
+	writer.numBatched++
+	if writer.numBatched >= writer.batchSize {
+		return writer.flushRecordBatch()
+	}
+	return nil
+}
+
+func (writer *RecordWriterArrow) flushRecordBatch() error {
+	if writer.numBatched == 0 {
+		return nil
+	}
+	recordBatch := writer.recordBuilder.NewRecordBatch()
+	defer recordBatch.Release()
+	writer.numBatched = 0
+	return writer.ipcWriter.Write(recordBatch)
+}
+
+// ----------------------------------------------------------------
+// SCHEMA CONSTRUCTION
+
+func (inferrer *columnTypeInferrer) toArrowFields(dictionaryStrings bool) []arrow.Field {
+	fields := make([]arrow.Field, len(inferrer.keys))
+	for i, key := range inferrer.keys {
+		fields[i] = arrow.Field{
+			Name:     key,
+			Type:     inferrer.children[key].toArrowType(dictionaryStrings),
+			Nullable: true,
+		}
+	}
+	return fields
+}
+
+func (inferrer *columnTypeInferrer) toArrowType(dictionaryStrings bool) arrow.DataType {
+	switch inferrer.kind {
+	case columnKindInt:
+		return arrow.PrimitiveTypes.Int64
+	case columnKindFloat:
+		return arrow.PrimitiveTypes.Float64
+	case columnKindBool:
+		return arrow.FixedWidthTypes.Boolean
+	case columnKindBytes:
+		return arrow.BinaryTypes.Binary
+	case columnKindMap:
+		return arrow.StructOf(inferrer.toArrowFields(dictionaryStrings)...)
+	case columnKindArray:
+		return arrow.ListOf(inferrer.element.toArrowType(dictionaryStrings))
+	case columnKindString:
+		if dictionaryStrings {
+			return &arrow.DictionaryType{
+				IndexType: arrow.PrimitiveTypes.Int32,
+				ValueType: arrow.BinaryTypes.String,
+			}
+		}
+		return arrow.BinaryTypes.String
+	default:
+		// Only empty values seen
+		return arrow.BinaryTypes.String
+	}
+}
+
+// ----------------------------------------------------------------
+// RECORD DISASSEMBLY
+
+// appendArrowValue appends a value to the builder for its column, or to the
+// builder for a struct field or list element within it. The name is for error
+// messages.
+func appendArrowValue(
+	builder array.Builder,
+	inferrer *columnTypeInferrer,
+	value *mlrval.Mlrval,
+	name string,
+) error {
+	if value == nil || columnKindOf(value) == columnKindUnknown {
+		builder.AppendNull()
+		return nil
+	}
+
+	switch inferrer.kind {
+	case columnKindInt:
+		if intValue, ok := value.GetIntValue(); ok {
+			builder.(*array.Int64Builder).Append(intValue)
+			return nil
+		}
+
+	case columnKindFloat:
+		if floatValue, ok := value.GetNumericToFloatValue(); ok {
+			builder.(*array.Float64Builder).Append(floatValue)
+			return nil
+		}
+
+	case columnKindBool:
+		if boolValue, ok := value.GetBoolValue(); ok {
+			builder.(*array.BooleanBuilder).Append(boolValue)
+			return nil
+		}
+
+	case columnKindBytes:
+		if value.IsBytes() {
+			builder.(*array.BinaryBuilder).Append(value.AcquireBytesValue())
+			return nil
+		}
+
+	case columnKindMap:
+		mapval := value.GetMap()
+		if mapval == nil {
+			break
+		}
+		for pe := mapval.Head; pe != nil; pe = pe.Next {
+			if _, ok := inferrer.children[pe.Key]; !ok {
+				return fmt.Errorf(
+					"arrow: field \"%s\" in map \"%s\" was not present in the records from which the schema was inferred",
+					pe.Key, name,
+				)
+			}
+		}
+		structBuilder := builder.(*array.StructBuilder)
+		structBuilder.Append(true)
+		for i, key := range inferrer.keys {
+			err := appendArrowValue(structBuilder.FieldBuilder(i), inferrer.children[key], mapval.Get(key), key)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case columnKindArray:
+		arrayval := value.GetArray()
+		if arrayval == nil {
+			break
+		}
+		listBuilder := builder.(*array.ListBuilder)
+		listBuilder.Append(true)
+		for _, element := range arrayval {
+			if err := appendArrowValue(listBuilder.ValueBuilder(), inferrer.element, element, name); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	default:
+		// Strings, and columns with only empty values seen in the first batch
+		if value.IsArrayOrMap() {
+			return columnTypeError("arrow", name, columnKindString, value)
+		}
+		if dictionaryBuilder, ok := builder.(*array.BinaryDictionaryBuilder); ok {
+			return dictionaryBuilder.AppendString(value.String())
+		}
+		builder.(*array.StringBuilder).Append(value.String())
+		return nil
+	}
+
+	return columnTypeError("arrow", name, inferrer.kind, value)
+}
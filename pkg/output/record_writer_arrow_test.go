@@ -0,0 +1,119 @@
+// Tests for the Arrow record-writer's schema inference and batching.
+// Round-trips through the Arrow record-reader are covered by the regression
+// cases in test/cases/io-arrow; here we check the schema itself, which those
+// cannot see.
+
+package output
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/ipc"
+
+	"github.com/johnkerl/miller/v6/pkg/cli"
+	"github.com/johnkerl/miller/v6/pkg/mlrval"
+)
+
+func writeArrowRecords(
+	t *testing.T,
+	batchSize int64,
+	dictionaryStrings bool,
+	records ...*mlrval.Mlrmap,
+) (*arrow.Schema, []int64) {
+	t.Helper()
+	writer, err := NewRecordWriterArrow(&cli.TWriterOptions{
+		ArrowCompression:       "none",
+		ArrowBatchSize:         batchSize,
+		ArrowDictionaryStrings: dictionaryStrings,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buffer bytes.Buffer
+	bufferedOutputStream := bufio.NewWriter(&buffer)
+	for _, record := range records {
+		if err := writer.Write(record, nil, bufferedOutputStream, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Write(nil, nil, bufferedOutputStream, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := bufferedOutputStream.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	reader, err := ipc.NewReader(&buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	batchLengths := make([]int64, 0)
+	for {
+		recordBatch, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		batchLengths = append(batchLengths, recordBatch.NumRows())
+	}
+	return reader.Schema(), batchLengths
+}
+
+func TestArrowWriterSchemaInference(t *testing.T) {
+	inner := mlrval.NewMlrmap()
+	inner.PutReference("p", mlrval.FromInt(1))
+
+	record1 := mlrval.NewMlrmapAsRecord()
+	record1.PutReference("z", mlrval.FromInt(1))
+	record1.PutReference("y", mlrval.FromFloat(2.5))
+	record1.PutReference("x", mlrval.FromInt(3))
+	record1.PutReference("w", mlrval.FromArray([]*mlrval.Mlrval{mlrval.FromString("a")}))
+	record1.PutReference("u", mlrval.FromMap(inner))
+
+	record2 := mlrval.NewMlrmapAsRecord()
+	record2.PutReference("z", mlrval.FromFloat(4.5))
+	record2.PutReference("x", mlrval.FromString("abc"))
+	record2.PutReference("v", mlrval.FromBool(true))
+
+	schema, batchLengths := writeArrowRecords(t, 10, true, record1, record2)
+
+	// First-seen order, not alphabetical
+	expected := []string{
+		"z: type=float64, nullable",
+		"y: type=float64, nullable",
+		"x: type=dictionary<values=utf8, indices=int32, ordered=false>, nullable",
+		"w: type=list<item: dictionary<values=utf8, indices=int32, ordered=false>, nullable>, nullable",
+		"u: type=struct<p: int64 nullable>, nullable",
+		"v: type=bool, nullable",
+	}
+	fields := schema.Fields()
+	if len(fields) != len(expected) {
+		t.Fatalf("expected %d fields, got %v", len(expected), schema)
+	}
+	for i, field := range fields {
+		if got := field.String(); got != expected[i] {
+			t.Errorf("field %d: expected %s, got %s", i, expected[i], got)
+		}
+	}
+	if len(batchLengths) != 1 || batchLengths[0] != 2 {
+		t.Fatalf("expected one batch of 2 records, got %v", batchLengths)
+	}
+}
+
+func TestArrowWriterBatches(t *testing.T) {
+	records := make([]*mlrval.Mlrmap, 5)
+	for i := range records {
+		records[i] = mlrval.NewMlrmapAsRecord()
+		records[i].PutReference("i", mlrval.FromInt(int64(i)))
+	}
+	_, batchLengths := writeArrowRecords(t, 2, false, records...)
+	if len(batchLengths) != 3 || batchLengths[0] != 2 || batchLengths[1] != 2 || batchLengths[2] != 1 {
+		t.Fatalf("expected batches of 2, 2, and 1 records, got %v", batchLengths)
+	}
+}
@@ -0,0 +1,115 @@
+// AsciiDoc record-writer.
+//
+// Each homogeneous batch of records is a table:
+//
+//   [%header,cols="<,<,<"]
+//   |===
+//   | a   | b   | i
+//
+//   | pan | pan | 1
+//   | eks | pan | 2
+//   |===
+//
+// Cell separators within values are escaped, and values which could be read as
+// inline markup, such as *bold* or {attribute}, are passed through as they
+// are. With --right-align-numeric, numeric columns are > rather than <.
+
+package output
+
+import (
+	"bufio"
+	"regexp"
+	"strings"
+
+	"github.com/johnkerl/miller/v6/pkg/cli"
+	"github.com/johnkerl/miller/v6/pkg/mlrval"
+	"github.com/johnkerl/miller/v6/pkg/types"
+)
+
+// Marks which can start inline formatting, attribute references, macros, or
+// cross-references; an underscore only at the edge of a word
+var asciidocMarkupRegex = regexp.MustCompile("[*`#^~+{\\[\\]<]|(^|\\W)_|_(\\W|$)")
+
+type RecordWriterAsciiDoc struct {
+	writerOptions *cli.TWriterOptions
+	batcher       tMarkupTableBatcher
+}
+
+func NewRecordWriterAsciiDoc(writerOptions *cli.TWriterOptions) (*RecordWriterAsciiDoc, error) {
+	return &RecordWriterAsciiDoc{
+		writerOptions: writerOptions,
+	}, nil
+}
+
+func (writer *RecordWriterAsciiDoc) Write(
+	outrec *mlrval.Mlrmap,
+	_ *types.Context,
+	bufferedOutputStream *bufio.Writer,
+	outputIsStdout bool,
+) error {
+	writer.batcher.add(outrec, bufferedOutputStream, func(records []*mlrval.Mlrmap) {
+		writer.writeTable(records, bufferedOutputStream)
+	})
+	return nil
+}
+
+func (writer *RecordWriterAsciiDoc) writeTable(
+	records []*mlrval.Mlrmap,
+	bufferedOutputStream *bufio.Writer,
+) {
+	table := newMarkupTable(records, escapeAsciiDoc, writer.writerOptions.RightAlignNumericOutput)
+
+	bufferedOutputStream.WriteString(`[%header,cols="`)
+	for i, rightAligned := range table.rightAligned {
+		if i > 0 {
+			bufferedOutputStream.WriteString(",")
+		}
+		if rightAligned {
+			bufferedOutputStream.WriteString(">")
+		} else {
+			bufferedOutputStream.WriteString("<")
+		}
+	}
+	bufferedOutputStream.WriteString("\"]\n")
+	bufferedOutputStream.WriteString("|===\n")
+	writer.writeRow(table, table.header, bufferedOutputStream)
+	bufferedOutputStream.WriteString("\n")
+	for _, row := range table.rows {
+		writer.writeRow(table, row, bufferedOutputStream)
+	}
+	bufferedOutputStream.WriteString("|===\n")
+}
+
+func (writer *RecordWriterAsciiDoc) writeRow(
+	table *tMarkupTable,
+	row [][]string,
+	bufferedOutputStream *bufio.Writer,
+) {
+	for i, cell := range row {
+		if i > 0 {
+			bufferedOutputStream.WriteString(" ")
+		}
+		bufferedOutputStream.WriteString("| ")
+		if i < len(row)-1 {
+			bufferedOutputStream.WriteString(table.pad(cell[0], i))
+		} else {
+			// No trailing whitespace
+			bufferedOutputStream.WriteString(strings.TrimRight(table.pad(cell[0], i), " "))
+		}
+	}
+	bufferedOutputStream.WriteString("\n")
+}
+
+func escapeAsciiDoc(s string) []string {
+	s = markupTableSingleLine(s)
+	if asciidocMarkupRegex.MatchString(s) {
+		s = strings.ReplaceAll(s, "]", `\]`)
+		if strings.HasSuffix(s, `\`) {
+			// Else it would escape the closing bracket. Trailing space in a
+			// cell doesn't show.
+			s += " "
+		}
+		s = "pass:c[" + s + "]"
+	}
+	return []string{strings.ReplaceAll(s, "|", `\|`)}
+}
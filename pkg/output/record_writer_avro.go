@@ -0,0 +1,607 @@
+// Avro object-container-file record-writer.
+//
+// The schema is either from --avro-schema, or inferred as for Parquet: we
+// buffer the first block's worth of records and infer it from them. Inferred
+// schemas have a record named "miller", with a field for each key, each of
+// which is a union of null and:
+//
+// * int -> long
+// * float, or a mix of int and float -> double
+// * boolean -> boolean
+// * string, or a mix of other scalar types -> string
+// * bytes -> bytes
+// * map -> record
+// * array -> array
+// * empty -> string
+//
+// Keys which aren't valid Avro names have their other characters replaced by
+// underscores. Records after the first block must fit that schema.
+//
+// With --avro-schema, each record is written to fit the given schema, such as
+// a string "2024-01-02" for a date, with empty values as nulls where the
+// schema allows them.
+
+package output
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"math/big"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/johnkerl/miller/v6/pkg/avro"
+	"github.com/johnkerl/miller/v6/pkg/cli"
+	"github.com/johnkerl/miller/v6/pkg/mlrval"
+	"github.com/johnkerl/miller/v6/pkg/types"
+)
+
+type RecordWriterAvro struct {
+	writerOptions *cli.TWriterOptions
+	blockSize     int64
+
+	// From --avro-schema, or inferred from the first block
+	schema *avro.Schema
+	// Miller keys for the fields of inferred schemas, where they differ from
+	// the Avro field names
+	fieldKeys map[*avro.Field]string
+
+	// Used until the schema is known
+	bufferedRecords []*mlrval.Mlrmap
+
+	// Used after the schema is known
+	ocfWriter  *avro.OCFWriter
+	block      []byte
+	blockCount int64
+}
+
+func NewRecordWriterAvro(writerOptions *cli.TWriterOptions) (*RecordWriterAvro, error) {
+	if err := avro.CheckCodec(writerOptions.AvroCodec); err != nil {
+		return nil, err
+	}
+	blockSize := writerOptions.AvroBlockSize
+	if blockSize <= 0 {
+		return nil, fmt.Errorf("avro block size must be positive; got %d", blockSize)
+	}
+
+	writer := &RecordWriterAvro{
+		writerOptions:   writerOptions,
+		blockSize:       blockSize,
+		fieldKeys:       make(map[*avro.Field]string),
+		bufferedRecords: make([]*mlrval.Mlrmap, 0),
+	}
+
+	if writerOptions.AvroSchemaFile != "" {
+		text, err := os.ReadFile(writerOptions.AvroSchemaFile)
+		if err != nil {
+			return nil, fmt.Errorf("avro: %v", err)
+		}
+		writer.schema, err = avro.ParseSchema(text)
+		if err != nil {
+			return nil, fmt.Errorf("avro: %s: %v", writerOptions.AvroSchemaFile, err)
+		}
+		if writer.schema.Type != "record" {
+			return nil, fmt.Errorf(
+				"avro: %s: the schema must be a record; got %s", writerOptions.AvroSchemaFile, writer.schema.Type,
+			)
+		}
+	}
+	return writer, nil
+}
+
+func (writer *RecordWriterAvro) Write(
+	outrec *mlrval.Mlrmap,
+	_ *types.Context,
+	bufferedOutputStream *bufio.Writer,
+	outputIsStdout bool,
+) error {
+	if writer.ocfWriter == nil {
+		if outrec != nil {
+			writer.bufferedRecords = append(writer.bufferedRecords, outrec)
+			if writer.schema == nil && int64(len(writer.bufferedRecords)) < writer.blockSize {
+				return nil
+			}
+		} else if len(writer.bufferedRecords) == 0 {
+			// No records at all: no output at all, as with CSV.
+			return nil
+		}
+
+		if err := writer.startWriting(bufferedOutputStream); err != nil {
+			return err
+		}
+		if outrec != nil {
+			return nil
+		}
+	} else if outrec != nil {
+		return writer.writeRecord(outrec)
+	}
+
+	// End of record stream
+	return writer.flushBlock()
+}
+
+func (writer *RecordWriterAvro) startWriting(bufferedOutputStream *bufio.Writer) error {
+	if writer.schema == nil {
+		inferrer := newColumnTypeInferrer()
+		for _, record := range writer.bufferedRecords {
+			if err := inferrer.addRecord(record); err != nil {
+				return fmt.Errorf("avro: %v", err)
+			}
+		}
+		if len(inferrer.keys) == 0 {
+			return fmt.Errorf("avro: cannot write records having no fields")
+		}
+		writer.schema = writer.inferredRecordSchema(inferrer, "miller", make(map[string]bool))
+	}
+
+	// Encode the buffered records before writing the header, so that records
+	// not fitting the schema don't leave a file with a header and no data.
+	for _, record := range writer.bufferedRecords {
+		var err error
+		writer.block, err = writer.encodeRecord(writer.block, writer.schema, record, "")
+		if err != nil {
+			return fmt.Errorf("avro: %v", err)
+		}
+		writer.blockCount++
+	}
+	writer.bufferedRecords = nil
+
+	ocfWriter, err := avro.NewOCFWriter(bufferedOutputStream, writer.schema.String(), writer.writerOptions.AvroCodec)
+	if err != nil {
+		return fmt.Errorf("avro: %v", err)
+	}
+	writer.ocfWriter = ocfWriter
+	if writer.blockCount >= writer.blockSize {
+		return writer.flushBlock()
+	}
+	return nil
+}
+
+func (writer *RecordWriterAvro) writeRecord(record *mlrval.Mlrmap) error {
+	var err error
+	writer.block, err = writer.encodeRecord(writer.block, writer.schema, record, "")
+	if err != nil {
+		return fmt.Errorf("avro: %v", err)
+	}
+	writer.blockCount++
+	if writer.blockCount >= writer.blockSize {
+		return writer.flushBlock()
+	}
+	return nil
+}
+
+func (writer *RecordWriterAvro) flushBlock() error {
+	if err := writer.ocfWriter.WriteBlock(writer.blockCount, writer.block); err != nil {
+		return fmt.Errorf("avro: %v", err)
+	}
+	writer.block = writer.block[:0]
+	writer.blockCount = 0
+	return nil
+}
+
+// ----------------------------------------------------------------
+// Schema inference
+
+func (writer *RecordWriterAvro) inferredRecordSchema(
+	inferrer *columnTypeInferrer,
+	name string,
+	usedNames map[string]bool,
+) *avro.Schema {
+	schema := &avro.Schema{Type: "record", Name: uniqueAvroName(name, usedNames)}
+	fieldNames := make(map[string]bool)
+	for _, key := range inferrer.keys {
+		fieldName := uniqueAvroName(avroName(key), fieldNames)
+		field := &avro.Field{
+			Name: fieldName,
+			Type: &avro.Schema{
+				Type: "union",
+				Branches: []*avro.Schema{
+					{Type: "null"},
+					writer.inferredSchema(inferrer.children[key], schema.Name+"_"+fieldName, usedNames),
+				},
+			},
+			HasDefault: true,
+		}
+		if fieldName != key {
+			writer.fieldKeys[field] = key
+		}
+		schema.Fields = append(schema.Fields, field)
+	}
+	return schema
+}
+
+func (writer *RecordWriterAvro) inferredSchema(
+	inferrer *columnTypeInferrer,
+	name string,
+	usedNames map[string]bool,
+) *avro.Schema {
+	switch inferrer.kind {
+	case columnKindInt:
+		return &avro.Schema{Type: "long"}
+	case columnKindFloat:
+		return &avro.Schema{Type: "double"}
+	case columnKindBool:
+		return &avro.Schema{Type: "boolean"}
+	case columnKindBytes:
+		return &avro.Schema{Type: "bytes"}
+	case columnKindMap:
+		return writer.inferredRecordSchema(inferrer, name, usedNames)
+	case columnKindArray:
+		return &avro.Schema{
+			Type: "array",
+			Items: &avro.Schema{
+				Type: "union",
+				Branches: []*avro.Schema{
+					{Type: "null"},
+					writer.inferredSchema(inferrer.element, name, usedNames),
+				},
+			},
+		}
+	default:
+		return &avro.Schema{Type: "string"}
+	}
+}
+
+// avroName makes a Miller key into a valid Avro name.
+func avroName(key string) string {
+	if avro.IsValidName(key) {
+		return key
+	}
+	var buffer strings.Builder
+	for i, r := range key {
+		if r == '_' || r >= 'A' && r <= 'Z' || r >= 'a' && r <= 'z' || (i > 0 && r >= '0' && r <= '9') {
+			buffer.WriteRune(r)
+		} else if i == 0 && r >= '0' && r <= '9' {
+			buffer.WriteRune('_')
+			buffer.WriteRune(r)
+		} else {
+			buffer.WriteRune('_')
+		}
+	}
+	if buffer.Len() == 0 {
+		return "_"
+	}
+	return buffer.String()
+}
+
+func uniqueAvroName(name string, usedNames map[string]bool) string {
+	unique := name
+	for i := 2; usedNames[unique]; i++ {
+		unique = name + "_" + strconv.Itoa(i)
+	}
+	usedNames[unique] = true
+	return unique
+}
+
+// ----------------------------------------------------------------
+// Encoding
+
+func (writer *RecordWriterAvro) encodeRecord(
+	buffer []byte,
+	schema *avro.Schema,
+	record *mlrval.Mlrmap,
+	path string,
+) ([]byte, error) {
+	var err error
+	used := 0
+	for _, field := range schema.Fields {
+		key, ok := writer.fieldKeys[field]
+		if !ok {
+			key = field.Name
+		}
+		value := record.Get(key)
+		if value != nil {
+			used++
+		} else if field.HasDefault {
+			value = avroDefaultToMlrval(field.Default)
+		} else {
+			value = mlrval.ABSENT
+		}
+		buffer, err = writer.encodeValue(buffer, field.Type, value, joinAvroPath(path, key))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if int64(used) < record.FieldCount {
+		for pe := record.Head; pe != nil; pe = pe.Next {
+			if !writer.schemaHasKey(schema, pe.Key) {
+				return nil, fmt.Errorf("field \"%s\" is not in the schema", joinAvroPath(path, pe.Key))
+			}
+		}
+	}
+	return buffer, nil
+}
+
+func (writer *RecordWriterAvro) schemaHasKey(schema *avro.Schema, key string) bool {
+	for _, field := range schema.Fields {
+		fieldKey, ok := writer.fieldKeys[field]
+		if !ok {
+			fieldKey = field.Name
+		}
+		if fieldKey == key {
+			return true
+		}
+	}
+	return false
+}
+
+func joinAvroPath(path string, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func avroDefaultToMlrval(value any) *mlrval.Mlrval {
+	switch v := value.(type) {
+	case nil:
+		return mlrval.ABSENT
+	case bool:
+		return mlrval.FromBool(v)
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < 1<<53 {
+			return mlrval.FromInt(int64(v))
+		}
+		return mlrval.FromFloat(v)
+	case string:
+		return mlrval.FromString(v)
+	default:
+		return mlrval.FromAnonymousError()
+	}
+}
+
+func isAvroNull(value *mlrval.Mlrval) bool {
+	return value.IsAbsent() || value.IsVoid() || value.Type() == mlrval.MT_NULL
+}
+
+func (writer *RecordWriterAvro) encodeValue(
+	buffer []byte,
+	schema *avro.Schema,
+	value *mlrval.Mlrval,
+	path string,
+) ([]byte, error) {
+	switch schema.Type {
+	case "union":
+		return writer.encodeUnion(buffer, schema, value, path)
+
+	case "null":
+		if isAvroNull(value) {
+			return buffer, nil
+		}
+
+	case "boolean":
+		if b, ok := value.GetBoolValue(); ok {
+			return avro.AppendBoolean(buffer, b), nil
+		}
+		if value.IsStringOrVoid() {
+			if b, err := strconv.ParseBool(value.String()); err == nil {
+				return avro.AppendBoolean(buffer, b), nil
+			}
+		}
+
+	case "int", "long":
+		if n, ok := avroLongValue(schema, value); ok {
+			if schema.Type == "int" && (n < math.MinInt32 || n > math.MaxInt32) {
+				return nil, fmt.Errorf("field \"%s\": value %d is out of range for int", path, n)
+			}
+			return avro.AppendLong(buffer, n), nil
+		}
+
+	case "float", "double":
+		if f, ok := value.GetNumericToFloatValue(); ok {
+			if schema.Type == "float" {
+				return avro.AppendFloat(buffer, float32(f)), nil
+			}
+			return avro.AppendDouble(buffer, f), nil
+		}
+
+	case "bytes", "fixed":
+		b, err := avroBytesValue(schema, value)
+		if err != nil {
+			return nil, fmt.Errorf("field \"%s\": %v", path, err)
+		}
+		if b != nil {
+			if schema.Type == "bytes" {
+				return avro.AppendBytes(buffer, b), nil
+			}
+			if len(b) == schema.Size {
+				return append(buffer, b...), nil
+			}
+		}
+
+	case "string":
+		if !value.IsAbsent() && !value.IsMap() && !value.IsArray() && value.Type() != mlrval.MT_NULL {
+			return avro.AppendString(buffer, value.String()), nil
+		}
+
+	case "enum":
+		if value.IsStringOrVoid() {
+			s := value.String()
+			for i, symbol := range schema.Symbols {
+				if s == symbol {
+					return avro.AppendLong(buffer, int64(i)), nil
+				}
+			}
+		}
+
+	case "array":
+		if value.IsArray() {
+			array := value.GetArray()
+			var err error
+			if len(array) > 0 {
+				buffer = avro.AppendLong(buffer, int64(len(array)))
+				for i, element := range array {
+					buffer, err = writer.encodeValue(buffer, schema.Items, element, path+"."+strconv.Itoa(i+1))
+					if err != nil {
+						return nil, err
+					}
+				}
+			}
+			return avro.AppendLong(buffer, 0), nil
+		}
+
+	case "map":
+		if value.IsMap() {
+			mlrmap := value.GetMap()
+			var err error
+			if mlrmap.FieldCount > 0 {
+				buffer = avro.AppendLong(buffer, mlrmap.FieldCount)
+				for pe := mlrmap.Head; pe != nil; pe = pe.Next {
+					buffer = avro.AppendString(buffer, pe.Key)
+					buffer, err = writer.encodeValue(buffer, schema.Values, pe.Value, joinAvroPath(path, pe.Key))
+					if err != nil {
+						return nil, err
+					}
+				}
+			}
+			return avro.AppendLong(buffer, 0), nil
+		}
+
+	case "record":
+		if value.IsMap() {
+			return writer.encodeRecord(buffer, schema, value.GetMap(), path)
+		}
+	}
+
+	if value.IsAbsent() {
+		return nil, fmt.Errorf("field \"%s\" is missing, but the schema's %s type is not nullable", path, avroTypeName(schema))
+	}
+	return nil, fmt.Errorf(
+		"field \"%s\": %s value \"%s\" does not match the schema's %s type",
+		path, value.GetTypeName(), value.String(), avroTypeName(schema),
+	)
+}
+
+// encodeUnion writes the first branch which the value fits. Empty values are
+// written as null if the union has null.
+func (writer *RecordWriterAvro) encodeUnion(
+	buffer []byte,
+	schema *avro.Schema,
+	value *mlrval.Mlrval,
+	path string,
+) ([]byte, error) {
+	if isAvroNull(value) || (value.IsMap() && value.GetMap().IsEmpty()) {
+		for i, branch := range schema.Branches {
+			if branch.Type == "null" {
+				return avro.AppendLong(buffer, int64(i)), nil
+			}
+		}
+	}
+
+	var firstErr error
+	for i, branch := range schema.Branches {
+		if branch.Type == "null" {
+			continue
+		}
+		encoded, err := writer.encodeValue(avro.AppendLong(nil, int64(i)), branch, value, path)
+		if err == nil {
+			return append(buffer, encoded...), nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	if firstErr == nil {
+		firstErr = fmt.Errorf(
+			"field \"%s\": %s value \"%s\" does not match the schema's %s type",
+			path, value.GetTypeName(), value.String(), avroTypeName(schema),
+		)
+	}
+	return nil, firstErr
+}
+
+func avroTypeName(schema *avro.Schema) string {
+	if schema.LogicalType != "" {
+		return schema.LogicalType
+	}
+	if schema.Name != "" {
+		return schema.Type + " " + schema.Name
+	}
+	return schema.Type
+}
+
+// avroLongValue handles ints, and strings for the date, time, and timestamp
+// logical types.
+func avroLongValue(schema *avro.Schema, value *mlrval.Mlrval) (int64, bool) {
+	if n, ok := value.GetIntValue(); ok {
+		return n, true
+	}
+	if !value.IsStringOrVoid() {
+		return 0, false
+	}
+	s := value.String()
+
+	switch schema.LogicalType {
+	case "date":
+		t, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			return 0, false
+		}
+		return t.Unix() / 86400, true
+	case "time-millis", "time-micros":
+		t, err := time.Parse("15:04:05.999999999", s)
+		if err != nil {
+			return 0, false
+		}
+		sinceMidnight := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute +
+			time.Duration(t.Second())*time.Second + time.Duration(t.Nanosecond())
+		return int64(sinceMidnight / avroLogicalTypeUnit(schema.LogicalType)), true
+	case "timestamp-millis", "timestamp-micros", "timestamp-nanos",
+		"local-timestamp-millis", "local-timestamp-micros", "local-timestamp-nanos":
+		for _, layout := range avroTimestampLayouts {
+			if t, err := time.Parse(layout, s); err == nil {
+				unit := avroLogicalTypeUnit(schema.LogicalType)
+				return t.Unix()*int64(time.Second/unit) + int64(t.Nanosecond())/int64(unit), true
+			}
+		}
+	}
+	return 0, false
+}
+
+var avroTimestampLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02",
+}
+
+func avroLogicalTypeUnit(logicalType string) time.Duration {
+	switch {
+	case strings.HasSuffix(logicalType, "-millis"):
+		return time.Millisecond
+	case strings.HasSuffix(logicalType, "-micros"):
+		return time.Microsecond
+	default:
+		return time.Nanosecond
+	}
+}
+
+// avroBytesValue returns the bytes for a bytes or fixed value, or nil if the
+// value doesn't fit. Decimals are from numbers or numeric strings.
+func avroBytesValue(schema *avro.Schema, value *mlrval.Mlrval) ([]byte, error) {
+	if value.IsAbsent() || value.IsMap() || value.IsArray() || value.Type() == mlrval.MT_NULL {
+		return nil, nil
+	}
+	if schema.LogicalType != "decimal" {
+		return []byte(value.String()), nil
+	}
+
+	rat, ok := new(big.Rat).SetString(value.String())
+	if !ok {
+		return nil, nil
+	}
+	rat.Mul(rat, new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(schema.Scale)), nil)))
+	if !rat.IsInt() {
+		return nil, fmt.Errorf("value %s has more than %d decimal places", value.String(), schema.Scale)
+	}
+	size := 0
+	if schema.Type == "fixed" {
+		size = schema.Size
+	}
+	return avro.DecimalBytes(rat.Num(), size)
+}
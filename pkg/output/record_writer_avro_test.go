@@ -0,0 +1,96 @@
+// Tests for the Avro record-writer's schema inference. Round-trips through
+// the Avro record-reader are covered by the regression cases in
+// test/cases/io-avro; here we check the schema itself.
+
+package output
+
+import (
+	"bufio"
+	"bytes"
+	"testing"
+
+	"github.com/johnkerl/miller/v6/pkg/avro"
+	"github.com/johnkerl/miller/v6/pkg/cli"
+	"github.com/johnkerl/miller/v6/pkg/mlrval"
+)
+
+func writeAvroRecords(t *testing.T, blockSize int64, records ...*mlrval.Mlrmap) (*avro.OCFReader, error) {
+	t.Helper()
+	writer, err := NewRecordWriterAvro(&cli.TWriterOptions{
+		AvroCodec:     "deflate",
+		AvroBlockSize: blockSize,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buffer bytes.Buffer
+	bufferedOutputStream := bufio.NewWriter(&buffer)
+	for _, record := range records {
+		if err := writer.Write(record, nil, bufferedOutputStream, false); err != nil {
+			return nil, err
+		}
+	}
+	if err := writer.Write(nil, nil, bufferedOutputStream, false); err != nil {
+		return nil, err
+	}
+	if err := bufferedOutputStream.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	reader, err := avro.NewOCFReader(&buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return reader, nil
+}
+
+func TestAvroWriterSchemaInference(t *testing.T) {
+	inner := mlrval.NewMlrmapAsRecord()
+	inner.PutReference("p", mlrval.FromInt(1))
+
+	record1 := mlrval.NewMlrmapAsRecord()
+	record1.PutReference("z", mlrval.FromInt(1))
+	record1.PutReference("y", mlrval.FromFloat(2.5))
+	record1.PutReference("x", mlrval.FromInt(3))
+	record1.PutReference("w", mlrval.FromArray([]*mlrval.Mlrval{mlrval.FromString("a")}))
+	record1.PutReference("m", mlrval.FromMap(inner))
+
+	record2 := mlrval.NewMlrmapAsRecord()
+	record2.PutReference("z", mlrval.FromFloat(4.5))
+	record2.PutReference("x", mlrval.FromString("abc"))
+	record2.PutReference("v", mlrval.FromBool(true))
+	record2.PutReference("a-b", mlrval.FromInt(5))
+
+	reader, err := writeAvroRecords(t, 10, record1, record2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `{"type":"record","name":"miller","fields":[` +
+		`{"name":"z","type":["null","double"],"default":null},` +
+		`{"name":"y","type":["null","double"],"default":null},` +
+		`{"name":"x","type":["null","string"],"default":null},` +
+		`{"name":"w","type":["null",{"type":"array","items":["null","string"]}],"default":null},` +
+		`{"name":"m","type":["null",{"type":"record","name":"miller_m","fields":[` +
+		`{"name":"p","type":["null","long"],"default":null}]}],"default":null},` +
+		`{"name":"v","type":["null","boolean"],"default":null},` +
+		`{"name":"a_b","type":["null","long"],"default":null}]}`
+	if reader.Schema().String() != expected {
+		t.Errorf("got\n%s\nexpected\n%s", reader.Schema().String(), expected)
+	}
+}
+
+func TestAvroWriterAfterFirstBlock(t *testing.T) {
+	record1 := mlrval.NewMlrmapAsRecord()
+	record1.PutReference("a", mlrval.FromInt(1))
+	record2 := mlrval.NewMlrmapAsRecord()
+	record2.PutReference("a", mlrval.FromString("xyz"))
+
+	// In the first block, so the schema has a string
+	if _, err := writeAvroRecords(t, 2, record1, record2); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	// After it, so it doesn't fit the long
+	if _, err := writeAvroRecords(t, 1, record1, record2); err == nil {
+		t.Errorf("expected error for a record not fitting the schema")
+	}
+}
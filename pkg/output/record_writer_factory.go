@@ -20,16 +20,58 @@ func Create(writerOptions *cli.TWriterOptions) (IRecordWriter, error) {
 		return NewRecordWriterJSON(writerOptions)
 	case "jsonl":
 		return NewRecordWriterJSONLines(writerOptions)
+	case "geojson":
+		return NewRecordWriterGeoJSON(writerOptions)
 	case "yaml":
 		return NewRecordWriterYAML(writerOptions)
 	case "dcf":
 		return NewRecordWriterDCF(writerOptions)
 	case "recutils":
 		return NewRecordWriterREC(writerOptions)
+	case "parquet":
+		return NewRecordWriterParquet(writerOptions)
+	case "arrow":
+		return NewRecordWriterArrow(writerOptions)
+	case "avro":
+		return NewRecordWriterAvro(writerOptions)
+	case "xlsx":
+		return NewRecordWriterXLSX(writerOptions)
+	case "xml":
+		return NewRecordWriterXML(writerOptions)
+	case "sql":
+		return NewRecordWriterSQL(writerOptions)
+	case "sqlite":
+		return NewRecordWriterSQLite(writerOptions)
+	case "template":
+		return NewRecordWriterTemplate(writerOptions)
+	case "html":
+		return NewRecordWriterHTML(writerOptions)
+	case "latex":
+		return NewRecordWriterLaTeX(writerOptions)
+	case "rst":
+		return NewRecordWriterRST(writerOptions)
+	case "asciidoc":
+		return NewRecordWriterAsciiDoc(writerOptions)
+	case "fixed":
+		return NewRecordWriterFixedWidth(writerOptions)
+	case "msgpack":
+		return NewRecordWriterMsgpack(writerOptions)
+	case "cbor":
+		return NewRecordWriterCBOR(writerOptions)
+	case "logfmt":
+		return NewRecordWriterLogfmt(writerOptions)
+	case "ltsv":
+		return NewRecordWriterLTSV(writerOptions)
+	case "toml":
+		return NewRecordWriterTOML(writerOptions)
+	case "ini":
+		return NewRecordWriterINI(writerOptions)
 	case "md":
 		return NewRecordWriterMarkdown(writerOptions)
 	case "markdown":
 		return NewRecordWriterMarkdown(writerOptions)
+	case "org":
+		return NewRecordWriterOrg(writerOptions)
 	case "nidx":
 		return NewRecordWriterNIDX(writerOptions)
 	case "pprint":
@@ -0,0 +1,72 @@
+// Fixed-width record-writer.
+//
+// Each record is one line, with the columns given by the column-spec file of
+// --fixed-spec or --ofixed-spec, in the spec's order: each value is padded to
+// its column's width, with its column's alignment and pad character. Fields
+// not in the spec are not written; fields in the spec but not in the record
+// are written as empty values. There is no header line, and no separator
+// between columns. Values longer than their column's width are truncated, or
+// are an error, as the spec says.
+
+package output
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+
+	"github.com/johnkerl/miller/v6/pkg/cli"
+	"github.com/johnkerl/miller/v6/pkg/lib"
+	"github.com/johnkerl/miller/v6/pkg/mlrval"
+	"github.com/johnkerl/miller/v6/pkg/types"
+)
+
+type RecordWriterFixedWidth struct {
+	writerOptions *cli.TWriterOptions
+	spec          *lib.FixedWidthSpec
+}
+
+func NewRecordWriterFixedWidth(writerOptions *cli.TWriterOptions) (*RecordWriterFixedWidth, error) {
+	if writerOptions.FixedWidthSpecFile == "" {
+		return nil, fmt.Errorf("fixed-width output needs a column spec: please use --fixed-spec or --ofixed-spec")
+	}
+	spec, err := lib.ReadFixedWidthSpecFile(writerOptions.FixedWidthSpecFile)
+	if err != nil {
+		return nil, err
+	}
+	return &RecordWriterFixedWidth{
+		writerOptions: writerOptions,
+		spec:          spec,
+	}, nil
+}
+
+func (writer *RecordWriterFixedWidth) Write(
+	outrec *mlrval.Mlrmap,
+	_ *types.Context,
+	bufferedOutputStream *bufio.Writer,
+	outputIsStdout bool,
+) error {
+	if outrec == nil {
+		// End of record stream: nothing special for this output format
+		return nil
+	}
+
+	// Format the whole line before writing any of it, so that an overflow
+	// error doesn't leave a partial line.
+	var buffer strings.Builder
+	for _, column := range writer.spec.Columns {
+		value := ""
+		if mv := outrec.Get(column.Name); mv != nil {
+			value = mv.String()
+		}
+		formatted, err := column.Format(value)
+		if err != nil {
+			return fmt.Errorf("fixed: %v", err)
+		}
+		buffer.WriteString(formatted)
+	}
+	bufferedOutputStream.WriteString(buffer.String())
+	bufferedOutputStream.WriteString(writer.writerOptions.ORS)
+
+	return nil
+}
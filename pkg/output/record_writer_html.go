@@ -0,0 +1,119 @@
+// HTML record-writer.
+//
+// Records are written as the rows of a <table>, with the keys in <thead>. As
+// with PPRINT, a change in record keys starts a new table. Keys and values are
+// HTML-escaped. With --html-standalone the tables are within a complete HTML
+// document, with a minimal stylesheet, ready to be served as a page.
+
+package output
+
+import (
+	"bufio"
+	"html"
+	"strings"
+
+	"github.com/johnkerl/miller/v6/pkg/cli"
+	"github.com/johnkerl/miller/v6/pkg/mlrval"
+	"github.com/johnkerl/miller/v6/pkg/types"
+)
+
+const htmlStandaloneHeader = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>%TITLE%</title>
+<style>
+body { font-family: sans-serif; }
+table { border-collapse: collapse; margin-bottom: 1em; }
+th, td { border: 1px solid #ccc; padding: 0.25em 0.5em; text-align: left; }
+th { background-color: #f0f0f0; }
+tbody tr:nth-child(even) { background-color: #fafafa; }
+</style>
+</head>
+<body>
+`
+
+const htmlStandaloneFooter = `</body>
+</html>
+`
+
+type RecordWriterHTML struct {
+	writerOptions *cli.TWriterOptions
+
+	// nil until the first record
+	lastJoinedHeader *string
+}
+
+func NewRecordWriterHTML(writerOptions *cli.TWriterOptions) (*RecordWriterHTML, error) {
+	return &RecordWriterHTML{
+		writerOptions:    writerOptions,
+		lastJoinedHeader: nil,
+	}, nil
+}
+
+func (writer *RecordWriterHTML) Write(
+	outrec *mlrval.Mlrmap,
+	_ *types.Context,
+	bufferedOutputStream *bufio.Writer,
+	outputIsStdout bool,
+) error {
+	if outrec == nil {
+		// End of record stream
+		if writer.lastJoinedHeader != nil {
+			writer.writeTableEnd(bufferedOutputStream)
+			if writer.writerOptions.HTMLStandalone {
+				bufferedOutputStream.WriteString(htmlStandaloneFooter)
+			}
+		}
+		return nil
+	}
+
+	joinedHeader := outrec.GetKeysJoined()
+	if writer.lastJoinedHeader == nil || *writer.lastJoinedHeader != joinedHeader {
+		if writer.lastJoinedHeader != nil {
+			writer.writeTableEnd(bufferedOutputStream)
+			bufferedOutputStream.WriteString("\n")
+		} else if writer.writerOptions.HTMLStandalone {
+			title := html.EscapeString(writer.writerOptions.HTMLTitle)
+			bufferedOutputStream.WriteString(strings.Replace(htmlStandaloneHeader, "%TITLE%", title, 1))
+		}
+		writer.writeTableStart(outrec, bufferedOutputStream)
+		writer.lastJoinedHeader = &joinedHeader
+	}
+
+	bufferedOutputStream.WriteString("    <tr>")
+	for pe := outrec.Head; pe != nil; pe = pe.Next {
+		if writer.writerOptions.RightAlignNumericOutput && pe.Value.IsNumeric() {
+			bufferedOutputStream.WriteString(`<td style="text-align: right">`)
+		} else {
+			bufferedOutputStream.WriteString("<td>")
+		}
+		bufferedOutputStream.WriteString(html.EscapeString(pe.Value.String()))
+		bufferedOutputStream.WriteString("</td>")
+	}
+	bufferedOutputStream.WriteString("</tr>\n")
+
+	return nil
+}
+
+func (writer *RecordWriterHTML) writeTableStart(
+	outrec *mlrval.Mlrmap,
+	bufferedOutputStream *bufio.Writer,
+) {
+	bufferedOutputStream.WriteString("<table>\n")
+	bufferedOutputStream.WriteString("  <thead>\n")
+	bufferedOutputStream.WriteString("    <tr>")
+	for pe := outrec.Head; pe != nil; pe = pe.Next {
+		bufferedOutputStream.WriteString("<th>")
+		bufferedOutputStream.WriteString(html.EscapeString(pe.Key))
+		bufferedOutputStream.WriteString("</th>")
+	}
+	bufferedOutputStream.WriteString("</tr>\n")
+	bufferedOutputStream.WriteString("  </thead>\n")
+	bufferedOutputStream.WriteString("  <tbody>\n")
+}
+
+func (writer *RecordWriterHTML) writeTableEnd(bufferedOutputStream *bufio.Writer) {
+	bufferedOutputStream.WriteString("  </tbody>\n")
+	bufferedOutputStream.WriteString("</table>\n")
+}
@@ -0,0 +1,120 @@
+// INI record-writer. Each record is a section, named by its _section field,
+// as read by the INI record-reader, with key = value lines for the other
+// fields, and blank lines between sections. Only the first record may lack a
+// _section field, or have it empty, since it's written before any section
+// header.
+//
+// Values are double-quoted, with backslash escapes, when the INI
+// record-reader would otherwise not read them back as they are: when they
+// have leading or trailing whitespace, or line breaks, or start with a
+// quote.
+
+package output
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+
+	"github.com/johnkerl/miller/v6/pkg/cli"
+	"github.com/johnkerl/miller/v6/pkg/colorizer"
+	"github.com/johnkerl/miller/v6/pkg/lib"
+	"github.com/johnkerl/miller/v6/pkg/mlrval"
+	"github.com/johnkerl/miller/v6/pkg/types"
+)
+
+type RecordWriterINI struct {
+	writerOptions *cli.TWriterOptions
+	numWritten    int64
+}
+
+func NewRecordWriterINI(writerOptions *cli.TWriterOptions) (*RecordWriterINI, error) {
+	return &RecordWriterINI{
+		writerOptions: writerOptions,
+	}, nil
+}
+
+func (writer *RecordWriterINI) Write(
+	outrec *mlrval.Mlrmap,
+	_ *types.Context,
+	bufferedOutputStream *bufio.Writer,
+	outputIsStdout bool,
+) error {
+	if outrec == nil {
+		// End of record stream: nothing special for this output format
+		return nil
+	}
+	writer.numWritten++
+
+	section := ""
+	if value := outrec.Get(lib.SECTION_FIELD_NAME); value != nil {
+		section = value.String()
+	}
+
+	if section == "" {
+		if writer.numWritten > 1 {
+			return fmt.Errorf(
+				"ini: record %d has no %s field; only the first record can be without a section. "+
+					"Please set $%s to a section name.",
+				writer.numWritten, lib.SECTION_FIELD_NAME, lib.SECTION_FIELD_NAME,
+			)
+		}
+	} else {
+		if strings.ContainsAny(section, "\r\n") {
+			return fmt.Errorf("ini: section name \"%s\" cannot contain CR or LF", section)
+		}
+		if writer.numWritten > 1 {
+			bufferedOutputStream.WriteString("\n")
+		}
+		bufferedOutputStream.WriteString("[")
+		bufferedOutputStream.WriteString(colorizer.MaybeColorizeKey(section, outputIsStdout))
+		bufferedOutputStream.WriteString("]\n")
+	}
+
+	for pe := outrec.Head; pe != nil; pe = pe.Next {
+		if pe.Key == lib.SECTION_FIELD_NAME {
+			continue
+		}
+		key := strings.TrimSpace(pe.Key)
+		if key == "" || key != pe.Key || strings.ContainsAny(key, "=:\r\n") ||
+			key[0] == '[' || key[0] == ';' || key[0] == '#' {
+			return fmt.Errorf("ini: key \"%s\" cannot be written in INI format", pe.Key)
+		}
+		bufferedOutputStream.WriteString(colorizer.MaybeColorizeKey(key, outputIsStdout))
+		bufferedOutputStream.WriteString(" = ")
+		bufferedOutputStream.WriteString(colorizer.MaybeColorizeValue(formatINIValue(pe.Value.String()), outputIsStdout))
+		bufferedOutputStream.WriteString("\n")
+	}
+
+	return nil
+}
+
+func formatINIValue(value string) string {
+	if value == "" {
+		return value
+	}
+	if value == strings.TrimSpace(value) && !strings.ContainsAny(value, "\r\n") &&
+		value[0] != '"' && value[0] != '\'' {
+		return value
+	}
+	var buffer strings.Builder
+	buffer.WriteByte('"')
+	for _, r := range value {
+		switch r {
+		case '"':
+			buffer.WriteString(`\"`)
+		case '\\':
+			buffer.WriteString(`\\`)
+		case '\n':
+			buffer.WriteString(`\n`)
+		case '\r':
+			buffer.WriteString(`\r`)
+		case '\t':
+			buffer.WriteString(`\t`)
+		default:
+			buffer.WriteRune(r)
+		}
+	}
+	buffer.WriteByte('"')
+	return buffer.String()
+}
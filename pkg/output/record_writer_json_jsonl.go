@@ -2,8 +2,10 @@ package output
 
 import (
 	"bufio"
+	"fmt"
 
 	"github.com/johnkerl/miller/v6/pkg/cli"
+	"github.com/johnkerl/miller/v6/pkg/lib"
 	"github.com/johnkerl/miller/v6/pkg/mlrval"
 	"github.com/johnkerl/miller/v6/pkg/types"
 )
@@ -14,8 +16,13 @@ type RecordWriterJSON struct {
 	jsonFormatting mlrval.TJSONFormatting
 	jvQuoteAll     bool
 
+	// For GeoJSON, records are written as the features of a
+	// FeatureCollection; see featureFromRecord.
+	isGeoJSON bool
+
 	// State:
 	wroteAnyRecords bool
+	numWritten      int64
 }
 
 func NewRecordWriterJSON(writerOptions *cli.TWriterOptions) (*RecordWriterJSON, error) {
@@ -43,6 +50,23 @@ func NewRecordWriterJSONLines(writerOptions *cli.TWriterOptions) (*RecordWriterJ
 	}, nil
 }
 
+func NewRecordWriterGeoJSON(writerOptions *cli.TWriterOptions) (*RecordWriterJSON, error) {
+	if (writerOptions.GeoJSONLatField == "") != (writerOptions.GeoJSONLonField == "") {
+		return nil, fmt.Errorf("for GeoJSON, please specify both --geojson-lat-field and --geojson-lon-field, or neither")
+	}
+	var jsonFormatting mlrval.TJSONFormatting = mlrval.JSON_SINGLE_LINE
+	if writerOptions.JSONOutputMultiline {
+		jsonFormatting = mlrval.JSON_MULTILINE
+	}
+	return &RecordWriterJSON{
+		writerOptions:   writerOptions,
+		jsonFormatting:  jsonFormatting,
+		jvQuoteAll:      writerOptions.JVQuoteAll,
+		isGeoJSON:       true,
+		wroteAnyRecords: false,
+	}, nil
+}
+
 func (writer *RecordWriterJSON) Write(
 	outrec *mlrval.Mlrmap,
 	context *types.Context,
@@ -53,6 +77,9 @@ func (writer *RecordWriterJSON) Write(
 		outrec.StringifyValuesRecursively()
 	}
 
+	if writer.isGeoJSON {
+		return writer.writeGeoJSON(outrec, bufferedOutputStream, outputIsStdout)
+	}
 	if writer.writerOptions.WrapJSONOutputInOuterList {
 		return writer.writeWithListWrap(outrec, context, bufferedOutputStream, outputIsStdout)
 	}
@@ -122,3 +149,103 @@ func (writer *RecordWriterJSON) writeWithoutListWrap(
 	bufferedOutputStream.WriteString("\n")
 	return nil
 }
+
+func (writer *RecordWriterJSON) writeGeoJSON(
+	outrec *mlrval.Mlrmap,
+	bufferedOutputStream *bufio.Writer,
+	outputIsStdout bool,
+) error {
+	if outrec != nil { // Not end of record stream
+		writer.numWritten++
+		feature, err := writer.featureFromRecord(outrec)
+		if err != nil {
+			return err
+		}
+		s, err := feature.FormatAsJSON(writer.jsonFormatting, outputIsStdout)
+		if err != nil {
+			return err
+		}
+
+		if !writer.wroteAnyRecords {
+			bufferedOutputStream.WriteString("{\n\"type\": \"FeatureCollection\",\n\"features\": [\n")
+		} else {
+			bufferedOutputStream.WriteString(",\n")
+		}
+		bufferedOutputStream.WriteString(s)
+		writer.wroteAnyRecords = true
+
+	} else { // End of record stream
+		// With no records, this is still a valid GeoJSON file, with no features.
+		if !writer.wroteAnyRecords {
+			bufferedOutputStream.WriteString("{\n\"type\": \"FeatureCollection\",\n\"features\": [")
+		}
+		bufferedOutputStream.WriteString("\n]\n}\n")
+	}
+	return nil
+}
+
+// featureFromRecord is the reverse of the GeoJSON record-reader: the _id
+// field, if any, is the feature's id, and the geometry field is its geometry,
+// unless there are lat/lon fields to make a Point from; the other fields are
+// its properties.
+func (writer *RecordWriterJSON) featureFromRecord(outrec *mlrval.Mlrmap) (*mlrval.Mlrmap, error) {
+	feature := mlrval.NewMlrmap()
+	feature.PutReference("type", mlrval.FromString("Feature"))
+	if id := outrec.Get(lib.GEOJSON_ID_FIELD_NAME); id != nil {
+		feature.PutReference("id", id)
+	}
+
+	geometry, err := writer.pointFromRecord(outrec)
+	if err != nil {
+		return nil, err
+	}
+	if geometry == nil {
+		geometry = outrec.Get(lib.GEOJSON_GEOMETRY_FIELD_NAME)
+		if geometry == nil || geometry.IsVoid() {
+			geometry = mlrval.NULL
+		} else if !geometry.IsMap() && !geometry.IsNull() {
+			return nil, fmt.Errorf(
+				"geojson: record %d: %s must be a map; got %s",
+				writer.numWritten, lib.GEOJSON_GEOMETRY_FIELD_NAME, geometry.GetTypeName(),
+			)
+		}
+	}
+	feature.PutReference("geometry", geometry)
+
+	properties := mlrval.NewMlrmap()
+	for pe := outrec.Head; pe != nil; pe = pe.Next {
+		if pe.Key != lib.GEOJSON_ID_FIELD_NAME && pe.Key != lib.GEOJSON_GEOMETRY_FIELD_NAME {
+			properties.PutReference(pe.Key, pe.Value)
+		}
+	}
+	feature.PutReference("properties", mlrval.FromMap(properties))
+
+	return feature, nil
+}
+
+// pointFromRecord returns a Point geometry made from the --geojson-lat-field
+// and --geojson-lon-field fields, or nil if those weren't specified, or are
+// absent or empty in the record.
+func (writer *RecordWriterJSON) pointFromRecord(outrec *mlrval.Mlrmap) (*mlrval.Mlrval, error) {
+	if writer.writerOptions.GeoJSONLatField == "" {
+		return nil, nil
+	}
+	lat := outrec.Get(writer.writerOptions.GeoJSONLatField)
+	lon := outrec.Get(writer.writerOptions.GeoJSONLonField)
+	if lat == nil || lon == nil || lat.IsVoid() || lon.IsVoid() {
+		return nil, nil
+	}
+	for _, value := range []*mlrval.Mlrval{lat, lon} {
+		if !value.IsNumeric() {
+			return nil, fmt.Errorf(
+				"geojson: record %d: coordinate \"%s\" is not a number", writer.numWritten, value.String(),
+			)
+		}
+	}
+
+	point := mlrval.NewMlrmap()
+	point.PutReference("type", mlrval.FromString("Point"))
+	// GeoJSON has longitude first.
+	point.PutReference("coordinates", mlrval.FromArray([]*mlrval.Mlrval{lon, lat}))
+	return mlrval.FromMap(point), nil
+}
@@ -0,0 +1,109 @@
+// LaTeX record-writer.
+//
+// Each homogeneous batch of records is a tabular environment with booktabs
+// rules, for \usepackage{booktabs}:
+//
+//   \begin{tabular}{lll}
+//   \toprule
+//   a   & b   & i \\
+//   \midrule
+//   pan & pan & 1 \\
+//   eks & pan & 2 \\
+//   \bottomrule
+//   \end{tabular}
+//
+// Characters special to LaTeX are escaped. With --right-align-numeric,
+// numeric columns are r rather than l.
+
+package output
+
+import (
+	"bufio"
+	"strings"
+
+	"github.com/johnkerl/miller/v6/pkg/cli"
+	"github.com/johnkerl/miller/v6/pkg/mlrval"
+	"github.com/johnkerl/miller/v6/pkg/types"
+)
+
+var latexReplacer = strings.NewReplacer(
+	`\`, `\textbackslash{}`,
+	`&`, `\&`,
+	`%`, `\%`,
+	`$`, `\$`,
+	`#`, `\#`,
+	`_`, `\_`,
+	`{`, `\{`,
+	`}`, `\}`,
+	`~`, `\textasciitilde{}`,
+	`^`, `\textasciicircum{}`,
+	`|`, `\textbar{}`,
+	`<`, `\textless{}`,
+	`>`, `\textgreater{}`,
+)
+
+type RecordWriterLaTeX struct {
+	writerOptions *cli.TWriterOptions
+	batcher       tMarkupTableBatcher
+}
+
+func NewRecordWriterLaTeX(writerOptions *cli.TWriterOptions) (*RecordWriterLaTeX, error) {
+	return &RecordWriterLaTeX{
+		writerOptions: writerOptions,
+	}, nil
+}
+
+func (writer *RecordWriterLaTeX) Write(
+	outrec *mlrval.Mlrmap,
+	_ *types.Context,
+	bufferedOutputStream *bufio.Writer,
+	outputIsStdout bool,
+) error {
+	writer.batcher.add(outrec, bufferedOutputStream, func(records []*mlrval.Mlrmap) {
+		writer.writeTable(records, bufferedOutputStream)
+	})
+	return nil
+}
+
+func (writer *RecordWriterLaTeX) writeTable(
+	records []*mlrval.Mlrmap,
+	bufferedOutputStream *bufio.Writer,
+) {
+	table := newMarkupTable(records, escapeLaTeX, writer.writerOptions.RightAlignNumericOutput)
+
+	bufferedOutputStream.WriteString(`\begin{tabular}{`)
+	for _, rightAligned := range table.rightAligned {
+		if rightAligned {
+			bufferedOutputStream.WriteString("r")
+		} else {
+			bufferedOutputStream.WriteString("l")
+		}
+	}
+	bufferedOutputStream.WriteString("}\n")
+	bufferedOutputStream.WriteString("\\toprule\n")
+	writer.writeRow(table, table.header, bufferedOutputStream)
+	bufferedOutputStream.WriteString("\\midrule\n")
+	for _, row := range table.rows {
+		writer.writeRow(table, row, bufferedOutputStream)
+	}
+	bufferedOutputStream.WriteString("\\bottomrule\n")
+	bufferedOutputStream.WriteString("\\end{tabular}\n")
+}
+
+func (writer *RecordWriterLaTeX) writeRow(
+	table *tMarkupTable,
+	row [][]string,
+	bufferedOutputStream *bufio.Writer,
+) {
+	for i, cell := range row {
+		if i > 0 {
+			bufferedOutputStream.WriteString(" & ")
+		}
+		bufferedOutputStream.WriteString(table.pad(cell[0], i))
+	}
+	bufferedOutputStream.WriteString(" \\\\\n")
+}
+
+func escapeLaTeX(s string) []string {
+	return []string{latexReplacer.Replace(markupTableSingleLine(s))}
+}
@@ -0,0 +1,60 @@
+// Logfmt record-writer: space-separated key=value pairs, one record per line.
+// Values are double-quoted, with backslash escapes, only when needed; keys
+// can't be quoted, so characters not allowed in them are replaced. See the
+// logfmt package.
+
+package output
+
+import (
+	"bufio"
+	"fmt"
+
+	"github.com/johnkerl/miller/v6/pkg/cli"
+	"github.com/johnkerl/miller/v6/pkg/colorizer"
+	"github.com/johnkerl/miller/v6/pkg/logfmt"
+	"github.com/johnkerl/miller/v6/pkg/mlrval"
+	"github.com/johnkerl/miller/v6/pkg/types"
+)
+
+type RecordWriterLogfmt struct {
+	writerOptions *cli.TWriterOptions
+}
+
+func NewRecordWriterLogfmt(writerOptions *cli.TWriterOptions) (*RecordWriterLogfmt, error) {
+	if writerOptions.OFS != " " {
+		return nil, fmt.Errorf("for logfmt, OFS cannot be altered")
+	}
+	if writerOptions.OPS != "=" {
+		return nil, fmt.Errorf("for logfmt, OPS cannot be altered")
+	}
+	if writerOptions.ORS != "\n" && writerOptions.ORS != "\r\n" {
+		return nil, fmt.Errorf("for logfmt, ORS must be newline or carriage-return/newline")
+	}
+	return &RecordWriterLogfmt{
+		writerOptions: writerOptions,
+	}, nil
+}
+
+func (writer *RecordWriterLogfmt) Write(
+	outrec *mlrval.Mlrmap,
+	_ *types.Context,
+	bufferedOutputStream *bufio.Writer,
+	outputIsStdout bool,
+) error {
+	if outrec == nil {
+		// End of record stream: nothing special for this output format
+		return nil
+	}
+
+	for pe := outrec.Head; pe != nil; pe = pe.Next {
+		if pe != outrec.Head {
+			bufferedOutputStream.WriteString(writer.writerOptions.OFS)
+		}
+		bufferedOutputStream.WriteString(colorizer.MaybeColorizeKey(logfmt.FormatKey(pe.Key), outputIsStdout))
+		bufferedOutputStream.WriteString(writer.writerOptions.OPS)
+		bufferedOutputStream.WriteString(colorizer.MaybeColorizeValue(logfmt.FormatValue(pe.Value.String()), outputIsStdout))
+	}
+	bufferedOutputStream.WriteString(writer.writerOptions.ORS)
+
+	return nil
+}
@@ -0,0 +1,59 @@
+// LTSV record-writer: tab-separated label:value pairs, one record per line.
+// LTSV has no quoting, so, as with TSV, tab, newline, carriage return, and
+// backslash in labels and values are written as \t, \n, \r, and \\.
+
+package output
+
+import (
+	"bufio"
+	"fmt"
+
+	"github.com/johnkerl/miller/v6/pkg/cli"
+	"github.com/johnkerl/miller/v6/pkg/colorizer"
+	"github.com/johnkerl/miller/v6/pkg/lib"
+	"github.com/johnkerl/miller/v6/pkg/mlrval"
+	"github.com/johnkerl/miller/v6/pkg/types"
+)
+
+type RecordWriterLTSV struct {
+	writerOptions *cli.TWriterOptions
+}
+
+func NewRecordWriterLTSV(writerOptions *cli.TWriterOptions) (*RecordWriterLTSV, error) {
+	if writerOptions.OFS != "\t" {
+		return nil, fmt.Errorf("for LTSV, OFS cannot be altered")
+	}
+	if writerOptions.OPS != ":" {
+		return nil, fmt.Errorf("for LTSV, OPS cannot be altered")
+	}
+	if writerOptions.ORS != "\n" && writerOptions.ORS != "\r\n" {
+		return nil, fmt.Errorf("for LTSV, ORS must be newline or carriage-return/newline")
+	}
+	return &RecordWriterLTSV{
+		writerOptions: writerOptions,
+	}, nil
+}
+
+func (writer *RecordWriterLTSV) Write(
+	outrec *mlrval.Mlrmap,
+	_ *types.Context,
+	bufferedOutputStream *bufio.Writer,
+	outputIsStdout bool,
+) error {
+	if outrec == nil {
+		// End of record stream: nothing special for this output format
+		return nil
+	}
+
+	for pe := outrec.Head; pe != nil; pe = pe.Next {
+		if pe != outrec.Head {
+			bufferedOutputStream.WriteString(writer.writerOptions.OFS)
+		}
+		bufferedOutputStream.WriteString(colorizer.MaybeColorizeKey(lib.TSVEncodeField(pe.Key), outputIsStdout))
+		bufferedOutputStream.WriteString(writer.writerOptions.OPS)
+		bufferedOutputStream.WriteString(colorizer.MaybeColorizeValue(lib.TSVEncodeField(pe.Value.String()), outputIsStdout))
+	}
+	bufferedOutputStream.WriteString(writer.writerOptions.ORS)
+
+	return nil
+}
@@ -0,0 +1,72 @@
+// MessagePack and CBOR record-writers. Each record is a top-level map, with
+// keys in record order, one after another with no framing between them.
+// Bytes values are written as binary, and ints and floats as such.
+
+package output
+
+import (
+	"bufio"
+
+	"github.com/vmihailenco/msgpack/v5"
+
+	"github.com/johnkerl/miller/v6/pkg/cli"
+	"github.com/johnkerl/miller/v6/pkg/mlrval"
+	"github.com/johnkerl/miller/v6/pkg/types"
+)
+
+type RecordWriterMsgpack struct {
+	writerOptions *cli.TWriterOptions
+	encoder       *msgpack.Encoder
+	// The stream the encoder writes to, which is the same on every call for
+	// main output but not necessarily for DSL redirects
+	encoderStream *bufio.Writer
+}
+
+func NewRecordWriterMsgpack(writerOptions *cli.TWriterOptions) (*RecordWriterMsgpack, error) {
+	return &RecordWriterMsgpack{
+		writerOptions: writerOptions,
+	}, nil
+}
+
+func (writer *RecordWriterMsgpack) Write(
+	outrec *mlrval.Mlrmap,
+	_ *types.Context,
+	bufferedOutputStream *bufio.Writer,
+	outputIsStdout bool,
+) error {
+	if outrec == nil {
+		// End of record stream: nothing special for this output format
+		return nil
+	}
+	if writer.encoder == nil || writer.encoderStream != bufferedOutputStream {
+		writer.encoder = msgpack.NewEncoder(bufferedOutputStream)
+		writer.encoderStream = bufferedOutputStream
+	}
+	return mlrval.MlrmapEncodeToMsgpack(outrec, writer.encoder)
+}
+
+type RecordWriterCBOR struct {
+	writerOptions *cli.TWriterOptions
+	buffer        []byte
+}
+
+func NewRecordWriterCBOR(writerOptions *cli.TWriterOptions) (*RecordWriterCBOR, error) {
+	return &RecordWriterCBOR{
+		writerOptions: writerOptions,
+	}, nil
+}
+
+func (writer *RecordWriterCBOR) Write(
+	outrec *mlrval.Mlrmap,
+	_ *types.Context,
+	bufferedOutputStream *bufio.Writer,
+	outputIsStdout bool,
+) error {
+	if outrec == nil {
+		// End of record stream: nothing special for this output format
+		return nil
+	}
+	writer.buffer = mlrval.MlrmapAppendCBOR(writer.buffer[:0], outrec)
+	_, err := bufferedOutputStream.Write(writer.buffer)
+	return err
+}
@@ -0,0 +1,150 @@
+// Emacs org-mode table record-writer. Records are written as tables with
+// columns padded to a uniform width, with an hline after the header, and a
+// new table, after a blank line, whenever the schema changes. Since column
+// widths depend on every value, nothing is written for a table until its last
+// record is seen. A | within a cell is written as \vert{}, which org-mode
+// displays as |, and which the org-mode record-reader reads back as |.
+
+package output
+
+import (
+	"bufio"
+	"strings"
+
+	"github.com/johnkerl/miller/v6/pkg/cli"
+	"github.com/johnkerl/miller/v6/pkg/colorizer"
+	"github.com/johnkerl/miller/v6/pkg/lib"
+	"github.com/johnkerl/miller/v6/pkg/mlrval"
+	"github.com/johnkerl/miller/v6/pkg/types"
+)
+
+type RecordWriterOrg struct {
+	writerOptions *cli.TWriterOptions
+
+	batch             []*mlrval.Mlrmap
+	batchJoinedHeader *string
+	numBatchesOutput  int
+}
+
+func NewRecordWriterOrg(writerOptions *cli.TWriterOptions) (*RecordWriterOrg, error) {
+	return &RecordWriterOrg{
+		writerOptions: writerOptions,
+	}, nil
+}
+
+func (writer *RecordWriterOrg) Write(
+	outrec *mlrval.Mlrmap,
+	_ *types.Context,
+	bufferedOutputStream *bufio.Writer,
+	outputIsStdout bool,
+) error {
+	if outrec == nil { // end of record stream
+		if len(writer.batch) > 0 {
+			writer.flushBatch(bufferedOutputStream, outputIsStdout)
+		}
+		return nil
+	}
+	if outrec.IsEmpty() {
+		return nil
+	}
+
+	joinedHeader := outrec.GetKeysJoined()
+	if writer.batchJoinedHeader != nil && *writer.batchJoinedHeader != joinedHeader {
+		writer.flushBatch(bufferedOutputStream, outputIsStdout)
+	}
+	if writer.batchJoinedHeader == nil {
+		writer.batchJoinedHeader = &joinedHeader
+	}
+	writer.batch = append(writer.batch, outrec)
+	return nil
+}
+
+func (writer *RecordWriterOrg) flushBatch(
+	bufferedOutputStream *bufio.Writer,
+	outputIsStdout bool,
+) {
+	if writer.numBatchesOutput > 0 {
+		bufferedOutputStream.WriteString(writer.writerOptions.ORS)
+	}
+
+	first := writer.batch[0]
+	n := int(first.FieldCount)
+
+	// With --right-align-numeric, columns whose values are all numeric are
+	// right-aligned, header included, as org-mode does for numeric columns.
+	rightAligned := make([]bool, n)
+	widths := make([]int, n)
+	i := 0
+	for pe := first.Head; pe != nil; pe = pe.Next {
+		rightAligned[i] = writer.writerOptions.RightAlignNumericOutput
+		widths[i] = max(lib.DisplayWidth(pe.Key), 1)
+		i++
+	}
+
+	cells := make([][]string, len(writer.batch))
+	for j, rec := range writer.batch {
+		cells[j] = make([]string, 0, n)
+		i := 0
+		for pe := rec.Head; pe != nil; pe = pe.Next {
+			value := strings.ReplaceAll(pe.Value.String(), "|", `\vert{}`)
+			cells[j] = append(cells[j], value)
+			widths[i] = max(widths[i], lib.DisplayWidth(value))
+			if !pe.Value.IsNumeric() {
+				rightAligned[i] = false
+			}
+			i++
+		}
+	}
+
+	// Header
+	bufferedOutputStream.WriteString("|")
+	i = 0
+	for pe := first.Head; pe != nil; pe = pe.Next {
+		writer.writeCell(bufferedOutputStream, colorizer.MaybeColorizeKey(pe.Key, outputIsStdout),
+			widths[i]-lib.DisplayWidth(pe.Key), rightAligned[i])
+		i++
+	}
+	bufferedOutputStream.WriteString(writer.writerOptions.ORS)
+
+	// Hline
+	bufferedOutputStream.WriteString("|")
+	for i := range widths {
+		if i > 0 {
+			bufferedOutputStream.WriteString("+")
+		}
+		bufferedOutputStream.WriteString(strings.Repeat("-", widths[i]+2))
+	}
+	bufferedOutputStream.WriteString("|")
+	bufferedOutputStream.WriteString(writer.writerOptions.ORS)
+
+	// Data
+	for _, row := range cells {
+		bufferedOutputStream.WriteString("|")
+		for i, value := range row {
+			writer.writeCell(bufferedOutputStream, colorizer.MaybeColorizeValue(value, outputIsStdout),
+				widths[i]-lib.DisplayWidth(value), rightAligned[i])
+		}
+		bufferedOutputStream.WriteString(writer.writerOptions.ORS)
+	}
+
+	writer.batch = nil
+	writer.batchJoinedHeader = nil
+	writer.numBatchesOutput++
+}
+
+func (writer *RecordWriterOrg) writeCell(
+	bufferedOutputStream *bufio.Writer,
+	text string,
+	padding int,
+	rightAligned bool,
+) {
+	bufferedOutputStream.WriteString(" ")
+	if rightAligned {
+		writePadding(bufferedOutputStream, padding)
+		bufferedOutputStream.WriteString(text)
+	} else {
+		bufferedOutputStream.WriteString(text)
+		writePadding(bufferedOutputStream, padding)
+	}
+	bufferedOutputStream.WriteString(" |")
+}
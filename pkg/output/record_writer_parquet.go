@@ -0,0 +1,430 @@
+// Parquet record-writer.
+//
+// Parquet needs a schema up front, while Miller records are schema-free. We
+// buffer the first row group's worth of records and infer the schema from
+// them: the union of their keys in first-seen order, with each column's type
+// being the narrowest one which holds all of that column's values. Records
+// after the first row group must fit that schema.
+//
+// Type mapping:
+// * int -> INT64
+// * float, or a mix of int and float -> DOUBLE
+// * boolean -> BOOLEAN
+// * string, or a mix of other scalar types -> BYTE_ARRAY (STRING)
+// * bytes -> BYTE_ARRAY
+// * map -> group
+// * array -> LIST
+// * empty, or empty map -> null
+//
+// All columns are optional, so records lacking some fields are written with
+// nulls for them.
+
+package output
+
+import (
+	"bufio"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/parquet-go/parquet-go/compress"
+	"github.com/parquet-go/parquet-go/encoding"
+
+	"github.com/johnkerl/miller/v6/pkg/cli"
+	"github.com/johnkerl/miller/v6/pkg/mlrval"
+	"github.com/johnkerl/miller/v6/pkg/types"
+	"github.com/johnkerl/miller/v6/pkg/version"
+)
+
+type RecordWriterParquet struct {
+	writerOptions *cli.TWriterOptions
+	compression   compress.Codec
+	rowGroupSize  int64
+
+	// Used until the schema is known
+	bufferedRecords []*mlrval.Mlrmap
+
+	// Used after the schema is known
+	root          *parquetWriterNode
+	parquetWriter *parquet.Writer
+	rows          []parquet.Row
+}
+
+func NewRecordWriterParquet(writerOptions *cli.TWriterOptions) (*RecordWriterParquet, error) {
+	compression, err := parquetCompressionCodec(writerOptions.ParquetCompression)
+	if err != nil {
+		return nil, err
+	}
+	rowGroupSize := writerOptions.ParquetRowGroupSize
+	if rowGroupSize <= 0 {
+		return nil, fmt.Errorf("parquet row-group size must be positive; got %d", rowGroupSize)
+	}
+	return &RecordWriterParquet{
+		writerOptions:   writerOptions,
+		compression:     compression,
+		rowGroupSize:    rowGroupSize,
+		bufferedRecords: make([]*mlrval.Mlrmap, 0),
+	}, nil
+}
+
+func parquetCompressionCodec(name string) (compress.Codec, error) {
+	switch name {
+	case "none", "uncompressed":
+		return &parquet.Uncompressed, nil
+	case "snappy":
+		return &parquet.Snappy, nil
+	case "gzip":
+		return &parquet.Gzip, nil
+	case "zstd":
+		return &parquet.Zstd, nil
+	case "brotli":
+		return &parquet.Brotli, nil
+	case "lz4":
+		return &parquet.Lz4Raw, nil
+	default:
+		return nil, fmt.Errorf(
+			"parquet compression \"%s\" not found; please use one of none, snappy, gzip, zstd, brotli, lz4",
+			name,
+		)
+	}
+}
+
+func (writer *RecordWriterParquet) Write(
+	outrec *mlrval.Mlrmap,
+	_ *types.Context,
+	bufferedOutputStream *bufio.Writer,
+	outputIsStdout bool,
+) error {
+	if writer.parquetWriter == nil {
+		if outrec != nil {
+			writer.bufferedRecords = append(writer.bufferedRecords, outrec)
+			if int64(len(writer.bufferedRecords)) < writer.rowGroupSize {
+				return nil
+			}
+		} else if len(writer.bufferedRecords) == 0 {
+			// No records at all: no output at all, as with CSV.
+			return nil
+		}
+
+		if err := writer.startWriting(bufferedOutputStream); err != nil {
+			return err
+		}
+		bufferedRecords := writer.bufferedRecords
+		writer.bufferedRecords = nil
+		for _, record := range bufferedRecords {
+			if err := writer.writeRecord(record); err != nil {
+				return err
+			}
+		}
+		if outrec != nil {
+			return nil
+		}
+	} else if outrec != nil {
+		return writer.writeRecord(outrec)
+	}
+
+	// End of record stream
+	if err := writer.flushRowGroup(); err != nil {
+		return err
+	}
+	return writer.parquetWriter.Close()
+}
+
+func (writer *RecordWriterParquet) startWriting(bufferedOutputStream *bufio.Writer) error {
+	inferrer := newColumnTypeInferrer()
+	for _, record := range writer.bufferedRecords {
+		if err := inferrer.addRecord(record); err != nil {
+			return fmt.Errorf("parquet: %v", err)
+		}
+	}
+	if len(inferrer.keys) == 0 {
+		return fmt.Errorf("parquet: cannot write records having no fields")
+	}
+
+	root := inferrer.toParquetNode(new(int))
+	writer.root = root
+	schema := parquet.NewSchema("miller", root.node)
+	writer.parquetWriter = parquet.NewWriter(
+		bufferedOutputStream,
+		schema,
+		parquet.Compression(writer.compression),
+		parquet.MaxRowsPerRowGroup(writer.rowGroupSize),
+		parquet.CreatedBy("mlr", version.STRING, ""),
+	)
+	writer.rows = make([]parquet.Row, 0, writer.rowGroupSize)
+	return nil
+}
+
+func (writer *RecordWriterParquet) writeRecord(record *mlrval.Mlrmap) error {
+	for pe := record.Head; pe != nil; pe = pe.Next {
+		if writer.root.childIndex(pe.Key) < 0 {
+			return fmt.Errorf(
+				"parquet: field \"%s\" was not present in the first %d records, from which the schema was inferred",
+				pe.Key, writer.rowGroupSize,
+			)
+		}
+	}
+
+	row := make(parquet.Row, 0, writer.root.numLeaves)
+	row, err := writer.root.appendContent(row, mlrval.FromMap(record), "", 0, 0, 0)
+	if err != nil {
+		return err
+	}
+	// Values must be in column order. List elements which are groups produce
+	// values interleaved across their columns.
+	sort.SliceStable(row, func(i, j int) bool {
+		return row[i].Column() < row[j].Column()
+	})
+
+	writer.rows = append(writer.rows, row)
+	if int64(len(writer.rows)) >= writer.rowGroupSize {
+		return writer.flushRowGroup()
+	}
+	return nil
+}
+
+func (writer *RecordWriterParquet) flushRowGroup() error {
+	if len(writer.rows) == 0 {
+		return nil
+	}
+	if _, err := writer.parquetWriter.WriteRows(writer.rows); err != nil {
+		return err
+	}
+	writer.rows = writer.rows[:0]
+	return writer.parquetWriter.Flush()
+}
+
+// ----------------------------------------------------------------
+// SCHEMA CONSTRUCTION
+
+func (inferrer *columnTypeInferrer) toParquetNode(pnextLeaf *int) *parquetWriterNode {
+	writerNode := &parquetWriterNode{
+		kind:      inferrer.kind,
+		firstLeaf: *pnextLeaf,
+	}
+
+	switch inferrer.kind {
+	case columnKindInt:
+		writerNode.node = parquet.Int(64)
+	case columnKindFloat:
+		writerNode.node = parquet.Leaf(parquet.DoubleType)
+	case columnKindBool:
+		writerNode.node = parquet.Leaf(parquet.BooleanType)
+	case columnKindBytes:
+		writerNode.node = parquet.Leaf(parquet.ByteArrayType)
+	case columnKindMap:
+		group := &parquetOrderedGroup{}
+		for _, key := range inferrer.keys {
+			child := inferrer.children[key].toParquetNode(pnextLeaf)
+			child.name = key
+			writerNode.children = append(writerNode.children, child)
+			group.fields = append(group.fields, &parquetOrderedField{
+				Node: parquet.Optional(child.node),
+				name: key,
+			})
+		}
+		writerNode.node = group
+	case columnKindArray:
+		element := inferrer.element.toParquetNode(pnextLeaf)
+		writerNode.children = []*parquetWriterNode{element}
+		writerNode.node = parquet.List(parquet.Optional(element.node))
+	default:
+		writerNode.kind = columnKindString
+		writerNode.node = parquet.String()
+	}
+
+	writerNode.numLeaves = *pnextLeaf - writerNode.firstLeaf
+	if writerNode.numLeaves == 0 {
+		// Leaf
+		*pnextLeaf++
+		writerNode.numLeaves = 1
+	}
+	return writerNode
+}
+
+// ----------------------------------------------------------------
+// RECORD DISASSEMBLY
+//
+// Each record is turned into a parquet.Row, which is a list of leaf-column
+// values each annotated with repetition and definition levels. Every field in
+// our schema is optional, and every list is a three-level list (an optional
+// group, containing a repeated group, containing an optional element) so:
+//
+// * A null field gets definition level equal to its parent's.
+// * Each present field adds one to the definition level.
+// * A present list adds one more for its repeated group when nonempty.
+// * List elements after the first one get the list's repetition level.
+
+type parquetWriterNode struct {
+	node      parquet.Node
+	name      string
+	kind      tColumnKind
+	firstLeaf int
+	numLeaves int
+	children  []*parquetWriterNode
+}
+
+func (writerNode *parquetWriterNode) childIndex(name string) int {
+	for i, child := range writerNode.children {
+		if child.name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func (writerNode *parquetWriterNode) appendNulls(
+	row parquet.Row,
+	repLevel int,
+	defLevel int,
+) parquet.Row {
+	for column := writerNode.firstLeaf; column < writerNode.firstLeaf+writerNode.numLeaves; column++ {
+		row = append(row, parquet.NullValue().Level(repLevel, defLevel, column))
+	}
+	return row
+}
+
+// appendField handles an optional field: null or present. The name is for
+// error messages. The list repetition level is that of the nearest enclosing
+// list, or zero if none.
+func (writerNode *parquetWriterNode) appendField(
+	row parquet.Row,
+	value *mlrval.Mlrval,
+	name string,
+	repLevel int,
+	defLevel int,
+	listRepLevel int,
+) (parquet.Row, error) {
+	if value == nil || columnKindOf(value) == columnKindUnknown {
+		return writerNode.appendNulls(row, repLevel, defLevel), nil
+	}
+	return writerNode.appendContent(row, value, name, repLevel, defLevel+1, listRepLevel)
+}
+
+func (writerNode *parquetWriterNode) appendContent(
+	row parquet.Row,
+	value *mlrval.Mlrval,
+	name string,
+	repLevel int,
+	defLevel int,
+	listRepLevel int,
+) (parquet.Row, error) {
+	switch writerNode.kind {
+	case columnKindMap:
+		mapval := value.GetMap()
+		if mapval == nil {
+			return nil, columnTypeError("parquet", name, writerNode.kind, value)
+		}
+		for pe := mapval.Head; pe != nil; pe = pe.Next {
+			if writerNode.childIndex(pe.Key) < 0 {
+				return nil, fmt.Errorf(
+					"parquet: field \"%s\" in map \"%s\" was not present in the records from which the schema was inferred",
+					pe.Key, name,
+				)
+			}
+		}
+		var err error
+		for _, child := range writerNode.children {
+			row, err = child.appendField(row, mapval.Get(child.name), child.name, repLevel, defLevel, listRepLevel)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return row, nil
+
+	case columnKindArray:
+		arrayval := value.GetArray()
+		if arrayval == nil {
+			return nil, columnTypeError("parquet", name, writerNode.kind, value)
+		}
+		element := writerNode.children[0]
+		if len(arrayval) == 0 {
+			return writerNode.appendNulls(row, repLevel, defLevel), nil
+		}
+		elementRepLevel := listRepLevel + 1
+		var err error
+		for i, elementValue := range arrayval {
+			if i > 0 {
+				repLevel = elementRepLevel
+			}
+			row, err = element.appendField(row, elementValue, name, repLevel, defLevel+1, elementRepLevel)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return row, nil
+
+	default:
+		leafValue, err := parquetLeafValue(writerNode.kind, name, value)
+		if err != nil {
+			return nil, err
+		}
+		return append(row, leafValue.Level(repLevel, defLevel, writerNode.firstLeaf)), nil
+	}
+}
+
+func parquetLeafValue(kind tColumnKind, name string, value *mlrval.Mlrval) (parquet.Value, error) {
+	switch kind {
+	case columnKindInt:
+		if intValue, ok := value.GetIntValue(); ok {
+			return parquet.Int64Value(intValue), nil
+		}
+	case columnKindFloat:
+		if floatValue, ok := value.GetNumericToFloatValue(); ok {
+			return parquet.DoubleValue(floatValue), nil
+		}
+	case columnKindBool:
+		if boolValue, ok := value.GetBoolValue(); ok {
+			return parquet.BooleanValue(boolValue), nil
+		}
+	case columnKindBytes:
+		if value.IsBytes() {
+			return parquet.ByteArrayValue(value.AcquireBytesValue()), nil
+		}
+	case columnKindString:
+		if !value.IsArrayOrMap() {
+			return parquet.ByteArrayValue([]byte(value.String())), nil
+		}
+	}
+	return parquet.Value{}, columnTypeError("parquet", name, kind, value)
+}
+
+// ----------------------------------------------------------------
+// parquet.Group sorts its fields by name. We want them in record order, so we
+// use our own group node.
+
+type parquetOrderedGroup struct {
+	fields []parquet.Field
+}
+
+type parquetOrderedField struct {
+	parquet.Node
+	name string
+}
+
+func (field *parquetOrderedField) Name() string { return field.name }
+
+func (field *parquetOrderedField) Value(base reflect.Value) reflect.Value {
+	if base.Kind() == reflect.Interface {
+		base = base.Elem()
+	}
+	if !base.IsValid() || base.Kind() != reflect.Map {
+		return reflect.Value{}
+	}
+	return base.MapIndex(reflect.ValueOf(field.name))
+}
+
+func (group *parquetOrderedGroup) ID() int                     { return 0 }
+func (group *parquetOrderedGroup) String() string              { return parquet.Group{}.String() }
+func (group *parquetOrderedGroup) Type() parquet.Type          { return parquet.Group{}.Type() }
+func (group *parquetOrderedGroup) Optional() bool              { return false }
+func (group *parquetOrderedGroup) Repeated() bool              { return false }
+func (group *parquetOrderedGroup) Required() bool              { return true }
+func (group *parquetOrderedGroup) Leaf() bool                  { return false }
+func (group *parquetOrderedGroup) Fields() []parquet.Field     { return group.fields }
+func (group *parquetOrderedGroup) Encoding() encoding.Encoding { return nil }
+func (group *parquetOrderedGroup) Compression() compress.Codec { return nil }
+func (group *parquetOrderedGroup) GoType() reflect.Type {
+	return reflect.TypeOf(map[string]any{})
+}
@@ -0,0 +1,115 @@
+// Tests for the Parquet record-writer's schema inference. Round-trips through
+// the Parquet record-reader are covered by the regression cases in
+// test/cases/io-parquet; here we check the schema itself, which those cannot
+// see.
+
+package output
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/parquet-go/parquet-go"
+
+	"github.com/johnkerl/miller/v6/pkg/cli"
+	"github.com/johnkerl/miller/v6/pkg/mlrval"
+)
+
+func writeParquetRecords(t *testing.T, rowGroupSize int64, records ...*mlrval.Mlrmap) (*parquet.File, error) {
+	t.Helper()
+	writer, err := NewRecordWriterParquet(&cli.TWriterOptions{
+		ParquetCompression:  "snappy",
+		ParquetRowGroupSize: rowGroupSize,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buffer bytes.Buffer
+	bufferedOutputStream := bufio.NewWriter(&buffer)
+	for _, record := range records {
+		if err := writer.Write(record, nil, bufferedOutputStream, false); err != nil {
+			return nil, err
+		}
+	}
+	if err := writer.Write(nil, nil, bufferedOutputStream, false); err != nil {
+		return nil, err
+	}
+	if err := bufferedOutputStream.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	file, err := parquet.OpenFile(bytes.NewReader(buffer.Bytes()), int64(buffer.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return file, nil
+}
+
+func TestParquetWriterSchemaInference(t *testing.T) {
+	record1 := mlrval.NewMlrmapAsRecord()
+	record1.PutReference("z", mlrval.FromInt(1))
+	record1.PutReference("y", mlrval.FromFloat(2.5))
+	record1.PutReference("x", mlrval.FromInt(3))
+	record1.PutReference("w", mlrval.FromArray([]*mlrval.Mlrval{mlrval.FromString("a")}))
+
+	record2 := mlrval.NewMlrmapAsRecord()
+	record2.PutReference("z", mlrval.FromFloat(4.5))
+	record2.PutReference("x", mlrval.FromString("abc"))
+	record2.PutReference("v", mlrval.FromBool(true))
+
+	file, err := writeParquetRecords(t, 10, record1, record2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fields := file.Schema().Fields()
+	names := make([]string, len(fields))
+	for i, field := range fields {
+		names[i] = field.Name()
+	}
+	// First-seen order, not alphabetical
+	if strings.Join(names, ",") != "z,y,x,w,v" {
+		t.Fatalf("unexpected field order %v", names)
+	}
+
+	expectedTypes := []string{"DOUBLE", "DOUBLE", "STRING", "LIST", "BOOLEAN"}
+	for i, field := range fields {
+		if !field.Optional() {
+			t.Errorf("field %s: expected optional", field.Name())
+		}
+		if got := field.Type().String(); got != expectedTypes[i] {
+			t.Errorf("field %s: expected type %s, got %s", field.Name(), expectedTypes[i], got)
+		}
+	}
+	if file.NumRows() != 2 {
+		t.Fatalf("expected 2 rows, got %d", file.NumRows())
+	}
+}
+
+func TestParquetWriterRowGroups(t *testing.T) {
+	records := make([]*mlrval.Mlrmap, 5)
+	for i := range records {
+		records[i] = mlrval.NewMlrmapAsRecord()
+		records[i].PutReference("i", mlrval.FromInt(int64(i)))
+	}
+	file, err := writeParquetRecords(t, 2, records...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(file.RowGroups()) != 3 {
+		t.Fatalf("expected 3 row groups, got %d", len(file.RowGroups()))
+	}
+}
+
+func TestParquetWriterSchemaMismatch(t *testing.T) {
+	record1 := mlrval.NewMlrmapAsRecord()
+	record1.PutReference("a", mlrval.FromInt(1))
+	record2 := mlrval.NewMlrmapAsRecord()
+	record2.PutReference("a", mlrval.FromString("abc"))
+
+	_, err := writeParquetRecords(t, 1, record1, record2)
+	if err == nil {
+		t.Fatal("expected error for string value in int column after the first row group")
+	}
+}
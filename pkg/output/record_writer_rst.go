@@ -0,0 +1,131 @@
+// reStructuredText record-writer.
+//
+// Each homogeneous batch of records is a grid table:
+//
+//   +-----+-----+---+
+//   | a   | b   | i |
+//   +=====+=====+===+
+//   | pan | pan | 1 |
+//   +-----+-----+---+
+//   | eks | pan | 2 |
+//   +-----+-----+---+
+//
+// Values with line breaks are multi-line cells. Characters which would start
+// inline markup, such as * and `, are backslash-escaped, as are values which
+// would otherwise be read as list items.
+
+package output
+
+import (
+	"bufio"
+	"regexp"
+	"strings"
+
+	"github.com/johnkerl/miller/v6/pkg/cli"
+	"github.com/johnkerl/miller/v6/pkg/mlrval"
+	"github.com/johnkerl/miller/v6/pkg/types"
+)
+
+var rstReplacer = strings.NewReplacer(
+	`\`, `\\`,
+	"*", `\*`,
+	"`", "\\`",
+	"_", `\_`,
+	"|", `\|`,
+)
+
+// Bullet-list and enumerated-list markers, such as "- x", "3. x", and "(a) x"
+var rstListMarkerRegex = regexp.MustCompile(`^([-+*•]|(\d+|#|[A-Za-z])[.)]|\((\d+|#|[A-Za-z])\))( |$)`)
+
+type RecordWriterRST struct {
+	writerOptions *cli.TWriterOptions
+	batcher       tMarkupTableBatcher
+}
+
+func NewRecordWriterRST(writerOptions *cli.TWriterOptions) (*RecordWriterRST, error) {
+	return &RecordWriterRST{
+		writerOptions: writerOptions,
+	}, nil
+}
+
+func (writer *RecordWriterRST) Write(
+	outrec *mlrval.Mlrmap,
+	_ *types.Context,
+	bufferedOutputStream *bufio.Writer,
+	outputIsStdout bool,
+) error {
+	writer.batcher.add(outrec, bufferedOutputStream, func(records []*mlrval.Mlrmap) {
+		writer.writeTable(records, bufferedOutputStream)
+	})
+	return nil
+}
+
+func (writer *RecordWriterRST) writeTable(
+	records []*mlrval.Mlrmap,
+	bufferedOutputStream *bufio.Writer,
+) {
+	table := newMarkupTable(records, escapeRST, writer.writerOptions.RightAlignNumericOutput)
+	for i := range table.widths {
+		// Grid-table columns can't be empty.
+		table.widths[i] = max(table.widths[i], 1)
+	}
+
+	writer.writeRule(table, "-", bufferedOutputStream)
+	writer.writeRow(table, table.header, bufferedOutputStream)
+	writer.writeRule(table, "=", bufferedOutputStream)
+	for _, row := range table.rows {
+		writer.writeRow(table, row, bufferedOutputStream)
+		writer.writeRule(table, "-", bufferedOutputStream)
+	}
+}
+
+func (writer *RecordWriterRST) writeRule(
+	table *tMarkupTable,
+	fill string,
+	bufferedOutputStream *bufio.Writer,
+) {
+	bufferedOutputStream.WriteString("+")
+	for _, width := range table.widths {
+		bufferedOutputStream.WriteString(strings.Repeat(fill, width+2))
+		bufferedOutputStream.WriteString("+")
+	}
+	bufferedOutputStream.WriteString("\n")
+}
+
+func (writer *RecordWriterRST) writeRow(
+	table *tMarkupTable,
+	row [][]string,
+	bufferedOutputStream *bufio.Writer,
+) {
+	numLines := 1
+	for _, cell := range row {
+		numLines = max(numLines, len(cell))
+	}
+	for j := 0; j < numLines; j++ {
+		bufferedOutputStream.WriteString("|")
+		for i, cell := range row {
+			line := ""
+			if j < len(cell) {
+				line = cell[j]
+			}
+			bufferedOutputStream.WriteString(" ")
+			bufferedOutputStream.WriteString(table.pad(line, i))
+			bufferedOutputStream.WriteString(" |")
+		}
+		bufferedOutputStream.WriteString("\n")
+	}
+}
+
+func escapeRST(s string) []string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		// Tabs would throw off the column alignment.
+		line = rstReplacer.Replace(strings.ReplaceAll(line, "\t", " "))
+		if i == 0 && rstListMarkerRegex.MatchString(line) {
+			line = `\` + line
+		}
+		lines[i] = line
+	}
+	return lines
+}
@@ -0,0 +1,457 @@
+// SQL record-writer: records are written as SQL statements for loading into a
+// database, such as 'mlr --icsv --osql cat x.csv | psql'.
+//
+// First comes a CREATE TABLE statement for the --sql-table table, with a
+// column for each field in the first --sql-infer-records records, and column
+// types inferred from their values: integer if all the values are ints,
+// floating-point if they're all numbers, boolean if they're all booleans, and
+// text otherwise. Empty values don't count, since they're written as NULL.
+// Fields not seen before then are added to the table with ALTER TABLE, with
+// the type of their first value. With --sql-no-create-table, there are no
+// CREATE TABLE or ALTER TABLE statements, for inserting into existing tables.
+//
+// Records are then written as multi-row INSERT statements of up to
+// --sql-batch-size rows, or, with --sql-copy, as a Postgres COPY FROM stdin
+// block. A change in record keys starts a new statement or block, since the
+// column list is given in each.
+//
+// Identifiers and strings are quoted as the --sql-dialect database expects.
+
+package output
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/johnkerl/miller/v6/pkg/cli"
+	"github.com/johnkerl/miller/v6/pkg/lib"
+	"github.com/johnkerl/miller/v6/pkg/mlrval"
+	"github.com/johnkerl/miller/v6/pkg/types"
+)
+
+// sqlDialect has what differs between databases: identifier and string
+// quoting, and column type names.
+type sqlDialect struct {
+	quoteIdentifier func(string) string
+	quoteString     func(string) string
+	intType         string
+	floatType       string
+	boolType        string
+	bytesType       string
+	textType        string
+	// For infinities and NaN, which not all databases have
+	floatSpecials map[string]string
+}
+
+var sqlDialects = map[string]*sqlDialect{
+	"postgres": {
+		quoteIdentifier: lib.SQLiteQuoteIdentifier,
+		quoteString:     sqlQuoteStringStandard,
+		intType:         "BIGINT",
+		floatType:       "DOUBLE PRECISION",
+		boolType:        "BOOLEAN",
+		bytesType:       "BYTEA",
+		textType:        "TEXT",
+		floatSpecials: map[string]string{
+			"+Inf": "'Infinity'",
+			"-Inf": "'-Infinity'",
+			"NaN":  "'NaN'",
+		},
+	},
+	"mysql": {
+		quoteIdentifier: sqlQuoteIdentifierMySQL,
+		quoteString:     sqlQuoteStringMySQL,
+		intType:         "BIGINT",
+		floatType:       "DOUBLE",
+		boolType:        "BOOLEAN",
+		bytesType:       "LONGBLOB",
+		textType:        "LONGTEXT",
+	},
+	"sqlite": {
+		quoteIdentifier: lib.SQLiteQuoteIdentifier,
+		quoteString:     sqlQuoteStringStandard,
+		intType:         "INTEGER",
+		floatType:       "REAL",
+		boolType:        "BOOLEAN",
+		bytesType:       "BLOB",
+		textType:        "TEXT",
+		floatSpecials: map[string]string{
+			"+Inf": "9e999",
+			"-Inf": "-9e999",
+		},
+	},
+}
+
+// Column types, ordered so that combining two types is taking the larger,
+// except that int and float combine to float, and others to text.
+type sqlColumnType int
+
+const (
+	sqlTypeUnknown sqlColumnType = iota // only empty values so far
+	sqlTypeInt
+	sqlTypeFloat
+	sqlTypeBool
+	sqlTypeBytes
+	sqlTypeText
+)
+
+type RecordWriterSQL struct {
+	writerOptions *cli.TWriterOptions
+	dialect       *sqlDialect
+	quotedTable   string
+
+	// Records held until the column types are inferred, and then nil
+	pending []*mlrval.Mlrmap
+	// Column types, by field name
+	columns map[string]sqlColumnType
+
+	// The INSERT statement or COPY block being written, if any, by joined
+	// field names
+	statementKeys  string
+	numRowsInBatch int64
+}
+
+func NewRecordWriterSQL(writerOptions *cli.TWriterOptions) (*RecordWriterSQL, error) {
+	dialect := sqlDialects[writerOptions.SQLDialect]
+	if dialect == nil {
+		return nil, fmt.Errorf("sql: unknown dialect \"%s\"", writerOptions.SQLDialect)
+	}
+	if writerOptions.SQLTable == "" {
+		return nil, fmt.Errorf("sql: table name must be non-empty")
+	}
+	if writerOptions.SQLCopy && writerOptions.SQLDialect != "postgres" {
+		return nil, fmt.Errorf("sql: --sql-copy is only for the postgres dialect")
+	}
+	return &RecordWriterSQL{
+		writerOptions: writerOptions,
+		dialect:       dialect,
+		quotedTable:   dialect.quoteIdentifier(writerOptions.SQLTable),
+		pending:       make([]*mlrval.Mlrmap, 0),
+		columns:       make(map[string]sqlColumnType),
+	}, nil
+}
+
+func (writer *RecordWriterSQL) Write(
+	outrec *mlrval.Mlrmap,
+	_ *types.Context,
+	bufferedOutputStream *bufio.Writer,
+	outputIsStdout bool,
+) error {
+	if outrec != nil && outrec.IsEmpty() {
+		return nil
+	}
+
+	if writer.pending != nil {
+		if outrec != nil {
+			writer.pending = append(writer.pending, outrec)
+			if int64(len(writer.pending)) < writer.writerOptions.SQLInferRecords {
+				return nil
+			}
+		}
+		pending := writer.pending
+		writer.pending = nil
+		writer.createTable(pending, bufferedOutputStream)
+		for _, record := range pending {
+			writer.writeRecord(record, bufferedOutputStream)
+		}
+		if outrec != nil {
+			return nil
+		}
+	}
+
+	if outrec == nil {
+		// End of record stream
+		writer.endStatement(bufferedOutputStream)
+		return nil
+	}
+
+	writer.writeRecord(outrec, bufferedOutputStream)
+	return nil
+}
+
+// createTable writes the CREATE TABLE statement for the records seen so far,
+// unless there are none.
+func (writer *RecordWriterSQL) createTable(records []*mlrval.Mlrmap, bufferedOutputStream *bufio.Writer) {
+	keys := make([]string, 0)
+	columnTypes := make(map[string]sqlColumnType)
+	for _, record := range records {
+		for pe := record.Head; pe != nil; pe = pe.Next {
+			columnType, seen := columnTypes[pe.Key]
+			if !seen {
+				keys = append(keys, pe.Key)
+			}
+			columnTypes[pe.Key] = combineSQLColumnTypes(columnType, sqlColumnTypeOf(pe.Value))
+		}
+	}
+	if len(keys) == 0 {
+		return
+	}
+
+	if !writer.writerOptions.SQLNoCreateTable {
+		bufferedOutputStream.WriteString("CREATE TABLE " + writer.quotedTable + " (\n")
+		for i, key := range keys {
+			bufferedOutputStream.WriteString("  " + writer.columnDefinition(key, columnTypes[key]))
+			if i < len(keys)-1 {
+				bufferedOutputStream.WriteString(",")
+			}
+			bufferedOutputStream.WriteString("\n")
+		}
+		bufferedOutputStream.WriteString(");\n")
+	}
+	for _, key := range keys {
+		writer.columns[key] = columnTypes[key]
+	}
+}
+
+func (writer *RecordWriterSQL) writeRecord(outrec *mlrval.Mlrmap, bufferedOutputStream *bufio.Writer) {
+	for pe := outrec.Head; pe != nil; pe = pe.Next {
+		if _, ok := writer.columns[pe.Key]; !ok {
+			writer.endStatement(bufferedOutputStream)
+			columnType := sqlColumnTypeOf(pe.Value)
+			if !writer.writerOptions.SQLNoCreateTable {
+				bufferedOutputStream.WriteString(
+					"ALTER TABLE " + writer.quotedTable + " ADD COLUMN " +
+						writer.columnDefinition(pe.Key, columnType) + ";\n",
+				)
+			}
+			writer.columns[pe.Key] = columnType
+		}
+	}
+
+	joinedKeys := outrec.GetKeysJoined()
+	if joinedKeys != writer.statementKeys ||
+		(!writer.writerOptions.SQLCopy && writer.numRowsInBatch >= writer.writerOptions.SQLBatchSize) {
+		writer.endStatement(bufferedOutputStream)
+	}
+
+	if writer.statementKeys == "" {
+		writer.beginStatement(outrec, bufferedOutputStream)
+		writer.statementKeys = joinedKeys
+	} else if !writer.writerOptions.SQLCopy {
+		bufferedOutputStream.WriteString(",\n")
+	}
+
+	if writer.writerOptions.SQLCopy {
+		for pe := outrec.Head; pe != nil; pe = pe.Next {
+			if pe != outrec.Head {
+				bufferedOutputStream.WriteString("\t")
+			}
+			bufferedOutputStream.WriteString(sqlCopyValue(pe.Value, writer.columns[pe.Key]))
+		}
+		bufferedOutputStream.WriteString("\n")
+	} else {
+		bufferedOutputStream.WriteString("  (")
+		for pe := outrec.Head; pe != nil; pe = pe.Next {
+			if pe != outrec.Head {
+				bufferedOutputStream.WriteString(", ")
+			}
+			bufferedOutputStream.WriteString(writer.sqlLiteral(pe.Value, writer.columns[pe.Key]))
+		}
+		bufferedOutputStream.WriteString(")")
+	}
+	writer.numRowsInBatch++
+}
+
+func (writer *RecordWriterSQL) beginStatement(outrec *mlrval.Mlrmap, bufferedOutputStream *bufio.Writer) {
+	quotedKeys := make([]string, 0, outrec.FieldCount)
+	for pe := outrec.Head; pe != nil; pe = pe.Next {
+		quotedKeys = append(quotedKeys, writer.dialect.quoteIdentifier(pe.Key))
+	}
+	if writer.writerOptions.SQLCopy {
+		bufferedOutputStream.WriteString(
+			"COPY " + writer.quotedTable + " (" + strings.Join(quotedKeys, ", ") + ") FROM stdin;\n",
+		)
+	} else {
+		bufferedOutputStream.WriteString(
+			"INSERT INTO " + writer.quotedTable + " (" + strings.Join(quotedKeys, ", ") + ") VALUES\n",
+		)
+	}
+}
+
+func (writer *RecordWriterSQL) endStatement(bufferedOutputStream *bufio.Writer) {
+	if writer.statementKeys == "" {
+		return
+	}
+	if writer.writerOptions.SQLCopy {
+		bufferedOutputStream.WriteString("\\.\n")
+	} else {
+		bufferedOutputStream.WriteString(";\n")
+	}
+	writer.statementKeys = ""
+	writer.numRowsInBatch = 0
+}
+
+func (writer *RecordWriterSQL) columnDefinition(key string, columnType sqlColumnType) string {
+	quotedKey := writer.dialect.quoteIdentifier(key)
+	switch columnType {
+	case sqlTypeInt:
+		return quotedKey + " " + writer.dialect.intType
+	case sqlTypeFloat:
+		return quotedKey + " " + writer.dialect.floatType
+	case sqlTypeBool:
+		return quotedKey + " " + writer.dialect.boolType
+	case sqlTypeBytes:
+		return quotedKey + " " + writer.dialect.bytesType
+	default:
+		return quotedKey + " " + writer.dialect.textType
+	}
+}
+
+// sqlLiteral formats a value for an INSERT statement. Values in text columns
+// are strings, as they were written, so that numbers such as 007 keep their
+// formatting.
+func (writer *RecordWriterSQL) sqlLiteral(value *mlrval.Mlrval, columnType sqlColumnType) string {
+	switch value.Type() {
+	case mlrval.MT_VOID, mlrval.MT_ABSENT:
+		return "NULL"
+	}
+	if columnType == sqlTypeText || columnType == sqlTypeUnknown {
+		return writer.dialect.quoteString(value.String())
+	}
+	switch value.Type() {
+	case mlrval.MT_INT:
+		return sqlIntString(value)
+	case mlrval.MT_FLOAT:
+		floatval, _ := value.GetFloatValue()
+		if math.IsInf(floatval, 0) || math.IsNaN(floatval) {
+			special, ok := writer.dialect.floatSpecials[strconv.FormatFloat(floatval, 'g', -1, 64)]
+			if !ok {
+				return "NULL"
+			}
+			return special
+		}
+		return sqlFloatString(value, floatval)
+	case mlrval.MT_BOOL:
+		boolval, _ := value.GetBoolValue()
+		if boolval {
+			return "TRUE"
+		}
+		return "FALSE"
+	case mlrval.MT_BYTES:
+		if writer.writerOptions.SQLDialect == "postgres" {
+			return `'\x` + hex.EncodeToString(value.AcquireBytesValue()) + `'`
+		}
+		return `X'` + hex.EncodeToString(value.AcquireBytesValue()) + `'`
+	default:
+		return writer.dialect.quoteString(value.String())
+	}
+}
+
+// sqlCopyValue formats a value for a Postgres COPY block, in its text format:
+// tab-separated, with \N for NULL, and backslash escapes.
+func sqlCopyValue(value *mlrval.Mlrval, columnType sqlColumnType) string {
+	switch value.Type() {
+	case mlrval.MT_VOID, mlrval.MT_ABSENT:
+		return `\N`
+	}
+	if columnType == sqlTypeText || columnType == sqlTypeUnknown {
+		return sqlCopyEscaper.Replace(value.String())
+	}
+	switch value.Type() {
+	case mlrval.MT_INT:
+		return sqlIntString(value)
+	case mlrval.MT_FLOAT:
+		floatval, _ := value.GetFloatValue()
+		switch {
+		case math.IsInf(floatval, 1):
+			return "Infinity"
+		case math.IsInf(floatval, -1):
+			return "-Infinity"
+		case math.IsNaN(floatval):
+			return "NaN"
+		}
+		return sqlFloatString(value, floatval)
+	case mlrval.MT_BYTES:
+		return `\\x` + hex.EncodeToString(value.AcquireBytesValue())
+	default:
+		return sqlCopyEscaper.Replace(value.String())
+	}
+}
+
+var sqlCopyEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	"\t", `\t`,
+	"\n", `\n`,
+	"\r", `\r`,
+)
+
+// sqlIntString gives ints as they were written if that's valid SQL, else in
+// decimal, since not all databases take 0xff and the like.
+func sqlIntString(value *mlrval.Mlrval) string {
+	s := value.String()
+	if sqlNumberRegex.MatchString(s) && !strings.ContainsAny(s, ".eE") {
+		return s
+	}
+	intval, _ := value.GetIntValue()
+	return strconv.FormatInt(intval, 10)
+}
+
+// sqlFloatString gives finite floats as they were written if that's valid
+// SQL, as it is for the likes of 1.50 and 6.02e23.
+func sqlFloatString(value *mlrval.Mlrval, floatval float64) string {
+	s := value.String()
+	if sqlNumberRegex.MatchString(s) {
+		return s
+	}
+	return strconv.FormatFloat(floatval, 'g', -1, 64)
+}
+
+var sqlNumberRegex = regexp.MustCompile(`^-?([0-9]+\.?[0-9]*|\.[0-9]+)([eE][+-]?[0-9]+)?$`)
+
+func sqlColumnTypeOf(value *mlrval.Mlrval) sqlColumnType {
+	switch value.Type() {
+	case mlrval.MT_VOID, mlrval.MT_ABSENT:
+		return sqlTypeUnknown
+	case mlrval.MT_INT:
+		return sqlTypeInt
+	case mlrval.MT_FLOAT:
+		return sqlTypeFloat
+	case mlrval.MT_BOOL:
+		return sqlTypeBool
+	case mlrval.MT_BYTES:
+		return sqlTypeBytes
+	default:
+		return sqlTypeText
+	}
+}
+
+func combineSQLColumnTypes(a, b sqlColumnType) sqlColumnType {
+	if a == sqlTypeUnknown || a == b {
+		return b
+	}
+	if b == sqlTypeUnknown {
+		return a
+	}
+	if (a == sqlTypeInt && b == sqlTypeFloat) || (a == sqlTypeFloat && b == sqlTypeInt) {
+		return sqlTypeFloat
+	}
+	return sqlTypeText
+}
+
+// sqlQuoteStringStandard quotes a string as in standard SQL, with single
+// quotes doubled, as for Postgres (with standard_conforming_strings, the
+// default) and SQLite.
+func sqlQuoteStringStandard(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// sqlQuoteStringMySQL quotes a string for MySQL, where backslashes within
+// strings are escapes, unless the NO_BACKSLASH_ESCAPES mode is set.
+func sqlQuoteStringMySQL(s string) string {
+	return "'" + sqlMySQLEscaper.Replace(s) + "'"
+}
+
+var sqlMySQLEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	"'", "''",
+	"\x00", `\0`,
+)
+
+func sqlQuoteIdentifierMySQL(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
@@ -0,0 +1,32 @@
+package output
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/johnkerl/miller/v6/pkg/mlrval"
+)
+
+func TestSQLQuoting(t *testing.T) {
+	assert.Equal(t, `'it''s \n'`, sqlQuoteStringStandard(`it's \n`))
+	assert.Equal(t, `'it''s \\n \0'`, sqlQuoteStringMySQL("it's \\n \x00"))
+	assert.Equal(t, "`a``b`", sqlQuoteIdentifierMySQL("a`b"))
+}
+
+func TestSQLCopyValue(t *testing.T) {
+	assert.Equal(t, `\N`, sqlCopyValue(mlrval.FromString(""), sqlTypeText))
+	assert.Equal(t, `a\tb\nc\\d`, sqlCopyValue(mlrval.FromString("a\tb\nc\\d"), sqlTypeText))
+	assert.Equal(t, "255", sqlCopyValue(mlrval.FromInferredType("0xff"), sqlTypeInt))
+	assert.Equal(t, "0xff", sqlCopyValue(mlrval.FromInferredType("0xff"), sqlTypeText))
+	assert.Equal(t, "1.50", sqlCopyValue(mlrval.FromInferredType("1.50"), sqlTypeFloat))
+}
+
+func TestCombineSQLColumnTypes(t *testing.T) {
+	assert.Equal(t, sqlTypeInt, combineSQLColumnTypes(sqlTypeUnknown, sqlTypeInt))
+	assert.Equal(t, sqlTypeInt, combineSQLColumnTypes(sqlTypeInt, sqlTypeUnknown))
+	assert.Equal(t, sqlTypeFloat, combineSQLColumnTypes(sqlTypeInt, sqlTypeFloat))
+	assert.Equal(t, sqlTypeFloat, combineSQLColumnTypes(sqlTypeFloat, sqlTypeInt))
+	assert.Equal(t, sqlTypeText, combineSQLColumnTypes(sqlTypeInt, sqlTypeBool))
+	assert.Equal(t, sqlTypeText, combineSQLColumnTypes(sqlTypeText, sqlTypeFloat))
+}
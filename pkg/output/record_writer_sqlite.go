@@ -0,0 +1,327 @@
+// SQLite record-writer.
+//
+// Records are inserted into the --sqlite-table table, which is created from
+// the first record if it doesn't already exist: one column per field, with
+// declared types from the first record's values (INTEGER, REAL, BOOLEAN,
+// BLOB, or TEXT; none for empty values). Fields not seen before add columns
+// to the table. Missing and empty fields are NULL.
+//
+// With --sqlite-output, the table is written into that database file.
+// Otherwise the database is built in a temporary file, whose contents are
+// written to standard output at end of stream, so that 'mlr --osqlite cat
+// x.csv > x.db' works, as does tee/split output.
+//
+// Inserts are batched into transactions of --sqlite-batch-size records.
+
+package output
+
+import (
+	"bufio"
+	"database/sql"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	_ "modernc.org/sqlite"
+
+	"github.com/johnkerl/miller/v6/pkg/cli"
+	"github.com/johnkerl/miller/v6/pkg/lib"
+	"github.com/johnkerl/miller/v6/pkg/mlrval"
+	"github.com/johnkerl/miller/v6/pkg/types"
+)
+
+type RecordWriterSQLite struct {
+	writerOptions *cli.TWriterOptions
+	quotedTable   string
+
+	// nil until the first record
+	db *sql.DB
+	tx *sql.Tx
+	// Database file, and whether it's our temporary file to be copied to the
+	// output stream
+	path        string
+	isTemporary bool
+
+	// Lowercased column names, since SQLite's are case-insensitive
+	columns map[string]bool
+	// Insert statements for the current transaction, by joined field names
+	statements        map[string]*sql.Stmt
+	numRecordsInBatch int64
+}
+
+func NewRecordWriterSQLite(writerOptions *cli.TWriterOptions) (*RecordWriterSQLite, error) {
+	if writerOptions.SQLiteTable == "" {
+		return nil, fmt.Errorf("sqlite: table name must be non-empty")
+	}
+	return &RecordWriterSQLite{
+		writerOptions: writerOptions,
+		quotedTable:   lib.SQLiteQuoteIdentifier(writerOptions.SQLiteTable),
+		columns:       make(map[string]bool),
+		statements:    make(map[string]*sql.Stmt),
+	}, nil
+}
+
+func (writer *RecordWriterSQLite) Write(
+	outrec *mlrval.Mlrmap,
+	_ *types.Context,
+	bufferedOutputStream *bufio.Writer,
+	outputIsStdout bool,
+) error {
+	if outrec == nil {
+		// End of record stream
+		if writer.db == nil {
+			return nil
+		}
+		return writer.finish(bufferedOutputStream)
+	}
+
+	if writer.db == nil {
+		if err := writer.open(outrec); err != nil {
+			writer.abandon()
+			return fmt.Errorf("sqlite: %v", err)
+		}
+	}
+
+	if err := writer.insert(outrec); err != nil {
+		writer.abandon()
+		return fmt.Errorf("sqlite: %v", err)
+	}
+	return nil
+}
+
+// open opens the database, and creates the table if needed.
+func (writer *RecordWriterSQLite) open(outrec *mlrval.Mlrmap) error {
+	path := writer.writerOptions.SQLiteOutput
+	if path == "" {
+		tempFile, err := os.CreateTemp("", "mlr-sqlite-*.db")
+		if err != nil {
+			return err
+		}
+		path = tempFile.Name()
+		_ = tempFile.Close()
+		writer.isTemporary = true
+	}
+	writer.path = path
+
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return err
+	}
+	// A single connection, so that the transaction sees the table as created.
+	db.SetMaxOpenConns(1)
+	writer.db = db
+
+	if err := writer.readExistingColumns(); err != nil {
+		return err
+	}
+	if len(writer.columns) > 0 {
+		return nil
+	}
+
+	columnDefinitions := make([]string, 0, outrec.FieldCount)
+	for pe := outrec.Head; pe != nil; pe = pe.Next {
+		key := strings.ToLower(pe.Key)
+		if writer.columns[key] {
+			continue
+		}
+		writer.columns[key] = true
+		columnDefinitions = append(columnDefinitions, sqliteColumnDefinition(pe.Key, pe.Value))
+	}
+	_, err = db.Exec(
+		"CREATE TABLE " + writer.quotedTable + " (" + strings.Join(columnDefinitions, ", ") + ")",
+	)
+	return err
+}
+
+func (writer *RecordWriterSQLite) readExistingColumns() error {
+	rows, err := writer.db.Query("SELECT name FROM pragma_table_info(?)", writer.writerOptions.SQLiteTable)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return err
+		}
+		writer.columns[strings.ToLower(name)] = true
+	}
+	return rows.Err()
+}
+
+func (writer *RecordWriterSQLite) insert(outrec *mlrval.Mlrmap) error {
+	if writer.tx == nil {
+		tx, err := writer.db.Begin()
+		if err != nil {
+			return err
+		}
+		writer.tx = tx
+	}
+
+	keys := make([]string, 0, outrec.FieldCount)
+	values := make([]any, 0, outrec.FieldCount)
+	seen := make(map[string]bool)
+	for pe := outrec.Head; pe != nil; pe = pe.Next {
+		key := strings.ToLower(pe.Key)
+		if seen[key] {
+			// Same column by SQLite's lights: the first one wins.
+			continue
+		}
+		seen[key] = true
+		if !writer.columns[key] {
+			writer.closeStatements()
+			_, err := writer.tx.Exec(
+				"ALTER TABLE " + writer.quotedTable + " ADD COLUMN " + sqliteColumnDefinition(pe.Key, pe.Value),
+			)
+			if err != nil {
+				return err
+			}
+			writer.columns[key] = true
+		}
+		keys = append(keys, pe.Key)
+		values = append(values, mlrvalToSQLiteValue(pe.Value))
+	}
+
+	joinedKeys := strings.Join(keys, "\x00")
+	statement := writer.statements[joinedKeys]
+	if statement == nil {
+		quotedKeys := make([]string, len(keys))
+		for i, key := range keys {
+			quotedKeys[i] = lib.SQLiteQuoteIdentifier(key)
+		}
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(keys)), ", ")
+		var err error
+		statement, err = writer.tx.Prepare(
+			"INSERT INTO " + writer.quotedTable + " (" + strings.Join(quotedKeys, ", ") +
+				") VALUES (" + placeholders + ")",
+		)
+		if err != nil {
+			return err
+		}
+		writer.statements[joinedKeys] = statement
+	}
+	if _, err := statement.Exec(values...); err != nil {
+		return err
+	}
+
+	writer.numRecordsInBatch++
+	if writer.numRecordsInBatch >= writer.writerOptions.SQLiteBatchSize {
+		return writer.commit()
+	}
+	return nil
+}
+
+func (writer *RecordWriterSQLite) commit() error {
+	writer.closeStatements()
+	writer.numRecordsInBatch = 0
+	if writer.tx == nil {
+		return nil
+	}
+	tx := writer.tx
+	writer.tx = nil
+	return tx.Commit()
+}
+
+func (writer *RecordWriterSQLite) closeStatements() {
+	for key, statement := range writer.statements {
+		_ = statement.Close()
+		delete(writer.statements, key)
+	}
+}
+
+// finish commits, closes the database, and copies it to the output stream if
+// it's a temporary file.
+func (writer *RecordWriterSQLite) finish(bufferedOutputStream *bufio.Writer) error {
+	err := writer.commit()
+	if closeErr := writer.db.Close(); err == nil {
+		err = closeErr
+	}
+	writer.db = nil
+	if err != nil {
+		writer.removeTemporary()
+		return fmt.Errorf("sqlite: %v", err)
+	}
+
+	if writer.isTemporary {
+		defer writer.removeTemporary()
+		handle, err := os.Open(writer.path)
+		if err != nil {
+			return fmt.Errorf("sqlite: %v", err)
+		}
+		defer handle.Close()
+		if _, err := io.Copy(bufferedOutputStream, handle); err != nil {
+			return fmt.Errorf("sqlite: %v", err)
+		}
+	}
+	return nil
+}
+
+// abandon cleans up after an error.
+func (writer *RecordWriterSQLite) abandon() {
+	writer.closeStatements()
+	if writer.tx != nil {
+		_ = writer.tx.Rollback()
+		writer.tx = nil
+	}
+	if writer.db != nil {
+		_ = writer.db.Close()
+		writer.db = nil
+	}
+	writer.removeTemporary()
+}
+
+func (writer *RecordWriterSQLite) removeTemporary() {
+	if writer.isTemporary {
+		_ = os.Remove(writer.path)
+		writer.isTemporary = false
+	}
+}
+
+// sqliteColumnDefinition gives a column's name and declared type, from the
+// first value seen for it. Empty values give no declared type, so the
+// column takes any values as they are.
+func sqliteColumnDefinition(key string, value *mlrval.Mlrval) string {
+	quotedKey := lib.SQLiteQuoteIdentifier(key)
+	switch value.Type() {
+	case mlrval.MT_INT:
+		return quotedKey + " INTEGER"
+	case mlrval.MT_FLOAT:
+		return quotedKey + " REAL"
+	case mlrval.MT_BOOL:
+		return quotedKey + " BOOLEAN"
+	case mlrval.MT_BYTES:
+		return quotedKey + " BLOB"
+	case mlrval.MT_VOID, mlrval.MT_ABSENT:
+		return quotedKey
+	default:
+		return quotedKey + " TEXT"
+	}
+}
+
+func mlrvalToSQLiteValue(value *mlrval.Mlrval) any {
+	switch value.Type() {
+	case mlrval.MT_VOID, mlrval.MT_ABSENT:
+		return nil
+	case mlrval.MT_INT:
+		intval, _ := value.GetIntValue()
+		// Keep ints such as 0xff and 007 as they were written.
+		if fmt.Sprintf("%d", intval) == value.String() {
+			return intval
+		}
+		return value.String()
+	case mlrval.MT_FLOAT:
+		floatval, _ := value.GetFloatValue()
+		return floatval
+	case mlrval.MT_BOOL:
+		boolval, _ := value.GetBoolValue()
+		if boolval {
+			return int64(1)
+		}
+		return int64(0)
+	case mlrval.MT_BYTES:
+		return value.AcquireBytesValue()
+	default:
+		return value.String()
+	}
+}
@@ -0,0 +1,274 @@
+// Template record-writer: each record is rendered through a Go text/template
+// from the --otemplate file, for output of any shape, such as config
+// snippets or shell commands.
+//
+// The template file may define "header", "record", and "footer" templates,
+// using {{define "header"}}...{{end}} etc. The header is rendered before the
+// first record, and the footer after the last, even if there are no records.
+// If there's no "record" template, the rest of the file is the record
+// template.
+//
+// Within templates, . is the record, as a map, so {{.color}} is the color
+// field, or {{index . "a b"}} for names which aren't Go identifiers. Ints,
+// floats, and booleans are Go values of those types, so that {{if gt .x 0.5}}
+// works, and maps and arrays are Go maps and slices. For the header, . is the
+// first record, and for the footer, the last, or an empty map if there are
+// none. There are also these functions:
+//
+// * NR, FNR, FILENAME, FILENUM: as in the DSL, for the record being written,
+// or for the footer, the last record
+// * fmtnum VALUE FORMAT: as in the DSL, such as {{fmtnum .x "%.3f"}}
+// * json VALUE: single-line JSON, such as {{json .}} for the whole record
+// * csvquote VALUE: double-quoted as needed for CSV
+// * shquote VALUE: single-quoted as needed for the shell
+// * keys MAP: the field names, in order, such as {{join (keys .) ","}}
+// * join LIST SEPARATOR: the list's elements joined by the separator
+
+package output
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/johnkerl/miller/v6/pkg/bifs"
+	"github.com/johnkerl/miller/v6/pkg/cli"
+	"github.com/johnkerl/miller/v6/pkg/mlrval"
+	"github.com/johnkerl/miller/v6/pkg/types"
+)
+
+type RecordWriterTemplate struct {
+	writerOptions *cli.TWriterOptions
+
+	headerTemplate *template.Template // nil if not defined
+	recordTemplate *template.Template
+	footerTemplate *template.Template // nil if not defined
+
+	// The context of the record being written, for the NR etc. functions
+	context *types.Context
+	// The maps within the template data, by map pointer, so that keys and
+	// json can give fields in record order rather than Go's sorted order
+	originalMaps map[uintptr]*mlrval.Mlrmap
+
+	headerWritten bool
+	lastData      map[string]any
+}
+
+func NewRecordWriterTemplate(writerOptions *cli.TWriterOptions) (*RecordWriterTemplate, error) {
+	if writerOptions.TemplateFile == "" {
+		return nil, fmt.Errorf("template: please give a template file with --otemplate")
+	}
+	text, err := os.ReadFile(writerOptions.TemplateFile)
+	if err != nil {
+		return nil, fmt.Errorf("template: %v", err)
+	}
+
+	writer := &RecordWriterTemplate{
+		writerOptions: writerOptions,
+		context:       types.NewContext(),
+		originalMaps:  make(map[uintptr]*mlrval.Mlrmap),
+	}
+
+	mainTemplate, err := template.New(filepath.Base(writerOptions.TemplateFile)).
+		Funcs(writer.templateFuncs()).
+		Parse(string(text))
+	if err != nil {
+		return nil, err
+	}
+	writer.headerTemplate = mainTemplate.Lookup("header")
+	writer.recordTemplate = mainTemplate.Lookup("record")
+	if writer.recordTemplate == nil {
+		writer.recordTemplate = mainTemplate
+	}
+	writer.footerTemplate = mainTemplate.Lookup("footer")
+	return writer, nil
+}
+
+func (writer *RecordWriterTemplate) Write(
+	outrec *mlrval.Mlrmap,
+	context *types.Context,
+	bufferedOutputStream *bufio.Writer,
+	outputIsStdout bool,
+) error {
+	// The footer sees the context of the last record, since at end of stream
+	// the context is empty for tee and emit redirects.
+	if context != nil && (outrec != nil || writer.lastData == nil) {
+		writer.context = context
+	}
+
+	var data map[string]any
+	if outrec != nil {
+		clear(writer.originalMaps)
+		data = writer.templateMap(outrec)
+	} else if writer.lastData != nil {
+		data = writer.lastData
+	} else {
+		data = make(map[string]any)
+	}
+
+	if !writer.headerWritten {
+		writer.headerWritten = true
+		if writer.headerTemplate != nil {
+			if err := writer.headerTemplate.Execute(bufferedOutputStream, data); err != nil {
+				return err
+			}
+		}
+	}
+
+	if outrec == nil {
+		// End of record stream
+		if writer.footerTemplate != nil {
+			if err := writer.footerTemplate.Execute(bufferedOutputStream, data); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	writer.lastData = data
+	if err := writer.recordTemplate.Execute(bufferedOutputStream, data); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (writer *RecordWriterTemplate) templateFuncs() template.FuncMap {
+	return template.FuncMap{
+		"NR":       func() int64 { return writer.context.NR },
+		"FNR":      func() int64 { return writer.context.FNR },
+		"FILENAME": func() string { return writer.context.FILENAME },
+		"FILENUM":  func() int64 { return writer.context.FILENUM },
+
+		"fmtnum": func(value any, format string) (string, error) {
+			input := writer.mlrvalFromTemplateValue(value)
+			if input.IsStringOrVoid() {
+				input = mlrval.FromInferredType(input.String())
+			}
+			output := bifs.BIF_fmtnum(input, mlrval.FromString(format))
+			if output.IsError() {
+				return "", fmt.Errorf("fmtnum: cannot format %v with \"%s\"", value, format)
+			}
+			return output.String(), nil
+		},
+
+		"json": func(value any) (string, error) {
+			outputBytes, err := writer.mlrvalFromTemplateValue(value).FormatAsJSON(mlrval.JSON_SINGLE_LINE, false)
+			return string(outputBytes), err
+		},
+
+		"csvquote": func(value any) string { return templateCSVQuote(fmt.Sprint(value)) },
+		"shquote":  func(value any) string { return templateShellQuote(fmt.Sprint(value)) },
+
+		"keys": func(value map[string]any) []string {
+			if original := writer.originalMaps[reflect.ValueOf(value).Pointer()]; original != nil {
+				return original.GetKeys()
+			}
+			return writer.mlrvalFromTemplateValue(value).GetMap().GetKeys()
+		},
+
+		"join": func(list any, separator string) (string, error) {
+			listValue := reflect.ValueOf(list)
+			if listValue.Kind() != reflect.Slice {
+				return "", fmt.Errorf("join: expected a list; got %T", list)
+			}
+			parts := make([]string, listValue.Len())
+			for i := range parts {
+				parts[i] = fmt.Sprint(listValue.Index(i).Interface())
+			}
+			return strings.Join(parts, separator), nil
+		},
+	}
+}
+
+func templateCSVQuote(s string) string {
+	if !fieldNeedsQuotes(s, ',') {
+		return s
+	}
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
+
+var templateShellSafeRegex = regexp.MustCompile(`^[A-Za-z0-9_./:=@%+,-]+$`)
+
+func templateShellQuote(s string) string {
+	if templateShellSafeRegex.MatchString(s) {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// templateMap converts a record, or a map within it, to template data.
+func (writer *RecordWriterTemplate) templateMap(mlrmap *mlrval.Mlrmap) map[string]any {
+	data := make(map[string]any, mlrmap.FieldCount)
+	for pe := mlrmap.Head; pe != nil; pe = pe.Next {
+		data[pe.Key] = writer.templateValue(pe.Value)
+	}
+	writer.originalMaps[reflect.ValueOf(data).Pointer()] = mlrmap
+	return data
+}
+
+func (writer *RecordWriterTemplate) templateValue(value *mlrval.Mlrval) any {
+	switch value.Type() {
+	case mlrval.MT_INT:
+		intval, _ := value.GetIntValue()
+		return intval
+	case mlrval.MT_FLOAT:
+		floatval, _ := value.GetFloatValue()
+		return floatval
+	case mlrval.MT_BOOL:
+		boolval, _ := value.GetBoolValue()
+		return boolval
+	case mlrval.MT_MAP:
+		return writer.templateMap(value.GetMap())
+	case mlrval.MT_ARRAY:
+		array := value.GetArray()
+		list := make([]any, len(array))
+		for i, element := range array {
+			list[i] = writer.templateValue(element)
+		}
+		return list
+	default:
+		return value.String()
+	}
+}
+
+// mlrvalFromTemplateValue converts template data back, for the helper
+// functions. Maps from records keep their field order.
+func (writer *RecordWriterTemplate) mlrvalFromTemplateValue(value any) *mlrval.Mlrval {
+	switch v := value.(type) {
+	case nil:
+		return mlrval.VOID
+	case string:
+		return mlrval.FromString(v)
+	case int:
+		return mlrval.FromInt(int64(v))
+	case int64:
+		return mlrval.FromInt(v)
+	case float64:
+		return mlrval.FromFloat(v)
+	case bool:
+		return mlrval.FromBool(v)
+	case map[string]any:
+		if original := writer.originalMaps[reflect.ValueOf(v).Pointer()]; original != nil {
+			return mlrval.FromMap(original)
+		}
+		mlrmap := mlrval.NewMlrmap()
+		for key, element := range v {
+			mlrmap.PutReference(key, writer.mlrvalFromTemplateValue(element))
+		}
+		mlrmap.SortByKey()
+		return mlrval.FromMap(mlrmap)
+	case []any:
+		array := make([]*mlrval.Mlrval, len(v))
+		for i, element := range v {
+			array[i] = writer.mlrvalFromTemplateValue(element)
+		}
+		return mlrval.FromArray(array)
+	default:
+		return mlrval.FromString(fmt.Sprint(v))
+	}
+}
@@ -0,0 +1,23 @@
+package output
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTemplateCSVQuote(t *testing.T) {
+	assert.Equal(t, "abc", templateCSVQuote("abc"))
+	assert.Equal(t, "", templateCSVQuote(""))
+	assert.Equal(t, `"a,b"`, templateCSVQuote("a,b"))
+	assert.Equal(t, `"say ""hi"""`, templateCSVQuote(`say "hi"`))
+	assert.Equal(t, "\"a\nb\"", templateCSVQuote("a\nb"))
+}
+
+func TestTemplateShellQuote(t *testing.T) {
+	assert.Equal(t, "data/file-1.csv", templateShellQuote("data/file-1.csv"))
+	assert.Equal(t, "''", templateShellQuote(""))
+	assert.Equal(t, "'a b'", templateShellQuote("a b"))
+	assert.Equal(t, `'it'\''s'`, templateShellQuote("it's"))
+	assert.Equal(t, "'$HOME'", templateShellQuote("$HOME"))
+}
@@ -0,0 +1,109 @@
+// TOML record-writer. Each record is a table, named by its _section field, as
+// read by the TOML record-reader: a _section ending in [] is written as an
+// array-of-tables entry. Only the first record may lack a _section field, or
+// have it empty, since it's written as the top-level table, before any table
+// header. Maps within records are written using dotted keys, and arrays, and
+// maps within them, inline.
+//
+// Strings which are valid TOML dates and times are written as such, since
+// the TOML record-reader reads them as strings.
+
+package output
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+
+	"github.com/johnkerl/miller/v6/pkg/cli"
+	"github.com/johnkerl/miller/v6/pkg/lib"
+	"github.com/johnkerl/miller/v6/pkg/mlrval"
+	"github.com/johnkerl/miller/v6/pkg/types"
+)
+
+type RecordWriterTOML struct {
+	writerOptions *cli.TWriterOptions
+	numWritten    int64
+	// A table may appear only once, but an array-of-tables entry any number
+	// of times
+	tablesWritten map[string]bool
+	buffer        []byte
+}
+
+func NewRecordWriterTOML(writerOptions *cli.TWriterOptions) (*RecordWriterTOML, error) {
+	return &RecordWriterTOML{
+		writerOptions: writerOptions,
+		tablesWritten: make(map[string]bool),
+	}, nil
+}
+
+func (writer *RecordWriterTOML) Write(
+	outrec *mlrval.Mlrmap,
+	_ *types.Context,
+	bufferedOutputStream *bufio.Writer,
+	outputIsStdout bool,
+) error {
+	if outrec == nil {
+		// End of record stream: nothing special for this output format
+		return nil
+	}
+	writer.numWritten++
+
+	section := ""
+	if value := outrec.Get(lib.SECTION_FIELD_NAME); value != nil {
+		section = value.String()
+	}
+
+	buffer := writer.buffer[:0]
+	if section == "" {
+		if writer.numWritten > 1 {
+			return fmt.Errorf(
+				"toml: record %d has no %s field; only the first record can be the top-level table. "+
+					"Please set $%s to a table name.",
+				writer.numWritten, lib.SECTION_FIELD_NAME, lib.SECTION_FIELD_NAME,
+			)
+		}
+	} else {
+		if writer.numWritten > 1 {
+			buffer = append(buffer, '\n')
+		}
+		if name, isArray := strings.CutSuffix(section, "[]"); isArray {
+			buffer = append(buffer, "[["...)
+			buffer = append(buffer, formatTOMLTableName(name)...)
+			buffer = append(buffer, "]]\n"...)
+		} else {
+			name = formatTOMLTableName(section)
+			if writer.tablesWritten[name] {
+				return fmt.Errorf("toml: table [%s] appears more than once", name)
+			}
+			writer.tablesWritten[name] = true
+			buffer = append(buffer, '[')
+			buffer = append(buffer, name...)
+			buffer = append(buffer, "]\n"...)
+		}
+	}
+
+	for pe := outrec.Head; pe != nil; pe = pe.Next {
+		if pe.Key != lib.SECTION_FIELD_NAME {
+			buffer = mlrval.MlrvalAppendTOMLKeyValue(buffer, pe.Key, pe.Value)
+		}
+	}
+
+	writer.buffer = buffer
+	_, err := bufferedOutputStream.Write(buffer)
+	return err
+}
+
+// formatTOMLTableName quotes the parts of a table name, as needed, if they
+// aren't already. Names with quotes are taken to be written as in TOML, as
+// the TOML record-reader does.
+func formatTOMLTableName(name string) string {
+	if strings.ContainsAny(name, `"'`) {
+		return name
+	}
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		parts[i] = strings.TrimSpace(part)
+	}
+	return lib.TOMLFormatDottedKey(parts)
+}
@@ -0,0 +1,263 @@
+// XLSX record-writer.
+//
+// An XLSX file is a zip archive of XML documents, which can only be written
+// once all the records have been seen. We accumulate worksheet rows as XML
+// text, and write the archive at end of stream.
+//
+// As with CSV-lite output, a change in record keys starts a new header block:
+// here, a new sheet, or with --xlsx-one-sheet, a new block after an empty row
+// in the same sheet. Ints and floats are written as numbers, booleans as
+// booleans, and everything else as text. Ints which don't print as plain
+// decimal, such as 0xff or 007, and ints too large for a spreadsheet to hold
+// exactly, are written as text so as not to lose anything. Empty values are
+// written as absent cells.
+
+package output
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/johnkerl/miller/v6/pkg/cli"
+	"github.com/johnkerl/miller/v6/pkg/lib"
+	"github.com/johnkerl/miller/v6/pkg/mlrval"
+	"github.com/johnkerl/miller/v6/pkg/types"
+)
+
+const xlsxMainNamespace = "http://schemas.openxmlformats.org/spreadsheetml/2006/main"
+const xlsxRelationshipsNamespace = "http://schemas.openxmlformats.org/officeDocument/2006/relationships"
+const xlsxPackageRelationshipsNamespace = "http://schemas.openxmlformats.org/package/2006/relationships"
+
+type tXLSXSheetBuffer struct {
+	rows       bytes.Buffer
+	numRows    int
+	numColumns int
+}
+
+type RecordWriterXLSX struct {
+	writerOptions *cli.TWriterOptions
+	sheets        []*tXLSXSheetBuffer
+	// For schema changes: we start a new sheet
+	lastJoinedHeader *string
+}
+
+func NewRecordWriterXLSX(writerOptions *cli.TWriterOptions) (*RecordWriterXLSX, error) {
+	return &RecordWriterXLSX{
+		writerOptions:    writerOptions,
+		sheets:           make([]*tXLSXSheetBuffer, 0),
+		lastJoinedHeader: nil,
+	}, nil
+}
+
+func (writer *RecordWriterXLSX) Write(
+	outrec *mlrval.Mlrmap,
+	_ *types.Context,
+	bufferedOutputStream *bufio.Writer,
+	outputIsStdout bool,
+) error {
+	if outrec == nil {
+		// End of record stream
+		if len(writer.sheets) == 0 {
+			// No records at all: no output at all, as with CSV.
+			return nil
+		}
+		return writer.writeWorkbook(bufferedOutputStream)
+	}
+
+	if outrec.IsEmpty() {
+		joinedHeader := ""
+		writer.lastJoinedHeader = &joinedHeader
+		return nil
+	}
+
+	joinedHeader := strings.Join(outrec.GetKeys(), ",")
+	if writer.lastJoinedHeader == nil || *writer.lastJoinedHeader != joinedHeader {
+		if len(writer.sheets) > 0 && writer.writerOptions.XLSXOneSheet {
+			writer.sheets[0].numRows++
+		} else {
+			writer.sheets = append(writer.sheets, &tXLSXSheetBuffer{})
+		}
+		writer.lastJoinedHeader = &joinedHeader
+		if !writer.writerOptions.HeaderlessOutput {
+			header := make([]*mlrval.Mlrval, 0, outrec.FieldCount)
+			for pe := outrec.Head; pe != nil; pe = pe.Next {
+				header = append(header, mlrval.FromString(pe.Key))
+			}
+			writer.sheets[len(writer.sheets)-1].writeRow(header)
+		}
+	}
+
+	values := make([]*mlrval.Mlrval, 0, outrec.FieldCount)
+	for pe := outrec.Head; pe != nil; pe = pe.Next {
+		values = append(values, pe.Value)
+	}
+	writer.sheets[len(writer.sheets)-1].writeRow(values)
+	return nil
+}
+
+func (sheet *tXLSXSheetBuffer) writeRow(values []*mlrval.Mlrval) {
+	sheet.numRows++
+	sheet.numColumns = max(sheet.numColumns, len(values))
+	rows := &sheet.rows
+
+	rows.WriteString(`<row r="`)
+	rows.WriteString(strconv.Itoa(sheet.numRows))
+	rows.WriteString(`">`)
+	for i, value := range values {
+		if value.IsVoid() {
+			continue
+		}
+		ref := lib.XLSXColumnName(i) + strconv.Itoa(sheet.numRows)
+
+		if number, ok := xlsxNumber(value); ok {
+			rows.WriteString(`<c r="` + ref + `"><v>` + number + `</v></c>`)
+		} else if boolValue, isBool := value.GetBoolValue(); isBool {
+			v := "0"
+			if boolValue {
+				v = "1"
+			}
+			rows.WriteString(`<c r="` + ref + `" t="b"><v>` + v + `</v></c>`)
+		} else {
+			text := lib.XLSXEncodeText(value.String())
+			rows.WriteString(`<c r="` + ref + `" t="inlineStr"><is><t`)
+			if strings.TrimSpace(text) != text {
+				rows.WriteString(` xml:space="preserve"`)
+			}
+			rows.WriteString(`>`)
+			xml.EscapeText(rows, []byte(text))
+			rows.WriteString(`</t></is></c>`)
+		}
+	}
+	rows.WriteString(`</row>`)
+}
+
+// xlsxNumber returns the text of a numeric cell, if the value is to be
+// written as one.
+func xlsxNumber(value *mlrval.Mlrval) (string, bool) {
+	switch value.Type() {
+	case mlrval.MT_INT:
+		intValue, _ := value.GetIntValue()
+		formatted := strconv.FormatInt(intValue, 10)
+		if formatted != value.String() || intValue >= 1<<53 || intValue <= -(1<<53) {
+			return "", false
+		}
+		return formatted, true
+	case mlrval.MT_FLOAT:
+		floatValue, _ := value.GetNumericToFloatValue()
+		if math.IsNaN(floatValue) || math.IsInf(floatValue, 0) {
+			return "", false
+		}
+		return strconv.FormatFloat(floatValue, 'g', -1, 64), true
+	default:
+		return "", false
+	}
+}
+
+func (writer *RecordWriterXLSX) writeWorkbook(bufferedOutputStream *bufio.Writer) error {
+	zipWriter := zip.NewWriter(bufferedOutputStream)
+	numSheets := len(writer.sheets)
+
+	var contentTypes strings.Builder
+	contentTypes.WriteString(`<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">`)
+	contentTypes.WriteString(`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>`)
+	contentTypes.WriteString(`<Default Extension="xml" ContentType="application/xml"/>`)
+	contentTypes.WriteString(`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>`)
+	contentTypes.WriteString(`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>`)
+	for i := 1; i <= numSheets; i++ {
+		contentTypes.WriteString(fmt.Sprintf(
+			`<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`,
+			i,
+		))
+	}
+	contentTypes.WriteString(`</Types>`)
+
+	packageRels := `<Relationships xmlns="` + xlsxPackageRelationshipsNamespace + `">` +
+		`<Relationship Id="rId1" Type="` + xlsxRelationshipsNamespace + `/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`
+
+	var workbook, workbookRels strings.Builder
+	workbook.WriteString(`<workbook xmlns="` + xlsxMainNamespace + `" xmlns:r="` + xlsxRelationshipsNamespace + `"><sheets>`)
+	workbookRels.WriteString(`<Relationships xmlns="` + xlsxPackageRelationshipsNamespace + `">`)
+	for i := 1; i <= numSheets; i++ {
+		workbook.WriteString(fmt.Sprintf(`<sheet name="Sheet%d" sheetId="%d" r:id="rId%d"/>`, i, i, i))
+		workbookRels.WriteString(fmt.Sprintf(
+			`<Relationship Id="rId%d" Type="%s/worksheet" Target="worksheets/sheet%d.xml"/>`,
+			i, xlsxRelationshipsNamespace, i,
+		))
+	}
+	workbook.WriteString(`</sheets></workbook>`)
+	workbookRels.WriteString(fmt.Sprintf(
+		`<Relationship Id="rId%d" Type="%s/styles" Target="styles.xml"/>`,
+		numSheets+1, xlsxRelationshipsNamespace,
+	))
+	workbookRels.WriteString(`</Relationships>`)
+
+	// Spreadsheet programs want at least the default style.
+	styles := `<styleSheet xmlns="` + xlsxMainNamespace + `">` +
+		`<fonts count="1"><font><sz val="11"/><name val="Calibri"/></font></fonts>` +
+		`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+		`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+		`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+		`<cellXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/></cellXfs>` +
+		`<cellStyles count="1"><cellStyle name="Normal" xfId="0" builtinId="0"/></cellStyles>` +
+		`</styleSheet>`
+
+	parts := []struct {
+		name     string
+		contents string
+	}{
+		{"[Content_Types].xml", contentTypes.String()},
+		{"_rels/.rels", packageRels},
+		{"xl/workbook.xml", workbook.String()},
+		{"xl/_rels/workbook.xml.rels", workbookRels.String()},
+		{"xl/styles.xml", styles},
+	}
+	for _, part := range parts {
+		if err := writeXLSXPart(zipWriter, part.name, []byte(part.contents)); err != nil {
+			return err
+		}
+	}
+
+	for i, sheet := range writer.sheets {
+		var worksheet bytes.Buffer
+		worksheet.WriteString(`<worksheet xmlns="` + xlsxMainNamespace + `">`)
+		if sheet.numRows > 0 {
+			worksheet.WriteString(fmt.Sprintf(
+				`<dimension ref="A1:%s%d"/>`, lib.XLSXColumnName(max(sheet.numColumns, 1)-1), sheet.numRows,
+			))
+		}
+		worksheet.WriteString(`<sheetData>`)
+		worksheet.Write(sheet.rows.Bytes())
+		worksheet.WriteString(`</sheetData></worksheet>`)
+		if err := writeXLSXPart(zipWriter, fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1), worksheet.Bytes()); err != nil {
+			return err
+		}
+	}
+
+	return zipWriter.Close()
+}
+
+func writeXLSXPart(zipWriter *zip.Writer, name string, contents []byte) error {
+	// A fixed timestamp keeps the output reproducible; zip can't represent
+	// times before 1980.
+	partWriter, err := zipWriter.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   zip.Deflate,
+		Modified: time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := partWriter.Write([]byte(xml.Header)); err != nil {
+		return err
+	}
+	_, err = partWriter.Write(contents)
+	return err
+}
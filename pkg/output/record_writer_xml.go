@@ -0,0 +1,196 @@
+// XML record-writer.
+//
+// This is the inverse of the XML record-reader. The records are written
+// within the enclosing elements from --xml-record-path -- for example, with
+// /feed/entry, within <feed> -- with * or no enclosing elements as
+// <records>. A record with a single field named for the record element,
+// such as the {"entry": {...}} records which the XML record-reader produces,
+// is written as that element; any other record is wrapped in a record
+// element, named <record> if the path doesn't say.
+//
+// Within an element, fields whose names start with @ are attributes, #text is
+// text, maps are child elements, and arrays are repeated child elements.
+// Records from non-JSON input, such as CSV with a field entry.title, are
+// auto-unflattened before they get here.
+//
+// Names which aren't valid XML names are adjusted: 1 becomes _1, and "a b"
+// becomes a_b.
+
+package output
+
+import (
+	"bufio"
+	"encoding/xml"
+	"strings"
+	"unicode"
+
+	"github.com/johnkerl/miller/v6/pkg/cli"
+	"github.com/johnkerl/miller/v6/pkg/lib"
+	"github.com/johnkerl/miller/v6/pkg/mlrval"
+	"github.com/johnkerl/miller/v6/pkg/types"
+)
+
+const xmlIndent = "  "
+
+type RecordWriterXML struct {
+	writerOptions *cli.TWriterOptions
+	enclosing     []string
+	// "" if any name goes
+	recordName string
+
+	wroteAnyRecords bool
+}
+
+func NewRecordWriterXML(writerOptions *cli.TWriterOptions) (*RecordWriterXML, error) {
+	recordPath, err := lib.NewXMLRecordPath(writerOptions.XMLRecordPath)
+	if err != nil {
+		return nil, err
+	}
+	enclosing := recordPath.Enclosing("records")
+	if len(enclosing) == 0 {
+		// A document has exactly one root element.
+		enclosing = []string{"records"}
+	}
+	for i := range enclosing {
+		enclosing[i] = xmlName(enclosing[i])
+	}
+	return &RecordWriterXML{
+		writerOptions:   writerOptions,
+		enclosing:       enclosing,
+		recordName:      recordPath.RecordName(),
+		wroteAnyRecords: false,
+	}, nil
+}
+
+func (writer *RecordWriterXML) Write(
+	outrec *mlrval.Mlrmap,
+	_ *types.Context,
+	bufferedOutputStream *bufio.Writer,
+	outputIsStdout bool,
+) error {
+	if outrec == nil {
+		// End of record stream
+		if writer.wroteAnyRecords {
+			for i := len(writer.enclosing) - 1; i >= 0; i-- {
+				bufferedOutputStream.WriteString(strings.Repeat(xmlIndent, i))
+				bufferedOutputStream.WriteString("</" + writer.enclosing[i] + ">\n")
+			}
+		}
+		return nil
+	}
+
+	if !writer.wroteAnyRecords {
+		bufferedOutputStream.WriteString(xml.Header)
+		for i, name := range writer.enclosing {
+			bufferedOutputStream.WriteString(strings.Repeat(xmlIndent, i))
+			bufferedOutputStream.WriteString("<" + name + ">\n")
+		}
+		writer.wroteAnyRecords = true
+	}
+
+	depth := len(writer.enclosing)
+	if outrec.FieldCount == 1 && outrec.Head.Value.IsMap() &&
+		(writer.recordName == "" || writer.recordName == outrec.Head.Key) {
+		writeXMLElement(bufferedOutputStream, outrec.Head.Key, outrec.Head.Value, depth)
+	} else {
+		recordName := writer.recordName
+		if recordName == "" {
+			recordName = "record"
+		}
+		writeXMLElement(bufferedOutputStream, recordName, mlrval.FromMap(outrec), depth)
+	}
+	return nil
+}
+
+func writeXMLElement(
+	bufferedOutputStream *bufio.Writer,
+	name string,
+	value *mlrval.Mlrval,
+	depth int,
+) {
+	if value.IsArray() {
+		for _, element := range value.GetArray() {
+			writeXMLElement(bufferedOutputStream, name, element, depth)
+		}
+		return
+	}
+
+	indent := strings.Repeat(xmlIndent, depth)
+	name = xmlName(name)
+	bufferedOutputStream.WriteString(indent)
+	bufferedOutputStream.WriteString("<" + name)
+
+	if !value.IsMap() {
+		if value.IsVoid() {
+			bufferedOutputStream.WriteString("/>\n")
+		} else {
+			bufferedOutputStream.WriteString(">")
+			xml.EscapeText(bufferedOutputStream, []byte(value.String()))
+			bufferedOutputStream.WriteString("</" + name + ">\n")
+		}
+		return
+	}
+
+	var text *mlrval.Mlrval
+	hasChildren := false
+	for pe := value.GetMap().Head; pe != nil; pe = pe.Next {
+		if pe.Key == "#text" {
+			text = pe.Value
+		} else if strings.HasPrefix(pe.Key, "@") && len(pe.Key) > 1 {
+			bufferedOutputStream.WriteString(" " + xmlName(pe.Key[1:]) + "=\"")
+			xml.EscapeText(bufferedOutputStream, []byte(pe.Value.String()))
+			bufferedOutputStream.WriteString("\"")
+		} else {
+			hasChildren = true
+		}
+	}
+
+	if !hasChildren {
+		if text == nil || text.IsVoid() {
+			bufferedOutputStream.WriteString("/>\n")
+		} else {
+			bufferedOutputStream.WriteString(">")
+			xml.EscapeText(bufferedOutputStream, []byte(text.String()))
+			bufferedOutputStream.WriteString("</" + name + ">\n")
+		}
+		return
+	}
+
+	bufferedOutputStream.WriteString(">")
+	if text != nil {
+		xml.EscapeText(bufferedOutputStream, []byte(text.String()))
+	}
+	bufferedOutputStream.WriteString("\n")
+	for pe := value.GetMap().Head; pe != nil; pe = pe.Next {
+		if pe.Key == "#text" || (strings.HasPrefix(pe.Key, "@") && len(pe.Key) > 1) {
+			continue
+		}
+		writeXMLElement(bufferedOutputStream, pe.Key, pe.Value, depth+1)
+	}
+	bufferedOutputStream.WriteString(indent)
+	bufferedOutputStream.WriteString("</" + name + ">\n")
+}
+
+// xmlName makes a valid XML element or attribute name, replacing invalid
+// characters with underscores, and prefixing one if the name doesn't start
+// with a letter. Colons are replaced too, since we don't write namespace
+// declarations.
+func xmlName(name string) string {
+	if name == "" {
+		return "_"
+	}
+	var buffer strings.Builder
+	for i, r := range name {
+		if unicode.IsLetter(r) || r == '_' {
+			buffer.WriteRune(r)
+		} else if unicode.IsDigit(r) || r == '-' || r == '.' {
+			if i == 0 {
+				buffer.WriteRune('_')
+			}
+			buffer.WriteRune(r)
+		} else {
+			buffer.WriteRune('_')
+		}
+	}
+	return buffer.String()
+}
@@ -44,7 +44,7 @@ func (writer *RecordWriterYAML) writeWithListWrap(
 		if writer.bufferedRecords == nil {
 			writer.bufferedRecords = []*yaml.Node{}
 		}
-		native, err := mlrval.MlrmapToYAMLNative(outrec)
+		native, err := writer.recordToYAMLNode(outrec)
 		if err != nil {
 			return err
 		}
@@ -73,7 +73,7 @@ func (writer *RecordWriterYAML) writeWithoutListWrap(
 	if writer.wroteAnyRecords {
 		bufferedOutputStream.WriteString("---\n")
 	}
-	native, err := mlrval.MlrmapToYAMLNative(outrec)
+	native, err := writer.recordToYAMLNode(outrec)
 	if err != nil {
 		return err
 	}
@@ -85,3 +85,27 @@ func (writer *RecordWriterYAML) writeWithoutListWrap(
 	writer.wroteAnyRecords = true
 	return nil
 }
+
+// recordToYAMLNode converts the record, with its _comment field, if any, as
+// comment lines before it for --yaml-comments.
+func (writer *RecordWriterYAML) recordToYAMLNode(outrec *mlrval.Mlrmap) (*yaml.Node, error) {
+	comment := ""
+	if writer.writerOptions.YAMLComments {
+		if value := outrec.Get(yamlCommentField); value != nil {
+			comment = value.String()
+			outrec = outrec.Copy()
+			outrec.Remove(yamlCommentField)
+		}
+	}
+	native, err := mlrval.MlrmapToYAMLNative(outrec)
+	if err != nil {
+		return nil, err
+	}
+	if comment != "" {
+		native.HeadComment = mlrval.YAMLHeadComment(comment)
+	}
+	return native, nil
+}
+
+// yamlCommentField is as in the YAML record-reader.
+const yamlCommentField = "_comment"
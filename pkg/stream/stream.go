@@ -6,6 +6,7 @@ import (
 
 	"github.com/johnkerl/miller/v6/pkg/cli"
 	"github.com/johnkerl/miller/v6/pkg/input"
+	"github.com/johnkerl/miller/v6/pkg/lib"
 	"github.com/johnkerl/miller/v6/pkg/output"
 	"github.com/johnkerl/miller/v6/pkg/transformers"
 	"github.com/johnkerl/miller/v6/pkg/types"
@@ -59,6 +60,14 @@ func Stream(
 		return err
 	}
 
+	// Names of zip and tar archives, or of archive members with globs, become
+	// the names of the members, each read as a file of its own; likewise for
+	// s3:// prefixes and globs.
+	fileNames, err = lib.ExpandInputFilenames(fileNames, options.ReaderOptions.Prepipe)
+	if err != nil {
+		return err
+	}
+
 	// Set up the reader-to-transformer and transformer-to-writer channels.
 	readerChannel := make(chan []*types.RecordAndContext, 2) // list of *types.RecordAndContext
 	writerChannel := make(chan []*types.RecordAndContext, 1) // list of *types.RecordAndContext
@@ -466,6 +466,14 @@ Markdown tabular:
 | | 4     | 5   | 6   | | Record 2: "apple":"4", "bat":"5", "cog":"6"
 +-----------------------+
 
+Org-mode tabular: Emacs org-mode tables; on input, other lines are skipped
++-----------------------+
+| | apple | bat | cog | |
+| |-------+-----+-----| |
+| | 1     | 2   | 3   | | Record 1: "apple:"1", "bat":"2", "cog":"3"
+| | 4     | 5   | 6   | | Record 2: "apple":"4", "bat":"5", "cog":"6"
++-----------------------+
+
 XTAB: pretty-printed transposed tabular
 +---------------------+
 | apple 1             | Record 1: "apple":"1", "bat":"2", "cog":"3"
@@ -488,6 +496,18 @@ DKVPX: delimited key-value pairs with CSV-style quoting
 | "x,y"="a,b,c",z=3                | Record 2: "x,y":"a,b,c", "z":"3"
 +----------------------------------+
 
+Logfmt: space-separated key=value pairs, with double-quoted values as needed
++----------------------------------+
+| level=info msg=ok                | Record 1: "level":"info", "msg":"ok"
+| level=warn msg="so \"slow\"" t   | Record 2: "level":"warn", "msg":"so \"slow\"", "t":""
++----------------------------------+
+
+LTSV: labeled tab-separated values, label:value, with \t etc. as in TSV
++----------------------------------+
+| host:a<TAB>time:03:04:05         | Record 1: "host":"a", "time":"03:04:05"
+| host:b<TAB>req:GET /             | Record 2: "host":"b", "req":"GET /"
++----------------------------------+
+
 NIDX: implicitly numerically indexed (Unix-toolkit style)
 +---------------------+
 | the quick brown     | Record 1: "1":"the", "2":"quick", "3":"brown"
@@ -504,6 +524,86 @@ DCF: Debian control file format
 | egg: 8     |
 | 3: flint   |
 +------------+
+
+Parquet: binary columnar format, with records read and written a row group at
+a time. Parquet groups, lists, and maps are Miller maps and arrays. The output
+schema is inferred from the first row group (see --parquet-row-group-size).
+
+Arrow: Apache Arrow IPC stream format, or IPC file format (Feather V2) for
+input. Records are read and written a record batch at a time; dictionary-encoded
+columns are decoded, and structs, maps, and lists are Miller maps and arrays.
+The output schema is inferred from the first batch (see --arrow-batch-size).
+
+Avro: Apache Avro object-container files, read using the schema in the file.
+Avro records, maps, and arrays are Miller maps and arrays, and dates and
+timestamps are ISO8601 strings. The output schema is inferred from the first
+block (see --avro-block-size), or taken from --avro-schema.
+
+XLSX: Excel spreadsheet. The first row of the sheet is the header, as with CSV;
+see --xlsx-sheet, --xlsx-range, and --xlsx-header-offset. Numeric and boolean
+cells are typed, and date cells are rendered as YYYY-MM-DD etc. On output, a
+change in record keys starts a new sheet.
+
+XML: each element matching --xml-record-path (here, /feed/entry) is a record
++-------------------------+
+| <feed>                  |
+|   <entry id="1">        | Record 1: "entry.@id":"1", "entry.title":"a"
+|     <title>a</title>    |
+|   </entry>              |
+|   <entry id="2">        | Record 2: "entry.@id":"2", "entry.title":"b"
+|     <title>b</title>    |
+|   </entry>              |
+| </feed>                 |
++-------------------------+
+
+SQLite: each row of a table, or of the result of --sqlite-query, is a record.
+On output, records are inserted into the --sqlite-table table, which is created
+from the first record's keys; new keys add columns.
+
+SQL (output only): a CREATE TABLE statement, with column types inferred from
+the first records, then multi-row INSERT statements, or a Postgres COPY block
+with --sql-copy, for loading into a database; see --sql-dialect.
+
+Template (output only): each record rendered through the Go text/template in
+the --otemplate file, with optional header and footer templates, for output of
+any other shape.
+
+HTML: a <table> with the keys in <thead>; a change in keys starts a new table.
+On input, the first table (or the one selected by --html-table) is read, with
+its first row as the header.
+
+LaTeX, reStructuredText, AsciiDoc (output only): tables for documents, as
+booktabs tabulars, grid tables, and |=== tables respectively. As with PPRINT, a
+change in keys starts a new table.
+
+Fixed-width: mainframe-style records, one per line, with columns padded to the
+widths given in the --fixed-spec column-spec file, which is shared by --ifixed
+input and --ofixed output. There is no header line and no separator.
+
+MessagePack, CBOR: binary formats, in which each top-level map is a record.
+Values keep their types: ints and floats stay distinct, and binary data is read
+and written as bytes. Maps and arrays are nested, as with JSON.
+
+Regex (input only): each line matching the --iregex regex is a record, with
+fields named by its named capture groups, as in (?P<host>\S+). There are
+presets for web-server and syslog formats, such as --iregex combined; see
+--regex-nonmatch for what becomes of other lines.
+
+TOML, INI: configuration files. Each table or section is a record, with its name
+in a _section field; on output, records are written as the tables or sections
+named by that field. TOML dotted keys and inline tables are maps.
++---------------------+
+| [server]            | Record 1: "_section":"server", "host":"a", "port":"80"
+| host = a            |
+| port = 80           |
+|                     |
+| [db]                | Record 2: "_section":"db", "url":"x"
+| url = x             |
++---------------------+
+
+GeoJSON: each feature of a FeatureCollection is a record, with its properties as
+fields, followed by its geometry as a map in a geometry field. On output,
+records are written as the features of a FeatureCollection.
 `)
 }
 
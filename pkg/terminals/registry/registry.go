@@ -20,6 +20,7 @@ const (
 	Repl         = "repl"
 	Script       = "script"
 	Skill        = "skill"
+	Sniff        = "sniff"
 	Version      = "version"
 	Which        = "which"
 )
@@ -34,6 +35,7 @@ var Names = []string{
 	Repl,
 	Script,
 	Skill,
+	Sniff,
 	Version,
 	Which,
 }
@@ -0,0 +1,115 @@
+// Entrypoint for `mlr sniff`: prints the main-flags for the format which
+// -i auto would detect in each input, so that people can pin them.
+
+package sniff
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/johnkerl/miller/v6/pkg/cli"
+	"github.com/johnkerl/miller/v6/pkg/input"
+	"github.com/johnkerl/miller/v6/pkg/lib"
+)
+
+func sniffUsage(o *os.File) {
+	fmt.Fprintf(o, "Usage: mlr sniff [options] {zero or more file names}\n")
+	fmt.Fprintf(o, "Reads the start of each file, or standard input if there are none, and prints\n")
+	fmt.Fprintf(o, "the main-flags for what -i auto (or --ifmt auto) detects there: CSV, TSV, JSON,\n")
+	fmt.Fprintf(o, "JSON Lines, DKVP, NIDX, or XTAB, with the field separator, the CSV quote\n")
+	fmt.Fprintf(o, "character, and whether there is a header line. With more than one file, each\n")
+	fmt.Fprintf(o, "line of output starts with the file name.\n")
+	fmt.Fprintf(o, "Options:\n")
+	fmt.Fprintf(o, "Main-flags for reading the data, such as --gzin, --prepipe, or --skip-comments.\n")
+	fmt.Fprintf(o, "-h or --help: print this message.\n")
+	fmt.Fprintf(o, "Example:\n")
+	fmt.Fprintf(o, "  $ mlr sniff example.csv\n")
+	fmt.Fprintf(o, "  --icsv\n")
+	fmt.Fprintf(o, "  $ mlr $(mlr sniff example.csv) --ojson head -n 2 example.csv\n")
+}
+
+// SniffMain is the entrypoint called by the terminals dispatcher for `mlr sniff`.
+func SniffMain(args []string) int {
+	argc := len(args)
+	argi := 1 // skip "sniff"
+	options := cli.DefaultOptions()
+
+	for argi < argc && strings.HasPrefix(args[argi], "-") {
+		if args[argi] == "-h" || args[argi] == "--help" {
+			sniffUsage(os.Stdout)
+			return 0
+		} else if handled, flagErr := cli.FLAG_TABLE.Parse(args, argc, &argi, options); flagErr != nil {
+			var exitRequest *lib.ExitRequest
+			if errors.As(flagErr, &exitRequest) {
+				return exitRequest.Code
+			}
+			fmt.Fprintf(os.Stderr, "%v\n", flagErr)
+			return 1
+		} else if !handled {
+			sniffUsage(os.Stderr)
+			return 1
+		}
+	}
+	if err := cli.FinalizeReaderOptions(&options.ReaderOptions); err != nil {
+		fmt.Fprintf(os.Stderr, "mlr sniff: %v\n", err)
+		return 1
+	}
+	readerOptions := &options.ReaderOptions
+
+	filenames := args[argi:]
+	if len(filenames) == 0 {
+		handle, err := lib.OpenStdin(readerOptions.Prepipe, readerOptions.PrepipeIsRaw, readerOptions.FileInputEncoding)
+		if err == nil {
+			err = sniffHandle(handle, "", readerOptions)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "mlr sniff: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+
+	filenames, err := lib.ExpandInputFilenames(filenames, readerOptions.Prepipe)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "mlr sniff: %v\n", err)
+		return 1
+	}
+	for _, filename := range filenames {
+		handle, err := lib.OpenFileForRead(
+			filename,
+			readerOptions.Prepipe,
+			readerOptions.PrepipeIsRaw,
+			readerOptions.FileInputEncoding,
+		)
+		if err == nil {
+			label := ""
+			if len(filenames) > 1 {
+				label = filename
+			}
+			err = sniffHandle(handle, label, readerOptions)
+			_ = handle.Close()
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "mlr sniff: %v\n", err)
+			return 1
+		}
+	}
+	return 0
+}
+
+func sniffHandle(handle io.Reader, label string, readerOptions *cli.TReaderOptions) error {
+	prefix, complete, err := input.ReadSniffPrefix(handle)
+	if err != nil {
+		return err
+	}
+	flags := strings.Join(input.Sniff(prefix, complete, readerOptions).Flags(), " ")
+	if label != "" {
+		fmt.Printf("%s: %s\n", label, flags)
+	} else {
+		fmt.Println(flags)
+	}
+	return nil
+}
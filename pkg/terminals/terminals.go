@@ -16,6 +16,7 @@ import (
 	"github.com/johnkerl/miller/v6/pkg/terminals/repl"
 	"github.com/johnkerl/miller/v6/pkg/terminals/script"
 	"github.com/johnkerl/miller/v6/pkg/terminals/skill"
+	"github.com/johnkerl/miller/v6/pkg/terminals/sniff"
 	"github.com/johnkerl/miller/v6/pkg/version"
 )
 
@@ -43,6 +44,7 @@ func init() {
 		{registry.Repl, repl.ReplMain},
 		{registry.Script, script.ScriptMain},
 		{registry.Skill, skill.SkillMain},
+		{registry.Sniff, sniff.SniffMain},
 		{registry.Version, showVersion},
 		{registry.Which, help.WhichMain},
 	}
@@ -4,10 +4,12 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/johnkerl/miller/v6/pkg/cli"
 	"github.com/johnkerl/miller/v6/pkg/lib"
 	"github.com/johnkerl/miller/v6/pkg/mlrval"
+	"github.com/johnkerl/miller/v6/pkg/transformers/utils"
 	"github.com/johnkerl/miller/v6/pkg/types"
 )
 
@@ -17,6 +19,7 @@ var countOptions = []OptionSpec{
 	{Flag: "-g", Arg: "{a,b,c}", Type: "csv-list", Desc: "Optional group-by-field names for counts, e.g. a,b,c"},
 	{Flag: "-n", Type: "bool", Desc: "Show only the number of distinct values. Not interesting without -g."},
 	{Flag: "-o", Arg: "{name}", Type: "string", Desc: "Field name for output-count. Default \"count\"."},
+	{Flag: "--flush-every", Arg: "{seconds}", Type: "float", Desc: "Besides at end of stream, also emit the counts so far whenever a record arrives at least this many seconds after the last emit. Useful with mlr --follow, where the end of stream is never seen."},
 }
 
 var CountSetup = TransformerSetup{
@@ -53,6 +56,7 @@ func transformerCountParseCLI(
 	var groupByFieldNames []string = nil
 	showCountsOnly := false
 	outputFieldName := "count"
+	flushSeconds := 0.0
 
 	var err error
 	for argi < argc /* variable increment: 1 or 2 depending on flag */ {
@@ -85,6 +89,15 @@ func transformerCountParseCLI(
 				return nil, err
 			}
 
+		case "--flush-every":
+			flushSeconds, err = cli.VerbGetFloatArg(verb, opt, args, &argi, argc)
+			if err != nil {
+				return nil, err
+			}
+			if flushSeconds <= 0 {
+				return nil, cli.VerbErrorf(verb, "--flush-every argument must be positive; got %s", args[argi-1])
+			}
+
 		default:
 			return nil, cli.VerbErrorf(verb, "option \"%s\" not recognized", opt)
 		}
@@ -99,6 +112,7 @@ func transformerCountParseCLI(
 		groupByFieldNames,
 		showCountsOnly,
 		outputFieldName,
+		time.Duration(flushSeconds*float64(time.Second)),
 	)
 	if err != nil {
 		return nil, err
@@ -115,6 +129,7 @@ type TransformerCount struct {
 
 	// state
 	recordTransformerFunc RecordTransformerFunc
+	flusher               *utils.PeriodicFlusher // nil unless --flush-every
 	ungroupedCount        int64
 	// Example:
 	// * Suppose group-by fields are a,b.
@@ -131,12 +146,14 @@ func NewTransformerCount(
 	groupByFieldNames []string,
 	showCountsOnly bool,
 	outputFieldName string,
+	flushInterval time.Duration, // zero for none
 ) (*TransformerCount, error) {
 
 	tr := &TransformerCount{
 		groupByFieldNames: groupByFieldNames,
 		showCountsOnly:    showCountsOnly,
 		outputFieldName:   outputFieldName,
+		flusher:           utils.NewPeriodicFlusher(flushInterval),
 
 		ungroupedCount: 0,
 		groupedCounts:  lib.NewOrderedMap[int64](),
@@ -170,16 +187,25 @@ func (tr *TransformerCount) countUngrouped(
 ) error {
 	if !inrecAndContext.EndOfStream {
 		tr.ungroupedCount++
+		if tr.flusher.IsDue() {
+			tr.emitUngrouped(&inrecAndContext.Context, outputRecordsAndContexts)
+		}
 	} else {
-		newrec := mlrval.NewMlrmapAsRecord()
-		newrec.PutCopy(tr.outputFieldName, mlrval.FromInt(tr.ungroupedCount))
-		*outputRecordsAndContexts = append(*outputRecordsAndContexts, types.NewRecordAndContext(newrec, &inrecAndContext.Context))
-
+		tr.emitUngrouped(&inrecAndContext.Context, outputRecordsAndContexts)
 		*outputRecordsAndContexts = append(*outputRecordsAndContexts, inrecAndContext) // end-of-stream marker
 	}
 	return nil
 }
 
+func (tr *TransformerCount) emitUngrouped(
+	context *types.Context,
+	outputRecordsAndContexts *[]*types.RecordAndContext, // list of *types.RecordAndContext
+) {
+	newrec := mlrval.NewMlrmapAsRecord()
+	newrec.PutCopy(tr.outputFieldName, mlrval.FromInt(tr.ungroupedCount))
+	*outputRecordsAndContexts = append(*outputRecordsAndContexts, types.NewRecordAndContext(newrec, context))
+}
+
 func (tr *TransformerCount) countGrouped(
 	inrecAndContext *types.RecordAndContext,
 	outputRecordsAndContexts *[]*types.RecordAndContext, // list of *types.RecordAndContext
@@ -207,42 +233,52 @@ func (tr *TransformerCount) countGrouped(
 			)
 		}
 
+		if tr.flusher.IsDue() {
+			tr.emitGrouped(&inrecAndContext.Context, outputRecordsAndContexts)
+		}
+
 	} else {
-		if tr.showCountsOnly {
-			newrec := mlrval.NewMlrmapAsRecord()
-			newrec.PutCopy(tr.outputFieldName, mlrval.FromInt(tr.groupedCounts.FieldCount))
+		tr.emitGrouped(&inrecAndContext.Context, outputRecordsAndContexts)
+		*outputRecordsAndContexts = append(*outputRecordsAndContexts, inrecAndContext) // end-of-stream marker
+	}
+	return nil
+}
 
-			outrecAndContext := types.NewRecordAndContext(newrec, &inrecAndContext.Context)
-			*outputRecordsAndContexts = append(*outputRecordsAndContexts, outrecAndContext)
+func (tr *TransformerCount) emitGrouped(
+	context *types.Context,
+	outputRecordsAndContexts *[]*types.RecordAndContext, // list of *types.RecordAndContext
+) {
+	if tr.showCountsOnly {
+		newrec := mlrval.NewMlrmapAsRecord()
+		newrec.PutCopy(tr.outputFieldName, mlrval.FromInt(tr.groupedCounts.FieldCount))
 
-		} else {
-			for outer := tr.groupedCounts.Head; outer != nil; outer = outer.Next {
-				groupingKey := outer.Key
-				newrec := mlrval.NewMlrmapAsRecord()
-
-				// Example:
-				// * Suppose group-by fields are a,b.
-				// * Record has a=foo,b=bar
-				// * Grouping key is "foo,bar"
-				// * Grouping values for key is ["foo", "bar"]
-				// Here we populate a record with "a=foo,b=bar".
-
-				groupingValuesForKey := tr.groupingValues.Get(groupingKey)
-				i := 0
-				for _, groupingValueForKey := range groupingValuesForKey {
-					newrec.PutCopy(tr.groupByFieldNames[i], groupingValueForKey)
-					i++
-				}
-
-				countForGroup := outer.Value
-				newrec.PutCopy(tr.outputFieldName, mlrval.FromInt(countForGroup))
-
-				outrecAndContext := types.NewRecordAndContext(newrec, &inrecAndContext.Context)
-				*outputRecordsAndContexts = append(*outputRecordsAndContexts, outrecAndContext)
+		outrecAndContext := types.NewRecordAndContext(newrec, context)
+		*outputRecordsAndContexts = append(*outputRecordsAndContexts, outrecAndContext)
+
+	} else {
+		for outer := tr.groupedCounts.Head; outer != nil; outer = outer.Next {
+			groupingKey := outer.Key
+			newrec := mlrval.NewMlrmapAsRecord()
+
+			// Example:
+			// * Suppose group-by fields are a,b.
+			// * Record has a=foo,b=bar
+			// * Grouping key is "foo,bar"
+			// * Grouping values for key is ["foo", "bar"]
+			// Here we populate a record with "a=foo,b=bar".
+
+			groupingValuesForKey := tr.groupingValues.Get(groupingKey)
+			i := 0
+			for _, groupingValueForKey := range groupingValuesForKey {
+				newrec.PutCopy(tr.groupByFieldNames[i], groupingValueForKey)
+				i++
 			}
-		}
 
-		*outputRecordsAndContexts = append(*outputRecordsAndContexts, inrecAndContext) // end-of-stream marker
+			countForGroup := outer.Value
+			newrec.PutCopy(tr.outputFieldName, mlrval.FromInt(countForGroup))
+
+			outrecAndContext := types.NewRecordAndContext(newrec, context)
+			*outputRecordsAndContexts = append(*outputRecordsAndContexts, outrecAndContext)
+		}
 	}
-	return nil
 }
@@ -554,8 +554,11 @@ func (tr *TransformerJoin) ingestLeftFile() error {
 
 	// Start the record reader.
 	// TODO: prepipe
-	leftFileNameArray := [1]string{tr.opts.leftFileName}
-	go recordReader.Read(leftFileNameArray[:], *initialContext, readerChannel, errorChannel, downstreamDoneChannel)
+	leftFileNames, err := lib.ExpandInputFilenames([]string{tr.opts.leftFileName}, readerOpts.Prepipe)
+	if err != nil {
+		return err
+	}
+	go recordReader.Read(leftFileNames, *initialContext, readerChannel, errorChannel, downstreamDoneChannel)
 
 	// Ingest parsed records and bucket them by their join-field values.  E.g.
 	// if the join-field is "id" then put all records with id=1 in one bucket,
@@ -8,6 +8,7 @@ import (
 	"strings"
 
 	"github.com/johnkerl/miller/v6/pkg/cli"
+	"github.com/johnkerl/miller/v6/pkg/lib"
 	"github.com/johnkerl/miller/v6/pkg/mlrval"
 	"github.com/johnkerl/miller/v6/pkg/output"
 	"github.com/johnkerl/miller/v6/pkg/types"
@@ -22,8 +23,8 @@ var splitOptions = []OptionSpec{
 	{Flag: "-m", Arg: "{m}", Type: "int", Desc: "Produce M files, round-robining records among them."},
 	{Flag: "-g", Arg: "{a,b,c}", Type: "csv-list", Desc: "Write separate files with records having distinct values for the specified field names."},
 	{Flag: "--prefix", Arg: "{p}", Type: "string", Desc: "Output filename prefix. Default \"split\"."},
-	{Flag: "--suffix", Arg: "{s}", Type: "string", Desc: "Output filename suffix. Default is from the output format, e.g. \"csv\"."},
-	{Flag: "--folder", Arg: "{f}", Type: "filename", Desc: "Output directory. Default is current directory."},
+	{Flag: "--suffix", Arg: "{s}", Type: "string", Desc: "Output filename suffix. Default is from the output format, e.g. \"csv\", and compression flag if any, e.g. \"csv.gz\" with --gzout."},
+	{Flag: "--folder", Arg: "{f}", Type: "filename", Desc: "Output directory, or S3 prefix such as s3://mybucket/parts. Default is current directory."},
 	{Flag: "-a", Type: "bool", Desc: "Append to existing files rather than overwriting."},
 	{Flag: "-v", Type: "bool", Desc: "Send records downstream as well as splitting to files."},
 	{Flag: "-e", Type: "bool", Desc: "Do NOT URL-escape names of output files."},
@@ -58,6 +59,8 @@ Examples: Suppose myfile.csv has 1,000,000 records.
   mlr --csv --from myfile.csv split -m 10
 Same, but with JSON output.
   mlr --csv --from myfile.csv split -m 10 -o json
+Same, but gzip-compressed, in split_1.json.gz, split_2.json.gz, etc.
+  mlr --csv --from myfile.csv split -m 10 -o json --gzout
 
 Same but instead of split_1.csv, split_2.csv, etc. there are test_1.dat, test_2.dat, etc.
   mlr --csv --from myfile.csv split -m 10 --prefix test --suffix dat
@@ -65,6 +68,8 @@ Same, but written to the /tmp/ directory.
   mlr --csv --from myfile.csv split -m 10 --prefix /tmp/test --suffix dat
 Or using --folder:
   mlr --csv --from myfile.csv split -m 10 --folder /tmp --prefix test --suffix dat
+Same, but written to S3 objects s3://mybucket/parts/test_1.dat, etc.
+  mlr --csv --from myfile.csv split -m 10 --folder s3://mybucket/parts --prefix test --suffix dat
 
 If the shape field has values triangle and square, then there will be split_triangle.csv and split_square.csv.
   mlr --csv --from myfile.csv split -g shape
@@ -211,7 +216,8 @@ func transformerSplitParseCLI(
 		return nil, cli.VerbErrorf(verb, "%v", err)
 	}
 	if !haveOutputFileNameSuffix {
-		outputFileNameSuffix = localOptions.WriterOptions.OutputFileFormat
+		outputFileNameSuffix = localOptions.WriterOptions.OutputFileFormat +
+			lib.OutputEncodingSuffix(localOptions.WriterOptions.FileOutputEncoding)
 	}
 
 	*pargi = argi
@@ -278,7 +284,7 @@ func NewTransformerSplit(
 	recordWriterOptions *cli.TWriterOptions,
 ) (*TransformerSplit, error) {
 
-	if outputFolder != "" {
+	if outputFolder != "" && !lib.IsS3Path(outputFolder) {
 		err := os.MkdirAll(outputFolder, 0755)
 		if err != nil {
 			return nil, fmt.Errorf("mlr split: could not create output folder %s: %w", outputFolder, err)
@@ -441,11 +447,7 @@ func (tr *TransformerSplit) splitGrouped(
 		groupByFieldValues, ok := inrecAndContext.Record.GetSelectedValues(tr.groupByFieldNames)
 		if !ok {
 			baseName := fmt.Sprintf("%s_ungrouped.%s", tr.outputFileNamePrefix, tr.outputFileNameSuffix)
-			if tr.outputFolder != "" {
-				filename = filepath.Join(tr.outputFolder, baseName)
-			} else {
-				filename = baseName
-			}
+			filename = tr.joinOutputFolder(baseName)
 		} else {
 			filename = tr.makeGroupedOutputFileName(groupByFieldValues)
 		}
@@ -483,10 +485,7 @@ func (tr *TransformerSplit) splitGrouped(
 // makeUngroupedOutputFileName example: "split_53.csv" or "folder/split_53.csv" with --folder
 func (tr *TransformerSplit) makeUngroupedOutputFileName(k int64) string {
 	baseName := fmt.Sprintf("%s_%d.%s", tr.outputFileNamePrefix, k, tr.outputFileNameSuffix)
-	if tr.outputFolder != "" {
-		return filepath.Join(tr.outputFolder, baseName)
-	}
-	return baseName
+	return tr.joinOutputFolder(baseName)
 }
 
 // makeGroupedOutputFileName example: "split_orange.csv"
@@ -510,8 +509,17 @@ func (tr *TransformerSplit) makeGroupedOutputFileName(
 	}
 
 	baseName := fileName + "." + tr.outputFileNameSuffix
-	if tr.outputFolder != "" {
-		return filepath.Join(tr.outputFolder, baseName)
+	return tr.joinOutputFolder(baseName)
+}
+
+// joinOutputFolder prepends the --folder, if any. An s3:// folder is joined
+// with a slash, as filepath.Join would make s3:// into s3:/.
+func (tr *TransformerSplit) joinOutputFolder(baseName string) string {
+	if tr.outputFolder == "" {
+		return baseName
+	}
+	if lib.IsS3Path(tr.outputFolder) {
+		return strings.TrimSuffix(tr.outputFolder, "/") + "/" + baseName
 	}
-	return baseName
+	return filepath.Join(tr.outputFolder, baseName)
 }
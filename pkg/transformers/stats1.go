@@ -7,6 +7,7 @@ import (
 	"regexp"
 	"slices"
 	"strings"
+	"time"
 
 	"github.com/johnkerl/miller/v6/pkg/cli"
 	"github.com/johnkerl/miller/v6/pkg/lib"
@@ -28,6 +29,7 @@ var stats1Options = []OptionSpec{
 	{Flag: "--grfx", Arg: "{regex}", Type: "regex", Desc: "Shorthand for --gr {regex} --fx {that same regex}."},
 	{Flag: "-i", Type: "bool", Desc: "Use interpolated percentiles, like R's type=7; default like type=1. Not sensical for string-valued fields."},
 	{Flag: "-s", Type: "bool", Desc: "Print iterative stats. Useful in tail -f contexts, in which case please avoid pprint-format output since end of input stream will never be seen. Likewise, if input is coming from `tail -f` be sure to use `--records-per-batch 1`."},
+	{Flag: "--flush-every", Arg: "{seconds}", Type: "float", Desc: "Besides at end of stream, also emit the statistics so far whenever a record arrives at least this many seconds after the last emit. Useful with mlr --follow, where the end of stream is never seen. Not compatible with -s or -w."},
 	{Flag: "-w", Arg: "{n}", Type: "int", Desc: "Sliding-window mode: compute statistics over a trailing window of up to n records (including the current one), rather than over the whole record stream. Windows are kept per group when -g is used. One output record is emitted per input record, with the windowed statistics appended to it. Not compatible with -s."},
 	{Flag: "-S", Type: "bool", Desc: "No-op flag for backward compatibility with Miller 5."},
 	{Flag: "-F", Type: "bool", Desc: "No-op flag for backward compatibility with Miller 5."},
@@ -113,6 +115,7 @@ func transformerStats1ParseCLI(
 	doInterpolatedPercentiles := false
 	doIterativeStats := false
 	slidingWindowSize := int64(0)
+	flushSeconds := 0.0
 
 	var err error
 	for argi < argc /* variable increment: 1 or 2 depending on flag */ {
@@ -201,6 +204,15 @@ func transformerStats1ParseCLI(
 				return nil, cli.VerbErrorf(verbNameStats1, "-w argument must be positive; got %d", slidingWindowSize)
 			}
 
+		case "--flush-every":
+			flushSeconds, err = cli.VerbGetFloatArg(verb, opt, args, &argi, argc)
+			if err != nil {
+				return nil, err
+			}
+			if flushSeconds <= 0 {
+				return nil, cli.VerbErrorf(verbNameStats1, "--flush-every argument must be positive; got %s", args[argi-1])
+			}
+
 		case "-S", "-F":
 			// No-op pass-through for backward compatibility with Miller 5
 
@@ -219,6 +231,9 @@ func transformerStats1ParseCLI(
 	if doIterativeStats && slidingWindowSize > 0 {
 		return nil, cli.VerbErrorf(verbNameStats1, "-s and -w may not be used together")
 	}
+	if flushSeconds > 0 && (doIterativeStats || slidingWindowSize > 0) {
+		return nil, cli.VerbErrorf(verbNameStats1, "--flush-every may not be used with -s or -w")
+	}
 
 	*pargi = argi
 	if !doConstruct { // All transformers must do this for main command-line parsing
@@ -238,6 +253,7 @@ func transformerStats1ParseCLI(
 		doInterpolatedPercentiles,
 		doIterativeStats,
 		slidingWindowSize,
+		time.Duration(flushSeconds*float64(time.Second)),
 	)
 	if err != nil {
 		return nil, err
@@ -277,6 +293,7 @@ type TransformerStats1 struct {
 
 	// State:
 	accumulatorFactory *utils.Stats1AccumulatorFactory
+	flusher            *utils.PeriodicFlusher // nil unless --flush-every
 
 	// For sliding-window mode: per grouping key, the last (up to)
 	// slidingWindowSize windowed entries. Each entry is a small record holding
@@ -350,6 +367,7 @@ func NewTransformerStats1(
 	doInterpolatedPercentiles bool,
 	doIterativeStats bool,
 	slidingWindowSize int64,
+	flushInterval time.Duration, // zero for none
 ) (*TransformerStats1, error) {
 	for _, name := range accumulatorNameList {
 		if !utils.ValidateStats1AccumulatorName(name) {
@@ -372,6 +390,7 @@ func NewTransformerStats1(
 		doIterativeStats:                 doIterativeStats,
 		slidingWindowSize:                slidingWindowSize,
 		accumulatorFactory:               utils.NewStats1AccumulatorFactory(),
+		flusher:                          utils.NewPeriodicFlusher(flushInterval),
 		slidingWindows:                   make(map[string][]*mlrval.Mlrmap),
 		namedAccumulators:                lib.NewOrderedMap[*lib.OrderedMap[*lib.OrderedMap[*utils.Stats1NamedAccumulator]]](),
 		groupingKeysToGroupByFieldValues: make(map[string]*lib.OrderedMap[*mlrval.Mlrval]),
@@ -472,6 +491,8 @@ func (tr *TransformerStats1) handleInputRecordNonWindowed(
 			inrec,
 		)
 		*outputRecordsAndContexts = append(*outputRecordsAndContexts, inrecAndContext)
+	} else if tr.flusher.IsDue() {
+		tr.emitAll(&inrecAndContext.Context, outputRecordsAndContexts)
 	}
 }
 
@@ -757,6 +778,17 @@ func (tr *TransformerStats1) handleEndOfRecordStream(
 		return
 	}
 
+	tr.emitAll(&inrecAndContext.Context, outputRecordsAndContexts)
+
+	*outputRecordsAndContexts = append(*outputRecordsAndContexts, inrecAndContext) // end-of-stream marker
+}
+
+// emitAll emits one record per grouping key with the statistics so far. This
+// is done at end of stream, and also every so often with --flush-every.
+func (tr *TransformerStats1) emitAll(
+	context *types.Context,
+	outputRecordsAndContexts *[]*types.RecordAndContext, // list of *types.RecordAndContext
+) {
 	for pa := tr.namedAccumulators.Head; pa != nil; pa = pa.Next {
 		groupingKey := pa.Key
 		level2 := pa.Value
@@ -765,16 +797,14 @@ func (tr *TransformerStats1) handleEndOfRecordStream(
 		newrec := mlrval.NewMlrmapAsRecord()
 
 		tr.emitIntoOutputRecord(
-			inrecAndContext.Record,
+			nil,
 			groupByFieldValues,
 			level2,
 			newrec,
 		)
 
-		*outputRecordsAndContexts = append(*outputRecordsAndContexts, types.NewRecordAndContext(newrec, &inrecAndContext.Context))
+		*outputRecordsAndContexts = append(*outputRecordsAndContexts, types.NewRecordAndContext(newrec, context))
 	}
-
-	*outputRecordsAndContexts = append(*outputRecordsAndContexts, inrecAndContext) // end-of-stream marker
 }
 
 func (tr *TransformerStats1) emitIntoOutputRecord(
@@ -2,6 +2,7 @@ package transformers
 
 import (
 	"testing"
+	"time"
 
 	"github.com/johnkerl/miller/v6/pkg/mlrval"
 	"github.com/johnkerl/miller/v6/pkg/types"
@@ -46,6 +47,7 @@ func TestStats1SlidingWindow(t *testing.T) {
 		false, // doInterpolatedPercentiles
 		false, // doIterativeStats
 		3,     // slidingWindowSize
+		0,     // flushInterval
 	)
 	if err != nil {
 		t.Fatal(err)
@@ -107,6 +109,7 @@ func TestStats1SlidingWindowGrouped(t *testing.T) {
 		false, // doInterpolatedPercentiles
 		false, // doIterativeStats
 		2,     // slidingWindowSize
+		0,     // flushInterval
 	)
 	if err != nil {
 		t.Fatal(err)
@@ -148,3 +151,58 @@ func TestStats1SlidingWindowGrouped(t *testing.T) {
 		}
 	}
 }
+
+// TestStats1FlushEvery exercises 'mlr stats1 -a count,sum -f x --flush-every ...':
+// results so far are emitted when a record arrives after the interval.
+func TestStats1FlushEvery(t *testing.T) {
+	newTransformer := func(flushInterval time.Duration) RecordTransformer {
+		tr, err := NewTransformerStats1(
+			[]string{"count", "sum"},
+			[]string{"x"},
+			[]string{},   // groupByFieldNameList
+			false, false, // doRegexValueFieldNames, doRegexGroupByFieldNames
+			false, false, // invertRegexValueFieldNames, invertRegexGroupByFieldNames
+			false, // doInterpolatedPercentiles
+			false, // doIterativeStats
+			0,     // slidingWindowSize
+			flushInterval,
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tr
+	}
+
+	// Interval not reached: only the end-of-stream output.
+	outputs := runStats1TestTransformer(newTransformer(time.Hour), []*types.RecordAndContext{
+		makeStats1TestRecord("x", "1"),
+		makeStats1TestRecord("x", "2"),
+	})
+	if len(outputs) != 2 {
+		t.Fatalf("got %d outputs, want 2", len(outputs))
+	}
+	if outputs[0].Record.Get("x_sum").String() != "3" {
+		t.Errorf("x_sum got %s, want 3", outputs[0].Record.Get("x_sum").String())
+	}
+
+	// Interval reached on every record: cumulative results after each.
+	tr := newTransformer(time.Nanosecond)
+	inputDownstreamDoneChannel := make(chan bool, 1)
+	outputDownstreamDoneChannel := make(chan bool, 1)
+	outputs = make([]*types.RecordAndContext, 0)
+	for _, x := range []string{"1", "2", "3"} {
+		time.Sleep(time.Millisecond)
+		_ = tr.Transform(makeStats1TestRecord("x", x), &outputs, inputDownstreamDoneChannel, outputDownstreamDoneChannel)
+	}
+	if len(outputs) != 3 {
+		t.Fatalf("got %d outputs, want 3", len(outputs))
+	}
+	for i, want := range []string{"1", "3", "6"} {
+		if outputs[i].Record.Get("x_sum").String() != want {
+			t.Errorf("record %d: x_sum got %s, want %s", i, outputs[i].Record.Get("x_sum").String(), want)
+		}
+		if outputs[i].EndOfStream {
+			t.Errorf("record %d: unexpected end-of-stream marker", i)
+		}
+	}
+}
@@ -34,7 +34,9 @@ func transformerTeeUsage(
 		`Any of the output-format command-line flags (see mlr -h). Example: using
   mlr --icsv --opprint put '...' then tee --ojson ./mytap.dat then stats1 ...
 the input is CSV, the output is pretty-print tabular, but the tee-file output
-is written in JSON format.
+is written in JSON format. Likewise, with --gzout, --bz2out, --zout, or --zstdout the
+tee-file output is compressed; it also is, without a flag, if the filename ends
+in .gz, .bz2, .z, or .zst.
 `)
 }
 
@@ -179,8 +179,11 @@ func NewJoinBucketKeeper(
 	downstreamDoneChannel := make(chan bool, 1)
 
 	// Start the record-reader in its own goroutine.
-	leftFileNameArray := [1]string{leftFileName}
-	go recordReader.Read(leftFileNameArray[:], *initialContext, readerChannel, errorChannel, downstreamDoneChannel)
+	leftFileNames, err := lib.ExpandInputFilenames([]string{leftFileName}, joinReaderOptions.Prepipe)
+	if err != nil {
+		return nil, cli.VerbErrorf("join", "%v", err)
+	}
+	go recordReader.Read(leftFileNames, *initialContext, readerChannel, errorChannel, downstreamDoneChannel)
 
 	keeper := &JoinBucketKeeper{
 		recordReader:     recordReader,
@@ -0,0 +1,50 @@
+// PeriodicFlusher is for verbs such as count and stats1 which normally emit
+// only at end of stream. With their --flush-every option they also emit their
+// results so far, every so often, so that output keeps updating when the
+// input doesn't end -- e.g. with mlr --follow.
+//
+// The check is made as each record arrives, rather than on a timer: results
+// only change when records arrive, so there is nothing new to show otherwise.
+
+package utils
+
+import (
+	"time"
+)
+
+type PeriodicFlusher struct {
+	interval  time.Duration
+	lastFlush time.Time
+	now       func() time.Time // for unit tests
+}
+
+// NewPeriodicFlusher returns nil for a non-positive interval, meaning no
+// periodic flushing; IsDue is false for a nil flusher.
+func NewPeriodicFlusher(interval time.Duration) *PeriodicFlusher {
+	return newPeriodicFlusherWithClock(interval, time.Now)
+}
+
+func newPeriodicFlusherWithClock(interval time.Duration, now func() time.Time) *PeriodicFlusher {
+	if interval <= 0 {
+		return nil
+	}
+	return &PeriodicFlusher{
+		interval:  interval,
+		lastFlush: now(),
+		now:       now,
+	}
+}
+
+// IsDue tells whether the interval has passed since the last flush (or since
+// the start), and if so starts the next interval.
+func (flusher *PeriodicFlusher) IsDue() bool {
+	if flusher == nil {
+		return false
+	}
+	now := flusher.now()
+	if now.Sub(flusher.lastFlush) < flusher.interval {
+		return false
+	}
+	flusher.lastFlush = now
+	return true
+}
@@ -0,0 +1,30 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPeriodicFlusher(t *testing.T) {
+	assert.Nil(t, NewPeriodicFlusher(0))
+	var flusher *PeriodicFlusher
+	assert.False(t, flusher.IsDue())
+
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	flusher = newPeriodicFlusherWithClock(10*time.Second, func() time.Time { return now })
+	assert.False(t, flusher.IsDue())
+
+	now = now.Add(9 * time.Second)
+	assert.False(t, flusher.IsDue())
+	now = now.Add(1 * time.Second)
+	assert.True(t, flusher.IsDue())
+
+	// The next interval starts from the flush, not from the start
+	now = now.Add(5 * time.Second)
+	assert.False(t, flusher.IsDue())
+	now = now.Add(7 * time.Second)
+	assert.True(t, flusher.IsDue())
+	assert.False(t, flusher.IsDue())
+}
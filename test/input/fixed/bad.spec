@@ -0,0 +1,2 @@
+color  4
+shape  x
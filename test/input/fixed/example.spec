@@ -0,0 +1,8 @@
+# Columns for test/input/example.csv
+color     8
+shape     10
+flag      6
+k         3   align=right pad=0
+index     6   align=right
+quantity  12  align=right
+rate      12  align=right overflow=error
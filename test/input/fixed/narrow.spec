@@ -0,0 +1,2 @@
+color  3
+shape  4   overflow=error
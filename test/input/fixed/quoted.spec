@@ -0,0 +1,3 @@
+"Seq No"   4  align=right pad=0
+"Name"     8  pad=.
+amount     8  align=right pad=0